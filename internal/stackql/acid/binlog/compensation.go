@@ -0,0 +1,45 @@
+package binlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+)
+
+// Compensation is a provider operation, and its parameters,
+// which reverses a mutation.  Compensations are serialised
+// into the raw undo log, one json object per line, so that
+// they survive concatenation and may be issued after a restart.
+type Compensation struct {
+	Provider   string                 `json:"provider"`
+	Service    string                 `json:"service"`
+	Resource   string                 `json:"resource"`
+	Method     string                 `json:"method"`
+	Parameters map[string]interface{} `json:"parameters"`
+}
+
+func (c Compensation) ToRaw() ([]byte, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+func CompensationsFromRaw(raw []byte) ([]Compensation, error) {
+	var rv []Compensation
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(nil, len(raw)+1)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var c Compensation
+		if err := json.Unmarshal(line, &c); err != nil {
+			return nil, err
+		}
+		rv = append(rv, c)
+	}
+	return rv, scanner.Err()
+}
@@ -90,3 +90,32 @@ func TestAppend(t *testing.T) {
 		)
 	}
 }
+
+func TestCompensationsSurviveConcatenation(t *testing.T) {
+	first := binlog.Compensation{
+		Provider:   "google",
+		Service:    "compute",
+		Resource:   "networks",
+		Method:     "delete",
+		Parameters: map[string]interface{}{"network": "n1", "project": "p"},
+	}
+	second := first
+	second.Parameters = map[string]interface{}{"network": "n2", "project": "p"}
+	firstRaw, err := first.ToRaw()
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	secondRaw, err := second.ToRaw()
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	entry := binlog.NewSimpleLogEntry(firstRaw, nil)
+	entry.Concatenate(binlog.NewSimpleLogEntry(secondRaw, nil))
+	compensations, err := binlog.CompensationsFromRaw(entry.GetRaw())
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if !reflect.DeepEqual(compensations, []binlog.Compensation{first, second}) {
+		t.Fatalf("test failed: unexpected compensations %+v", compensations)
+	}
+}
@@ -0,0 +1,29 @@
+package binlog
+
+import (
+	"context"
+)
+
+type recorderContextKey struct{}
+
+// MutationRecorder is notified of each provider request
+// issued on behalf of a mutating statement.
+// RecordRequest is called ahead of the request being sent
+// and an error aborts the request, so that no mutation
+// is issued without first being recorded.
+type MutationRecorder interface {
+	RecordRequest(description string) error
+	RecordResponse(description string, err error)
+}
+
+func NewRecorderContext(ctx context.Context, recorder MutationRecorder) context.Context {
+	return context.WithValue(ctx, recorderContextKey{}, recorder)
+}
+
+func RecorderFromContext(ctx context.Context) (MutationRecorder, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	recorder, ok := ctx.Value(recorderContextKey{}).(MutationRecorder)
+	return recorder, ok && recorder != nil
+}
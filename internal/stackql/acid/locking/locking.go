@@ -0,0 +1,142 @@
+package locking
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+)
+
+type Mode int
+
+const (
+	SharedMode Mode = iota
+	ExclusiveMode
+)
+
+func (m Mode) String() string {
+	if m == ExclusiveMode {
+		return "exclusive"
+	}
+	return "shared"
+}
+
+const amzTargetHeader = "X-Amz-Target"
+
+type lockerContextKey struct{}
+
+// ResourceLocker is consulted ahead of each provider request
+// issued on behalf of a statement, and blocks until the
+// resource may be accessed.  An error aborts the request.
+type ResourceLocker interface {
+	Lock(ctx context.Context, key string, isMutation bool) error
+}
+
+// NewKey identifies a provider resource instance by the values of its
+// identifying parameters, such as the region, the project and the name
+// or id of the object.
+func NewKey(provider, service, resource string, identifiers ...string) string {
+	return fmt.Sprintf("%s.%s.%s %s", provider, service, resource, strings.Join(identifiers, "/"))
+}
+
+// NewRequestKey identifies the resource instance addressed by a provider
+// request, whose host and path carry the identifying parameters.  Query
+// parameters are disregarded.  An insert addresses the collection, and
+// carries the name or id of the new instance in its body, so is keyed
+// alongside requests which address that instance, such as a delete.
+// APIs addressed by X-Amz-Target, such as AWS Cloud Control, share a
+// single path and carry all identifying parameters in the body.  The
+// target's API, but not its operation, is identifying, so that a read
+// and a delete of the same object contend.
+func NewRequestKey(provider, service, resource string, request *http.Request) (string, error) {
+	identifiers := []string{request.URL.Host}
+	for _, segment := range strings.Split(request.URL.Path, "/") {
+		if segment != "" {
+			identifiers = append(identifiers, segment)
+		}
+	}
+	if target := request.Header.Get(amzTargetHeader); target != "" {
+		api, _, _ := strings.Cut(target, ".")
+		identifiers = append(identifiers, api)
+	}
+	if request.Method != http.MethodPost {
+		return NewKey(provider, service, resource, identifiers...), nil
+	}
+	bodyIdentifiers, err := getBodyIdentifiers(request)
+	if err != nil {
+		return "", err
+	}
+	for _, id := range bodyIdentifiers {
+		if id != identifiers[len(identifiers)-1] {
+			identifiers = append(identifiers, id)
+		}
+	}
+	return NewKey(provider, service, resource, identifiers...), nil
+}
+
+// IsMutation decides the lock mode of a provider request from the SQL
+// verb of its operation, since reads may be issued as POST, as per AWS
+// Cloud Control and GraphQL.  Absent a verb, only safe HTTP methods are
+// deemed not to mutate.
+func IsMutation(sqlVerb string, request *http.Request) bool {
+	if sqlVerb != "" {
+		return !strings.EqualFold(sqlVerb, "select")
+	}
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+// getBodyIdentifiers returns the values identifying the instance from a
+// JSON object body, leaving the body to be read again.  AWS Cloud Control
+// bodies bear the type and, short of a list or create, the identifier of
+// the instance.  Otherwise, the name, or failing that the id, identifies
+// the instance; names may be qualified by their parents,
+// eg: 'projects/p/zones/z/disks/d'.
+func getBodyIdentifiers(request *http.Request) ([]string, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, nil
+	}
+	bodyBytes, err := io.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	request.Body.Close()
+	request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+	var body map[string]interface{}
+	if json.Unmarshal(bodyBytes, &body) != nil {
+		return nil, nil
+	}
+	if typeName, isString := body["TypeName"].(string); isString && typeName != "" {
+		rv := []string{typeName}
+		if id, isIDString := body["Identifier"].(string); isIDString && id != "" {
+			rv = append(rv, id)
+		}
+		return rv, nil
+	}
+	for _, k := range []string{"name", "id"} {
+		if v, isString := body[k].(string); isString && v != "" {
+			return []string{path.Base(v)}, nil
+		}
+	}
+	return nil, nil
+}
+
+func NewLockerContext(ctx context.Context, locker ResourceLocker) context.Context {
+	return context.WithValue(ctx, lockerContextKey{}, locker)
+}
+
+func LockerFromContext(ctx context.Context) (ResourceLocker, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	locker, ok := ctx.Value(lockerContextKey{}).(ResourceLocker)
+	return locker, ok && locker != nil
+}
@@ -0,0 +1,146 @@
+package locking_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	. "github.com/stackql/stackql/internal/stackql/acid/locking"
+)
+
+const (
+	instancesURL    = "https://compute.googleapis.com/compute/v1/projects/p/zones/z/instances"
+	cloudControlURL = "https://cloudcontrolapi.us-east-1.amazonaws.com/"
+)
+
+func newRequest(t *testing.T, method, url, body string) *http.Request {
+	var bodyReader io.Reader
+	if body != "" {
+		bodyReader = strings.NewReader(body)
+	}
+	request, err := http.NewRequest(method, url, bodyReader) //nolint:noctx // not issued
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	return request
+}
+
+func keyOf(t *testing.T, request *http.Request, body string) string {
+	key, err := NewRequestKey("google", "compute", "instances", request)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if body != "" {
+		remaining, readErr := io.ReadAll(request.Body)
+		if readErr != nil || string(remaining) != body {
+			t.Fatalf("test failed: request body not preserved, got '%s'", string(remaining))
+		}
+	}
+	return key
+}
+
+func requestKey(t *testing.T, method, url, body string) string {
+	return keyOf(t, newRequest(t, method, url, body), body)
+}
+
+// cloudControlRequest is as per AWS Cloud Control,
+// whose every operation is a POST against '/'.
+func cloudControlRequest(t *testing.T, operation, body string) *http.Request {
+	request := newRequest(t, http.MethodPost, cloudControlURL, body)
+	request.Header.Set("X-Amz-Target", "CloudApiService."+operation)
+	return request
+}
+
+func TestInsertConflictsWithDeleteOfInstance(t *testing.T) {
+	deleteKey := requestKey(t, http.MethodDelete, instancesURL+"/i1?requestId=r1", "")
+	for _, body := range []string{
+		`{"name": "i1", "machineType": "e2-micro"}`,
+		`{"name": "projects/p/zones/z/instances/i1"}`,
+		`{"id": "i1"}`,
+	} {
+		if insertKey := requestKey(t, http.MethodPost, instancesURL, body); insertKey != deleteKey {
+			t.Fatalf("test failed: insert key '%s' differs from delete key '%s'", insertKey, deleteKey)
+		}
+	}
+	if getKey := requestKey(t, http.MethodGet, instancesURL+"/i1", ""); getKey != deleteKey {
+		t.Fatalf("test failed: get key '%s' differs from delete key '%s'", getKey, deleteKey)
+	}
+}
+
+func TestDistinctInstancesDoNotConflict(t *testing.T) {
+	insertKey := requestKey(t, http.MethodPost, instancesURL, `{"name": "i2"}`)
+	deleteKey := requestKey(t, http.MethodDelete, instancesURL+"/i1", "")
+	if insertKey == deleteKey {
+		t.Fatalf("test failed: distinct instances share key '%s'", insertKey)
+	}
+	otherZoneKey := requestKey(
+		t, http.MethodDelete, strings.Replace(instancesURL, "zones/z", "zones/z2", 1)+"/i1", "")
+	if otherZoneKey == deleteKey {
+		t.Fatalf("test failed: instances of distinct zones share key '%s'", deleteKey)
+	}
+}
+
+func TestActionOnInstanceKeyedByPath(t *testing.T) {
+	// the body of a request other than an insert does not name the instance
+	patchKey := requestKey(t, http.MethodPatch, instancesURL+"/i1", `{"name": "i2"}`)
+	if expected := requestKey(t, http.MethodDelete, instancesURL+"/i1", ""); patchKey != expected {
+		t.Fatalf("test failed: patch key '%s' differs from '%s'", patchKey, expected)
+	}
+	// nor does a body which is not a JSON object
+	postKey := requestKey(t, http.MethodPost, instancesURL, `not json`)
+	if expected := requestKey(t, http.MethodGet, instancesURL, ""); postKey != expected {
+		t.Fatalf("test failed: post key '%s' differs from '%s'", postKey, expected)
+	}
+}
+
+func TestCloudControlReadsShared(t *testing.T) {
+	const body = `{"TypeName": "AWS::S3::Bucket"}`
+	request := cloudControlRequest(t, "ListResources", body)
+	if IsMutation("select", request) {
+		t.Fatalf("test failed: POST read locked exclusively")
+	}
+	if !IsMutation("delete", request) || !IsMutation("exec", request) {
+		t.Fatalf("test failed: mutation locked shared")
+	}
+	listKey := keyOf(t, request, body)
+	getKey := keyOf(t, cloudControlRequest(t, "GetResource",
+		`{"TypeName": "AWS::S3::Bucket", "Identifier": "b1"}`), "")
+	if listKey == getKey {
+		t.Fatalf("test failed: list and get of one bucket share key '%s'", listKey)
+	}
+	otherTypeKey := keyOf(t, cloudControlRequest(t, "ListResources",
+		`{"TypeName": "AWS::EC2::VPC"}`), "")
+	if otherTypeKey == listKey {
+		t.Fatalf("test failed: lists of distinct types share key '%s'", listKey)
+	}
+}
+
+func TestCloudControlKeyedByIdentifier(t *testing.T) {
+	getKey := keyOf(t, cloudControlRequest(t, "GetResource",
+		`{"TypeName": "AWS::S3::Bucket", "Identifier": "b1"}`), "")
+	// the operation is not identifying
+	deleteKey := keyOf(t, cloudControlRequest(t, "DeleteResource",
+		`{"TypeName": "AWS::S3::Bucket", "Identifier": "b1", "ClientToken": "c"}`), "")
+	if getKey != deleteKey {
+		t.Fatalf("test failed: get key '%s' differs from delete key '%s'", getKey, deleteKey)
+	}
+	otherKey := keyOf(t, cloudControlRequest(t, "DeleteResource",
+		`{"TypeName": "AWS::S3::Bucket", "Identifier": "b2"}`), "")
+	if otherKey == getKey {
+		t.Fatalf("test failed: distinct buckets share key '%s'", getKey)
+	}
+}
+
+func TestIsMutationFallsBackOnHTTPMethod(t *testing.T) {
+	for method, expected := range map[string]bool{
+		http.MethodGet:    false,
+		http.MethodHead:   false,
+		http.MethodPost:   true,
+		http.MethodDelete: true,
+	} {
+		if actual := IsMutation("", newRequest(t, method, instancesURL, "")); actual != expected {
+			t.Fatalf("test failed: '%s' mutation = %t, expected %t", method, actual, expected)
+		}
+	}
+}
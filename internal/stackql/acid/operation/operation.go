@@ -41,6 +41,8 @@ type Operation interface {
 	SetInputAlias(alias string, id int64) error
 	//
 	IsReadOnly() bool
+	// Get the plan time description of the underlying primitive.
+	GetDescription() primitive.Description
 }
 
 type reversibleOperation struct {
@@ -77,6 +79,10 @@ func (op *reversibleOperation) IsReadOnly() bool {
 	return op.pr.IsReadOnly()
 }
 
+func (op *reversibleOperation) GetDescription() primitive.Description {
+	return op.pr.GetDescription()
+}
+
 func (op *reversibleOperation) GetRedoLog() (binlog.LogEntry, bool) {
 	return op.pr.GetRedoLog()
 }
@@ -119,6 +125,10 @@ func (op *irreversibleOperation) IsReadOnly() bool {
 	return op.pr.IsReadOnly()
 }
 
+func (op *irreversibleOperation) GetDescription() primitive.Description {
+	return op.pr.GetDescription()
+}
+
 func (op *irreversibleOperation) GetRedoLog() (binlog.LogEntry, bool) {
 	return op.pr.GetRedoLog()
 }
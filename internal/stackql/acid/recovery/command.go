@@ -0,0 +1,158 @@
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/util"
+)
+
+type CommandType int
+
+const (
+	ListCommand CommandType = iota
+	CompensateCommand
+	DismissCommand
+)
+
+var (
+	//nolint:gochecknoglobals // acceptable
+	recoverRegex = regexp.MustCompile(`(?is)^\s*recover(?:\s+(compensate|dismiss))?(?:\s+'?([\w-]+)'?)?[\s;]*$`)
+	//nolint:gochecknoglobals // acceptable
+	recoverColumns = []string{
+		"txn_id",
+		"state",
+		"statement_id",
+		"query",
+		"requests",
+		"undo",
+		"compensations",
+		"error",
+	}
+)
+
+// Command is the RECOVER statement, which is handled
+// against the WAL rather than planned:
+//
+//	RECOVER
+//	RECOVER COMPENSATE '<txn_id>'
+//	RECOVER DISMISS '<txn_id>'
+//
+// The grammar is that of the stackql-parser module, which has
+// no RECOVER production, and the planner sits beneath the
+// transaction manager which writes the WAL, so cannot act upon
+// it.  Nor should RECOVER join the transaction of its session:
+// it concludes transactions of dead processes, and its own
+// records are written against those transactions.  Hence the
+// driver recognises RECOVER ahead of the normal statement path.
+type Command struct {
+	Type  CommandType
+	TxnID string
+}
+
+// ParseCommand signals whether the query is a RECOVER statement.
+func ParseCommand(query string) (Command, bool, error) {
+	m := recoverRegex.FindStringSubmatch(query)
+	if m == nil {
+		return Command{}, false, nil
+	}
+	rv := Command{TxnID: m[2]}
+	switch strings.ToLower(m[1]) {
+	case "compensate":
+		if rv.TxnID == "" {
+			return rv, true, fmt.Errorf("RECOVER COMPENSATE requires a transaction id")
+		}
+		rv.Type = CompensateCommand
+	case "dismiss":
+		if rv.TxnID == "" {
+			return rv, true, fmt.Errorf("RECOVER DISMISS requires a transaction id")
+		}
+		rv.Type = DismissCommand
+	default:
+		if rv.TxnID != "" {
+			return rv, true, fmt.Errorf("RECOVER lists all transactions requiring recovery")
+		}
+		rv.Type = ListCommand
+	}
+	return rv, true, nil
+}
+
+func ExecuteCommand(handlerCtx handler.HandlerContext, cmd Command) internaldto.ExecutorOutput {
+	recoverer, err := NewRecoverer(handlerCtx)
+	if err != nil {
+		return internaldto.NewErroneousExecutorOutput(err)
+	}
+	switch cmd.Type {
+	case CompensateCommand:
+		messages, compensateErr := recoverer.Compensate(cmd.TxnID)
+		if compensateErr != nil {
+			return internaldto.NewErroneousExecutorOutput(
+				errors.New(strings.Join(append(messages, compensateErr.Error()), "\n")))
+		}
+		return internaldto.NewNopEmptyExecutorOutput(messages)
+	case DismissCommand:
+		if dismissErr := recoverer.Dismiss(cmd.TxnID); dismissErr != nil {
+			return internaldto.NewErroneousExecutorOutput(dismissErr)
+		}
+		return internaldto.NewNopEmptyExecutorOutput(
+			[]string{fmt.Sprintf("transaction '%s': resolved", cmd.TxnID)})
+	default:
+		txns, listErr := recoverer.GetTransactions()
+		if listErr != nil {
+			return internaldto.NewErroneousExecutorOutput(listErr)
+		}
+		return renderTransactions(txns, handlerCtx)
+	}
+}
+
+func renderTransactions(txns []Transaction, handlerCtx handler.HandlerContext) internaldto.ExecutorOutput {
+	rowMap := make(map[string]map[string]interface{})
+	var rowKeys []string
+	for _, txn := range txns {
+		for _, stmt := range txn.Statements {
+			requests := make([]string, len(stmt.Requests))
+			for i, req := range stmt.Requests {
+				requests[i] = req.String()
+			}
+			key := strconv.Itoa(len(rowKeys))
+			rowKeys = append(rowKeys, key)
+			rowMap[key] = map[string]interface{}{
+				"txn_id":        txn.TxnID,
+				"state":         string(txn.State),
+				"statement_id":  stmt.ID,
+				"query":         stmt.Query,
+				"requests":      strings.Join(requests, "\n"),
+				"undo":          strings.Join(stmt.Undo, "\n"),
+				"compensations": len(stmt.GetOutstandingCompensations()),
+				"error":         stmt.Error,
+			}
+		}
+	}
+	var messages internaldto.BackendMessages
+	if len(txns) > 0 {
+		messages = internaldto.NewBackendMessages([]string{
+			"RECOVER COMPENSATE '<txn_id>' issues outstanding compensations and rolls back the transaction",
+			"RECOVER DISMISS '<txn_id>' marks the transaction resolved without compensation",
+		})
+	}
+	rv := util.PrepareResultSet(
+		internaldto.NewPrepareResultSetDTO(
+			nil,
+			rowMap,
+			recoverColumns,
+			func(map[string]map[string]interface{}) []string { return rowKeys },
+			nil,
+			messages,
+			handlerCtx.GetTypingConfig(),
+		),
+	)
+	if len(rowMap) > 0 {
+		return rv
+	}
+	return util.EmptyProtectResultSet(rv, recoverColumns, handlerCtx.GetTypingConfig())
+}
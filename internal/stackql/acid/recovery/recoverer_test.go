@@ -0,0 +1,126 @@
+package recovery //nolint:testpackage // compensations are substituted
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stackql/stackql/internal/stackql/acid/binlog"
+	"github.com/stackql/stackql/internal/stackql/acid/tsm_physio"
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/srvauth"
+)
+
+type recoveryHandlerContext struct {
+	handler.HandlerContext
+}
+
+func (hc *recoveryHandlerContext) GetAuthorisationPolicy() srvauth.Policy { return nil }
+
+// writeInterruptedWAL writes a transaction of two statements, as per
+// a process killed part way through writing the UNDO of the second,
+// and returns the transaction id.
+func writeInterruptedWAL(t *testing.T, walPath string) string {
+	compensation := func(network string) []byte {
+		raw, err := binlog.Compensation{
+			Provider:   "google",
+			Service:    "compute",
+			Resource:   "networks",
+			Method:     "delete",
+			Parameters: map[string]interface{}{"network": network},
+		}.ToRaw()
+		if err != nil {
+			t.Fatalf("test failed: %v", err)
+		}
+		return raw
+	}
+	wal := tsm_physio.NewLogManager(walPath)
+	txnID := wal.NewTxnID()
+	for _, record := range []tsm_physio.WALRecord{
+		{TxnID: txnID, Type: tsm_physio.WALRedo, StatementID: 1, Query: "insert n1"},
+		{TxnID: txnID, Type: tsm_physio.WALRequest, StatementID: 1, Description: "POST https://x/networks"},
+		{TxnID: txnID, Type: tsm_physio.WALResponse, StatementID: 1, Description: "POST https://x/networks -> 200"},
+		{TxnID: txnID, Type: tsm_physio.WALUndo, StatementID: 1, HumanReadable: []string{"delete n1"}, Raw: compensation("n1")},
+		{TxnID: txnID, Type: tsm_physio.WALRedo, StatementID: 2, Query: "insert n2"},
+		{TxnID: txnID, Type: tsm_physio.WALRequest, StatementID: 2, Description: "POST https://x/networks"},
+		{TxnID: txnID, Type: tsm_physio.WALResponse, StatementID: 2, Description: "POST https://x/networks -> 200"},
+		{TxnID: txnID, Type: tsm_physio.WALUndo, StatementID: 2, HumanReadable: []string{"delete n2"}, Raw: compensation("n2")},
+	} {
+		if err := wal.Append(record); err != nil {
+			t.Fatalf("test failed: %v", err)
+		}
+	}
+	records, err := tsm_physio.ReadWAL(walPath)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	// The process dies: its owner lock is gone, and the final record torn.
+	ownerPath := filepath.Join(filepath.Dir(walPath), "owners", records[0].Owner+".lock")
+	if err = os.Remove(ownerPath); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	info, err := os.Stat(walPath)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if err = os.Truncate(walPath, info.Size()-40); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	return txnID
+}
+
+func TestRecoverTruncatedWAL(t *testing.T) {
+	walPath := tsm_physio.GetWALFilePath(t.TempDir())
+	txnID := writeInterruptedWAL(t, walPath)
+	r := newRecoverer(&recoveryHandlerContext{}, tsm_physio.NewLogManagerWithCheckpoint(walPath, 0, 0), walPath)
+	var issued []binlog.Compensation
+	isFailing := true
+	r.issue = func(compensations []binlog.Compensation) error {
+		if isFailing {
+			return errors.New("provider unavailable")
+		}
+		issued = append(issued, compensations...)
+		return nil
+	}
+
+	txns, err := r.GetTransactions()
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if len(txns) != 1 || txns[0].TxnID != txnID || txns[0].State != InterruptedState || len(txns[0].Statements) != 2 {
+		t.Fatalf("test failed: unexpected transactions %+v", txns)
+	}
+	first, second := txns[0].Statements[0], txns[0].Statements[1]
+	if len(first.GetOutstandingCompensations()) != 1 || first.Undo[0] != "delete n1" {
+		t.Fatalf("test failed: unexpected first statement %+v", first)
+	}
+	// The torn UNDO record is discarded, so its compensation is unknown.
+	if len(second.GetOutstandingCompensations()) != 0 || len(second.Requests) != 1 || second.Requests[0].IsPending {
+		t.Fatalf("test failed: unexpected second statement %+v", second)
+	}
+
+	// A failed compensation is recorded, and the transaction remains for retry.
+	if _, err = r.Compensate(txnID); err == nil {
+		t.Fatal("test failed: expected compensation failure")
+	}
+	txns, err = r.GetTransactions()
+	if err != nil || len(txns) != 1 || txns[0].Statements[0].Error == "" {
+		t.Fatalf("test failed: expected failed compensation recorded, got %+v, %v", txns, err)
+	}
+
+	isFailing = false
+	if _, err = r.Compensate(txnID); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if len(issued) != 1 || issued[0].Parameters["network"] != "n1" {
+		t.Fatalf("test failed: unexpected compensations issued %+v", issued)
+	}
+	txns, err = r.GetTransactions()
+	if err != nil || len(txns) != 0 {
+		t.Fatalf("test failed: expected no transactions after compensation, got %+v, %v", txns, err)
+	}
+	if _, err = r.Compensate(txnID); err == nil {
+		t.Fatal("test failed: compensated transaction compensated again")
+	}
+}
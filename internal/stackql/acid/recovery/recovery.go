@@ -0,0 +1,260 @@
+package recovery
+
+import (
+	"fmt"
+
+	"github.com/stackql/any-sdk/anysdk"
+	"github.com/stackql/any-sdk/pkg/logging"
+	"github.com/stackql/stackql/internal/stackql/acid/binlog"
+	"github.com/stackql/stackql/internal/stackql/acid/tsm_physio"
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/builder_input"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/primitivebuilder"
+	"github.com/stackql/stackql/internal/stackql/primitivegraph"
+	"github.com/stackql/stackql/internal/stackql/streaming/http_preparator_stream.go"
+)
+
+var (
+	_ Recoverer = &standardRecoverer{}
+)
+
+// Recoverer reads the WAL for transactions interrupted
+// by a crash, or aborted with compensations outstanding,
+// and resolves them.  Transactions in flight, in this or
+// any other live process, are excluded.  Where the session
+// has an authorisation policy, only transactions begun by
+// its user are visible, and each compensation requires
+// authorisation for the resource it mutates.
+type Recoverer interface {
+	GetTransactions() ([]Transaction, error)
+	// Compensate issues the outstanding compensations of a transaction,
+	// in reverse order, and then records the transaction rolled back.
+	Compensate(txnID string) ([]string, error)
+	// Dismiss records a transaction resolved, without compensation.
+	Dismiss(txnID string) error
+}
+
+type standardRecoverer struct {
+	handlerCtx handler.HandlerContext
+	logManager tsm_physio.LogManager
+	walPath    string
+	// issue executes the compensations of a statement.
+	issue func([]binlog.Compensation) error
+}
+
+func NewRecoverer(handlerCtx handler.HandlerContext) (Recoverer, error) {
+	logManager, err := tsm_physio.GetLogManager(handlerCtx)
+	if err != nil {
+		return nil, err
+	}
+	walPath, hasWAL := logManager.GetFilePath()
+	if !hasWAL {
+		return nil, fmt.Errorf("recovery requires an application files root")
+	}
+	return newRecoverer(handlerCtx, logManager, walPath), nil
+}
+
+func newRecoverer(
+	handlerCtx handler.HandlerContext,
+	logManager tsm_physio.LogManager,
+	walPath string,
+) *standardRecoverer {
+	rv := &standardRecoverer{
+		handlerCtx: handlerCtx,
+		logManager: logManager,
+		walPath:    walPath,
+	}
+	rv.issue = rv.issueCompensations
+	return rv
+}
+
+func (r *standardRecoverer) GetTransactions() ([]Transaction, error) {
+	a := newAnalyser()
+	err := tsm_physio.ScanWAL(r.walPath, func(record tsm_physio.WALRecord) error {
+		a.add(record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	policy := r.handlerCtx.GetAuthorisationPolicy()
+	var rv []Transaction
+	for _, txn := range a.getTransactions() {
+		if r.logManager.IsActive(txn.TxnID) {
+			continue
+		}
+		// aborted transactions are concluded by their owner
+		if txn.State == InterruptedState && r.logManager.IsOwnerLive(txn.Owner) {
+			continue
+		}
+		if policy != nil && txn.User != policy.GetUsername() {
+			continue
+		}
+		rv = append(rv, txn)
+	}
+	return rv, nil
+}
+
+func (r *standardRecoverer) getTransaction(txnID string) (Transaction, error) {
+	txns, err := r.GetTransactions()
+	if err != nil {
+		return Transaction{}, err
+	}
+	for _, txn := range txns {
+		if txn.TxnID == txnID {
+			return txn, nil
+		}
+	}
+	return Transaction{}, fmt.Errorf("no transaction '%s' requires recovery", txnID)
+}
+
+func (r *standardRecoverer) Dismiss(txnID string) error {
+	if _, err := r.getTransaction(txnID); err != nil {
+		return err
+	}
+	return r.logManager.AppendRecovery(tsm_physio.WALRecord{
+		TxnID: txnID,
+		Type:  tsm_physio.WALResolved,
+	})
+}
+
+func (r *standardRecoverer) Compensate(txnID string) ([]string, error) {
+	if txnID == "" {
+		return nil, fmt.Errorf("compensation requires a transaction id")
+	}
+	txn, err := r.getTransaction(txnID)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.authoriseCompensations(txn); err != nil {
+		return nil, err
+	}
+	return r.compensateTransaction(txn)
+}
+
+// authoriseCompensations checks every compensation
+// up front, so that none is issued unless all may be.
+func (r *standardRecoverer) authoriseCompensations(txn Transaction) error {
+	policy := r.handlerCtx.GetAuthorisationPolicy()
+	if policy == nil {
+		return nil
+	}
+	for _, stmt := range txn.Statements {
+		for _, c := range stmt.GetOutstandingCompensations() {
+			if err := policy.AuthoriseResource(c.Provider, c.Service, c.Resource); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compensateTransaction halts at the first failed compensation,
+// leaving the transaction to be retried or dismissed.
+func (r *standardRecoverer) compensateTransaction(txn Transaction) ([]string, error) {
+	var messages []string
+	for i := len(txn.Statements) - 1; i >= 0; i-- {
+		stmt := txn.Statements[i]
+		compensations := stmt.GetOutstandingCompensations()
+		if len(compensations) == 0 {
+			continue
+		}
+		compensationErr := r.issue(compensations)
+		record := tsm_physio.WALRecord{
+			TxnID:       txn.TxnID,
+			Type:        tsm_physio.WALCompensate,
+			StatementID: stmt.ID,
+			Query:       stmt.Query,
+		}
+		if compensationErr != nil {
+			record.Error = compensationErr.Error()
+		}
+		if err := r.logManager.AppendRecovery(record); err != nil {
+			return messages, err
+		}
+		if compensationErr != nil {
+			return messages, fmt.Errorf(
+				"compensation failed for transaction '%s', statement '%s': %w",
+				txn.TxnID, stmt.Query, compensationErr)
+		}
+		messages = append(messages, fmt.Sprintf("transaction '%s': compensated '%s'", txn.TxnID, stmt.Query))
+	}
+	if err := r.logManager.AppendRecovery(tsm_physio.WALRecord{
+		TxnID: txn.TxnID,
+		Type:  tsm_physio.WALRollback,
+	}); err != nil {
+		return messages, err
+	}
+	return append(messages, fmt.Sprintf("transaction '%s': rolled back", txn.TxnID)), nil
+}
+
+// issueCompensations rebuilds the inverse graph lost
+// with the process that executed the statement.
+func (r *standardRecoverer) issueCompensations(compensations []binlog.Compensation) error {
+	graphHolder := primitivegraph.NewPrimitiveGraphHolder(
+		r.handlerCtx.GetRuntimeContext().ExecutionConcurrencyLimit)
+	for _, c := range compensations {
+		if err := r.buildCompensation(graphHolder, c); err != nil {
+			return err
+		}
+	}
+	inverseGraph := graphHolder.GetInversePrimitiveGraph()
+	if err := inverseGraph.Optimise(); err != nil {
+		return err
+	}
+	pc := internaldto.NewBasicPrimitiveContext(
+		nil,
+		r.handlerCtx.GetOutfile(),
+		r.handlerCtx.GetOutErrFile(),
+	).WithContext(r.handlerCtx.GetContext())
+	output := inverseGraph.Execute(pc)
+	if output == nil {
+		return nil
+	}
+	return output.GetError()
+}
+
+func (r *standardRecoverer) buildCompensation(
+	graphHolder primitivegraph.PrimitiveGraphHolder,
+	c binlog.Compensation,
+) error {
+	prov, err := r.handlerCtx.GetProvider(c.Provider)
+	if err != nil {
+		return err
+	}
+	rsc, err := prov.GetResource(c.Service, c.Resource, r.handlerCtx.GetRuntimeContext())
+	if err != nil {
+		return err
+	}
+	opStore, err := rsc.FindMethod(c.Method)
+	if err != nil {
+		return err
+	}
+	anySdkProv, err := prov.GetProvider()
+	if err != nil {
+		return err
+	}
+	stream := http_preparator_stream.NewHttpPreparatorStream()
+	//nolint:errcheck // in memory stream
+	stream.Write(
+		anysdk.NewHTTPPreparator(
+			anySdkProv,
+			opStore.GetService(),
+			opStore,
+			map[int]map[string]interface{}{0: c.Parameters},
+			nil,
+			nil,
+			logging.GetLogger(),
+		),
+	)
+	builderInput := builder_input.NewBuilderInput(graphHolder, r.handlerCtx, nil)
+	builderInput.SetHTTPPreparatorStream(stream)
+	builderInput.SetOperationStore(opStore)
+	builderInput.SetProvider(prov)
+	builder, err := primitivebuilder.NewGenericHTTPReversal(builderInput)
+	if err != nil {
+		return err
+	}
+	return builder.Build()
+}
@@ -0,0 +1,87 @@
+package recovery_test
+
+import (
+	"testing"
+
+	. "github.com/stackql/stackql/internal/stackql/acid/recovery"
+
+	"github.com/stackql/stackql/internal/stackql/acid/binlog"
+	"github.com/stackql/stackql/internal/stackql/acid/tsm_physio"
+)
+
+func TestAnalyseInterruptedTransactions(t *testing.T) {
+	compensation, err := binlog.Compensation{
+		Provider: "google",
+		Service:  "compute",
+		Resource: "networks",
+		Method:   "delete",
+		Parameters: map[string]interface{}{
+			"network": "n1",
+		},
+	}.ToRaw()
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	records := []tsm_physio.WALRecord{
+		{TxnID: "committed", Type: tsm_physio.WALBegin},
+		{TxnID: "committed", Type: tsm_physio.WALRedo, StatementID: 1, Query: "insert 1"},
+		{TxnID: "committed", Type: tsm_physio.WALCommit},
+		{TxnID: "interrupted", Type: tsm_physio.WALBegin, Owner: "18a2b", User: "alice"},
+		{TxnID: "interrupted", Type: tsm_physio.WALRedo, StatementID: 2, Query: "insert 2"},
+		{TxnID: "interrupted", Type: tsm_physio.WALRequest, StatementID: 2, Description: "POST https://x/networks"},
+		{TxnID: "interrupted", Type: tsm_physio.WALResponse, StatementID: 2, Description: "POST https://x/networks -> 200"},
+		{
+			TxnID: "interrupted", Type: tsm_physio.WALUndo, StatementID: 2,
+			HumanReadable: []string{"delete n1"}, Raw: compensation,
+		},
+		{TxnID: "interrupted", Type: tsm_physio.WALRedo, StatementID: 3, Query: "insert 3"},
+		{TxnID: "interrupted", Type: tsm_physio.WALRequest, StatementID: 3, Description: "POST https://x/networks"},
+		{TxnID: "aborted", Type: tsm_physio.WALBegin},
+		{TxnID: "aborted", Type: tsm_physio.WALRedo, StatementID: 4, Query: "insert 4"},
+		{TxnID: "aborted", Type: tsm_physio.WALUndo, StatementID: 4, HumanReadable: []string{"delete n4"}},
+		{TxnID: "aborted", Type: tsm_physio.WALCompensate, StatementID: 4},
+		{TxnID: "aborted", Type: tsm_physio.WALAbort},
+		{TxnID: "resolved", Type: tsm_physio.WALBegin},
+		{TxnID: "resolved", Type: tsm_physio.WALResolved},
+	}
+	txns := Analyse(records)
+	if len(txns) != 1 {
+		t.Fatalf("test failed: expected 1 transaction, got %d", len(txns))
+	}
+	txn := txns[0]
+	if txn.TxnID != "interrupted" || txn.State != InterruptedState || len(txn.Statements) != 2 ||
+		txn.Owner != "18a2b" || txn.User != "alice" {
+		t.Fatalf("test failed: unexpected transaction %+v", txn)
+	}
+	first, second := txn.Statements[0], txn.Statements[1]
+	if len(first.GetOutstandingCompensations()) != 1 || first.Requests[0].IsPending {
+		t.Fatalf("test failed: unexpected statement %+v", first)
+	}
+	if first.GetOutstandingCompensations()[0].Parameters["network"] != "n1" {
+		t.Fatalf("test failed: unexpected compensation %+v", first.Compensations[0])
+	}
+	if len(second.Requests) != 1 || !second.Requests[0].IsPending {
+		t.Fatalf("test failed: expected pending request, got %+v", second.Requests)
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	for query, expected := range map[string]CommandType{
+		"RECOVER;":                   ListCommand,
+		"RECOVER COMPENSATE 'abc-1'": CompensateCommand,
+		"recover dismiss abc-1 ;":    DismissCommand,
+	} {
+		cmd, isRecover, err := ParseCommand(query)
+		if !isRecover || err != nil || cmd.Type != expected {
+			t.Fatalf("test failed: '%s' parsed as %+v, %v, %v", query, cmd, isRecover, err)
+		}
+	}
+	if _, isRecover, _ := ParseCommand("select 1 as recover"); isRecover {
+		t.Fatalf("test failed: select parsed as RECOVER")
+	}
+	for _, query := range []string{"recover dismiss", "recover compensate"} {
+		if _, isRecover, err := ParseCommand(query); !isRecover || err == nil {
+			t.Fatalf("test failed: expected error for '%s' without id", query)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package recovery
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stackql/stackql/internal/stackql/acid/binlog"
+	"github.com/stackql/stackql/internal/stackql/acid/tsm_physio"
+)
+
+type TransactionState string
+
+const (
+	// No outcome was recorded; the process died.
+	InterruptedState TransactionState = "interrupted"
+	// Commit or rollback failed with compensations outstanding.
+	AbortedState TransactionState = "aborted"
+)
+
+type Transaction struct {
+	TxnID string
+	State TransactionState
+	// The process, and the server user, if any, which began the transaction.
+	Owner      string
+	User       string
+	Statements []*Statement
+}
+
+// Statement is a mutating statement, and the
+// provider requests it is known to have issued.
+type Statement struct {
+	ID            int64
+	Query         string
+	Requests      []*Request
+	Undo          []string
+	Compensations []binlog.Compensation
+	IsCompensated bool
+	Error         string
+}
+
+// Request is pending if no response was recorded,
+// in which case the mutation may or may not have occurred.
+type Request struct {
+	Description string
+	Outcome     string
+	IsPending   bool
+}
+
+func (s *Statement) GetOutstandingCompensations() []binlog.Compensation {
+	if s.IsCompensated {
+		return nil
+	}
+	return s.Compensations
+}
+
+func (s *Statement) hasOutstandingUndo() bool {
+	return !s.IsCompensated && len(s.Undo) > 0
+}
+
+func (r *Request) String() string {
+	if r.IsPending {
+		return fmt.Sprintf("%s (no response)", r.Description)
+	}
+	return r.Outcome
+}
+
+func (t *Transaction) hasOutstandingUndo() bool {
+	for _, stmt := range t.Statements {
+		if stmt.hasOutstandingUndo() {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Transaction) getStatement(id int64) *Statement {
+	for _, stmt := range t.Statements {
+		if stmt.ID == id {
+			return stmt
+		}
+	}
+	stmt := &Statement{ID: id}
+	t.Statements = append(t.Statements, stmt)
+	return stmt
+}
+
+// Analyse returns those transactions lacking a COMMIT, ROLLBACK or
+// RESOLVED record, in the order in which they began.  Aborted
+// transactions are included only where undo remains outstanding.
+func Analyse(records []tsm_physio.WALRecord) []Transaction {
+	a := newAnalyser()
+	for _, record := range records {
+		a.add(record)
+	}
+	return a.getTransactions()
+}
+
+// analyser consumes records one at a time, as they are read,
+// and holds only those of transactions yet to conclude.
+type analyser struct {
+	txns  map[string]*Transaction
+	order []string
+}
+
+func newAnalyser() *analyser {
+	return &analyser{
+		txns: make(map[string]*Transaction),
+	}
+}
+
+func (a *analyser) add(record tsm_physio.WALRecord) {
+	txn, exists := a.txns[record.TxnID]
+	if !exists {
+		if record.Type != tsm_physio.WALBegin {
+			// outcomes of recovered transactions, or
+			// records of transactions already concluded
+			return
+		}
+		a.txns[record.TxnID] = &Transaction{
+			TxnID: record.TxnID,
+			State: InterruptedState,
+			Owner: record.Owner,
+			User:  record.User,
+		}
+		a.order = append(a.order, record.TxnID)
+		return
+	}
+	switch record.Type {
+	case tsm_physio.WALRedo:
+		txn.getStatement(record.StatementID).Query = record.Query
+	case tsm_physio.WALRequest:
+		stmt := txn.getStatement(record.StatementID)
+		stmt.Requests = append(stmt.Requests, &Request{Description: record.Description, IsPending: true})
+	case tsm_physio.WALResponse:
+		txn.getStatement(record.StatementID).applyResponse(record)
+	case tsm_physio.WALUndo:
+		stmt := txn.getStatement(record.StatementID)
+		stmt.Undo = append(stmt.Undo, record.HumanReadable...)
+		compensations, err := binlog.CompensationsFromRaw(record.Raw)
+		if err != nil {
+			stmt.Error = fmt.Sprintf("cannot read compensations: %s", err.Error())
+		}
+		stmt.Compensations = append(stmt.Compensations, compensations...)
+	case tsm_physio.WALFailed:
+		txn.getStatement(record.StatementID).Error = record.Error
+	case tsm_physio.WALCompensate:
+		if record.StatementID == 0 {
+			break
+		}
+		stmt := txn.getStatement(record.StatementID)
+		if record.Error != "" {
+			stmt.Error = fmt.Sprintf("compensation failed: %s", record.Error)
+			break
+		}
+		stmt.IsCompensated = true
+	case tsm_physio.WALAbort:
+		txn.State = AbortedState
+	case tsm_physio.WALCommit, tsm_physio.WALRollback, tsm_physio.WALResolved:
+		delete(a.txns, record.TxnID)
+	}
+}
+
+func (a *analyser) getTransactions() []Transaction {
+	var rv []Transaction
+	for _, txnID := range a.order {
+		txn, exists := a.txns[txnID]
+		if !exists {
+			continue
+		}
+		if txn.State == AbortedState && !txn.hasOutstandingUndo() {
+			continue
+		}
+		rv = append(rv, *txn)
+	}
+	return rv
+}
+
+func (s *Statement) applyResponse(record tsm_physio.WALRecord) {
+	outcome := record.Description
+	if record.Error != "" {
+		outcome = fmt.Sprintf("%s -> %s", record.Description, record.Error)
+	}
+	for _, req := range s.Requests {
+		if req.IsPending && strings.HasPrefix(outcome, req.Description) {
+			req.IsPending = false
+			req.Outcome = outcome
+			return
+		}
+	}
+}
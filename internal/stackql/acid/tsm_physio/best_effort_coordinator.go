@@ -1,8 +1,11 @@
 package tsm_physio //nolint:revive,stylecheck // prefer this nomenclature
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/stackql/any-sdk/pkg/constants"
+	"github.com/stackql/any-sdk/pkg/logging"
 	"github.com/stackql/stackql-parser/go/vt/sqlparser"
 	"github.com/stackql/stackql/internal/stackql/acid/acid_dto"
 	"github.com/stackql/stackql/internal/stackql/acid/binlog"
@@ -20,6 +23,8 @@ type basicBestEffortTransactionCoordinator struct {
 	tsmInstance       tsm.TSM
 	handlerCtx        handler.HandlerContext
 	parent            Coordinator
+	txnID             string
+	lockOwnerID       string
 	statementSequence []Statement
 	undoLogs          []binlog.LogEntry
 	redoLogs          []binlog.LogEntry
@@ -27,6 +32,8 @@ type basicBestEffortTransactionCoordinator struct {
 	maxTxnDepth       int
 	outputs           []internaldto.ExecutorOutput
 	isExecuted        bool
+	savepoints        savepointStack
+	isolationLevel    constants.IsolationLevel
 	// redoGraphs        []primitivegraph.PrimitiveGraph
 	// undoGraphs        []primitivegraph.PrimitiveGraph
 }
@@ -35,13 +42,19 @@ func newBasicBestEffortTransactionCoordinator(
 	tsmInstance tsm.TSM,
 	handlerCtx handler.HandlerContext,
 	parent Coordinator,
+	txnID string,
+	lockOwnerID string,
+	isolationLevel constants.IsolationLevel,
 	maxTxnDepth int,
 ) Coordinator {
 	return &basicBestEffortTransactionCoordinator{
-		tsmInstance: tsmInstance,
-		handlerCtx:  handlerCtx,
-		parent:      parent,
-		maxTxnDepth: maxTxnDepth,
+		tsmInstance:    tsmInstance,
+		handlerCtx:     handlerCtx,
+		parent:         parent,
+		txnID:          txnID,
+		lockOwnerID:    lockOwnerID,
+		isolationLevel: isolationLevel,
+		maxTxnDepth:    maxTxnDepth,
 	}
 }
 
@@ -90,6 +103,22 @@ func (m *basicBestEffortTransactionCoordinator) IsRollback() bool {
 	return false
 }
 
+func (m *basicBestEffortTransactionCoordinator) IsSavepoint() bool {
+	return false
+}
+
+func (m *basicBestEffortTransactionCoordinator) IsRollbackToSavepoint() bool {
+	return false
+}
+
+func (m *basicBestEffortTransactionCoordinator) IsReleaseSavepoint() bool {
+	return false
+}
+
+func (m *basicBestEffortTransactionCoordinator) GetSavepointName() (string, bool) {
+	return "", false
+}
+
 func (m *basicBestEffortTransactionCoordinator) SetUndoLog(log binlog.LogEntry) {
 	m.undoLogs = []binlog.LogEntry{log}
 }
@@ -149,6 +178,12 @@ func (m *basicBestEffortTransactionCoordinator) votingPhase() ([]internaldto.Exe
 	}()
 	var rv []internaldto.ExecutorOutput
 	for _, stmt := range m.statementSequence {
+		// statements already executed, whether by the
+		// orchestrator or an earlier, failed, commit,
+		// are not repeated
+		if stmt.IsExecuted() {
+			continue
+		}
 		coDomain := stmt.Execute()
 		rv = append(rv, coDomain)
 		err := coDomain.GetError()
@@ -171,26 +206,52 @@ func (m *basicBestEffortTransactionCoordinator) completionPhase() error {
 	return nil
 }
 
-func (m *basicBestEffortTransactionCoordinator) Begin() (Coordinator, error) {
+func (m *basicBestEffortTransactionCoordinator) Begin(isolationLevel constants.IsolationLevel) (Coordinator, error) {
 	if m.maxTxnDepth >= 0 && m.Depth() >= m.maxTxnDepth {
 		return nil, fmt.Errorf("cannot begin nested transaction of depth = %d", m.Depth()+1)
 	}
-	return newBasicBestEffortTransactionCoordinator(m.tsmInstance, m.handlerCtx, m, m.maxTxnDepth), nil
+	return newBasicBestEffortTransactionCoordinator(
+		m.tsmInstance,
+		m.handlerCtx,
+		m,
+		getLogManager(m.tsmInstance).NewTxnID(),
+		m.getChildLockOwnerID(),
+		isolationLevel,
+		m.maxTxnDepth,
+	), nil
 }
 
+// Commit retains locks upon failure, which
+// are released by the subsequent rollback.
 func (m *basicBestEffortTransactionCoordinator) Commit() acid_dto.CommitCoDomain {
 	rv, err := m.votingPhase()
 	if err != nil {
 		return acid_dto.NewCommitCoDomain(rv, err, nil)
 	}
 	completionErr := m.completionPhase()
+	m.releaseLocks()
 	return acid_dto.NewCommitCoDomain(rv, nil, completionErr)
 }
 
 // Rollback is best effort and runs in reverse order.
 func (m *basicBestEffortTransactionCoordinator) Rollback() acid_dto.CommitCoDomain {
+	defer m.releaseLocks()
+	return m.rollbackFrom(0)
+}
+
+// rollbackFrom runs inverse graphs in reverse order, down to and including
+// the statement at lowerBound.  Statements yet to execute are dequeued
+// without compensation, as per the lazy coordinator.  Compensated statements are dequeued.  A statement
+// whose compensation failed is retained, so that the transaction cannot
+// later roll back cleanly and is left to recovery.
+func (m *basicBestEffortTransactionCoordinator) rollbackFrom(lowerBound int) acid_dto.CommitCoDomain {
 	var coDomains []internaldto.ExecutorOutput
-	for i := len(m.statementGraphs) - 1; i >= 0; i-- {
+	for i := len(m.statementGraphs) - 1; i >= lowerBound; i-- {
+		if !m.statementSequence[i].IsExecuted() {
+			m.statementGraphs = m.statementGraphs[:i]
+			m.statementSequence = m.statementSequence[:i]
+			continue
+		}
 		stmt := m.statementGraphs[i]
 		pl := internaldto.NewBasicPrimitiveContext(
 			nil,
@@ -215,6 +276,7 @@ func (m *basicBestEffortTransactionCoordinator) Rollback() acid_dto.CommitCoDoma
 		}
 		coDomain := stmt.GetInversePrimitiveGraph().Execute(pl)
 		coDomains = append(coDomains, coDomain)
+		m.logCompensation(m.statementSequence[i], coDomain.GetError())
 		if coDomain.GetError() != nil {
 			return acid_dto.NewCommitCoDomain(
 				coDomains,
@@ -222,6 +284,8 @@ func (m *basicBestEffortTransactionCoordinator) Rollback() acid_dto.CommitCoDoma
 				coDomain.GetError(),
 			)
 		}
+		m.statementGraphs = m.statementGraphs[:i]
+		m.statementSequence = m.statementSequence[:i]
 	}
 	return acid_dto.NewCommitCoDomain(
 		coDomains,
@@ -230,6 +294,54 @@ func (m *basicBestEffortTransactionCoordinator) Rollback() acid_dto.CommitCoDoma
 	)
 }
 
+func (m *basicBestEffortTransactionCoordinator) Savepoint(name string) error {
+	if m.IsRoot() {
+		return errors.New(savepointNoTxnMessage)
+	}
+	m.savepoints = m.savepoints.push(name, len(m.statementGraphs))
+	return nil
+}
+
+// RollbackToSavepoint discards those statements enqueued after the
+// savepoint, compensating any already executed, and leaves earlier
+// statements in effect.
+func (m *basicBestEffortTransactionCoordinator) RollbackToSavepoint(name string) acid_dto.CommitCoDomain {
+	i, err := m.savepoints.find(name)
+	if err != nil {
+		return acid_dto.NewCommitCoDomain(nil, nil, err)
+	}
+	// savepoints set after this one are destroyed
+	// whether or not the rollback succeeds
+	m.savepoints = m.savepoints[:i+1]
+	return m.rollbackFrom(m.savepoints[i].statementIndex)
+}
+
+func (m *basicBestEffortTransactionCoordinator) ReleaseSavepoint(name string) error {
+	i, err := m.savepoints.find(name)
+	if err != nil {
+		return err
+	}
+	m.savepoints = m.savepoints[:i]
+	return nil
+}
+
+func (m *basicBestEffortTransactionCoordinator) logCompensation(stmt Statement, compensationErr error) {
+	record := WALRecord{
+		TxnID: m.txnID,
+		Type:  WALCompensate,
+		Query: stmt.GetQuery(),
+	}
+	if logged, isLogged := stmt.(*walStatement); isLogged {
+		record.StatementID = logged.getStatementID()
+	}
+	if compensationErr != nil {
+		record.Error = compensationErr.Error()
+	}
+	if err := getLogManager(m.tsmInstance).Append(record); err != nil {
+		logging.GetLogger().Errorf("failed to write WAL for transaction '%s': %v", m.txnID, err)
+	}
+}
+
 func (m *basicBestEffortTransactionCoordinator) Enqueue(stmt Statement) error {
 	graphHolder, graphHolderExists := stmt.GetPrimitiveGraphHolder()
 	if !graphHolderExists {
@@ -255,6 +367,33 @@ func (m *basicBestEffortTransactionCoordinator) IsRoot() bool {
 	return m.parent == nil
 }
 
+func (m *basicBestEffortTransactionCoordinator) GetTxnID() string {
+	return m.txnID
+}
+
+func (m *basicBestEffortTransactionCoordinator) GetLockOwnerID() string {
+	return m.lockOwnerID
+}
+
+// Nested transactions share the locks of the outermost transaction.
+func (m *basicBestEffortTransactionCoordinator) getChildLockOwnerID() string {
+	if m.lockOwnerID != "" {
+		return m.lockOwnerID
+	}
+	return getLockManager(m.tsmInstance).NewOwnerID()
+}
+
+func (m *basicBestEffortTransactionCoordinator) releaseLocks() {
+	if m.lockOwnerID == "" || (m.parent != nil && m.parent.GetLockOwnerID() == m.lockOwnerID) {
+		return
+	}
+	getLockManager(m.tsmInstance).ReleaseAll(m.lockOwnerID)
+}
+
+func (m *basicBestEffortTransactionCoordinator) GetIsolationLevel() constants.IsolationLevel {
+	return m.isolationLevel
+}
+
 func (m *basicBestEffortTransactionCoordinator) depth() int {
 	if m.parent != nil {
 		return m.parent.Depth() + 1
@@ -0,0 +1,264 @@
+package tsm_physio //nolint:revive,stylecheck,testpackage // the coordinator is constructed directly
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/stackql/any-sdk/pkg/constants"
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/primitive"
+	"github.com/stackql/stackql/internal/stackql/primitivegraph"
+)
+
+// savepointTestHandlerContext supplies only
+// what rollback requires of the handler context.
+type savepointTestHandlerContext struct {
+	handler.HandlerContext
+}
+
+func (hc *savepointTestHandlerContext) GetOutfile() io.Writer {
+	return io.Discard
+}
+
+func (hc *savepointTestHandlerContext) GetOutErrFile() io.Writer {
+	return io.Discard
+}
+
+// savepointTestStatement is a mutation, queued until commit,
+// whose execution and compensation record its query.
+type savepointTestStatement struct {
+	Statement
+	txn         *savepointTestTxn
+	query       string
+	graphHolder primitivegraph.PrimitiveGraphHolder
+	isExecuted  bool
+}
+
+func (st *savepointTestStatement) GetQuery() string {
+	return st.query
+}
+
+func (st *savepointTestStatement) Execute() internaldto.ExecutorOutput {
+	st.isExecuted = true
+	st.txn.executed = append(st.txn.executed, st.query)
+	if st.txn.failing[st.query] {
+		return internaldto.NewErroneousExecutorOutput(errors.New("failed: " + st.query))
+	}
+	return internaldto.NewEmptyExecutorOutput()
+}
+
+func (st *savepointTestStatement) IsExecuted() bool {
+	return st.isExecuted
+}
+
+func (st *savepointTestStatement) GetPrimitiveGraphHolder() (primitivegraph.PrimitiveGraphHolder, bool) {
+	return st.graphHolder, true
+}
+
+type savepointTestTxn struct {
+	t           *testing.T
+	coordinator Coordinator
+	failing     map[string]bool
+	executed    []string
+	compensated []string
+}
+
+func newSavepointTestTxn(t *testing.T, failing ...string) *savepointTestTxn {
+	root := newBasicBestEffortTransactionCoordinator(nil, &savepointTestHandlerContext{}, nil, "", "", constants.ReadUncommitted, 1)
+	coordinator, err := root.Begin(constants.ReadUncommitted)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	rv := &savepointTestTxn{t: t, coordinator: coordinator, failing: map[string]bool{}}
+	for _, query := range failing {
+		rv.failing[query] = true
+	}
+	return rv
+}
+
+func (txn *savepointTestTxn) enqueue(queries ...string) {
+	for _, query := range queries {
+		graphHolder := primitivegraph.NewPrimitiveGraphHolder(1)
+		graphHolder.CreateInversePrimitiveNode(primitive.NewLocalPrimitive(
+			func(primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
+				txn.compensated = append(txn.compensated, query)
+				return internaldto.NewEmptyExecutorOutput()
+			},
+		))
+		stmt := &savepointTestStatement{txn: txn, query: query, graphHolder: graphHolder}
+		if err := txn.coordinator.Enqueue(stmt); err != nil {
+			txn.t.Fatalf("test failed: %v", err)
+		}
+	}
+}
+
+func (txn *savepointTestTxn) savepoint(name string) {
+	if err := txn.coordinator.Savepoint(name); err != nil {
+		txn.t.Fatalf("test failed: %v", err)
+	}
+}
+
+func (txn *savepointTestTxn) rollbackTo(name string) {
+	if err, hasErr := txn.coordinator.RollbackToSavepoint(name).GetError(); hasErr {
+		txn.t.Fatalf("test failed: %v", err)
+	}
+}
+
+func (txn *savepointTestTxn) commit() error {
+	err, _ := txn.coordinator.Commit().GetError()
+	return err
+}
+
+func expectQueries(t *testing.T, description string, actual []string, expected []string) {
+	if len(expected) == 0 && len(actual) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("test failed: expected %s %v, got %v", description, expected, actual)
+	}
+}
+
+func (txn *savepointTestTxn) expectExecuted(expected ...string) {
+	expectQueries(txn.t, "executions", txn.executed, expected)
+}
+
+func (txn *savepointTestTxn) expectCompensated(expected ...string) {
+	expectQueries(txn.t, "compensations", txn.compensated, expected)
+}
+
+func (txn *savepointTestTxn) expectEnqueued(expected ...string) {
+	var enqueued []string
+	for _, stmt := range txn.coordinator.(*basicBestEffortTransactionCoordinator).statementSequence {
+		enqueued = append(enqueued, stmt.GetQuery())
+	}
+	expectQueries(txn.t, "statements in effect", enqueued, expected)
+}
+
+func TestNestedSavepoints(t *testing.T) {
+	txn := newSavepointTestTxn(t)
+	txn.enqueue("a")
+	txn.savepoint("s1")
+	txn.enqueue("b")
+	txn.savepoint("s2")
+	txn.enqueue("c", "d")
+	// queued statements have not executed, and so are discarded uncompensated
+	txn.rollbackTo("s2")
+	txn.expectEnqueued("a", "b")
+	// the savepoint survives its own rollback
+	txn.enqueue("e")
+	txn.rollbackTo("s2")
+	txn.expectEnqueued("a", "b")
+	txn.rollbackTo("s1")
+	txn.expectEnqueued("a")
+	txn.expectExecuted()
+	txn.expectCompensated()
+	// savepoints set after the target are destroyed
+	if _, hasErr := txn.coordinator.RollbackToSavepoint("s2").GetError(); !hasErr {
+		t.Fatal("test failed: expected error for rollback to destroyed savepoint")
+	}
+}
+
+func TestShadowedSavepoint(t *testing.T) {
+	txn := newSavepointTestTxn(t)
+	txn.savepoint("s")
+	txn.enqueue("a")
+	txn.savepoint("s")
+	txn.enqueue("b")
+	txn.rollbackTo("s")
+	txn.expectEnqueued("a")
+	if err := txn.coordinator.ReleaseSavepoint("s"); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	// the shadowed savepoint is once more visible
+	txn.rollbackTo("s")
+	txn.expectEnqueued()
+	txn.expectCompensated()
+}
+
+func TestRollbackToSavepointThenCommit(t *testing.T) {
+	txn := newSavepointTestTxn(t)
+	txn.enqueue("a")
+	txn.savepoint("s")
+	txn.enqueue("b")
+	txn.rollbackTo("s")
+	txn.enqueue("c", "d")
+	if err := txn.commit(); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	txn.expectExecuted("a", "c", "d")
+	txn.expectCompensated()
+	txn.expectEnqueued("a", "c", "d")
+}
+
+func TestRollbackToSavepointAfterFailedCommit(t *testing.T) {
+	txn := newSavepointTestTxn(t, "c")
+	txn.enqueue("a")
+	txn.savepoint("s")
+	txn.enqueue("b", "c", "d")
+	if err := txn.commit(); err == nil {
+		t.Fatal("test failed: expected commit failure")
+	}
+	txn.expectExecuted("a", "b", "c")
+	// executed statements are compensated, and those yet to execute discarded
+	txn.rollbackTo("s")
+	txn.expectCompensated("c", "b")
+	txn.expectEnqueued("a")
+	delete(txn.failing, "c")
+	txn.enqueue("e")
+	if err := txn.commit(); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	// statements executed by the failed commit are not repeated
+	txn.expectExecuted("a", "b", "c", "e")
+}
+
+func TestReleaseSavepoint(t *testing.T) {
+	txn := newSavepointTestTxn(t)
+	if err := txn.coordinator.ReleaseSavepoint("unknown"); err == nil {
+		t.Fatal("test failed: expected error for release of unknown savepoint")
+	}
+	txn.savepoint("s1")
+	txn.enqueue("a")
+	txn.savepoint("s2")
+	if err := txn.coordinator.ReleaseSavepoint("s1"); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	// release destroys the savepoint and those set after it
+	for _, name := range []string{"s1", "s2"} {
+		if _, hasErr := txn.coordinator.RollbackToSavepoint(name).GetError(); !hasErr {
+			t.Fatalf("test failed: expected error for rollback to released savepoint '%s'", name)
+		}
+	}
+	// released statements remain in effect, and are discarded by rollback
+	txn.expectEnqueued("a")
+	if err, hasErr := txn.coordinator.Rollback().GetError(); hasErr {
+		t.Fatalf("test failed: %v", err)
+	}
+	txn.expectEnqueued()
+	txn.expectExecuted()
+	txn.expectCompensated()
+}
+
+func TestRollbackAfterFailedCommit(t *testing.T) {
+	txn := newSavepointTestTxn(t, "b")
+	txn.enqueue("a", "b", "c")
+	if err := txn.commit(); err == nil {
+		t.Fatal("test failed: expected commit failure")
+	}
+	if err, hasErr := txn.coordinator.Rollback().GetError(); hasErr {
+		t.Fatalf("test failed: %v", err)
+	}
+	txn.expectExecuted("a", "b")
+	txn.expectCompensated("b", "a")
+	txn.expectEnqueued()
+}
+
+func TestSavepointOutsideTransaction(t *testing.T) {
+	root := newBasicBestEffortTransactionCoordinator(nil, &savepointTestHandlerContext{}, nil, "", "", constants.ReadUncommitted, 1)
+	if err := root.Savepoint("s"); err == nil {
+		t.Fatal("test failed: expected error for savepoint outside of a transaction")
+	}
+}
@@ -1,14 +1,17 @@
 package tsm_physio //nolint:revive,stylecheck // prefer this nomenclature
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/stackql/stackql/internal/stackql/acid/binlog"
 	"github.com/stackql/stackql/internal/stackql/acid/tsm"
 	"github.com/stackql/stackql/internal/stackql/acid/txn_context"
 	"github.com/stackql/stackql/internal/stackql/handler"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/parser"
 	"github.com/stackql/stackql/internal/stackql/primitivegraph"
 )
 
@@ -42,9 +45,12 @@ func (orc *bestEffortOrchestrator) processQueryOrQueries(
 ) ([]internaldto.ExecutorOutput, bool) {
 	var retVal []internaldto.ExecutorOutput
 	cmdString := handlerCtx.GetRawQuery()
-	for _, s := range strings.Split(cmdString, ";") {
+	for _, s := range parser.SplitStatements(cmdString) {
+		bufferSQLResults(retVal)
+		start := time.Now()
 		response, hasResponse := orc.processQuery(handlerCtx, s)
 		if hasResponse {
+			observeStatement(s, start, response)
 			retVal = append(retVal, response...)
 		}
 	}
@@ -55,6 +61,7 @@ func (orc *bestEffortOrchestrator) undo(precedingMessages []string) ([]internald
 	rollbackREsponse := orc.txnCoordinator.Rollback()
 	rollbackErr, rollbackErrExists := rollbackREsponse.GetError()
 	if rollbackErrExists {
+		endTransaction(orc.tsmInstance, orc.txnCoordinator, WALAbort, rollbackErr)
 		return []internaldto.ExecutorOutput{
 			internaldto.NewNopEmptyExecutorOutput(
 				precedingMessages,
@@ -62,6 +69,7 @@ func (orc *bestEffortOrchestrator) undo(precedingMessages []string) ([]internald
 			internaldto.NewErroneousExecutorOutput(rollbackErr),
 		}, true
 	}
+	endTransaction(orc.tsmInstance, orc.txnCoordinator, WALRollback, errors.New(strings.Join(precedingMessages, "; ")))
 	return []internaldto.ExecutorOutput{
 		internaldto.NewNopEmptyExecutorOutput(
 			precedingMessages,
@@ -84,7 +92,20 @@ func (orc *bestEffortOrchestrator) processQuery(
 	}
 	clonedCtx := handlerCtx.Clone()
 	clonedCtx.SetQuery(query)
-	transactStatement := NewStatement(query, clonedCtx, txn_context.NewTransactionContext(orc.txnCoordinator.Depth()))
+	transactStatement := newWALStatement(
+		query,
+		clonedCtx,
+		txn_context.NewTransactionContext(orc.txnCoordinator.Depth()),
+		getLogManager(orc.tsmInstance),
+		orc.txnCoordinator,
+	)
+	locker := attachStatementLocker(
+		clonedCtx,
+		getLockManager(orc.tsmInstance),
+		orc.txnCoordinator,
+		transactStatement,
+	)
+	defer locker.releaseAutoCommit()
 	prepareErr := transactStatement.Prepare()
 	if prepareErr != nil {
 		return []internaldto.ExecutorOutput{
@@ -96,7 +117,7 @@ func (orc *bestEffortOrchestrator) processQuery(
 	//       and lazy execution for mutating statements.
 	// TODO: implement transaction stack.
 	if transactStatement.IsBegin() { //nolint:gocritic,nestif // TODO: review
-		txnCoordinator, beginErr := orc.txnCoordinator.Begin()
+		txnCoordinator, beginErr := orc.txnCoordinator.Begin(clonedCtx.TakeTransactionIsolationLevel())
 		if beginErr != nil {
 			return []internaldto.ExecutorOutput{
 				internaldto.NewErroneousExecutorOutput(beginErr),
@@ -114,6 +135,7 @@ func (orc *bestEffortOrchestrator) processQuery(
 				commitErr.Error(),
 			})
 		}
+		endTransaction(orc.tsmInstance, orc.txnCoordinator, WALCommit, nil)
 		retVal := commitCoDomain.GetExecutorOutput()
 		parent, hasParent := orc.txnCoordinator.GetParent()
 		if hasParent {
@@ -129,11 +151,13 @@ func (orc *bestEffortOrchestrator) processQuery(
 		rollbackREsponse := orc.txnCoordinator.Rollback()
 		rollbackErr, rollbackErrExists := rollbackREsponse.GetError()
 		if rollbackErrExists {
+			endTransaction(orc.tsmInstance, orc.txnCoordinator, WALAbort, rollbackErr)
 			retVal = append(retVal, internaldto.NewErroneousExecutorOutput(rollbackErr))
 			retVal = append(retVal, internaldto.NewErroneousExecutorOutput(
 				fmt.Errorf("Rollback failed")))
 			return retVal, true
 		}
+		endTransaction(orc.tsmInstance, orc.txnCoordinator, WALRollback, nil)
 		parent, hasParent := orc.txnCoordinator.GetParent()
 		if hasParent {
 			orc.txnCoordinator = parent
@@ -156,6 +180,12 @@ func (orc *bestEffortOrchestrator) processQuery(
 		)
 		return retVal, true
 	}
+	if savepointOutput, isSavepointStatement := processSavepoint(
+		orc.txnCoordinator,
+		transactStatement,
+	); isSavepointStatement {
+		return savepointOutput, true
+	}
 	if isReadOnly || orc.txnCoordinator.IsRoot() {
 		stmtOutput := transactStatement.Execute()
 		return []internaldto.ExecutorOutput{
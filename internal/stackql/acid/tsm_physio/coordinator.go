@@ -11,11 +11,11 @@ func newCoordinator(tsmInstance tsm.TSM, handlerCtx handler.HandlerContext, maxT
 	rollbackType := handlerCtx.GetRollbackType()
 	switch rollbackType {
 	case constants.NopRollback:
-		return newBasicLazyTransactionCoordinator(tsmInstance, nil, maxTxnDepth)
+		return newBasicLazyTransactionCoordinator(tsmInstance, nil, "", "", handlerCtx.GetIsolationLevel(), maxTxnDepth)
 	case constants.EagerRollback:
-		return newBasicBestEffortTransactionCoordinator(tsmInstance, handlerCtx, nil, maxTxnDepth)
+		return newBasicBestEffortTransactionCoordinator(tsmInstance, handlerCtx, nil, "", "", handlerCtx.GetIsolationLevel(), maxTxnDepth)
 	default:
-		return newBasicLazyTransactionCoordinator(tsmInstance, nil, maxTxnDepth)
+		return newBasicLazyTransactionCoordinator(tsmInstance, nil, "", "", handlerCtx.GetIsolationLevel(), maxTxnDepth)
 	}
 }
 
@@ -24,12 +24,21 @@ func newCoordinator(tsmInstance tsm.TSM, handlerCtx handler.HandlerContext, maxT
 // and that 2PC is performed.
 type Coordinator interface {
 	Statement
-	// Begin a new transaction.
-	Begin() (Coordinator, error)
+	// Begin a new transaction at the supplied isolation level.
+	Begin(isolationLevel constants.IsolationLevel) (Coordinator, error)
 	// Commit the current transaction.
 	Commit() acid_dto.CommitCoDomain
 	// Rollback the current transaction.
 	Rollback() acid_dto.CommitCoDomain
+	// Set a named savepoint at the
+	// current position of the transaction.
+	Savepoint(name string) error
+	// Rollback those statements enqueued after the
+	// savepoint, which itself remains established.
+	RollbackToSavepoint(name string) acid_dto.CommitCoDomain
+	// Release the savepoint and those set after it,
+	// leaving their statements part of the transaction.
+	ReleaseSavepoint(name string) error
 	// Enqueue a transaction operation.
 	// This method will return an error
 	// in the case that the transaction
@@ -42,4 +51,14 @@ type Coordinator interface {
 	GetParent() (Coordinator, bool)
 	//
 	IsRoot() bool
+	// Get the WAL identifier of the transaction,
+	// which is empty for the root.
+	GetTxnID() string
+	// Get the owner of the resource locks taken by the
+	// transaction, which are released upon Commit or Rollback.
+	// Empty for the root, whose statements own their locks.
+	GetLockOwnerID() string
+	// Get the isolation level fixed upon BEGIN.  Statements outside
+	// of an explicit transaction take that of the session instead.
+	GetIsolationLevel() constants.IsolationLevel
 }
@@ -0,0 +1,40 @@
+//go:build unix
+
+package tsm_physio //nolint:revive,stylecheck // prefer this nomenclature
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile blocks until an exclusive
+// advisory lock upon the file is held.
+func lockFile(f *os.File) error {
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if !errors.Is(err, syscall.EINTR) {
+			return err
+		}
+	}
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
+
+// tryLockFile acquires an exclusive advisory lock upon
+// the file, if available, without blocking.
+func tryLockFile(f *os.File) (bool, error) {
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		switch {
+		case err == nil:
+			return true, nil
+		case errors.Is(err, syscall.EWOULDBLOCK):
+			return false, nil
+		case !errors.Is(err, syscall.EINTR):
+			return false, err
+		}
+	}
+}
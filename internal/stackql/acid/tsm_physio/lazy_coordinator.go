@@ -1,8 +1,10 @@
 package tsm_physio //nolint:revive,stylecheck // prefer this nomenclature
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/stackql/any-sdk/pkg/constants"
 	"github.com/stackql/stackql-parser/go/vt/sqlparser"
 	"github.com/stackql/stackql/internal/stackql/acid/acid_dto"
 	"github.com/stackql/stackql/internal/stackql/acid/binlog"
@@ -18,19 +20,33 @@ var (
 type basicLazyTransactionCoordinator struct {
 	tsmInstance       tsm.TSM
 	parent            Coordinator
+	txnID             string
+	lockOwnerID       string
 	statementSequence []Statement
 	undoLogs          []binlog.LogEntry
 	redoLogs          []binlog.LogEntry
 	maxTxnDepth       int
 	outputs           []internaldto.ExecutorOutput
 	isExecuted        bool
+	savepoints        savepointStack
+	isolationLevel    constants.IsolationLevel
 }
 
-func newBasicLazyTransactionCoordinator(tsmInstance tsm.TSM, parent Coordinator, maxTxnDepth int) Coordinator {
+func newBasicLazyTransactionCoordinator(
+	tsmInstance tsm.TSM,
+	parent Coordinator,
+	txnID string,
+	lockOwnerID string,
+	isolationLevel constants.IsolationLevel,
+	maxTxnDepth int,
+) Coordinator {
 	return &basicLazyTransactionCoordinator{
-		tsmInstance: tsmInstance,
-		parent:      parent,
-		maxTxnDepth: maxTxnDepth,
+		tsmInstance:    tsmInstance,
+		parent:         parent,
+		txnID:          txnID,
+		lockOwnerID:    lockOwnerID,
+		isolationLevel: isolationLevel,
+		maxTxnDepth:    maxTxnDepth,
 	}
 }
 
@@ -75,6 +91,22 @@ func (m *basicLazyTransactionCoordinator) IsRollback() bool {
 	return false
 }
 
+func (m *basicLazyTransactionCoordinator) IsSavepoint() bool {
+	return false
+}
+
+func (m *basicLazyTransactionCoordinator) IsRollbackToSavepoint() bool {
+	return false
+}
+
+func (m *basicLazyTransactionCoordinator) IsReleaseSavepoint() bool {
+	return false
+}
+
+func (m *basicLazyTransactionCoordinator) GetSavepointName() (string, bool) {
+	return "", false
+}
+
 func (m *basicLazyTransactionCoordinator) SetUndoLog(log binlog.LogEntry) {
 	m.undoLogs = []binlog.LogEntry{log}
 }
@@ -160,14 +192,24 @@ func (m *basicLazyTransactionCoordinator) GetQuery() string {
 	return ""
 }
 
-func (m *basicLazyTransactionCoordinator) Begin() (Coordinator, error) {
+func (m *basicLazyTransactionCoordinator) Begin(isolationLevel constants.IsolationLevel) (Coordinator, error) {
 	if m.maxTxnDepth >= 0 && m.Depth() >= m.maxTxnDepth {
 		return nil, fmt.Errorf("cannot begin nested transaction of depth = %d", m.Depth()+1)
 	}
-	return newBasicLazyTransactionCoordinator(m.tsmInstance, m, m.maxTxnDepth), nil
+	return newBasicLazyTransactionCoordinator(
+		m.tsmInstance,
+		m,
+		getLogManager(m.tsmInstance).NewTxnID(),
+		m.getChildLockOwnerID(),
+		isolationLevel,
+		m.maxTxnDepth,
+	), nil
 }
 
+// Commit releases locks whatever the outcome,
+// since there is no subsequent rollback.
 func (m *basicLazyTransactionCoordinator) Commit() acid_dto.CommitCoDomain {
+	defer m.releaseLocks()
 	rv, err := m.votingPhase()
 	if err != nil {
 		return acid_dto.NewCommitCoDomain(rv, err, nil)
@@ -180,6 +222,7 @@ func (m *basicLazyTransactionCoordinator) Commit() acid_dto.CommitCoDomain {
 // The redo logs will simply be
 // displayed to the user.
 func (m *basicLazyTransactionCoordinator) Rollback() acid_dto.CommitCoDomain {
+	m.releaseLocks()
 	return acid_dto.NewCommitCoDomain(
 		nil,
 		nil,
@@ -187,6 +230,35 @@ func (m *basicLazyTransactionCoordinator) Rollback() acid_dto.CommitCoDomain {
 	)
 }
 
+func (m *basicLazyTransactionCoordinator) Savepoint(name string) error {
+	if m.IsRoot() {
+		return errors.New(savepointNoTxnMessage)
+	}
+	m.savepoints = m.savepoints.push(name, len(m.statementSequence))
+	return nil
+}
+
+// RollbackToSavepoint discards the statements queued
+// after the savepoint, none of which have executed.
+func (m *basicLazyTransactionCoordinator) RollbackToSavepoint(name string) acid_dto.CommitCoDomain {
+	i, err := m.savepoints.find(name)
+	if err != nil {
+		return acid_dto.NewCommitCoDomain(nil, nil, err)
+	}
+	m.statementSequence = m.statementSequence[:m.savepoints[i].statementIndex]
+	m.savepoints = m.savepoints[:i+1]
+	return acid_dto.NewCommitCoDomain(nil, nil, nil)
+}
+
+func (m *basicLazyTransactionCoordinator) ReleaseSavepoint(name string) error {
+	i, err := m.savepoints.find(name)
+	if err != nil {
+		return err
+	}
+	m.savepoints = m.savepoints[:i]
+	return nil
+}
+
 func (m *basicLazyTransactionCoordinator) Enqueue(stmt Statement) error {
 	m.statementSequence = append(m.statementSequence, stmt)
 	return nil
@@ -200,6 +272,33 @@ func (m *basicLazyTransactionCoordinator) IsRoot() bool {
 	return m.parent == nil
 }
 
+func (m *basicLazyTransactionCoordinator) GetTxnID() string {
+	return m.txnID
+}
+
+func (m *basicLazyTransactionCoordinator) GetLockOwnerID() string {
+	return m.lockOwnerID
+}
+
+// Nested transactions share the locks of the outermost transaction.
+func (m *basicLazyTransactionCoordinator) getChildLockOwnerID() string {
+	if m.lockOwnerID != "" {
+		return m.lockOwnerID
+	}
+	return getLockManager(m.tsmInstance).NewOwnerID()
+}
+
+func (m *basicLazyTransactionCoordinator) releaseLocks() {
+	if m.lockOwnerID == "" || (m.parent != nil && m.parent.GetLockOwnerID() == m.lockOwnerID) {
+		return
+	}
+	getLockManager(m.tsmInstance).ReleaseAll(m.lockOwnerID)
+}
+
+func (m *basicLazyTransactionCoordinator) GetIsolationLevel() constants.IsolationLevel {
+	return m.isolationLevel
+}
+
 func (m *basicLazyTransactionCoordinator) depth() int {
 	if m.parent != nil {
 		return m.parent.Depth() + 1
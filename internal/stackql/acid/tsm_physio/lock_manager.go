@@ -1,11 +1,229 @@
 package tsm_physio //nolint:revive,stylecheck // prefer this nomenclature
 
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stackql/stackql/internal/stackql/acid/locking"
+)
+
 var (
 	_ LockManager = (*lockManager)(nil)
 )
 
+//nolint:gochecknoglobals // process wide singleton
+var (
+	processLockManager     LockManager
+	processLockManagerOnce sync.Once
+)
+
+// LockManager implements strict two phase locking of provider
+// resources, on behalf of owners which are typically transactions.
+// It is shared by all sessions in the process.
 type LockManager interface {
-	//
+	NewOwnerID() string
+	// Acquire blocks until the lock is granted, returning an error
+	// should waiting deadlock, time out or be cancelled.  The lock is
+	// held until ReleaseAll.  Locks are upgraded from shared to exclusive
+	// as required.  A zero timeout waits indefinitely.
+	Acquire(ctx context.Context, ownerID string, key string, mode locking.Mode, timeout time.Duration) error
+	// Await blocks, as does Acquire, but does not take the lock.
+	Await(ctx context.Context, ownerID string, key string, mode locking.Mode, timeout time.Duration) error
+	ReleaseAll(ownerID string)
+}
+
+type lockEntry struct {
+	holders map[string]locking.Mode
+}
+
+// lockRequest is an owner's outstanding request, the
+// edges of the waits-for graph being derived from it.
+type lockRequest struct {
+	key  string
+	mode locking.Mode
+}
+
+type lockManager struct {
+	mutex    sync.Mutex
+	ownerSeq atomic.Int64
+	entries  map[string]*lockEntry
+	owned    map[string]map[string]struct{}
+	waiting  map[string]lockRequest
+	// changed is closed, and replaced, upon each release.
+	changed chan struct{}
+}
+
+func NewLockManager() LockManager {
+	return &lockManager{
+		entries: make(map[string]*lockEntry),
+		owned:   make(map[string]map[string]struct{}),
+		waiting: make(map[string]lockRequest),
+		changed: make(chan struct{}),
+	}
+}
+
+func getProcessLockManager() LockManager {
+	processLockManagerOnce.Do(func() {
+		processLockManager = NewLockManager()
+	})
+	return processLockManager
 }
 
-type lockManager struct{}
+func (lm *lockManager) NewOwnerID() string {
+	return fmt.Sprintf("lock-owner-%d", lm.ownerSeq.Add(1))
+}
+
+func (lm *lockManager) Acquire(
+	ctx context.Context,
+	ownerID string,
+	key string,
+	mode locking.Mode,
+	timeout time.Duration,
+) error {
+	return lm.wait(ctx, ownerID, lockRequest{key: key, mode: mode}, timeout, true)
+}
+
+func (lm *lockManager) Await(
+	ctx context.Context,
+	ownerID string,
+	key string,
+	mode locking.Mode,
+	timeout time.Duration,
+) error {
+	return lm.wait(ctx, ownerID, lockRequest{key: key, mode: mode}, timeout, false)
+}
+
+func (lm *lockManager) wait(
+	ctx context.Context,
+	ownerID string,
+	request lockRequest,
+	timeout time.Duration,
+	isGranted bool,
+) error {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
+	for {
+		if len(lm.getBlockers(ownerID, request)) == 0 {
+			delete(lm.waiting, ownerID)
+			if isGranted {
+				lm.grant(ownerID, request)
+			}
+			return nil
+		}
+		lm.waiting[ownerID] = request
+		if lm.isDeadlocked(ownerID) {
+			delete(lm.waiting, ownerID)
+			return fmt.Errorf("deadlock detected awaiting %s lock on '%s'", request.mode, request.key)
+		}
+		changed := lm.changed
+		lm.mutex.Unlock()
+		var waitErr error
+		select {
+		case <-changed:
+		case <-deadline:
+			waitErr = fmt.Errorf("%s lock on '%s' not obtained within %s", request.mode, request.key, timeout)
+		case <-ctx.Done():
+			waitErr = context.Cause(ctx)
+		}
+		lm.mutex.Lock()
+		if waitErr != nil {
+			delete(lm.waiting, ownerID)
+			return waitErr
+		}
+	}
+}
+
+// getBlockers returns those owners holding locks
+// which conflict with the request.
+func (lm *lockManager) getBlockers(ownerID string, request lockRequest) []string {
+	entry, exists := lm.entries[request.key]
+	if !exists {
+		return nil
+	}
+	var rv []string
+	for holder, mode := range entry.holders {
+		if holder == ownerID {
+			continue
+		}
+		if request.mode == locking.ExclusiveMode || mode == locking.ExclusiveMode {
+			rv = append(rv, holder)
+		}
+	}
+	return rv
+}
+
+// isDeadlocked searches the waits-for graph for a cycle through the owner.
+// Every edge is added by a waiter, which then searches, so the last
+// owner to close any cycle finds it.
+func (lm *lockManager) isDeadlocked(ownerID string) bool {
+	visited := make(map[string]struct{})
+	var search func(string) bool
+	search = func(waiter string) bool {
+		request, isWaiting := lm.waiting[waiter]
+		if !isWaiting {
+			return false
+		}
+		for _, blocker := range lm.getBlockers(waiter, request) {
+			if blocker == ownerID {
+				return true
+			}
+			if _, seen := visited[blocker]; seen {
+				continue
+			}
+			visited[blocker] = struct{}{}
+			if search(blocker) {
+				return true
+			}
+		}
+		return false
+	}
+	return search(ownerID)
+}
+
+func (lm *lockManager) grant(ownerID string, request lockRequest) {
+	entry, exists := lm.entries[request.key]
+	if !exists {
+		entry = &lockEntry{holders: make(map[string]locking.Mode)}
+		lm.entries[request.key] = entry
+	}
+	if held, isHeld := entry.holders[ownerID]; !isHeld || held < request.mode {
+		entry.holders[ownerID] = request.mode
+	}
+	owned, exists := lm.owned[ownerID]
+	if !exists {
+		owned = make(map[string]struct{})
+		lm.owned[ownerID] = owned
+	}
+	owned[request.key] = struct{}{}
+}
+
+func (lm *lockManager) ReleaseAll(ownerID string) {
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
+	owned, exists := lm.owned[ownerID]
+	if !exists {
+		return
+	}
+	for key := range owned {
+		entry, entryExists := lm.entries[key]
+		if !entryExists {
+			continue
+		}
+		delete(entry.holders, ownerID)
+		if len(entry.holders) == 0 {
+			delete(lm.entries, key)
+		}
+	}
+	delete(lm.owned, ownerID)
+	close(lm.changed)
+	lm.changed = make(chan struct{})
+}
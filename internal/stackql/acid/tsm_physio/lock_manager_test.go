@@ -0,0 +1,68 @@
+package tsm_physio_test //nolint:revive,stylecheck // prefer this nomenclature
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/stackql/stackql/internal/stackql/acid/tsm_physio"
+
+	"github.com/stackql/stackql/internal/stackql/acid/locking"
+)
+
+func TestLockModesAndRelease(t *testing.T) {
+	ctx := context.Background()
+	lm := NewLockManager()
+	a, b := lm.NewOwnerID(), lm.NewOwnerID()
+	if err := lm.Acquire(ctx, a, "k", locking.SharedMode, time.Second); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if err := lm.Acquire(ctx, b, "k", locking.SharedMode, time.Second); err != nil {
+		t.Fatalf("test failed: shared locks should be compatible: %v", err)
+	}
+	if err := lm.Acquire(ctx, a, "k", locking.ExclusiveMode, 50*time.Millisecond); err == nil {
+		t.Fatalf("test failed: upgrade should wait upon other shared holders")
+	}
+	granted := make(chan error)
+	go func() {
+		granted <- lm.Acquire(ctx, a, "k", locking.ExclusiveMode, 5*time.Second)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	lm.ReleaseAll(b)
+	if err := <-granted; err != nil {
+		t.Fatalf("test failed: release should wake waiter: %v", err)
+	}
+	if err := lm.Await(ctx, b, "k", locking.SharedMode, 50*time.Millisecond); err == nil {
+		t.Fatalf("test failed: exclusive lock should block readers")
+	}
+	lm.ReleaseAll(a)
+	if err := lm.Acquire(ctx, b, "k", locking.ExclusiveMode, time.Second); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+}
+
+func TestLockDeadlockDetected(t *testing.T) {
+	ctx := context.Background()
+	lm := NewLockManager()
+	a, b := lm.NewOwnerID(), lm.NewOwnerID()
+	if err := lm.Acquire(ctx, a, "x", locking.ExclusiveMode, time.Second); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if err := lm.Acquire(ctx, b, "y", locking.ExclusiveMode, time.Second); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	waiting := make(chan error)
+	go func() {
+		waiting <- lm.Acquire(ctx, a, "y", locking.ExclusiveMode, 5*time.Second)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	err := lm.Acquire(ctx, b, "x", locking.ExclusiveMode, 5*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "deadlock") {
+		t.Fatalf("test failed: expected deadlock, got %v", err)
+	}
+	lm.ReleaseAll(b)
+	if err := <-waiting; err != nil {
+		t.Fatalf("test failed: survivor should proceed: %v", err)
+	}
+}
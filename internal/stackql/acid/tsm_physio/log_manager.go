@@ -1,12 +1,25 @@
 package tsm_physio //nolint:revive,stylecheck // prefer this nomenclature
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/stackql/any-sdk/pkg/logging"
 	"github.com/stackql/stackql/internal/stackql/handler"
 )
 
 var (
 	_ LogManager = (*walManager)(nil)
+	_ LogManager = (*nopLogManager)(nil)
 )
 
 //nolint:gochecknoglobals // singleton pattern
@@ -15,19 +28,713 @@ var (
 	walSingleton LogManager
 )
 
+const (
+	walDirName            string = "wal"
+	walFileName           string = "stackql.wal"
+	walLockFileSuffix     string = ".lock"
+	walCheckpointFileName string = "stackql.wal.checkpoint"
+	walOwnersDirName      string = "owners"
+)
+
+const (
+	// DefaultWALCheckpointSize and DefaultWALCheckpointInterval
+	// batch checkpoints: once a transaction concludes, the WAL is
+	// checkpointed only where it has reached this size, or where
+	// this interval has elapsed since the last checkpoint.
+	DefaultWALCheckpointSize     int64         = 4 << 20
+	DefaultWALCheckpointInterval time.Duration = time.Minute
+)
+
+type WALRecordType string
+
+const (
+	// Transaction boundaries.  BEGIN is written
+	// implicitly ahead of the first record of a transaction.
+	WALBegin    WALRecordType = "BEGIN"
+	WALCommit   WALRecordType = "COMMIT"
+	WALRollback WALRecordType = "ROLLBACK"
+	WALAbort    WALRecordType = "ABORT"
+	// A mutating statement, written ahead of execution.
+	WALRedo WALRecordType = "REDO"
+	// A provider request, written ahead of issue,
+	// and its outcome.
+	WALRequest  WALRecordType = "REQUEST"
+	WALResponse WALRecordType = "RESPONSE"
+	// Compensations outstanding for an executed statement.
+	WALUndo WALRecordType = "UNDO"
+	// A statement which failed during execution.
+	WALFailed WALRecordType = "FAILED"
+	// A compensation executed during rollback or recovery.
+	WALCompensate WALRecordType = "COMPENSATE"
+	// The outcome of an interrupted transaction,
+	// dismissed during recovery without compensation.
+	WALResolved WALRecordType = "RESOLVED"
+)
+
+type WALRecord struct {
+	TxnID         string        `json:"txn_id"`
+	Type          WALRecordType `json:"type"`
+	Time          time.Time     `json:"time"`
+	StatementID   int64         `json:"statement_id,omitempty"`
+	Query         string        `json:"query,omitempty"`
+	Description   string        `json:"description,omitempty"`
+	HumanReadable []string      `json:"human_readable,omitempty"`
+	Raw           []byte        `json:"raw,omitempty"`
+	Error         string        `json:"error,omitempty"`
+	// The process, and the server user, if any, which began the
+	// transaction.  Read from BEGIN records only; the user of
+	// the first record of a transaction is copied to its BEGIN.
+	Owner string `json:"owner,omitempty"`
+	User  string `json:"user,omitempty"`
+}
+
+// LogManager is the write ahead log.
+// Records are durable once written.
 type LogManager interface {
-	//
+	// NewTxnID returns an identifier unique across process lifetimes.
+	NewTxnID() string
+	// Append writes a record, preceded by a BEGIN
+	// record if it is the first for its transaction.
+	Append(WALRecord) error
+	// End writes the terminal record of a transaction,
+	// provided that any record has been written for it.
+	End(txnID string, recordType WALRecordType, err error) error
+	// IsActive is true for transactions of this process
+	// which have records and no outcome, as yet.
+	IsActive(txnID string) bool
+	// AppendRecovery writes a record for a transaction
+	// interrupted in a prior process, without implicit BEGIN.
+	AppendRecovery(WALRecord) error
+	// IsOwnerLive is true where the process which wrote the
+	// supplied BEGIN owner, other than this one, is still running.
+	IsOwnerLive(owner string) bool
+	GetFilePath() (string, bool)
+}
+
+// walManager appends one line per record:
+// the hex crc32 of the record, a space and the record as json.
+// Each write is synced before returning.
+// Writes are serialised across processes sharing the WAL
+// by an exclusive lock upon a sibling lock file.
+// Checkpoints discard the records of concluded transactions,
+// and are batched by size and interval, so that concluding a
+// transaction does not, as a rule, rewrite the WAL.
+// Ahead of its first BEGIN, each process claims an owner file,
+// which it holds locked until exit, so that recovery
+// elsewhere may tell whether the process survives.
+type walManager struct {
+	mutex     sync.Mutex
+	filePath  string
+	file      *os.File
+	lockFile  *os.File
+	ownerFile *os.File
+	epoch     int64
+	txnSeq    uint64
+	openTxns  map[string]struct{}
+	// checkpointSize and checkpointInterval are
+	// as per DefaultWALCheckpointSize; where both
+	// are zero, each conclusion is checkpointed.
+	checkpointSize     int64
+	checkpointInterval time.Duration
+	lastCheckpoint     time.Time
 }
 
-type walManager struct{}
+type nopLogManager struct{}
 
-func getWalManager(_ handler.HandlerContext) (LogManager, error) {
-	var err error
+func getWalManager(handlerCtx handler.HandlerContext) (LogManager, error) {
 	walOnce.Do(func() {
+		walSingleton = newLogManagerForRoot(handlerCtx.GetRuntimeContext().ApplicationFilesRootPath)
+	})
+	return walSingleton, nil
+}
+
+// GetLogManager returns the WAL for the application files root.
+func GetLogManager(handlerCtx handler.HandlerContext) (LogManager, error) {
+	return getWalManager(handlerCtx)
+}
+
+func newLogManagerForRoot(appRoot string) LogManager {
+	if appRoot == "" {
+		return &nopLogManager{}
+	}
+	return NewLogManager(GetWALFilePath(appRoot))
+}
+
+// GetWALFilePath returns the WAL location under the application files root.
+func GetWALFilePath(appRoot string) string {
+	return filepath.Join(appRoot, walDirName, walFileName)
+}
+
+// NewLogManager returns a WAL writing to the supplied file,
+// which is created upon the first write, checkpointed
+// as per DefaultWALCheckpointSize.
+func NewLogManager(filePath string) LogManager {
+	return NewLogManagerWithCheckpoint(filePath, DefaultWALCheckpointSize, DefaultWALCheckpointInterval)
+}
+
+// NewLogManagerWithCheckpoint is as per NewLogManager,
+// with the supplied checkpoint size and interval.
+func NewLogManagerWithCheckpoint(filePath string, size int64, interval time.Duration) LogManager {
+	now := time.Now()
+	return &walManager{
+		filePath:           filePath,
+		epoch:              now.UnixNano(),
+		openTxns:           make(map[string]struct{}),
+		checkpointSize:     size,
+		checkpointInterval: interval,
+		lastCheckpoint:     now,
+	}
+}
+
+func (w *walManager) GetFilePath() (string, bool) {
+	return w.filePath, true
+}
+
+func (w *walManager) NewTxnID() string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.txnSeq++
+	return fmt.Sprintf("%x-%d", w.epoch, w.txnSeq)
+}
+
+func (w *walManager) Append(record WALRecord) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	unlock, err := w.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, isOpen := w.openTxns[record.TxnID]; !isOpen {
+		if err = w.claimOwner(); err != nil {
+			return err
+		}
+		begin := WALRecord{
+			TxnID: record.TxnID,
+			Type:  WALBegin,
+			Owner: w.getOwner(),
+			User:  record.User,
+		}
+		if err = w.write(begin); err != nil {
+			return err
+		}
+		w.openTxns[record.TxnID] = struct{}{}
+	}
+	return w.write(record)
+}
+
+func (w *walManager) End(txnID string, recordType WALRecordType, err error) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if _, isOpen := w.openTxns[txnID]; !isOpen {
+		return nil
+	}
+	unlock, lockErr := w.lock()
+	if lockErr != nil {
+		return lockErr
+	}
+	defer unlock()
+	delete(w.openTxns, txnID)
+	record := WALRecord{TxnID: txnID, Type: recordType}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if writeErr := w.write(record); writeErr != nil {
+		return writeErr
+	}
+	w.checkpoint()
+	return nil
+}
+
+func (w *walManager) IsActive(txnID string) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	_, isOpen := w.openTxns[txnID]
+	return isOpen
+}
+
+func (w *walManager) AppendRecovery(record WALRecord) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	unlock, err := w.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err = w.write(record); err != nil {
+		return err
+	}
+	switch record.Type { //nolint:exhaustive // only outcomes conclude a transaction
+	case WALCommit, WALRollback, WALAbort, WALResolved:
+		w.checkpoint()
+	}
+	return nil
+}
+
+func (w *walManager) IsOwnerLive(owner string) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if owner == "" || owner == w.getOwner() {
+		return false
+	}
+	unlock, err := w.lock()
+	if err != nil {
+		// err on the side of leaving the transaction be
+		logging.GetLogger().Warnf("cannot determine liveness of WAL owner '%s': %v", owner, err)
+		return true
+	}
+	defer unlock()
+	isLive, err := w.isOwnerLive(owner)
+	if err != nil {
+		logging.GetLogger().Warnf("cannot determine liveness of WAL owner '%s': %v", owner, err)
+		return true
+	}
+	return isLive
+}
+
+func (w *walManager) getOwner() string {
+	return fmt.Sprintf("%x", w.epoch)
+}
+
+func (w *walManager) getOwnersDir() string {
+	return filepath.Join(filepath.Dir(w.filePath), walOwnersDirName)
+}
+
+// claimOwner must be called with the WAL locked,
+// so that no other process mistakes the owner
+// file, prior to its lock, for that of a dead process.
+func (w *walManager) claimOwner() error {
+	if w.ownerFile != nil {
+		return nil
+	}
+	if err := os.MkdirAll(w.getOwnersDir(), 0o700); err != nil {
+		return fmt.Errorf("failed to create WAL owners directory: %w", err)
+	}
+	f, err := os.OpenFile(
+		filepath.Join(w.getOwnersDir(), w.getOwner()+walLockFileSuffix),
+		os.O_RDWR|os.O_CREATE,
+		0o600,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to open WAL owner: %w", err)
+	}
+	if err = lockFile(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to lock WAL owner: %w", err)
+	}
+	// held until exit, whereupon the lock is released
+	w.ownerFile = f
+	return nil
+}
+
+// isOwnerLive must be called with the WAL locked.
+// An owner which is not live has its file removed.
+func (w *walManager) isOwnerLive(owner string) (bool, error) {
+	ownerPath := filepath.Join(w.getOwnersDir(), owner+walLockFileSuffix)
+	f, err := os.OpenFile(ownerPath, os.O_RDWR, 0o600)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	isLocked, err := tryLockFile(f)
+	if err != nil || !isLocked {
+		f.Close()
+		return !isLocked, err
+	}
+	unlockFile(f) //nolint:errcheck // released upon close regardless
+	f.Close()
+	return false, os.Remove(ownerPath)
+}
+
+// sweepOwners removes the files of dead owners, and must be
+// called with the WAL locked.  It is best effort.
+func (w *walManager) sweepOwners() {
+	entries, err := os.ReadDir(w.getOwnersDir())
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		owner, isOwnerFile := strings.CutSuffix(entry.Name(), walLockFileSuffix)
+		if !isOwnerFile || owner == w.getOwner() {
+			continue
+		}
+		if _, err = w.isOwnerLive(owner); err != nil {
+			logging.GetLogger().Warnf("failed to sweep WAL owner '%s': %v", owner, err)
+		}
+	}
+}
+
+// lock serialises access to the WAL across processes;
+// the returned func releases the lock.
+func (w *walManager) lock() (func(), error) {
+	if w.lockFile == nil {
+		if err := os.MkdirAll(filepath.Dir(w.filePath), 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create WAL directory: %w", err)
+		}
+		f, err := os.OpenFile(w.filePath+walLockFileSuffix, os.O_RDWR|os.O_CREATE, 0o600)
 		if err != nil {
-			return
+			return nil, fmt.Errorf("failed to open WAL lock: %w", err)
+		}
+		w.lockFile = f
+	}
+	if err := lockFile(w.lockFile); err != nil {
+		return nil, fmt.Errorf("failed to lock WAL: %w", err)
+	}
+	return func() {
+		if err := unlockFile(w.lockFile); err != nil {
+			logging.GetLogger().Errorf("failed to unlock WAL: %v", err)
+		}
+	}, nil
+}
+
+func (w *walManager) write(record WALRecord) error {
+	if err := w.open(); err != nil {
+		return err
+	}
+	if record.Time.IsZero() {
+		record.Time = time.Now().UTC()
+	}
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line := fmt.Sprintf("%08x %s\n", crc32.ChecksumIEEE(b), b)
+	if _, err = w.file.WriteString(line); err != nil {
+		return fmt.Errorf("failed to write WAL: %w", err)
+	}
+	return w.file.Sync()
+}
+
+// open terminates any torn final line
+// left behind by a crash, so that it
+// does not corrupt the next record.
+// A WAL replaced by the checkpoint of
+// another process is reopened.
+func (w *walManager) open() error {
+	if w.file != nil {
+		if w.isCurrent() {
+			return nil
+		}
+		w.file.Close()
+		w.file = nil
+	}
+	if err := os.MkdirAll(filepath.Dir(w.filePath), 0o700); err != nil {
+		return fmt.Errorf("failed to create WAL directory: %w", err)
+	}
+	f, err := os.OpenFile(w.filePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open WAL: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err = f.ReadAt(last, info.Size()-1); err != nil {
+			f.Close()
+			return err
+		}
+		if last[0] != '\n' {
+			if _, err = f.WriteString("\n"); err != nil {
+				f.Close()
+				return err
+			}
 		}
-		walSingleton = &walManager{}
+	}
+	w.file = f
+	return nil
+}
+
+func (w *walManager) isCurrent() bool {
+	openInfo, err := w.file.Stat()
+	if err != nil {
+		return false
+	}
+	pathInfo, err := os.Stat(w.filePath)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(openInfo, pathInfo)
+}
+
+// checkpoint is best effort, since the WAL
+// remains correct, if larger, without it.
+// It is skipped until the WAL reaches the checkpoint
+// size or the checkpoint interval elapses.
+func (w *walManager) checkpoint() {
+	if !w.isCheckpointDue() {
+		return
+	}
+	w.lastCheckpoint = time.Now()
+	if err := w.checkpointWAL(); err != nil {
+		logging.GetLogger().Warnf("failed to checkpoint WAL: %v", err)
+	}
+	w.sweepOwners()
+}
+
+// isCheckpointDue measures the WAL upon disk,
+// so as to account for the writes of other processes.
+func (w *walManager) isCheckpointDue() bool {
+	if time.Since(w.lastCheckpoint) >= w.checkpointInterval {
+		return true
+	}
+	info, err := os.Stat(w.filePath)
+	if err != nil {
+		return false
+	}
+	return info.Size() >= w.checkpointSize
+}
+
+// checkpointWAL retains the records of transactions yet to conclude,
+// of this and any other process, and discards the remainder.
+// Where nothing is retained, the WAL is truncated in place.
+// Otherwise, retained records are copied to a new WAL,
+// which then replaces the old one.
+func (w *walManager) checkpointWAL() error {
+	outcomes := newWALOutcomes()
+	if err := ScanWAL(w.filePath, outcomes.add); err != nil {
+		return err
+	}
+	if !outcomes.hasConcludedTxns() {
+		return nil
+	}
+	if !outcomes.hasRetainedTxns() {
+		if err := w.open(); err != nil {
+			return err
+		}
+		if err := w.file.Truncate(0); err != nil {
+			return err
+		}
+		return w.file.Sync()
+	}
+	checkpointPath := filepath.Join(filepath.Dir(w.filePath), walCheckpointFileName)
+	if err := writeRetainedWAL(w.filePath, checkpointPath, outcomes); err != nil {
+		os.Remove(checkpointPath)
+		return err
+	}
+	// the WAL must be closed prior to rename on some platforms
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+	if err := os.Rename(checkpointPath, w.filePath); err != nil {
+		os.Remove(checkpointPath)
+		return err
+	}
+	syncDir(filepath.Dir(w.filePath))
+	return nil
+}
+
+func writeRetainedWAL(walPath string, checkpointPath string, outcomes *walOutcomes) error {
+	f, err := os.OpenFile(checkpointPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	bw := bufio.NewWriter(f)
+	err = scanWALLines(walPath, func(line []byte, record WALRecord) error {
+		if !outcomes.isRetained(record.TxnID) {
+			return nil
+		}
+		if _, writeErr := bw.Write(line); writeErr != nil {
+			return writeErr
+		}
+		return bw.WriteByte('\n')
+	})
+	if err != nil {
+		return err
+	}
+	if err = bw.Flush(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// syncDir persists a rename, where the platform permits.
+func syncDir(dirPath string) {
+	d, err := os.Open(dirPath)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	d.Sync() //nolint:errcheck // unsupported on some platforms
+}
+
+// walOutcomes tracks, per transaction, whether it has concluded.
+// As per recovery, an aborted transaction remains outstanding
+// until each statement with compensations is compensated.
+type walOutcomes struct {
+	txns         map[string]*walTxnOutcome
+	hasConcluded bool
+}
+
+type walTxnOutcome struct {
+	isConcluded   bool
+	isAborted     bool
+	uncompensated map[int64]struct{}
+}
+
+func newWALOutcomes() *walOutcomes {
+	return &walOutcomes{
+		txns: make(map[string]*walTxnOutcome),
+	}
+}
+
+func (o *walOutcomes) add(record WALRecord) error {
+	txn, exists := o.txns[record.TxnID]
+	if !exists {
+		if record.Type != WALBegin {
+			// records of transactions already discarded
+			o.hasConcluded = true
+			return nil
+		}
+		o.txns[record.TxnID] = &walTxnOutcome{uncompensated: make(map[int64]struct{})}
+		return nil
+	}
+	switch record.Type { //nolint:exhaustive // remaining records do not bear upon the outcome
+	case WALUndo:
+		txn.uncompensated[record.StatementID] = struct{}{}
+	case WALCompensate:
+		if record.Error == "" {
+			delete(txn.uncompensated, record.StatementID)
+		}
+	case WALAbort:
+		txn.isAborted = true
+	case WALCommit, WALRollback, WALResolved:
+		txn.isConcluded = true
+	}
+	return nil
+}
+
+func (o *walOutcomes) isRetained(txnID string) bool {
+	txn, exists := o.txns[txnID]
+	if !exists {
+		return false
+	}
+	return !(txn.isConcluded || (txn.isAborted && len(txn.uncompensated) == 0))
+}
+
+func (o *walOutcomes) hasConcludedTxns() bool {
+	if o.hasConcluded {
+		return true
+	}
+	for txnID := range o.txns {
+		if !o.isRetained(txnID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *walOutcomes) hasRetainedTxns() bool {
+	for txnID := range o.txns {
+		if o.isRetained(txnID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *nopLogManager) NewTxnID() string {
+	return ""
+}
+
+func (w *nopLogManager) Append(WALRecord) error {
+	return nil
+}
+
+func (w *nopLogManager) End(string, WALRecordType, error) error {
+	return nil
+}
+
+func (w *nopLogManager) IsActive(string) bool {
+	return false
+}
+
+func (w *nopLogManager) AppendRecovery(WALRecord) error {
+	return nil
+}
+
+func (w *nopLogManager) IsOwnerLive(string) bool {
+	return false
+}
+
+func (w *nopLogManager) GetFilePath() (string, bool) {
+	return "", false
+}
+
+// ReadWAL returns all intact records in the WAL, in order.
+// The WAL is bounded by checkpoints, but
+// ScanWAL is preferable where it suffices.
+func ReadWAL(filePath string) ([]WALRecord, error) {
+	var rv []WALRecord
+	err := ScanWAL(filePath, func(record WALRecord) error {
+		rv = append(rv, record)
+		return nil
 	})
-	return walSingleton, err
+	return rv, err
+}
+
+// ScanWAL passes each intact record in the WAL, in order,
+// to the supplied func, reading one record at a time.
+// A record torn by a crash during its write is
+// discarded, since it cannot have been acted upon.
+// A missing WAL is empty.
+func ScanWAL(filePath string, fn func(WALRecord) error) error {
+	return scanWALLines(filePath, func(_ []byte, record WALRecord) error {
+		return fn(record)
+	})
+}
+
+func scanWALLines(filePath string, fn func([]byte, WALRecord) error) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	reader := bufio.NewReader(f)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return readErr
+		}
+		line = bytes.TrimSuffix(line, []byte("\n"))
+		if len(line) > 0 {
+			record, parseErr := parseWALLine(line)
+			// torn lines are terminated upon reopening,
+			// so may precede intact records
+			if parseErr == nil {
+				if err = fn(line, record); err != nil {
+					return err
+				}
+			}
+		}
+		if readErr != nil {
+			return nil
+		}
+	}
+}
+
+func parseWALLine(line []byte) (WALRecord, error) {
+	var rv WALRecord
+	checksumStr, payload, found := bytes.Cut(line, []byte(" "))
+	if !found {
+		return rv, fmt.Errorf("malformed WAL record")
+	}
+	var checksum uint32
+	if _, err := fmt.Sscanf(string(checksumStr), "%08x", &checksum); err != nil {
+		return rv, fmt.Errorf("malformed WAL record checksum")
+	}
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return rv, fmt.Errorf("WAL record checksum mismatch")
+	}
+	err := json.Unmarshal(payload, &rv)
+	return rv, err
 }
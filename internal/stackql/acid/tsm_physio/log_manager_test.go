@@ -0,0 +1,245 @@
+package tsm_physio_test //nolint:revive,stylecheck // prefer this nomenclature
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/stackql/stackql/internal/stackql/acid/tsm_physio"
+)
+
+// newEagerLogManager checkpoints upon each conclusion.
+func newEagerLogManager(walPath string) LogManager {
+	return NewLogManagerWithCheckpoint(walPath, 0, 0)
+}
+
+func appendRecords(t *testing.T, wal LogManager, records ...WALRecord) {
+	for _, record := range records {
+		if err := wal.Append(record); err != nil {
+			t.Fatalf("test failed: %v", err)
+		}
+	}
+}
+
+func expectRecordTypes(t *testing.T, walPath string, expected ...WALRecordType) []WALRecord {
+	records, err := ReadWAL(walPath)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("test failed: expected %d records, got %d: %v", len(expected), len(records), records)
+	}
+	for i, record := range records {
+		if record.Type != expected[i] {
+			t.Fatalf("test failed: record %d expected type %s, got %s", i, expected[i], record.Type)
+		}
+	}
+	return records
+}
+
+func TestWALRoundTripSurvivesTornWrite(t *testing.T) {
+	walPath := GetWALFilePath(t.TempDir())
+	wal := NewLogManager(walPath)
+	txnID := wal.NewTxnID()
+	appendRecords(t, wal,
+		WALRecord{TxnID: txnID, Type: WALRedo, Query: "delete from google.compute.instances where instance = 'x'"},
+		WALRecord{TxnID: txnID, Type: WALRequest, Description: "DELETE https://compute.googleapis.com/x"},
+		WALRecord{TxnID: txnID, Type: WALUndo, HumanReadable: []string{"Undo the delete on google.compute.instances"}},
+	)
+	// simulate a crash part way through a write
+	f, err := os.OpenFile(walPath, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	fmt.Fprint(f, `deadbeef {"txn_id":"`)
+	f.Close()
+	restarted := NewLogManager(walPath)
+	if restarted.IsActive(txnID) {
+		t.Fatal("test failed: transaction of a prior process reported active")
+	}
+	laterTxnID := restarted.NewTxnID()
+	if laterTxnID == txnID {
+		t.Fatal("test failed: transaction ID reused across processes")
+	}
+	appendRecords(t, restarted, WALRecord{TxnID: laterTxnID, Type: WALRedo, Query: "exec x"})
+	records := expectRecordTypes(t, walPath, WALBegin, WALRedo, WALRequest, WALUndo, WALBegin, WALRedo)
+	if records[3].HumanReadable[0] != "Undo the delete on google.compute.instances" || records[5].TxnID != laterTxnID {
+		t.Fatal("test failed: record content mismatch")
+	}
+	missing, err := ReadWAL(filepath.Join(t.TempDir(), "absent.wal"))
+	if err != nil || len(missing) != 0 {
+		t.Fatal("test failed: expected missing WAL to be empty")
+	}
+}
+
+func TestWALCommitCheckpointsAndReopens(t *testing.T) {
+	walPath := GetWALFilePath(t.TempDir())
+	wal := newEagerLogManager(walPath)
+	committedTxnID := wal.NewTxnID()
+	openTxnID := wal.NewTxnID()
+	appendRecords(t, wal,
+		WALRecord{TxnID: committedTxnID, Type: WALRedo, StatementID: 1, Query: "insert into a"},
+		WALRecord{TxnID: openTxnID, Type: WALRedo, StatementID: 2, Query: "insert into b"},
+		WALRecord{TxnID: committedTxnID, Type: WALResponse, StatementID: 1, Description: "POST a -> 200"},
+	)
+	if !wal.IsActive(committedTxnID) || !wal.IsActive(openTxnID) {
+		t.Fatal("test failed: expected both transactions active")
+	}
+	if err := wal.End(committedTxnID, WALCommit, nil); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if wal.IsActive(committedTxnID) {
+		t.Fatal("test failed: committed transaction reported active")
+	}
+	// the committed transaction is discarded at checkpoint
+	records := expectRecordTypes(t, walPath, WALBegin, WALRedo)
+	if records[1].TxnID != openTxnID || records[1].Query != "insert into b" {
+		t.Fatalf("test failed: unexpected retained record %v", records[1])
+	}
+	// the open transaction continues after the checkpoint,
+	// and its records are legible to a later process
+	appendRecords(t, wal, WALRecord{TxnID: openTxnID, Type: WALResponse, StatementID: 2, Description: "POST b -> 200"})
+	reopened := newEagerLogManager(walPath)
+	laterTxnID := reopened.NewTxnID()
+	appendRecords(t, reopened, WALRecord{TxnID: laterTxnID, Type: WALRedo, StatementID: 3, Query: "insert into c"})
+	records = expectRecordTypes(t, walPath, WALBegin, WALRedo, WALResponse, WALBegin, WALRedo)
+	if records[2].Description != "POST b -> 200" || records[4].TxnID != laterTxnID {
+		t.Fatalf("test failed: unexpected records after reopen %v", records)
+	}
+	if err := wal.End(openTxnID, WALRollback, nil); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if err := reopened.End(laterTxnID, WALCommit, nil); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	info, err := os.Stat(walPath)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if info.Size() != 0 {
+		t.Fatalf("test failed: expected WAL truncated once all transactions concluded, size %d", info.Size())
+	}
+}
+
+func TestWALCheckpointsBatchedBySize(t *testing.T) {
+	walPath := GetWALFilePath(t.TempDir())
+	wal := NewLogManagerWithCheckpoint(walPath, 1024, time.Hour)
+	var concluded int
+	for {
+		txnID := wal.NewTxnID()
+		appendRecords(t, wal, WALRecord{TxnID: txnID, Type: WALRedo, Query: "insert into a"})
+		info, err := os.Stat(walPath)
+		if err != nil {
+			t.Fatalf("test failed: %v", err)
+		}
+		isCheckpointDue := info.Size() >= 1024
+		if err = wal.End(txnID, WALCommit, nil); err != nil {
+			t.Fatalf("test failed: %v", err)
+		}
+		concluded++
+		records, err := ReadWAL(walPath)
+		if err != nil {
+			t.Fatalf("test failed: %v", err)
+		}
+		if !isCheckpointDue {
+			// concluded transactions remain until the WAL reaches the checkpoint size
+			if len(records) != 3*concluded {
+				t.Fatalf("test failed: expected %d records prior to checkpoint, got %d", 3*concluded, len(records))
+			}
+			continue
+		}
+		if len(records) != 0 {
+			t.Fatalf("test failed: expected WAL discarded at checkpoint, got %d records", len(records))
+		}
+		if concluded < 2 {
+			t.Fatal("test failed: expected checkpoint to be deferred")
+		}
+		return
+	}
+}
+
+func TestWALCheckpointsBatchedByInterval(t *testing.T) {
+	walPath := GetWALFilePath(t.TempDir())
+	wal := NewLogManagerWithCheckpoint(walPath, DefaultWALCheckpointSize, 50*time.Millisecond)
+	for i := 0; i < 2; i++ {
+		txnID := wal.NewTxnID()
+		appendRecords(t, wal, WALRecord{TxnID: txnID, Type: WALRedo, Query: "insert into a"})
+		if err := wal.End(txnID, WALCommit, nil); err != nil {
+			t.Fatalf("test failed: %v", err)
+		}
+	}
+	expectRecordTypes(t, walPath, WALBegin, WALRedo, WALCommit, WALBegin, WALRedo, WALCommit)
+	time.Sleep(50 * time.Millisecond)
+	txnID := wal.NewTxnID()
+	appendRecords(t, wal, WALRecord{TxnID: txnID, Type: WALRedo, Query: "insert into a"})
+	if err := wal.End(txnID, WALCommit, nil); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	expectRecordTypes(t, walPath)
+}
+
+func TestWALCheckpointRetainsAbortUntilCompensated(t *testing.T) {
+	walPath := GetWALFilePath(t.TempDir())
+	wal := newEagerLogManager(walPath)
+	txnID := wal.NewTxnID()
+	appendRecords(t, wal,
+		WALRecord{TxnID: txnID, Type: WALRedo, StatementID: 1, Query: "insert into a"},
+		WALRecord{TxnID: txnID, Type: WALUndo, StatementID: 1, HumanReadable: []string{"delete a"}},
+	)
+	if err := wal.End(txnID, WALAbort, fmt.Errorf("rollback failed")); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	expectRecordTypes(t, walPath, WALBegin, WALRedo, WALUndo, WALAbort)
+	// as per recovery in a later process
+	recovering := newEagerLogManager(walPath)
+	if err := recovering.AppendRecovery(WALRecord{TxnID: txnID, Type: WALCompensate, StatementID: 1, Error: "timeout"}); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if err := recovering.AppendRecovery(WALRecord{TxnID: txnID, Type: WALAbort}); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	expectRecordTypes(t, walPath, WALBegin, WALRedo, WALUndo, WALAbort, WALCompensate, WALAbort)
+	if err := recovering.AppendRecovery(WALRecord{TxnID: txnID, Type: WALCompensate, StatementID: 1}); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if err := recovering.AppendRecovery(WALRecord{TxnID: txnID, Type: WALRollback}); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	expectRecordTypes(t, walPath)
+}
+
+func TestWALOwnerLiveness(t *testing.T) {
+	walPath := GetWALFilePath(t.TempDir())
+	wal := NewLogManager(walPath)
+	txnID := wal.NewTxnID()
+	appendRecords(t, wal, WALRecord{TxnID: txnID, Type: WALRedo, StatementID: 1, Query: "insert into a", User: "alice"})
+	records := expectRecordTypes(t, walPath, WALBegin, WALRedo)
+	owner := records[0].Owner
+	if owner == "" || records[0].User != "alice" {
+		t.Fatalf("test failed: unexpected BEGIN record %v", records[0])
+	}
+	if wal.IsOwnerLive(owner) {
+		t.Fatal("test failed: own transactions are subject to IsActive rather than liveness")
+	}
+	// as per a concurrent process
+	other := NewLogManager(walPath)
+	if !other.IsOwnerLive(owner) {
+		t.Fatal("test failed: owner holding its lock reported dead")
+	}
+	if other.IsOwnerLive("") || other.IsOwnerLive("absent") {
+		t.Fatal("test failed: owner without a lock file reported live")
+	}
+	// as per a process which exited without concluding its transactions
+	deadOwnerPath := filepath.Join(filepath.Dir(walPath), "owners", "dead.lock")
+	if err := os.WriteFile(deadOwnerPath, nil, 0o600); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if other.IsOwnerLive("dead") {
+		t.Fatal("test failed: unlocked owner reported live")
+	}
+	if _, err := os.Stat(deadOwnerPath); !os.IsNotExist(err) {
+		t.Fatalf("test failed: expected dead owner file removed, got %v", err)
+	}
+}
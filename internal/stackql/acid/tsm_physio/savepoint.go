@@ -0,0 +1,34 @@
+package tsm_physio //nolint:revive,stylecheck // prefer this nomenclature
+
+import (
+	"fmt"
+)
+
+const (
+	savepointNoTxnMessage = "savepoints can only be used in transaction blocks"
+)
+
+// A savepoint marks the position in
+// the statement sequence at which it was set.
+type savepoint struct {
+	name           string
+	statementIndex int
+}
+
+// Savepoints may be shadowed by later
+// savepoints of the same name.
+type savepointStack []savepoint
+
+func (s savepointStack) push(name string, statementIndex int) savepointStack {
+	return append(s, savepoint{name: name, statementIndex: statementIndex})
+}
+
+// find returns the position of the most recent savepoint of the name.
+func (s savepointStack) find(name string) (int, error) {
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i].name == name {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("savepoint \"%s\" does not exist", name)
+}
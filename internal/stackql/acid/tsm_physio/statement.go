@@ -22,6 +22,12 @@ type Statement interface {
 	IsCommit() bool
 	IsExecuted() bool
 	IsRollback() bool
+	IsSavepoint() bool
+	IsRollbackToSavepoint() bool
+	IsReleaseSavepoint() bool
+	// Get the name of the savepoint addressed by a
+	// SAVEPOINT, ROLLBACK TO SAVEPOINT or RELEASE statement.
+	GetSavepointName() (string, bool)
 	GetQuery() string
 }
 
@@ -77,6 +83,50 @@ func (st *basicStatement) IsRollback() bool {
 	return false
 }
 
+func (st *basicStatement) IsSavepoint() bool {
+	ast, hasAst := st.GetAST()
+	if hasAst {
+		_, isSavepoint := ast.(*sqlparser.Savepoint)
+		return isSavepoint
+	}
+	return false
+}
+
+func (st *basicStatement) IsRollbackToSavepoint() bool {
+	ast, hasAst := st.GetAST()
+	if hasAst {
+		_, isRollbackToSavepoint := ast.(*sqlparser.SRollback)
+		return isRollbackToSavepoint
+	}
+	return false
+}
+
+func (st *basicStatement) IsReleaseSavepoint() bool {
+	ast, hasAst := st.GetAST()
+	if hasAst {
+		_, isRelease := ast.(*sqlparser.Release)
+		return isRelease
+	}
+	return false
+}
+
+func (st *basicStatement) GetSavepointName() (string, bool) {
+	ast, hasAst := st.GetAST()
+	if !hasAst {
+		return "", false
+	}
+	switch node := ast.(type) {
+	case *sqlparser.Savepoint:
+		return node.Name.Lowered(), true
+	case *sqlparser.SRollback:
+		return node.Name.Lowered(), true
+	case *sqlparser.Release:
+		return node.Name.Lowered(), true
+	default:
+		return "", false
+	}
+}
+
 func (st *basicStatement) IsReadOnly() bool {
 	if st.querySubmitter == nil {
 		return true
@@ -86,14 +136,14 @@ func (st *basicStatement) IsReadOnly() bool {
 
 func (st *basicStatement) GetUndoLog() (binlog.LogEntry, bool) {
 	if st.querySubmitter != nil {
-		st.querySubmitter.GetUndoLog()
+		return st.querySubmitter.GetUndoLog()
 	}
 	return nil, false
 }
 
 func (st *basicStatement) GetRedoLog() (binlog.LogEntry, bool) {
 	if st.querySubmitter != nil {
-		st.querySubmitter.GetRedoLog()
+		return st.querySubmitter.GetRedoLog()
 	}
 	return nil, false
 }
@@ -0,0 +1,81 @@
+package tsm_physio //nolint:revive,stylecheck // prefer this nomenclature
+
+import (
+	"context"
+	"time"
+
+	"github.com/stackql/any-sdk/pkg/constants"
+	"github.com/stackql/stackql/internal/stackql/acid/locking"
+	"github.com/stackql/stackql/internal/stackql/handler"
+)
+
+var (
+	_ locking.ResourceLocker = &statementLocker{}
+)
+
+// statementLocker takes the resource locks required by the provider
+// requests of a statement, per the isolation level of the session:
+//   - read uncommitted: none.
+//   - read committed: exclusive locks for mutations, reads
+//     awaiting conflicting locks but taking none.
+//   - repeatable read and serializable: shared locks for reads also.
+//
+// Locks are held until the transaction ends or, outside of an
+// explicit transaction, until the statement completes.
+// Only mutating statements take exclusive locks.
+type statementLocker struct {
+	lockManager    LockManager
+	ownerID        string
+	isAutoCommit   bool
+	isolationLevel constants.IsolationLevel
+	timeout        time.Duration
+	statement      Statement
+}
+
+// attachStatementLocker decorates the handler context private to the statement.
+func attachStatementLocker(
+	handlerCtx handler.HandlerContext,
+	lockManager LockManager,
+	txnCoordinator Coordinator,
+	statement Statement,
+) *statementLocker {
+	isAutoCommit := txnCoordinator.IsRoot()
+	ownerID := txnCoordinator.GetLockOwnerID()
+	isolationLevel := txnCoordinator.GetIsolationLevel()
+	if isAutoCommit {
+		ownerID = lockManager.NewOwnerID()
+		isolationLevel = handlerCtx.GetIsolationLevel()
+	}
+	rv := &statementLocker{
+		lockManager:    lockManager,
+		ownerID:        ownerID,
+		isAutoCommit:   isAutoCommit,
+		isolationLevel: isolationLevel,
+		timeout:        handlerCtx.GetLockTimeout(),
+		statement:      statement,
+	}
+	handlerCtx.SetContext(locking.NewLockerContext(handlerCtx.GetContext(), rv))
+	return rv
+}
+
+func (l *statementLocker) Lock(ctx context.Context, key string, isMutation bool) error {
+	if l.isolationLevel == constants.ReadUncommitted {
+		return nil
+	}
+	if isMutation && !l.statement.IsReadOnly() {
+		return l.lockManager.Acquire(ctx, l.ownerID, key, locking.ExclusiveMode, l.timeout)
+	}
+	if l.isolationLevel == constants.ReadCommitted {
+		return l.lockManager.Await(ctx, l.ownerID, key, locking.SharedMode, l.timeout)
+	}
+	return l.lockManager.Acquire(ctx, l.ownerID, key, locking.SharedMode, l.timeout)
+}
+
+// releaseAutoCommit releases the locks of a statement
+// executed outside of an explicit transaction, which
+// are otherwise released by the transaction coordinator.
+func (l *statementLocker) releaseAutoCommit() {
+	if l.isAutoCommit {
+		l.lockManager.ReleaseAll(l.ownerID)
+	}
+}
@@ -19,6 +19,36 @@ func GetTSM(handlerCtx handler.HandlerContext) (tsm.TSM, error) {
 		return nil, walErr
 	}
 	return &tsmImplementation{
-		logManager: walManager,
+		logManager:  walManager,
+		lockManager: getProcessLockManager(),
 	}, nil
 }
+
+func (t *tsmImplementation) getLogManager() LogManager {
+	return t.logManager
+}
+
+// getLogManager tolerates foreign TSM implementations,
+// which are not logged.
+func getLogManager(tsmInstance tsm.TSM) LogManager {
+	impl, isImpl := tsmInstance.(*tsmImplementation)
+	if !isImpl || impl.getLogManager() == nil {
+		return &nopLogManager{}
+	}
+	return impl.getLogManager()
+}
+
+func (t *tsmImplementation) getLockManager() LockManager {
+	return t.lockManager
+}
+
+// getLockManager returns the process lock manager
+// for foreign TSM implementations, since locks
+// must be shared by all sessions.
+func getLockManager(tsmInstance tsm.TSM) LockManager {
+	impl, isImpl := tsmInstance.(*tsmImplementation)
+	if !isImpl || impl.getLockManager() == nil {
+		return getProcessLockManager()
+	}
+	return impl.getLockManager()
+}
@@ -2,13 +2,17 @@ package tsm_physio //nolint:revive,stylecheck // prefer this nomenclature
 
 import (
 	"fmt"
-	"strings"
+	"sync"
+	"time"
 
 	"github.com/stackql/any-sdk/pkg/constants"
+	"github.com/stackql/any-sdk/pkg/logging"
 	"github.com/stackql/stackql/internal/stackql/acid/tsm"
 	"github.com/stackql/stackql/internal/stackql/acid/txn_context"
 	"github.com/stackql/stackql/internal/stackql/handler"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/metrics"
+	"github.com/stackql/stackql/internal/stackql/parser"
 )
 
 type Orchestrator interface {
@@ -52,6 +56,90 @@ func newBestEffortTxnOrchestrator(
 	}, nil
 }
 
+// observeStatement records the statement's metrics once any streamed
+// results are released, such that the latency spans reading them;
+// any erroneous output, or failure to read, marks it failed.
+func observeStatement(query string, start time.Time, outputs []internaldto.ExecutorOutput) {
+	var mutex sync.Mutex
+	isError := false
+	for _, output := range outputs {
+		if output.GetError() != nil {
+			isError = true
+			break
+		}
+	}
+	pending := len(outputs) + 1
+	observe := func(err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		isError = isError || err != nil
+		if pending--; pending == 0 {
+			metrics.ObserveQuery(metrics.GetStatementType(query), isError, time.Since(start))
+		}
+	}
+	for _, output := range outputs {
+		internaldto.OnSQLResultReleased(output, observe)
+	}
+	observe(nil)
+}
+
+// bufferSQLResults reads through any streamed results of earlier
+// statements, so that backend rows are not held open while later
+// statements execute.
+func bufferSQLResults(outputs []internaldto.ExecutorOutput) {
+	for _, output := range outputs {
+		if err := internaldto.BufferSQLResult(output); err != nil {
+			logging.GetLogger().Errorf("failed to buffer statement result: %v", err)
+		}
+	}
+}
+
+// endTransaction records the outcome of an explicit transaction.
+// Failure to do so is not fatal, since the outcome has already occurred.
+func endTransaction(tsmInstance tsm.TSM, txnCoordinator Coordinator, recordType WALRecordType, err error) {
+	txnID := txnCoordinator.GetTxnID()
+	if endErr := getLogManager(tsmInstance).End(txnID, recordType, err); endErr != nil {
+		logging.GetLogger().Errorf("failed to write WAL for transaction '%s': %v", txnID, endErr)
+	}
+}
+
+// processSavepoint handles SAVEPOINT, ROLLBACK TO SAVEPOINT
+// and RELEASE, which do not end the transaction.
+func processSavepoint(
+	txnCoordinator Coordinator,
+	transactStatement Statement,
+) ([]internaldto.ExecutorOutput, bool) {
+	name, isSavepointStatement := transactStatement.GetSavepointName()
+	if !isSavepointStatement {
+		return nil, false
+	}
+	switch {
+	case transactStatement.IsSavepoint():
+		if err := txnCoordinator.Savepoint(name); err != nil {
+			return []internaldto.ExecutorOutput{internaldto.NewErroneousExecutorOutput(err)}, true
+		}
+	case transactStatement.IsRollbackToSavepoint():
+		rollbackCoDomain := txnCoordinator.RollbackToSavepoint(name)
+		if rollbackErr, rollbackErrExists := rollbackCoDomain.GetError(); rollbackErrExists {
+			return []internaldto.ExecutorOutput{
+				internaldto.NewErroneousExecutorOutput(rollbackErr),
+				internaldto.NewErroneousExecutorOutput(
+					fmt.Errorf("rollback to savepoint \"%s\" failed", name)),
+			}, true
+		}
+		return []internaldto.ExecutorOutput{
+			internaldto.NewNopEmptyExecutorOutput([]string{"Rollback OK"}),
+		}, true
+	case transactStatement.IsReleaseSavepoint():
+		if err := txnCoordinator.ReleaseSavepoint(name); err != nil {
+			return []internaldto.ExecutorOutput{internaldto.NewErroneousExecutorOutput(err)}, true
+		}
+	}
+	return []internaldto.ExecutorOutput{
+		internaldto.NewNopEmptyExecutorOutput([]string{"OK"}),
+	}, true
+}
+
 type standardOrchestrator struct {
 	tsmInstance    tsm.TSM
 	txnCoordinator Coordinator
@@ -68,9 +156,12 @@ func (orc *standardOrchestrator) processQueryOrQueries(
 ) ([]internaldto.ExecutorOutput, bool) {
 	var retVal []internaldto.ExecutorOutput
 	cmdString := handlerCtx.GetRawQuery()
-	for _, s := range strings.Split(cmdString, ";") {
+	for _, s := range parser.SplitStatements(cmdString) {
+		bufferSQLResults(retVal)
+		start := time.Now()
 		response, hasResponse := orc.processQuery(handlerCtx, s)
 		if hasResponse {
+			observeStatement(s, start, response)
 			retVal = append(retVal, response...)
 		}
 	}
@@ -87,7 +178,20 @@ func (orc *standardOrchestrator) processQuery(
 	}
 	clonedCtx := handlerCtx.Clone()
 	clonedCtx.SetQuery(query)
-	transactStatement := NewStatement(query, clonedCtx, txn_context.NewTransactionContext(orc.txnCoordinator.Depth()))
+	transactStatement := newWALStatement(
+		query,
+		clonedCtx,
+		txn_context.NewTransactionContext(orc.txnCoordinator.Depth()),
+		getLogManager(orc.tsmInstance),
+		orc.txnCoordinator,
+	)
+	locker := attachStatementLocker(
+		clonedCtx,
+		getLockManager(orc.tsmInstance),
+		orc.txnCoordinator,
+		transactStatement,
+	)
+	defer locker.releaseAutoCommit()
 	prepareErr := transactStatement.Prepare()
 	if prepareErr != nil {
 		return []internaldto.ExecutorOutput{
@@ -99,7 +203,7 @@ func (orc *standardOrchestrator) processQuery(
 	//       and lazy execution for mutating statements.
 	// TODO: implement transaction stack.
 	if transactStatement.IsBegin() { //nolint:gocritic,nestif // TODO: review
-		txnCoordinator, beginErr := orc.txnCoordinator.Begin()
+		txnCoordinator, beginErr := orc.txnCoordinator.Begin(clonedCtx.TakeTransactionIsolationLevel())
 		if beginErr != nil {
 			return []internaldto.ExecutorOutput{
 				internaldto.NewErroneousExecutorOutput(beginErr),
@@ -113,6 +217,7 @@ func (orc *standardOrchestrator) processQuery(
 		commitCoDomain := orc.txnCoordinator.Commit()
 		commitErr, commitErrExists := commitCoDomain.GetError()
 		if commitErrExists {
+			endTransaction(orc.tsmInstance, orc.txnCoordinator, WALAbort, commitErr)
 			retVal := []internaldto.ExecutorOutput{
 				internaldto.NewErroneousExecutorOutput(commitErr),
 			}
@@ -129,6 +234,7 @@ func (orc *standardOrchestrator) processQuery(
 			}
 			return retVal, true
 		}
+		endTransaction(orc.tsmInstance, orc.txnCoordinator, WALCommit, nil)
 		retVal := commitCoDomain.GetExecutorOutput()
 		parent, hasParent := orc.txnCoordinator.GetParent()
 		if hasParent {
@@ -144,7 +250,10 @@ func (orc *standardOrchestrator) processQuery(
 		rollbackREsponse := orc.txnCoordinator.Rollback()
 		rollbackErr, rollbackErrExists := rollbackREsponse.GetError()
 		if rollbackErrExists {
+			endTransaction(orc.tsmInstance, orc.txnCoordinator, WALAbort, rollbackErr)
 			retVal = append(retVal, internaldto.NewErroneousExecutorOutput(rollbackErr))
+		} else {
+			endTransaction(orc.tsmInstance, orc.txnCoordinator, WALRollback, nil)
 		}
 		parent, hasParent := orc.txnCoordinator.GetParent()
 		if hasParent {
@@ -159,6 +268,12 @@ func (orc *standardOrchestrator) processQuery(
 		)
 		return retVal, true
 	}
+	if savepointOutput, isSavepointStatement := processSavepoint(
+		orc.txnCoordinator,
+		transactStatement,
+	); isSavepointStatement {
+		return savepointOutput, true
+	}
 	if isReadOnly || orc.txnCoordinator.IsRoot() {
 		stmtOutput := transactStatement.Execute()
 		return []internaldto.ExecutorOutput{
@@ -0,0 +1,201 @@
+package tsm_physio //nolint:revive,stylecheck // prefer this nomenclature
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/stackql/any-sdk/pkg/logging"
+	"github.com/stackql/stackql/internal/stackql/acid/binlog"
+	"github.com/stackql/stackql/internal/stackql/acid/txn_context"
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+)
+
+//nolint:gochecknoglobals // process wide sequence
+var walStatementSequence atomic.Int64
+
+var (
+	_ Statement               = &walStatement{}
+	_ binlog.MutationRecorder = &walRecorder{}
+)
+
+// walStatement writes ahead each mutating statement,
+// followed by its outcome and any compensations required to undo it.
+// Outside of an explicit transaction, the statement is
+// logged as a transaction of its own.
+type walStatement struct {
+	Statement
+	logManager   LogManager
+	recorder     *walRecorder
+	txnID        string
+	statementID  int64
+	isAutoCommit bool
+	user         string
+}
+
+// walRecorder logs the provider requests issued by a statement,
+// once that statement is known to be mutating.
+type walRecorder struct {
+	mutex       sync.Mutex
+	logManager  LogManager
+	txnID       string
+	statementID int64
+	isActive    bool
+}
+
+// newWALStatement must be supplied a handler context private
+// to the statement, which is decorated with the request recorder.
+func newWALStatement(
+	query string,
+	handlerCtx handler.HandlerContext,
+	transactionContext txn_context.ITransactionContext,
+	logManager LogManager,
+	txnCoordinator Coordinator,
+) Statement {
+	recorder := &walRecorder{
+		logManager: logManager,
+	}
+	handlerCtx.SetContext(binlog.NewRecorderContext(handlerCtx.GetContext(), recorder))
+	isAutoCommit := txnCoordinator.IsRoot()
+	txnID := txnCoordinator.GetTxnID()
+	if isAutoCommit {
+		txnID = logManager.NewTxnID()
+	}
+	var user string
+	if policy := handlerCtx.GetAuthorisationPolicy(); policy != nil {
+		user = policy.GetUsername()
+	}
+	return &walStatement{
+		Statement:    NewStatement(query, handlerCtx, transactionContext),
+		logManager:   logManager,
+		recorder:     recorder,
+		txnID:        txnID,
+		statementID:  walStatementSequence.Add(1),
+		isAutoCommit: isAutoCommit,
+		user:         user,
+	}
+}
+
+func (st *walStatement) isLogged() bool {
+	if _, isSavepointStatement := st.GetSavepointName(); isSavepointStatement {
+		return false
+	}
+	return !(st.IsReadOnly() || st.IsBegin() || st.IsCommit() || st.IsRollback())
+}
+
+func (st *walStatement) Execute() internaldto.ExecutorOutput {
+	if !st.isLogged() {
+		return st.Statement.Execute()
+	}
+	redoErr := st.logManager.Append(WALRecord{
+		TxnID:       st.txnID,
+		Type:        WALRedo,
+		StatementID: st.statementID,
+		Query:       st.GetQuery(),
+		User:        st.user,
+	})
+	if redoErr != nil {
+		return internaldto.NewErroneousExecutorOutput(fmt.Errorf("cannot write ahead mutating statement: %w", redoErr))
+	}
+	st.recorder.activate(st.txnID, st.statementID)
+	output := st.Statement.Execute()
+	if err := st.logOutcome(output); err != nil {
+		logging.GetLogger().Errorf("failed to write WAL for transaction '%s': %v", st.txnID, err)
+	}
+	return output
+}
+
+func (st *walStatement) logOutcome(output internaldto.ExecutorOutput) error {
+	if output == nil {
+		output = internaldto.NewEmptyExecutorOutput()
+	}
+	undoLog, undoLogExists := output.GetUndoLog()
+	if undoLogExists && undoLog != nil && (len(undoLog.GetHumanReadable()) > 0 || undoLog.Size() > 0) {
+		err := st.logManager.Append(WALRecord{
+			TxnID:         st.txnID,
+			Type:          WALUndo,
+			StatementID:   st.statementID,
+			Query:         st.GetQuery(),
+			HumanReadable: undoLog.GetHumanReadable(),
+			Raw:           undoLog.GetRaw(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	execErr := output.GetError()
+	if execErr != nil {
+		err := st.logManager.Append(WALRecord{
+			TxnID:       st.txnID,
+			Type:        WALFailed,
+			StatementID: st.statementID,
+			Query:       st.GetQuery(),
+			Error:       execErr.Error(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if !st.isAutoCommit {
+		return nil
+	}
+	if execErr != nil {
+		return st.logManager.End(st.txnID, WALAbort, execErr)
+	}
+	return st.logManager.End(st.txnID, WALCommit, nil)
+}
+
+func (st *walStatement) getStatementID() int64 {
+	return st.statementID
+}
+
+func (r *walRecorder) activate(txnID string, statementID int64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.txnID = txnID
+	r.statementID = statementID
+	r.isActive = true
+}
+
+func (r *walRecorder) getTxnID() (string, int64, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.txnID, r.statementID, r.isActive
+}
+
+func (r *walRecorder) RecordRequest(description string) error {
+	txnID, statementID, isActive := r.getTxnID()
+	if !isActive {
+		return nil
+	}
+	err := r.logManager.Append(WALRecord{
+		TxnID:       txnID,
+		Type:        WALRequest,
+		StatementID: statementID,
+		Description: description,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot write ahead provider request: %w", err)
+	}
+	return nil
+}
+
+func (r *walRecorder) RecordResponse(description string, requestErr error) {
+	txnID, statementID, isActive := r.getTxnID()
+	if !isActive {
+		return
+	}
+	record := WALRecord{
+		TxnID:       txnID,
+		Type:        WALResponse,
+		StatementID: statementID,
+		Description: description,
+	}
+	if requestErr != nil {
+		record.Error = requestErr.Error()
+	}
+	if err := r.logManager.Append(record); err != nil {
+		logging.GetLogger().Errorf("failed to write WAL for transaction '%s': %v", txnID, err)
+	}
+}
@@ -1,10 +1,13 @@
 package asyncmonitor
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
-	"strings"
+	"net/url"
 	"time"
 
 	"github.com/stackql/any-sdk/anysdk"
@@ -21,7 +24,8 @@ import (
 )
 
 var (
-	MonitorPollIntervalSeconds int = 10 //nolint:revive,gochecknoglobals // TODO: global vars refactor
+	MonitorPollIntervalSeconds    int = 10 //nolint:revive,gochecknoglobals // TODO: global vars refactor
+	MonitorMaxPollIntervalSeconds int = 60 //nolint:revive,gochecknoglobals // TODO: global vars refactor
 )
 
 type IAsyncMonitor interface {
@@ -40,12 +44,14 @@ type AsyncHTTPMonitorPrimitive struct {
 	op                  anysdk.OperationStore
 	initialCtx          primitive.IPrimitiveCtx
 	precursor           primitive.IPrimitive
-	executor            func(pc primitive.IPrimitiveCtx, initalBody interface{}) internaldto.ExecutorOutput
+	executor            func(pc primitive.IPrimitiveCtx, initial internaldto.ExecutorOutput) internaldto.ExecutorOutput
 	elapsedSeconds      int
 	pollIntervalSeconds int
+	timeout             time.Duration
 	noStatus            bool
 	id                  int64
 	comments            sqlparser.CommentDirectives
+	description         primitive.Description
 }
 
 func (pr *AsyncHTTPMonitorPrimitive) SetTxnID(_ int) {
@@ -67,6 +73,18 @@ func (pr *AsyncHTTPMonitorPrimitive) WithDebugName(_ string) primitive.IPrimitiv
 	return pr
 }
 
+func (pr *AsyncHTTPMonitorPrimitive) WithDescription(description primitive.Description) primitive.IPrimitive {
+	pr.description = description
+	return pr
+}
+
+func (pr *AsyncHTTPMonitorPrimitive) GetDescription() primitive.Description {
+	if pr.description != nil {
+		return pr.description
+	}
+	return primitive.NewDescription("AsyncHTTPMonitorPrimitive")
+}
+
 func (pr *AsyncHTTPMonitorPrimitive) SetUndoLog(_ binlog.LogEntry) {
 }
 
@@ -105,8 +123,8 @@ func (pr *AsyncHTTPMonitorPrimitive) Execute(pc primitive.IPrimitiveCtx) interna
 			pr.initialCtx.GetAuthContext,
 			pc.GetWriter(),
 			pc.GetErrWriter(),
-		)
-		return pr.executor(asyP, subPr.GetOutputBody())
+		).WithContext(primitive.ContextOf(pc))
+		return pr.executor(asyP, subPr)
 	}
 	return internaldto.NewExecutorOutput(nil, nil, nil, nil, nil)
 }
@@ -129,51 +147,74 @@ func NewAsyncMonitor(
 	prov provider.IProvider,
 	op anysdk.OperationStore,
 ) (IAsyncMonitor, error) {
-	//nolint:gocritic //TODO: refactor
-	switch prov.GetProviderString() {
-	case "google":
-		return newGoogleAsyncMonitor(handlerCtx, prov, op, prov.GetVersion())
+	strategy, err := GetPollingStrategy(prov.GetProviderString(), op)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"async operation monitor for provider = '%s', api version = '%s' currently not supported: %w",
+			prov.GetProviderString(), prov.GetVersion(), err)
 	}
-	return nil, fmt.Errorf(
-		"async operation monitor for provider = '%s', api version = '%s' currently not supported",
-		prov.GetProviderString(), prov.GetVersion())
+	return &standardAsyncMonitor{
+		handlerCtx: handlerCtx,
+		prov:       prov,
+		op:         op,
+		strategy:   strategy,
+		dispatch: func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			return httpmiddleware.HTTPApiCallFromRequest(ctx, handlerCtx.Clone(), prov, op, req)
+		},
+	}, nil
 }
 
-func newGoogleAsyncMonitor(
-	handlerCtx handler.HandlerContext,
-	prov provider.IProvider,
-	op anysdk.OperationStore,
-	version string, //nolint:unparam // TODO: refactor
-) (IAsyncMonitor, error) {
-	//nolint:gocritic //TODO: refactor
-	switch version {
-	default:
-		return &DefaultGoogleAsyncMonitor{
-			handlerCtx: handlerCtx,
-			prov:       prov,
-			op:         op,
-		}, nil
-	}
+// CheckAwaitable returns an error should the operation have no polling strategy.
+func CheckAwaitable(prov provider.IProvider, op anysdk.OperationStore) error {
+	_, err := GetPollingStrategy(prov.GetProviderString(), op)
+	return err
 }
 
-type DefaultGoogleAsyncMonitor struct {
+type standardAsyncMonitor struct {
 	handlerCtx handler.HandlerContext
 	prov       provider.IProvider
 	op         anysdk.OperationStore
+	strategy   PollingStrategy
+	// dispatch issues poll requests.
+	dispatch func(ctx context.Context, req *http.Request) (*http.Response, error)
 }
 
-func (gm *DefaultGoogleAsyncMonitor) GetMonitorPrimitive(
+func (am *standardAsyncMonitor) GetMonitorPrimitive(
 	prov provider.IProvider,
 	op anysdk.OperationStore,
 	precursor primitive.IPrimitive,
 	initialCtx primitive.IPrimitiveCtx,
 	comments sqlparser.CommentDirectives,
 ) (primitive.IPrimitive, error) {
-	//nolint:gocritic,staticcheck //TODO: refactor
-	switch strings.ToLower(prov.GetVersion()) {
-	default:
-		return gm.getV1Monitor(prov, op, precursor, initialCtx, comments)
+	pollIntervalSeconds := MonitorPollIntervalSeconds
+	if am.strategy.PollIntervalSeconds > 0 {
+		pollIntervalSeconds = am.strategy.PollIntervalSeconds
 	}
+	asyncPrim := AsyncHTTPMonitorPrimitive{
+		handlerCtx:          am.handlerCtx,
+		prov:                prov,
+		op:                  op,
+		initialCtx:          initialCtx,
+		precursor:           precursor,
+		elapsedSeconds:      0,
+		pollIntervalSeconds: pollIntervalSeconds,
+		timeout:             am.handlerCtx.GetAwaitTimeout(),
+		comments:            comments,
+	}
+	if comments != nil {
+		asyncPrim.noStatus = comments.IsSet("NOSTATUS")
+		timeout, hasTimeout, err := GetAwaitTimeout(comments)
+		if err != nil {
+			return nil, err
+		}
+		if hasTimeout {
+			asyncPrim.timeout = timeout
+		}
+	}
+	asyncPrim.executor = func(pc primitive.IPrimitiveCtx, initial internaldto.ExecutorOutput) internaldto.ExecutorOutput {
+		return am.monitor(&asyncPrim, pc, initial)
+	}
+	return &asyncPrim, nil
 }
 
 func getOperationDescriptor(body map[string]interface{}) string {
@@ -195,114 +236,131 @@ func getOperationDescriptor(body map[string]interface{}) string {
 	return operationDescriptor
 }
 
-//nolint:gocognit,funlen // review later
-func (gm *DefaultGoogleAsyncMonitor) getV1Monitor(
-	prov provider.IProvider,
-	op anysdk.OperationStore,
-	precursor primitive.IPrimitive,
-	initialCtx primitive.IPrimitiveCtx,
-	comments sqlparser.CommentDirectives,
-) (primitive.IPrimitive, error) {
-	asyncPrim := AsyncHTTPMonitorPrimitive{
-		handlerCtx:          gm.handlerCtx,
-		prov:                prov,
-		op:                  op,
-		initialCtx:          initialCtx,
-		precursor:           precursor,
-		elapsedSeconds:      0,
-		pollIntervalSeconds: MonitorPollIntervalSeconds,
-		comments:            comments,
+// monitor polls, per the strategy, until the operation begun by the
+// initial response completes, backing off between polls, or until
+// the maximum wait elapses.
+//
+//nolint:funlen // review later
+func (am *standardAsyncMonitor) monitor(
+	asyncPrim *AsyncHTTPMonitorPrimitive,
+	pc primitive.IPrimitiveCtx,
+	initial internaldto.ExecutorOutput,
+) internaldto.ExecutorOutput {
+	if pc == nil {
+		return internaldto.NewErroneousExecutorOutput(fmt.Errorf("cannot execute monitor: nil plan primitive"))
 	}
-	if comments != nil {
-		asyncPrim.noStatus = comments.IsSet("NOSTATUS")
+	body := initial.GetOutputBody()
+	var header http.Header
+	var origin *url.URL
+	if response, hasResponse := initial.GetHTTPResponse(); hasResponse {
+		header = response.Header
+		if response.Request != nil {
+			origin = response.Request.URL
+		}
 	}
-	m := gm.op
-	if m.IsAwaitable() { //nolint:nestif // encapulation probably sufficient
-		asyncPrim.executor = func(pc primitive.IPrimitiveCtx, bd interface{}) internaldto.ExecutorOutput {
-			body, ok := bd.(map[string]interface{})
-			if !ok {
-				return internaldto.NewExecutorOutput(
-					nil,
-					nil,
-					nil,
-					nil,
-					fmt.Errorf("cannot execute monitor: response body of type '%T' unreadable", bd),
-				)
-			}
-			if pc == nil {
-				return internaldto.NewExecutorOutput(nil, nil, nil, nil, fmt.Errorf("cannot execute monitor: nil plan primitive"))
-			}
-			if body == nil {
-				return internaldto.NewExecutorOutput(nil, nil, nil, nil, fmt.Errorf("cannot execute monitor: no body present"))
-			}
-			logging.GetLogger().Infoln(fmt.Sprintf("body = %v", body))
-
-			operationDescriptor := getOperationDescriptor(body)
-			endTime, endTimeOk := body["endTime"]
-			if endTimeOk && endTime != "" {
-				return prepareReultSet(&asyncPrim, pc, body, operationDescriptor)
-			}
-			url, ok := body["selfLink"]
-			if !ok {
-				return internaldto.NewExecutorOutput(
-					nil,
-					nil,
-					nil,
-					nil,
-					fmt.Errorf("cannot execute monitor: no 'selfLink' property present"),
-				)
-			}
-			prStr := gm.prov.GetProviderString()
-			authCtx, err := pc.GetAuthContext(prStr)
-			if err != nil {
-				return internaldto.NewExecutorOutput(nil, nil, nil, nil, err)
-			}
-			if authCtx == nil {
-				return internaldto.NewExecutorOutput(nil, nil, nil, nil, fmt.Errorf("cannot execute monitor: no auth context"))
-			}
-			time.Sleep(time.Duration(asyncPrim.pollIntervalSeconds) * time.Second)
-			asyncPrim.elapsedSeconds += asyncPrim.pollIntervalSeconds
-			if !asyncPrim.noStatus {
-				//nolint:errcheck //TODO: handle error
-				pc.GetWriter().Write(
-					[]byte(
-						fmt.Sprintf(
-							"%s in progress, %d seconds elapsed",
-							operationDescriptor,
-							asyncPrim.elapsedSeconds,
-						) + fmt.Sprintln(""),
-					),
-				)
-			}
-			req, err := getMonitorRequest(url.(string))
-			if req != nil && req.Body != nil {
-				defer req.Body.Close()
-			}
-			if err != nil {
-				return internaldto.NewExecutorOutput(nil, nil, nil, nil, err)
-			}
-			response, apiErr := httpmiddleware.HTTPApiCallFromRequest(gm.handlerCtx.Clone(), gm.prov, m, req)
-			if response != nil && response.Body != nil {
-				defer response.Body.Close()
-			}
-			if apiErr != nil {
-				return internaldto.NewExecutorOutput(nil, nil, nil, nil, apiErr)
-			}
-			target, err := m.DeprecatedProcessResponse(response)
-			gm.handlerCtx.LogHTTPResponseMap(target)
-			if err != nil {
-				return internaldto.NewExecutorOutput(nil, nil, nil, nil, err)
+	logging.GetLogger().Infoln(fmt.Sprintf("body = %v", body))
+	operationDescriptor := getOperationDescriptor(body)
+	isComplete, err := am.strategy.IsInitiallyComplete(header, body)
+	var pollTarget string
+	start := time.Now()
+	backoff := newPollBackoff(
+		time.Duration(asyncPrim.pollIntervalSeconds)*time.Second,
+		time.Duration(MonitorMaxPollIntervalSeconds)*time.Second,
+	)
+	for {
+		if err != nil {
+			return internaldto.NewErroneousExecutorOutput(fmt.Errorf("%s failed: %w", operationDescriptor, err))
+		}
+		if isComplete {
+			return prepareReultSet(asyncPrim, pc, body, operationDescriptor)
+		}
+		if target, hasTarget := am.strategy.GetPollTarget(header, body); hasTarget {
+			pollTarget = target
+		}
+		if pollTarget == "" {
+			return internaldto.NewErroneousExecutorOutput(
+				fmt.Errorf("cannot execute monitor: no %s present", am.strategy.describeTarget()))
+		}
+		authCtx, authErr := pc.GetAuthContext(am.prov.GetProviderString())
+		if authErr != nil {
+			return internaldto.NewErroneousExecutorOutput(authErr)
+		}
+		if authCtx == nil {
+			return internaldto.NewErroneousExecutorOutput(fmt.Errorf("cannot execute monitor: no auth context"))
+		}
+		wait := backoff.next()
+		if asyncPrim.timeout > 0 {
+			remaining := asyncPrim.timeout - time.Since(start)
+			if remaining <= 0 {
+				return internaldto.NewErroneousExecutorOutput(&AwaitTimeoutError{
+					OperationDescriptor: operationDescriptor,
+					Timeout:             asyncPrim.timeout,
+					LastBody:            body,
+				})
 			}
-			return asyncPrim.executor(internaldto.NewBasicPrimitiveContext(
-				pc.GetAuthContext,
-				pc.GetWriter(),
-				pc.GetErrWriter(),
-			),
-				target)
+			wait = min(wait, remaining)
+		}
+		ctx := primitive.ContextOf(pc)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return internaldto.NewErroneousExecutorOutput(context.Cause(ctx))
+		case <-timer.C:
+		}
+		asyncPrim.elapsedSeconds = int(time.Since(start).Round(time.Second).Seconds())
+		if !asyncPrim.noStatus {
+			//nolint:errcheck //TODO: handle error
+			pc.GetWriter().Write(
+				[]byte(
+					fmt.Sprintf(
+						"%s in progress, %d seconds elapsed",
+						operationDescriptor,
+						asyncPrim.elapsedSeconds,
+					) + fmt.Sprintln(""),
+				),
+			)
+		}
+		req, reqErr := am.strategy.NewPollRequest(origin, pollTarget)
+		if reqErr != nil {
+			return internaldto.NewErroneousExecutorOutput(reqErr)
+		}
+		response, apiErr := am.dispatch(ctx, req)
+		if apiErr != nil {
+			return internaldto.NewErroneousExecutorOutput(apiErr)
 		}
-		return &asyncPrim, nil
+		var statusCode int
+		statusCode, header, body, err = readPollResponse(response)
+		if err != nil {
+			return internaldto.NewErroneousExecutorOutput(err)
+		}
+		am.handlerCtx.LogHTTPResponseMap(body)
+		if statusCode >= http.StatusBadRequest {
+			return internaldto.NewErroneousExecutorOutput(
+				fmt.Errorf("%s poll over HTTP error: %s", operationDescriptor, response.Status))
+		}
+		if body != nil {
+			operationDescriptor = getOperationDescriptor(body)
+		}
+		isComplete, err = am.strategy.IsComplete(statusCode, body)
 	}
-	return nil, fmt.Errorf("method %s is not awaitable", m.GetName())
+}
+
+func readPollResponse(response *http.Response) (int, http.Header, map[string]interface{}, error) {
+	defer response.Body.Close()
+	b, err := io.ReadAll(response.Body)
+	if err != nil {
+		return response.StatusCode, response.Header, nil, err
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return response.StatusCode, response.Header, nil, nil
+	}
+	var body map[string]interface{}
+	if err = json.Unmarshal(b, &body); err != nil {
+		return response.StatusCode, response.Header, nil, fmt.Errorf(
+			"cannot execute monitor: poll response unreadable: %w", err)
+	}
+	return response.StatusCode, response.Header, body, nil
 }
 
 func prepareReultSet(
@@ -325,16 +383,3 @@ func prepareReultSet(
 	}
 	return util.PrepareResultSet(payload)
 }
-
-func getMonitorRequest(urlStr string) (*http.Request, error) {
-	req, err := http.NewRequest(
-		http.MethodGet,
-		urlStr,
-		nil,
-	)
-	if err != nil {
-		return nil, err
-	}
-	req = req.WithContext(context.Background())
-	return req, nil
-}
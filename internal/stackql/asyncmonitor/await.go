@@ -0,0 +1,124 @@
+package asyncmonitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+)
+
+const (
+	awaitDirective             string = "AWAIT"
+	awaitTimeoutDirectiveParam string = "timeout"
+	pollBackoffFactor                 = 2
+)
+
+// AwaitTimeoutError arises when an awaited operation is not
+// complete within the maximum wait.  It carries the most
+// recently observed operation body, which may be nil.
+type AwaitTimeoutError struct {
+	OperationDescriptor string
+	Timeout             time.Duration
+	LastBody            map[string]interface{}
+}
+
+func (e *AwaitTimeoutError) Error() string {
+	lastBody := "none"
+	if e.LastBody != nil {
+		if b, err := json.Marshal(e.LastBody); err == nil {
+			lastBody = string(b)
+		}
+	}
+	return fmt.Sprintf(
+		"%s not complete within maximum wait of %s; last observed body: %s",
+		e.OperationDescriptor,
+		e.Timeout,
+		lastBody,
+	)
+}
+
+// IsAwaitDirective reports whether the directives request awaiting,
+// either bare `/*+ AWAIT */` or parameterised, eg: `/*+ AWAIT(timeout=600) */`.
+func IsAwaitDirective(directives sqlparser.CommentDirectives) bool {
+	if directives.IsSet(awaitDirective) {
+		return true
+	}
+	_, isParameterised := getAwaitDirectiveParams(directives)
+	return isParameterised
+}
+
+// GetAwaitTimeout returns the maximum wait, in seconds, from a
+// parameterised AWAIT directive.  Zero waits indefinitely.
+func GetAwaitTimeout(directives sqlparser.CommentDirectives) (time.Duration, bool, error) {
+	params, isParameterised := getAwaitDirectiveParams(directives)
+	if !isParameterised {
+		return 0, false, nil
+	}
+	raw, hasTimeout := params[awaitTimeoutDirectiveParam]
+	if !hasTimeout {
+		return 0, false, nil
+	}
+	seconds, err := strconv.Atoi(raw)
+	if err != nil || seconds < 0 {
+		return 0, false, fmt.Errorf("invalid AWAIT %s = '%s': non negative seconds expected", awaitTimeoutDirectiveParam, raw)
+	}
+	return time.Duration(seconds) * time.Second, true, nil
+}
+
+// getAwaitDirectiveParams recovers the parameters of `AWAIT(k1=v1,k2=v2)`,
+// which the directive parser splits, upon the first `=`, into the key
+// `AWAIT(k1` and value `v1,k2=v2)`.
+func getAwaitDirectiveParams(directives sqlparser.CommentDirectives) (map[string]string, bool) {
+	for k, v := range directives {
+		if !strings.HasPrefix(strings.ToUpper(k), awaitDirective+"(") {
+			continue
+		}
+		raw := k
+		if v != true {
+			raw = fmt.Sprintf("%s=%v", k, v)
+		}
+		raw = strings.TrimSuffix(strings.TrimSpace(raw[len(awaitDirective)+1:]), ")")
+		rv := make(map[string]string)
+		for _, param := range strings.Split(raw, ",") {
+			key, val, _ := strings.Cut(param, "=")
+			if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+				rv[key] = strings.TrimSpace(val)
+			}
+		}
+		return rv, true
+	}
+	return nil, false
+}
+
+// pollBackoff waits the initial interval before the first poll and thereafter
+// doubles the interval, up to a maximum, each wait being drawn uniformly
+// from the upper half of the interval.
+type pollBackoff struct {
+	interval    time.Duration
+	maxInterval time.Duration
+	isStarted   bool
+}
+
+func newPollBackoff(interval time.Duration, maxInterval time.Duration) *pollBackoff {
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+	return &pollBackoff{
+		interval:    interval,
+		maxInterval: maxInterval,
+	}
+}
+
+func (b *pollBackoff) next() time.Duration {
+	if !b.isStarted {
+		b.isStarted = true
+		return b.interval
+	}
+	b.interval = min(pollBackoffFactor*b.interval, b.maxInterval)
+	jitter := time.Duration(rand.Int63n(int64(b.interval/pollBackoffFactor) + 1)) //nolint:gosec // jitter only
+	return b.interval - jitter
+}
@@ -0,0 +1,47 @@
+package asyncmonitor_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/stackql/stackql/internal/stackql/asyncmonitor"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+)
+
+func TestAwaitDirective(t *testing.T) {
+	bare := sqlparser.ExtractCommentDirectives(sqlparser.Comments{[]byte("/*+ AWAIT */")})
+	if !IsAwaitDirective(bare) {
+		t.Fatalf("test failed: bare AWAIT not detected")
+	}
+	if _, hasTimeout, _ := GetAwaitTimeout(bare); hasTimeout {
+		t.Fatalf("test failed: bare AWAIT should carry no timeout")
+	}
+	parameterised := sqlparser.ExtractCommentDirectives(sqlparser.Comments{[]byte("/*+ AWAIT(timeout=600) NOSTATUS */")})
+	if !IsAwaitDirective(parameterised) {
+		t.Fatalf("test failed: parameterised AWAIT not detected")
+	}
+	timeout, hasTimeout, err := GetAwaitTimeout(parameterised)
+	if err != nil || !hasTimeout || timeout != 600*time.Second {
+		t.Fatalf("test failed: unexpected timeout = %s, err = %v", timeout, err)
+	}
+	invalid := sqlparser.ExtractCommentDirectives(sqlparser.Comments{[]byte("/*+ AWAIT(timeout=soon) */")})
+	if _, _, err = GetAwaitTimeout(invalid); err == nil {
+		t.Fatalf("test failed: expected error for invalid timeout")
+	}
+	if IsAwaitDirective(sqlparser.ExtractCommentDirectives(sqlparser.Comments{[]byte("/*+ NOSTATUS */")})) {
+		t.Fatalf("test failed: AWAIT wrongly detected")
+	}
+}
+
+func TestAwaitTimeoutError(t *testing.T) {
+	err := &AwaitTimeoutError{
+		OperationDescriptor: "compute#operation: insert",
+		Timeout:             10 * time.Second,
+		LastBody:            map[string]interface{}{"status": "RUNNING"},
+	}
+	expected := `compute#operation: insert not complete within maximum wait of 10s; last observed body: {"status":"RUNNING"}`
+	if err.Error() != expected {
+		t.Fatalf("test failed: unexpected error message: %s", err.Error())
+	}
+}
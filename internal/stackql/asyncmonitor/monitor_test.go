@@ -0,0 +1,204 @@
+package asyncmonitor //nolint:testpackage // poll dispatch is substituted
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/provider"
+)
+
+type monitorTestHandlerContext struct {
+	handler.HandlerContext
+}
+
+func (hc *monitorTestHandlerContext) LogHTTPResponseMap(interface{}) {}
+
+type monitorTestProvider struct {
+	provider.IProvider
+	name string
+}
+
+func (p *monitorTestProvider) GetProviderString() string {
+	return p.name
+}
+
+// monitorTestOperation serves the initial response of
+// an operation, followed by the supplied poll responses.
+// Bodies may refer to the server url as "{server}".
+type monitorTestOperation struct {
+	t            *testing.T
+	server       *httptest.Server
+	pollPath     string
+	pollMethod   string
+	pollBody     string
+	pollStatuses []int
+	pollBodies   []string
+	polls        atomic.Int32
+}
+
+func newMonitorTestOperation(
+	t *testing.T,
+	initialHeader http.Header,
+	initialStatus int,
+	initialBody string,
+) *monitorTestOperation {
+	rv := &monitorTestOperation{t: t}
+	rv.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/begin" {
+			for k, v := range initialHeader {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(initialStatus)
+			io.WriteString(w, strings.ReplaceAll(initialBody, "{server}", rv.server.URL)) //nolint:errcheck // test server
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		if r.URL.RequestURI() != rv.pollPath || r.Method != rv.pollMethod || string(b) != rv.pollBody {
+			http.Error(w, "unexpected poll", http.StatusNotFound)
+			return
+		}
+		i := int(rv.polls.Add(1)) - 1
+		if i >= len(rv.pollStatuses) {
+			http.Error(w, "too many polls", http.StatusConflict)
+			return
+		}
+		w.WriteHeader(rv.pollStatuses[i])
+		io.WriteString(w, strings.ReplaceAll(rv.pollBodies[i], "{server}", rv.server.URL)) //nolint:errcheck // test server
+	}))
+	t.Cleanup(rv.server.Close)
+	return rv
+}
+
+func (o *monitorTestOperation) expectPolls(method string, uri string, body string) {
+	o.pollMethod = method
+	o.pollPath = uri
+	o.pollBody = body
+}
+
+func (o *monitorTestOperation) respond(statusCode int, body string) {
+	o.pollStatuses = append(o.pollStatuses, statusCode)
+	o.pollBodies = append(o.pollBodies, body)
+}
+
+// run begins the operation, as per an executor
+// which attaches its response, and monitors it.
+func (o *monitorTestOperation) run(strategy PollingStrategy) internaldto.ExecutorOutput {
+	response, err := http.Post(o.server.URL+"/begin?x=1", "application/json", nil) //nolint:noctx // test
+	if err != nil {
+		o.t.Fatalf("test failed: %v", err)
+	}
+	defer response.Body.Close()
+	var body map[string]interface{}
+	b, _ := io.ReadAll(response.Body)
+	if len(b) > 0 {
+		if err = json.Unmarshal(b, &body); err != nil {
+			o.t.Fatalf("test failed: %v", err)
+		}
+	}
+	initial := internaldto.NewExecutorOutput(nil, body, nil, nil, nil).WithHTTPResponse(response)
+	am := &standardAsyncMonitor{
+		handlerCtx: &monitorTestHandlerContext{},
+		prov:       &monitorTestProvider{name: "test"},
+		strategy:   strategy,
+		dispatch: func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			return http.DefaultClient.Do(req.WithContext(ctx))
+		},
+	}
+	pc := internaldto.NewBasicPrimitiveContext(
+		func(string) (*dto.AuthCtx, error) { return &dto.AuthCtx{}, nil },
+		io.Discard,
+		io.Discard,
+	).WithContext(context.Background())
+	return am.monitor(&AsyncHTTPMonitorPrimitive{}, pc, initial)
+}
+
+func (o *monitorTestOperation) expectComplete(output internaldto.ExecutorOutput, path string, expected string) {
+	if output.GetError() != nil {
+		o.t.Fatalf("test failed: %v", output.GetError())
+	}
+	if int(o.polls.Load()) != len(o.pollStatuses) {
+		o.t.Fatalf("test failed: expected %d polls, got %d", len(o.pollStatuses), o.polls.Load())
+	}
+	if val, _ := lookupString(path, output.GetOutputBody()); val != expected {
+		o.t.Fatalf("test failed: expected '%s' at '%s', got body %v", expected, path, output.GetOutputBody())
+	}
+}
+
+func TestMonitorBodyStrategy(t *testing.T) {
+	op := newMonitorTestOperation(t, nil, http.StatusOK, `{"selfLink": "{server}/operations/op-1", "status": "RUNNING"}`)
+	op.expectPolls(http.MethodGet, "/operations/op-1", "")
+	op.respond(http.StatusOK, `{"selfLink": "{server}/operations/op-1", "status": "RUNNING"}`)
+	op.respond(http.StatusOK, `{"selfLink": "{server}/operations/op-1", "status": "DONE", "endTime": "2026-01-01T00:00:00Z"}`)
+	output := op.run(PollingStrategy{
+		Kind:       BodyStrategy,
+		LinkPath:   "$.selfLink",
+		Completion: CompletionPredicate{Path: "$.endTime"},
+	})
+	op.expectComplete(output, "$.status", "DONE")
+}
+
+func TestMonitorHeaderStrategy(t *testing.T) {
+	strategy := PollingStrategy{
+		Kind:    HeaderStrategy,
+		Headers: []string{"Azure-AsyncOperation", "Location"},
+		Completion: CompletionPredicate{
+			Path:               "$.status",
+			Success:            []string{"Succeeded"},
+			Failure:            []string{"Failed", "Canceled"},
+			ErrorPath:          "$.error.message",
+			CompleteWhenAbsent: true,
+		},
+	}
+	// the relative status url is resolved against the request which began the operation
+	header := http.Header{"Azure-Asyncoperation": []string{"/operations/op-1?api-version=1"}}
+	op := newMonitorTestOperation(t, header, http.StatusAccepted, "")
+	op.expectPolls(http.MethodGet, "/operations/op-1?api-version=1", "")
+	op.respond(http.StatusAccepted, "")
+	op.respond(http.StatusOK, `{"status": "InProgress"}`)
+	op.respond(http.StatusOK, `{"status": "Succeeded"}`)
+	op.expectComplete(op.run(strategy), "$.status", "Succeeded")
+
+	failing := newMonitorTestOperation(t, header, http.StatusAccepted, "")
+	failing.expectPolls(http.MethodGet, "/operations/op-1?api-version=1", "")
+	failing.respond(http.StatusOK, `{"status": "Failed", "error": {"message": "quota exceeded"}}`)
+	output := failing.run(strategy)
+	if output.GetError() == nil || !strings.Contains(output.GetError().Error(), "quota exceeded") {
+		t.Fatalf("test failed: expected failure of operation, got %v", output.GetError())
+	}
+}
+
+func TestMonitorRequestTokenStrategy(t *testing.T) {
+	op := newMonitorTestOperation(
+		t,
+		nil,
+		http.StatusOK,
+		`{"ProgressEvent": {"RequestToken": "t-1", "OperationStatus": "IN_PROGRESS"}}`,
+	)
+	op.expectPolls(http.MethodPost, "/?Action=GetResourceRequestStatus", `{"RequestToken": "t-1"}`)
+	op.respond(http.StatusOK, `{"ProgressEvent": {"RequestToken": "t-1", "OperationStatus": "IN_PROGRESS"}}`)
+	op.respond(http.StatusOK, `{"ProgressEvent": {"RequestToken": "t-1", "OperationStatus": "SUCCESS"}}`)
+	output := op.run(PollingStrategy{
+		Kind:      RequestTokenStrategy,
+		TokenPath: "$.ProgressEvent.RequestToken",
+		Request: PollRequest{
+			Method: http.MethodPost,
+			URL:    "/?Action=GetResourceRequestStatus",
+			Body:   `{"RequestToken": "{token}"}`,
+		},
+		Completion: CompletionPredicate{
+			Path:    "$.ProgressEvent.OperationStatus",
+			Success: []string{"SUCCESS"},
+			Failure: []string{"FAILED"},
+		},
+	})
+	op.expectComplete(output, "$.ProgressEvent.OperationStatus", "SUCCESS")
+}
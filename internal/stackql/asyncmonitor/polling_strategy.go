@@ -0,0 +1,353 @@
+package asyncmonitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/stackql/any-sdk/anysdk"
+	"github.com/stackql/any-sdk/pkg/jsonpath"
+)
+
+const (
+	// ExtensionKeyAsync is the operation, or service document, extension
+	// declaring the polling strategy for asynchronous operations.
+	ExtensionKeyAsync string = "x-stackQL-async"
+	// RequestTokenPlaceholder is substituted, in the poll request url
+	// and body, by the token of request token strategies.
+	RequestTokenPlaceholder string = "{token}"
+)
+
+const (
+	// BodyStrategy polls a status url found in the response body.
+	BodyStrategy StrategyKind = "body"
+	// HeaderStrategy polls a status url found in a response header.
+	HeaderStrategy StrategyKind = "header"
+	// RequestTokenStrategy polls a status endpoint with a request token
+	// found in the response body.
+	RequestTokenStrategy StrategyKind = "requestToken"
+)
+
+const (
+	googleProviderName              string = "google"
+	azureProviderName               string = "azure"
+	awsProviderName                 string = "aws"
+	awsCloudControlServiceName      string = "cloud_control"
+	awsCloudControlStatusRequestURL string = "/?Action=GetResourceRequestStatus&Version=2021-09-30"
+)
+
+type StrategyKind string
+
+// PollingStrategy describes how an asynchronous operation is monitored.
+type PollingStrategy struct {
+	Kind                StrategyKind `json:"kind"`
+	PollIntervalSeconds int          `json:"pollIntervalSeconds,omitempty"`
+	// LinkPath is the JSONPath to the status url, for body strategies.
+	LinkPath string `json:"linkPath,omitempty"`
+	// Headers are those which may carry the status url, in order of
+	// precedence, for header strategies.
+	Headers []string `json:"headers,omitempty"`
+	// TokenPath is the JSONPath to the request token, for request token strategies.
+	TokenPath  string              `json:"tokenPath,omitempty"`
+	Request    PollRequest         `json:"request,omitempty"`
+	Completion CompletionPredicate `json:"completion,omitempty"`
+}
+
+// PollRequest templates the poll request.  Relative urls are resolved
+// against that of the request which began the operation.
+type PollRequest struct {
+	Method  string            `json:"method,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// CompletionPredicate decides, from a response body, whether an operation is done.
+// Absent a path, any response completes the operation.  Absent success
+// values, any non empty value at the path completes the operation.
+type CompletionPredicate struct {
+	Path               string   `json:"path,omitempty"`
+	Success            []string `json:"success,omitempty"`
+	Failure            []string `json:"failure,omitempty"`
+	ErrorPath          string   `json:"errorPath,omitempty"`
+	CompleteWhenAbsent bool     `json:"completeWhenAbsent,omitempty"`
+}
+
+func (c CompletionPredicate) Evaluate(body map[string]interface{}) (bool, error) {
+	if c.Path == "" {
+		return true, nil
+	}
+	val, ok := lookupString(c.Path, body)
+	if !ok {
+		return c.CompleteWhenAbsent, nil
+	}
+	for _, failure := range c.Failure {
+		if strings.EqualFold(val, failure) {
+			return true, fmt.Errorf("status '%s': %s", val, c.getErrorDetail(body))
+		}
+	}
+	if len(c.Success) == 0 {
+		return val != "", nil
+	}
+	for _, success := range c.Success {
+		if strings.EqualFold(val, success) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c CompletionPredicate) getErrorDetail(body map[string]interface{}) string {
+	if c.ErrorPath == "" {
+		return "no error detail available"
+	}
+	detail, err := jsonpath.Get(c.ErrorPath, body)
+	if err != nil || detail == nil {
+		return "no error detail available"
+	}
+	if s, isString := detail.(string); isString {
+		return s
+	}
+	b, err := json.Marshal(detail)
+	if err != nil {
+		return fmt.Sprintf("%v", detail)
+	}
+	return string(b)
+}
+
+// IsInitiallyComplete decides whether the response which began
+// the operation already signals its completion.
+func (s PollingStrategy) IsInitiallyComplete(header http.Header, body map[string]interface{}) (bool, error) {
+	if s.Kind == HeaderStrategy {
+		_, hasStatusURL := s.GetPollTarget(header, body)
+		return !hasStatusURL, nil
+	}
+	return s.Completion.Evaluate(body)
+}
+
+// IsComplete decides whether a poll response signals completion.
+func (s PollingStrategy) IsComplete(statusCode int, body map[string]interface{}) (bool, error) {
+	if statusCode == http.StatusAccepted {
+		return false, nil
+	}
+	return s.Completion.Evaluate(body)
+}
+
+// GetPollTarget returns the status url, or request
+// token, from the most recent response.
+func (s PollingStrategy) GetPollTarget(header http.Header, body map[string]interface{}) (string, bool) {
+	switch s.Kind {
+	case HeaderStrategy:
+		for _, k := range s.Headers {
+			if v := header.Get(k); v != "" {
+				return v, true
+			}
+		}
+		return "", false
+	case BodyStrategy:
+		return lookupString(s.LinkPath, body)
+	case RequestTokenStrategy:
+		return lookupString(s.TokenPath, body)
+	default:
+		return "", false
+	}
+}
+
+func (s PollingStrategy) describeTarget() string {
+	switch s.Kind {
+	case HeaderStrategy:
+		return fmt.Sprintf("status url header amongst '%s'", strings.Join(s.Headers, "', '"))
+	case BodyStrategy:
+		return fmt.Sprintf("'%s' property", s.LinkPath)
+	case RequestTokenStrategy:
+		return fmt.Sprintf("request token at '%s'", s.TokenPath)
+	default:
+		return "poll target"
+	}
+}
+
+// NewPollRequest builds the poll request for a target, as returned by GetPollTarget.
+func (s PollingStrategy) NewPollRequest(origin *url.URL, target string) (*http.Request, error) {
+	urlRef := target
+	body := s.Request.Body
+	method := s.Request.Method
+	if s.Kind == RequestTokenStrategy {
+		urlRef = strings.ReplaceAll(s.Request.URL, RequestTokenPlaceholder, target)
+		body = strings.ReplaceAll(body, RequestTokenPlaceholder, target)
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+	var u *url.URL
+	var err error
+	if origin != nil {
+		u, err = origin.Parse(urlRef)
+	} else {
+		u, err = url.Parse(urlRef)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var reqBody io.Reader
+	if body != "" {
+		reqBody = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, u.String(), reqBody) //nolint:noctx // context is set upon dispatch
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range s.Request.Headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+func (s PollingStrategy) validate() error {
+	switch s.Kind {
+	case BodyStrategy:
+		if s.LinkPath == "" {
+			return fmt.Errorf("body polling strategy requires 'linkPath'")
+		}
+	case HeaderStrategy:
+		if len(s.Headers) == 0 {
+			return fmt.Errorf("header polling strategy requires 'headers'")
+		}
+	case RequestTokenStrategy:
+		if s.TokenPath == "" {
+			return fmt.Errorf("request token polling strategy requires 'tokenPath'")
+		}
+	default:
+		return fmt.Errorf("polling strategy kind = '%s' not supported", s.Kind)
+	}
+	return nil
+}
+
+// GetPollingStrategy returns the polling strategy for an operation.  The
+// strategy is read from the operation's ExtensionKeyAsync extension, else that
+// of its service document, else the provider's default, should it have one.
+func GetPollingStrategy(providerName string, op anysdk.OperationStore) (PollingStrategy, error) {
+	raw, hasExtension := getAsyncExtension(op)
+	if hasExtension {
+		rv, err := decodePollingStrategy(raw)
+		if err != nil {
+			return PollingStrategy{}, fmt.Errorf("method %s has invalid %s: %w", op.GetName(), ExtensionKeyAsync, err)
+		}
+		return rv, nil
+	}
+	rv, hasDefault := getProviderDefaultPollingStrategy(providerName, op)
+	if !hasDefault {
+		return PollingStrategy{}, fmt.Errorf("method %s is not awaitable", op.GetName())
+	}
+	return rv, nil
+}
+
+func getAsyncExtension(op anysdk.OperationStore) (interface{}, bool) {
+	if opRef := op.GetOperationRef(); opRef != nil && opRef.Value != nil {
+		if raw, ok := opRef.Value.Extensions[ExtensionKeyAsync]; ok {
+			return raw, true
+		}
+	}
+	svc := op.GetService()
+	if svc == nil || svc.GetT() == nil {
+		return nil, false
+	}
+	raw, ok := svc.GetT().Extensions[ExtensionKeyAsync]
+	return raw, ok
+}
+
+func decodePollingStrategy(raw interface{}) (PollingStrategy, error) {
+	var b []byte
+	var err error
+	switch raw := raw.(type) {
+	case json.RawMessage:
+		b = raw
+	default:
+		b, err = json.Marshal(raw)
+		if err != nil {
+			return PollingStrategy{}, err
+		}
+	}
+	var rv PollingStrategy
+	if err = json.Unmarshal(b, &rv); err != nil {
+		return PollingStrategy{}, err
+	}
+	return rv, rv.validate()
+}
+
+func getProviderDefaultPollingStrategy(providerName string, op anysdk.OperationStore) (PollingStrategy, bool) {
+	switch providerName {
+	case googleProviderName:
+		if !op.IsAwaitable() {
+			return PollingStrategy{}, false
+		}
+		return PollingStrategy{
+			Kind:     BodyStrategy,
+			LinkPath: "$.selfLink",
+			Completion: CompletionPredicate{
+				Path: "$.endTime",
+			},
+		}, true
+	case azureProviderName:
+		if strings.EqualFold(op.GetAPIMethod(), http.MethodGet) {
+			return PollingStrategy{}, false
+		}
+		return PollingStrategy{
+			Kind:    HeaderStrategy,
+			Headers: []string{"Azure-AsyncOperation", "Location"},
+			Completion: CompletionPredicate{
+				Path:      "$.status",
+				Success:   []string{"Succeeded"},
+				Failure:   []string{"Failed", "Canceled"},
+				ErrorPath: "$.error",
+				// Location polls return the resource, sans status, once done.
+				CompleteWhenAbsent: true,
+			},
+		}, true
+	case awsProviderName:
+		svc := op.GetService()
+		if svc == nil || svc.GetName() != awsCloudControlServiceName {
+			return PollingStrategy{}, false
+		}
+		if strings.EqualFold(op.GetAPIMethod(), http.MethodGet) || op.GetSQLVerb() == "select" {
+			return PollingStrategy{}, false
+		}
+		return PollingStrategy{
+			Kind:      RequestTokenStrategy,
+			TokenPath: "$.ProgressEvent.RequestToken",
+			Request: PollRequest{
+				Method: http.MethodPost,
+				URL:    awsCloudControlStatusRequestURL,
+				Headers: map[string]string{
+					"Content-Type": "application/x-amz-json-1.0",
+					"X-Amz-Target": "CloudApiService.GetResourceRequestStatus",
+				},
+				Body: fmt.Sprintf(`{"RequestToken": "%s"}`, RequestTokenPlaceholder),
+			},
+			Completion: CompletionPredicate{
+				Path:      "$.ProgressEvent.OperationStatus",
+				Success:   []string{"SUCCESS"},
+				Failure:   []string{"FAILED", "CANCEL_COMPLETE"},
+				ErrorPath: "$.ProgressEvent.StatusMessage",
+			},
+		}, true
+	default:
+		return PollingStrategy{}, false
+	}
+}
+
+func lookupString(path string, body map[string]interface{}) (string, bool) {
+	if path == "" || body == nil {
+		return "", false
+	}
+	val, err := jsonpath.Get(path, body)
+	if err != nil || val == nil {
+		return "", false
+	}
+	if s, isString := val.(string); isString {
+		return s, true
+	}
+	return fmt.Sprintf("%v", val), true
+}
@@ -0,0 +1,99 @@
+package asyncmonitor_test
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	. "github.com/stackql/stackql/internal/stackql/asyncmonitor"
+)
+
+func TestCompletionPredicate(t *testing.T) {
+	predicate := CompletionPredicate{
+		Path:      "$.status",
+		Success:   []string{"Succeeded"},
+		Failure:   []string{"Failed"},
+		ErrorPath: "$.error.message",
+	}
+	isComplete, err := predicate.Evaluate(map[string]interface{}{"status": "InProgress"})
+	if err != nil || isComplete {
+		t.Fatalf("test failed: expected in progress, got complete = %t, err = %v", isComplete, err)
+	}
+	isComplete, err = predicate.Evaluate(map[string]interface{}{"status": "succeeded"})
+	if err != nil || !isComplete {
+		t.Fatalf("test failed: expected success, got complete = %t, err = %v", isComplete, err)
+	}
+	_, err = predicate.Evaluate(map[string]interface{}{
+		"status": "Failed",
+		"error":  map[string]interface{}{"message": "quota exceeded"},
+	})
+	if err == nil || err.Error() != "status 'Failed': quota exceeded" {
+		t.Fatalf("test failed: unexpected failure error: %v", err)
+	}
+	isComplete, err = CompletionPredicate{Path: "$.endTime"}.Evaluate(map[string]interface{}{"endTime": ""})
+	if err != nil || isComplete {
+		t.Fatalf("test failed: expected empty value to be incomplete, got complete = %t, err = %v", isComplete, err)
+	}
+}
+
+func TestHeaderStrategy(t *testing.T) {
+	strategy := PollingStrategy{
+		Kind:       HeaderStrategy,
+		Headers:    []string{"Azure-AsyncOperation", "Location"},
+		Completion: CompletionPredicate{Path: "$.status", CompleteWhenAbsent: true},
+	}
+	isComplete, err := strategy.IsInitiallyComplete(http.Header{}, nil)
+	if err != nil || !isComplete {
+		t.Fatalf("test failed: expected response sans status header to be complete")
+	}
+	header := http.Header{}
+	header.Set("Location", "/operations/1")
+	isComplete, err = strategy.IsInitiallyComplete(header, nil)
+	if err != nil || isComplete {
+		t.Fatalf("test failed: expected response with status header to be in progress")
+	}
+	isComplete, _ = strategy.IsComplete(http.StatusAccepted, nil)
+	if isComplete {
+		t.Fatalf("test failed: expected accepted poll to be in progress")
+	}
+	target, _ := strategy.GetPollTarget(header, nil)
+	origin, _ := url.Parse("https://management.azure.com/subscriptions/s1/resourceGroups/g1?api-version=1")
+	req, err := strategy.NewPollRequest(origin, target)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if req.Method != http.MethodGet || req.URL.String() != "https://management.azure.com/operations/1" {
+		t.Fatalf("test failed: unexpected poll request: %s %s", req.Method, req.URL)
+	}
+}
+
+func TestRequestTokenStrategy(t *testing.T) {
+	strategy := PollingStrategy{
+		Kind:      RequestTokenStrategy,
+		TokenPath: "$.ProgressEvent.RequestToken",
+		Request: PollRequest{
+			Method:  http.MethodPost,
+			URL:     "/?Action=GetResourceRequestStatus",
+			Headers: map[string]string{"X-Amz-Target": "CloudApiService.GetResourceRequestStatus"},
+			Body:    `{"RequestToken": "{token}"}`,
+		},
+	}
+	target, hasTarget := strategy.GetPollTarget(nil, map[string]interface{}{
+		"ProgressEvent": map[string]interface{}{"RequestToken": "t-1"},
+	})
+	if !hasTarget || target != "t-1" {
+		t.Fatalf("test failed: unexpected request token '%s'", target)
+	}
+	origin, _ := url.Parse("https://cloudcontrolapi.us-east-1.amazonaws.com/?Action=CreateResource")
+	req, err := strategy.NewPollRequest(origin, target)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	b, _ := io.ReadAll(req.Body)
+	if req.URL.String() != "https://cloudcontrolapi.us-east-1.amazonaws.com/?Action=GetResourceRequestStatus" ||
+		string(b) != `{"RequestToken": "t-1"}` ||
+		req.Header.Get("X-Amz-Target") != "CloudApiService.GetResourceRequestStatus" {
+		t.Fatalf("test failed: unexpected poll request: %s %s", req.URL, string(b))
+	}
+}
@@ -17,6 +17,9 @@ package cmd
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"runtime/pprof"
@@ -47,43 +50,73 @@ stackql exec -i iqlscripts/listinstances.iql --credentialsfilepath /mnt/c/tmp/st
 
 stackql exec -i iqlscripts/create-disk.iql --credentialsfilepath /mnt/c/tmp/stackql-demo.json
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-
-		var err error
-		var rdr io.Reader
-
-		flagErr := dependentFlagHandler(&runtimeCtx)
-		iqlerror.PrintErrorAndExitOneIfError(flagErr)
-
-		if runtimeCtx.CPUProfile != "" {
-			var f *os.File
-			f, err = os.Create(runtimeCtx.CPUProfile)
-			if err != nil {
-				iqlerror.PrintErrorAndExitOneIfError(err)
-			}
-			pprof.StartCPUProfile(f) //nolint:errcheck // not important for dev option
-			defer pprof.StopCPUProfile()
+	// Errors are written once, to stderr, whereupon exit follows
+	// flushing of telemetry and profiles, as it does for failing
+	// scripts, which set the exit code.
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		iqlerror.PrintErrorAndExitOneIfError(runExec(cmd, args))
+		return nil
+	},
+}
+
+// runExec returns any error short of failure of the script, per
+// its summary, which instead sets the exit code.
+func runExec(cmd *cobra.Command, args []string) error {
+	var err error
+	var rdr io.Reader
+
+	if err = dependentFlagHandler(&runtimeCtx); err != nil {
+		return err
+	}
+	onError, err := driver.ParseErrorPolicy(execOnError)
+	if err != nil {
+		return err
+	}
+
+	if runtimeCtx.CPUProfile != "" {
+		var f *os.File
+		f, err = os.Create(runtimeCtx.CPUProfile)
+		if err != nil {
+			return err
 		}
+		pprof.StartCPUProfile(f) //nolint:errcheck // not important for dev option
+		iqlerror.RegisterExitHook(pprof.StopCPUProfile)
+	}
 
-		switch runtimeCtx.InfilePath {
-		case "stdin":
-			if len(args) == 0 || args[0] == "" {
-				cmd.Help() //nolint:errcheck // not important
-				os.Exit(0)
-			}
-			rdr = bytes.NewReader([]byte(args[0]))
-		default:
-			rdr, err = os.Open(runtimeCtx.InfilePath)
-			iqlerror.PrintErrorAndExitOneIfError(err)
+	switch runtimeCtx.InfilePath {
+	case "stdin":
+		if len(args) == 0 || args[0] == "" {
+			return cmd.Help()
 		}
-		inputBundle, err := entryutil.BuildInputBundle(runtimeCtx)
-		iqlerror.PrintErrorAndExitOneIfError(err)
-		handlerCtx, err := entryutil.BuildHandlerContext(runtimeCtx, rdr, queryCache, inputBundle)
-		iqlerror.PrintErrorAndExitOneIfError(err)
-		iqlerror.PrintErrorAndExitOneIfNil(handlerCtx, "Handler context error")
-		cr := newCommandRunner()
-		cr.RunCommand(handlerCtx, nil, nil)
-	},
+		rdr = bytes.NewReader([]byte(args[0]))
+	default:
+		rdr, err = os.Open(runtimeCtx.InfilePath)
+		if err != nil {
+			return err
+		}
+	}
+	inputBundle, err := entryutil.BuildInputBundle(runtimeCtx)
+	if err != nil {
+		return err
+	}
+	handlerCtx, err := entryutil.BuildHandlerContext(runtimeCtx, rdr, queryCache, inputBundle)
+	if err != nil {
+		return err
+	}
+	if handlerCtx == nil {
+		return fmt.Errorf("handler context error")
+	}
+	sr := newScriptRunner()
+	isSuccess, err := sr.RunScript(handlerCtx, onError)
+	if err != nil {
+		return err
+	}
+	if !isSuccess {
+		setExitCode(1)
+	}
+	return nil
 }
 
 func getOutputFile(filename string) (*os.File, error) {
@@ -126,3 +159,46 @@ func (cr *commandRunnerImpl) RunCommand(handlerCtx handler.HandlerContext, outfi
 	}
 	stackqlDriver.ProcessQuery(handlerCtx.GetRawQuery())
 }
+
+type scriptRunner interface {
+	// RunScript returns false where any statement failed.  The
+	// summary, if sought, is written regardless of any error.
+	RunScript(handlerCtx handler.HandlerContext, onError driver.ErrorPolicy) (bool, error)
+}
+
+func newScriptRunner() scriptRunner {
+	return &scriptRunnerImpl{}
+}
+
+type scriptRunnerImpl struct {
+}
+
+func (sr *scriptRunnerImpl) RunScript(handlerCtx handler.HandlerContext, onError driver.ErrorPolicy) (bool, error) {
+	defer iqlerror.HandlePanic(nil)
+	if handlerCtx.GetRuntimeContext().DryRunFlag {
+		newCommandRunner().RunCommand(handlerCtx, nil, nil)
+		return true, nil
+	}
+	outfile, _ := getOutputFile(handlerCtx.GetRuntimeContext().OutfilePath)
+	outErrFile, _ := getOutputFile(writer.StdErrStr)
+	handlerCtx.SetOutfile(outfile)
+	handlerCtx.SetOutErrFile(outErrFile)
+	stackqlDriver, err := driver.NewStackQLDriver(handlerCtx)
+	if err != nil {
+		return false, err
+	}
+	summary, err := stackqlDriver.ProcessScript(context.Background(), handlerCtx.GetRawQuery(), onError)
+	if execSummaryPath != "" {
+		summaryFile, fileErr := getOutputFile(execSummaryPath)
+		if fileErr != nil {
+			return false, errors.Join(err, fileErr)
+		}
+		if writeErr := summary.Write(summaryFile); writeErr != nil {
+			return false, errors.Join(err, writeErr)
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	return !summary.IsFailed(), nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright © 2019 stackql info@stackql.io
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/stackql/stackql/internal/stackql/iqlerror"
+	"github.com/stackql/stackql/internal/stackql/srvauth"
+)
+
+//nolint:gochecknoglobals // cobra pattern
+var passwdCmd = &cobra.Command{
+	Use:   "passwd",
+	Short: "Generate a SCRAM-SHA-256 password verifier for the server users file.  Usage: stackql passwd",
+	Long: `
+	Generate a SCRAM-SHA-256 password verifier, as required for the password
+	of each user in the users file supplied via --pgsrv.users.
+	Usage: stackql passwd
+	The password is read from the terminal, without echo, or else
+	as the first line of standard input, so that it appears in neither
+	shell history nor the process list.  The verifier is written to
+	standard output; the password itself is never stored.
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		usagemsg := cmd.Long + "\n\n" + cmd.UsageString()
+		if len(args) > 0 {
+			iqlerror.PrintErrorAndExitOneWithMessage(usagemsg)
+		}
+		password, err := readPassword()
+		iqlerror.PrintErrorAndExitOneIfError(err)
+		verifier, err := srvauth.NewSCRAMSHA256Verifier(password)
+		iqlerror.PrintErrorAndExitOneIfError(err)
+		fmt.Fprintln(os.Stdout, verifier)
+	},
+}
+
+// readPassword prompts upon, and reads without echo from, a terminal,
+// or else reads the first line of piped standard input.
+func readPassword() (string, error) {
+	stdinFd := int(os.Stdin.Fd())
+	var password string
+	if term.IsTerminal(stdinFd) {
+		fmt.Fprint(os.Stderr, "Password: ")
+		raw, err := term.ReadPassword(stdinFd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		password = string(raw)
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("could not read password from standard input: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if password == "" {
+		return "", fmt.Errorf("empty password")
+	}
+	return password, nil
+}
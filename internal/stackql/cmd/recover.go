@@ -0,0 +1,70 @@
+/*
+Copyright © 2019 stackql info@stackql.io
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stackql/stackql/internal/stackql/acid/recovery"
+	"github.com/stackql/stackql/internal/stackql/entryutil"
+	"github.com/stackql/stackql/internal/stackql/iqlerror"
+)
+
+//nolint:gochecknoglobals // cobra pattern
+var recoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Recover transactions interrupted by a crash.  Usage: stackql recover [{subcommand} [{txn_id}]]",
+	Long: `
+	Recover transactions from the write ahead log which began but were neither
+	committed nor rolled back, for example because the process was killed.
+	Usage: stackql recover [{subcommand} [{txn_id}]]
+	With no subcommand, transactions requiring recovery are listed,
+	along with the provider requests they issued and the undo outstanding.
+	Currently supported subcommands:
+	  - compensate {txn_id}     issue outstanding compensations and roll back the transaction
+	  - dismiss {txn_id}        mark a transaction resolved without compensation
+	Transactions in flight in other live stackql processes are not listed.
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		flagErr := dependentFlagHandler(&runtimeCtx)
+		iqlerror.PrintErrorAndExitOneIfError(flagErr)
+
+		usagemsg := cmd.Long + "\n\n" + cmd.UsageString()
+		if len(args) > 2 { //nolint:mnd // subcommand and txn id
+			iqlerror.PrintErrorAndExitOneWithMessage(usagemsg)
+		}
+		query := "recover"
+		if len(args) > 0 {
+			query = fmt.Sprintf("recover %s", strings.Join(args, " "))
+		}
+		if _, isRecover, parseErr := recovery.ParseCommand(query); !isRecover || parseErr != nil {
+			iqlerror.PrintErrorAndExitOneWithMessage(usagemsg)
+		}
+
+		inputBundle, err := entryutil.BuildInputBundle(runtimeCtx)
+		iqlerror.PrintErrorAndExitOneIfError(err)
+		handlerCtx, err := entryutil.BuildHandlerContext(runtimeCtx, bytes.NewReader([]byte(query)), queryCache, inputBundle)
+		iqlerror.PrintErrorAndExitOneIfError(err)
+		iqlerror.PrintErrorAndExitOneIfNil(handlerCtx, "Handler context error")
+		cr := newCommandRunner()
+		cr.RunCommand(handlerCtx, nil, nil)
+	},
+}
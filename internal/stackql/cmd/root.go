@@ -16,6 +16,7 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path"
@@ -25,6 +26,12 @@ import (
 	"github.com/stackql/any-sdk/pkg/dto"
 	"github.com/stackql/any-sdk/pkg/logging"
 	"github.com/stackql/stackql/internal/stackql/config"
+	"github.com/stackql/stackql/internal/stackql/driver"
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/httpaudit"
+	"github.com/stackql/stackql/internal/stackql/httpmiddleware"
+	"github.com/stackql/stackql/internal/stackql/iqlerror"
+	"github.com/stackql/stackql/internal/stackql/tracing"
 
 	"github.com/magiconair/properties"
 	"github.com/spf13/cobra"
@@ -46,15 +53,36 @@ var (
 )
 
 const (
-	defaultRegistryURLString string = "https://registry.stackql.app/providers"
+	defaultRegistryURLString  string = "https://registry.stackql.app/providers"
+	pgSrvUsersFilePathKey     string = "pgsrv.users"
+	pgSrvAllowInsecureAuthKey string = "pgsrv.allow-insecure-auth"
+	httpRecordDirKey          string = "http.record"
+	httpReplayDirKey          string = "http.replay"
+	httpAuditCfgRawKey        string = "http.audit"
+	metricsAddressKey         string = "metrics.address"
+	traceOTLPEndpointKey      string = "trace.otlp.endpoint"
+	execOnErrorKey            string = "on-error"
+	execSummaryKey            string = "summary"
+	awaitTimeoutKey           string = "await-timeout"
 )
 
 //nolint:revive,gochecknoglobals // global vars are a pattern for this lib
 var (
-	runtimeCtx      dto.RuntimeCtx
-	queryCache      *lrucache.LRUCache
-	SemVersion      string = fmt.Sprintf("%s.%s.%s", BuildMajorVersion, BuildMinorVersion, BuildPatchVersion)
-	replicateCtrMgr bool   = false //nolint:unused // TODO: investigate and test then remove if possible
+	runtimeCtx             dto.RuntimeCtx
+	queryCache             *lrucache.LRUCache
+	SemVersion             string = fmt.Sprintf("%s.%s.%s", BuildMajorVersion, BuildMinorVersion, BuildPatchVersion)
+	replicateCtrMgr        bool   = false //nolint:unused // TODO: investigate and test then remove if possible
+	pgSrvUsersFilePath     string
+	pgSrvAllowInsecureAuth bool
+	httpRecordDir          string
+	httpReplayDir          string
+	httpAuditCfgRaw        string
+	metricsAddress         string
+	traceOTLPEndpoint      string
+	execOnError            string
+	execSummaryPath        string
+	awaitTimeout           string
+	exitCode               int
 )
 
 // rootCmd represents the base command when called without any subcommands.
@@ -98,18 +126,95 @@ func dependentFlagHandler(rc *dto.RuntimeCtx) error {
 			return setErr
 		}
 	}
+	if err := setHTTPCassette(); err != nil {
+		return err
+	}
+	if err := setTracing(); err != nil {
+		return err
+	}
+	if err := setAwaitTimeout(); err != nil {
+		return err
+	}
+	return setHTTPAuditor()
+}
+
+func setAwaitTimeout() error {
+	if awaitTimeout == "" {
+		return nil
+	}
+	timeout, err := handler.ParseAwaitTimeout(awaitTimeout)
+	if err != nil {
+		return fmt.Errorf("flag '--%s': %w", awaitTimeoutKey, err)
+	}
+	handler.SetStartupAwaitTimeout(timeout)
+	return nil
+}
+
+func setTracing() error {
+	if traceOTLPEndpoint == "" {
+		return nil
+	}
+	return tracing.Init(traceOTLPEndpoint)
+}
+
+func setHTTPAuditor() error {
+	if httpAuditCfgRaw == "" {
+		return nil
+	}
+	auditor, err := httpaudit.NewAuditor(httpAuditCfgRaw)
+	if err != nil {
+		return err
+	}
+	httpmiddleware.SetAuditor(auditor)
+	return nil
+}
+
+func setHTTPCassette() error {
+	if httpRecordDir != "" && httpReplayDir != "" {
+		return fmt.Errorf("flags '--%s' and '--%s' are mutually exclusive", httpRecordDirKey, httpReplayDirKey)
+	}
+	var cassette *httpmiddleware.Cassette
+	var err error
+	if httpRecordDir != "" {
+		cassette, err = httpmiddleware.NewRecordingCassette(httpRecordDir)
+	} else if httpReplayDir != "" {
+		cassette, err = httpmiddleware.NewReplayingCassette(httpReplayDir)
+	}
+	if err != nil {
+		return err
+	}
+	httpmiddleware.SetCassette(cassette)
 	return nil
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+	iqlerror.RunExitHooks()
+	if err == nil && exitCode != 0 {
+		iqlerror.Exit(exitCode)
+	}
+	return err
+}
+
+// setExitCode sets the code upon which the process exits once
+// the command returns, after flushing of telemetry and profiles.
+func setExitCode(code int) {
+	exitCode = code
+}
+
+// flushBeforeExit flushes any buffered spans and audit records.  It
+// runs upon return of the command or, failing that, upon iqlerror.Exit.
+func flushBeforeExit() {
+	tracing.Shutdown(context.Background()) //nolint:errcheck // best effort at exit
+	httpmiddleware.CloseAuditor()          //nolint:errcheck // best effort at exit
 }
 
 //nolint:lll,funlen,gochecknoinits,mnd // init is a pattern for this lib
 func init() {
 	cobra.OnInitialize(initConfig)
+	iqlerror.RegisterExitHook(flushBeforeExit)
 	rootCmd.SetVersionTemplate("stackql v{{.Version}} " + BuildPlatform + " (" + BuildShortCommitSHA + ")\nBuildDate: " + BuildDate + "\nhttps://stackql.io\n")
 
 	rootCmd.PersistentFlags().StringVar(&runtimeCtx.CPUProfile, dto.CPUProfileKey, "", "cpuprofile file, none if empty")
@@ -143,10 +248,15 @@ func init() {
 		fmt.Sprintf(`openapi registry context keyvals in json form, eg: '{ "url": "%s" }'.`, defaultRegistryURLString),
 	)
 	rootCmd.PersistentFlags().BoolVar(&runtimeCtx.HTTPLogEnabled, dto.HTTPLogEnabledKey, false, "Display http request info in terminal")
+	rootCmd.PersistentFlags().StringVar(&httpRecordDir, httpRecordDirKey, "", "directory in which to record provider http interactions, with credentials redacted, for later replay")
+	rootCmd.PersistentFlags().StringVar(&httpReplayDir, httpReplayDirKey, "", "directory from which to replay recorded provider http interactions in lieu of live calls; unmatched requests fail")
+	rootCmd.PersistentFlags().StringVar(&httpAuditCfgRaw, httpAuditCfgRawKey, "", `JSON / YAML string to configure a structured audit log of provider http calls, eg: '{ "sink": "file:///path/to/audit.jsonl", "redact_headers": [ "X-Org-Id" ], "redact_fields": [ "password" ] }'; sinks may alternatively be syslog over 'udp://host:port'`)
+	rootCmd.PersistentFlags().StringVar(&awaitTimeout, awaitTimeoutKey, "", "max wait for operations awaited via the AWAIT hint, eg: '10m', '0' waits indefinitely; defaults to '1h' and is overridden by the 'await_timeout' session setting")
+	rootCmd.PersistentFlags().StringVar(&traceOTLPEndpoint, traceOTLPEndpointKey, "", "base url, eg: 'http://localhost:4318', of an OTLP / HTTP collector to which to export query traces, none if empty")
 	rootCmd.PersistentFlags().IntVar(&runtimeCtx.HTTPMaxResults, dto.HTTPMaxResultsKey, -1, "Max results per http request, any number <=0 results in no limitation")
 	rootCmd.PersistentFlags().IntVar(&runtimeCtx.HTTPPageLimit, dto.HTTPPAgeLimitKey, 20, "Max pages of results that will be returned per resource, any number <=0 results in no limitation") //nolint:mnd // TODO: investigate
 	rootCmd.PersistentFlags().IntVar(&runtimeCtx.HTTPProxyPort, dto.HTTPProxyPortKey, -1, "http proxy port, any number <=0 will result in the default port for a given scheme (eg: http -> 80)")
-	rootCmd.PersistentFlags().IntVar(&runtimeCtx.ExecutionConcurrencyLimit, dto.ExecutionConcurrencyLimitKey, 1, "concurrency limit for query execution")
+	rootCmd.PersistentFlags().IntVar(&runtimeCtx.ExecutionConcurrencyLimit, dto.ExecutionConcurrencyLimitKey, 1, "concurrency limit for query execution, unbounded if zero or less")
 	rootCmd.PersistentFlags().IntVar(&runtimeCtx.IndirectDepthMax, dto.IndirectDepthMaxKey, 5, "max depth for indirect queries: views and subqueries") //nolint:mnd // TODO: investigate
 	rootCmd.PersistentFlags().IntVar(&runtimeCtx.DataflowDependencyMax, dto.DataflowDependencyMaxKey, 50, "max dataflow dependency depth for a given query")
 	rootCmd.PersistentFlags().IntVar(&runtimeCtx.DataflowComponentsMax, dto.DataflowComponentsMaxKey, 50, "max dataflow weakly connected components for a given query")
@@ -160,7 +270,7 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&runtimeCtx.VerboseFlag, dto.VerboseFlagKey, "v", false, "Verbose flag")
 	rootCmd.PersistentFlags().BoolVar(&runtimeCtx.DryRunFlag, dto.DryRunFlagKey, false, "dryrun flag; preprocessor only will run and output returned")
 	rootCmd.PersistentFlags().BoolVarP(&runtimeCtx.CSVHeadersDisable, dto.CSVHeadersDisableKey, "H", false, "Disable CSV headers flag")
-	rootCmd.PersistentFlags().StringVarP(&runtimeCtx.OutputFormat, dto.OutputFormatKey, "o", "table", "Output format, must be (json | table | csv)")
+	rootCmd.PersistentFlags().StringVarP(&runtimeCtx.OutputFormat, dto.OutputFormatKey, "o", "table", "Output format, must be (json | jsonl | yaml | table | csv | markdown | text | pretty | expanded)")
 	rootCmd.PersistentFlags().StringVarP(&runtimeCtx.OutfilePath, dto.OutfilePathKey, "f", "stdout", "Output file into which results are written")
 	rootCmd.PersistentFlags().StringVarP(&runtimeCtx.InfilePath, dto.InfilePathKey, "i", "stdin", "Input file from which queries are read")
 	rootCmd.PersistentFlags().StringVarP(&runtimeCtx.TemplateCtxFilePath, dto.TemplateCtxFilePathKey, "q", "", "Context file for templating")
@@ -177,6 +287,9 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&runtimeCtx.PGSrvLogLevel, dto.PgSrvLogLevelKey, "WARN", "Log level, for server mode only")
 	rootCmd.PersistentFlags().StringVar(&runtimeCtx.PGSrvRawTLSCfg, dto.PgSrvRawTLSCfgKey, "", "tls config for server, for server mode only")
 	rootCmd.PersistentFlags().IntVar(&runtimeCtx.PGSrvPort, dto.PgSrvPortKey, 5466, "TCP server port, for server mode only") //nolint:mnd // TODO: investigate
+	rootCmd.PersistentFlags().StringVar(&metricsAddress, metricsAddressKey, "", "address, eg: ':9464', at which to expose prometheus metrics on '/metrics', none if empty, for server mode only")
+	rootCmd.PersistentFlags().StringVar(&pgSrvUsersFilePath, pgSrvUsersFilePathKey, "", "users file for password authentication and per user policy, for server mode only")
+	rootCmd.PersistentFlags().BoolVar(&pgSrvAllowInsecureAuth, pgSrvAllowInsecureAuthKey, false, "permit password authentication without TLS, for server mode only")
 
 	rootCmd.PersistentFlags().StringSliceVar(&runtimeCtx.VarList, dto.VarListKey, []string{}, "list of variables to be used in queries")
 
@@ -190,11 +303,16 @@ func init() {
 
 	queryCache = lrucache.NewLRUCache(int64(runtimeCtx.QueryCacheSize))
 
+	execCmd.Flags().StringVar(&execOnError, execOnErrorKey, string(driver.OnErrorContinue), "policy upon statement failure, must be (stop | continue | rollback); rollback requires session rollback_type 'eager'")
+	execCmd.Flags().StringVar(&execSummaryPath, execSummaryKey, "", "file, or 'stdout' / 'stderr', into which a JSON summary of statement outcomes is written, none if empty")
+
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 	rootCmd.AddCommand(execCmd)
 	rootCmd.AddCommand(shellCmd)
 	rootCmd.AddCommand(registryCmd)
 	rootCmd.AddCommand(srvCmd)
+	rootCmd.AddCommand(recoverCmd)
+	rootCmd.AddCommand(passwdCmd)
 }
 
 func mergeConfigFromFile(runtimeCtx *dto.RuntimeCtx, flagSet pflag.FlagSet) {
@@ -16,20 +16,25 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"os/signal"
 	"runtime"
 	"strconv"
 	"strings"
 
 	"github.com/stackql/any-sdk/pkg/dto"
 	"github.com/stackql/any-sdk/pkg/logging"
+	"github.com/stackql/stackql/internal/stackql/completion"
 	"github.com/stackql/stackql/internal/stackql/config"
 	"github.com/stackql/stackql/internal/stackql/driver"
 	"github.com/stackql/stackql/internal/stackql/entryutil"
 	"github.com/stackql/stackql/internal/stackql/handler"
 	"github.com/stackql/stackql/internal/stackql/iqlerror"
+	"github.com/stackql/stackql/internal/stackql/parser"
 	"github.com/stackql/stackql/internal/stackql/presentation"
 	"github.com/stackql/stackql/internal/stackql/provider"
 	"github.com/stackql/stackql/internal/stackql/writer"
@@ -61,7 +66,7 @@ func getShellIntroLong() string {
 }
 
 func usage(w io.Writer) {
-	io.WriteString(w, getShellIntroLong()+"\r\n") //nolint:errcheck // TODO: investigate
+	io.WriteString(w, getShellIntroLong()+"\r\n"+metaCommandHelpStr+"\r\n") //nolint:errcheck // TODO: investigate
 }
 
 func getShellPRompt(authCtx *dto.AuthCtx, cd presentation.Driver) string {
@@ -160,6 +165,7 @@ var shellCmd = &cobra.Command{
 			}
 		}
 
+		completer := completion.NewCompleter(completion.NewHandlerMetadataSource(handlerCtx))
 		readlineCfg := &readline.Config{
 			Stderr:               outErrFile,
 			Stdout:               outfile,
@@ -169,6 +175,7 @@ var shellCmd = &cobra.Command{
 			HistoryFile:          config.GetReadlineFilePath(handlerCtx.GetRuntimeContext()),
 			HistorySearchFold:    true,
 			HistoryExternalWrite: true,
+			AutoComplete:         completer,
 		}
 
 		sessionRunnerInstance, sessionErr := newSessionRunner(
@@ -177,6 +184,8 @@ var shellCmd = &cobra.Command{
 			outfile,
 		)
 		iqlerror.PrintErrorAndExitOneIfError(sessionErr)
+		session := newShellSession(sessionRunnerInstance, completer, handlerCtx, runtimeCtx, outfile, outErrFile)
+		defer session.closeRedirect()
 
 		l, err := readline.NewEx(readlineCfg)
 		if err != nil {
@@ -212,28 +221,20 @@ var shellCmd = &cobra.Command{
 			case line == "exit" || line == `\q` || line == "quit":
 				goto exit
 			case line == "":
+			case strings.HasPrefix(line, `\`):
+				l.WriteToHistory(line) //nolint:errcheck // TODO: investigate
+				session.handleMetaCommand(line)
 			default:
 				logging.GetLogger().Debugln("you said:", strconv.Quote(line))
-				inlineCommentIdx := strings.Index(line, "--")
-				if inlineCommentIdx > -1 {
-					line = line[:inlineCommentIdx]
-				}
-				semiColonIdx := strings.Index(line, ";")
-				if semiColonIdx > -1 {
-					line = strings.TrimSpace(line[:semiColonIdx+1])
-					subSemiColonIdx := strings.Index(line, ";")
-					sb.WriteString(" " + line[:subSemiColonIdx+1])
-					rawQuery := sb.String()
-					queryToExecute, qErr := entryutil.PreprocessInline(runtimeCtx, rawQuery)
-					if qErr != nil {
-						io.WriteString(outErrFile, "\r\n"+qErr.Error()+"\r\n") //nolint:errcheck // TODO: investigate
-					}
+				// Lines are newline delimited, so that line comments end.
+				sb.WriteString(line + "\n")
+				statements, remainder := parser.SplitTerminatedStatements(sb.String())
+				sb.Reset()
+				sb.WriteString(remainder)
+				for _, statement := range statements {
+					rawQuery := statement + ";"
 					l.WriteToHistory(rawQuery) //nolint:errcheck // TODO: investigate
-					sessionRunnerInstance.RunCommand(queryToExecute)
-					sb.Reset()
-					sb.WriteString(line[subSemiColonIdx+1:])
-				} else {
-					sb.WriteString(" " + line)
+					session.runStatement(rawQuery)
 				}
 			}
 		}
@@ -304,5 +305,8 @@ func (cr *sessionRunnerImpl) RunCommand(
 		cr.drv.ProcessDryRun(query)
 		return
 	}
-	cr.drv.ProcessQuery(query)
+	// Ctrl-C cancels the running query, rather than the shell.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	cr.drv.ProcessQueryContext(ctx, query)
 }
@@ -0,0 +1,235 @@
+/*
+Copyright © 2019 stackql info@stackql.io
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"github.com/stackql/stackql/internal/stackql/completion"
+	"github.com/stackql/stackql/internal/stackql/entryutil"
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/metacommand"
+	"github.com/stackql/stackql/internal/stackql/output"
+)
+
+const (
+	metaCommandHelpStr string = `Meta-commands:
+  \d RESOURCE             describe provider.service.resource, \d+ for extended
+  \dt SERVICE             show resources in provider.service
+  \x [on|off]             toggle expanded display of records
+  \timing [on|off]        toggle display of statement elapsed time
+  \o [FILE]               write output to file, or back to the default if omitted
+  \i FILE                 run statements and meta-commands from file
+  \set [NAME [VALUE]]     set a preprocessor variable, or list all if omitted
+  \?                      show this help
+  \q                      quit`
+	maxIncludeDepth int = 16
+)
+
+// shellSession runs shell statements and meta-commands,
+// and holds the display state that meta-commands alter.
+type shellSession struct {
+	runner       sessionRunner
+	completer    *completion.Completer
+	handlerCtx   handler.HandlerContext
+	runtimeCtx   dto.RuntimeCtx
+	outErrFile   io.Writer
+	redirector   *metacommand.Redirector
+	outputFormat string
+	isExpanded   bool
+	isTiming     bool
+	includeDepth int
+}
+
+func newShellSession(
+	runner sessionRunner,
+	completer *completion.Completer,
+	handlerCtx handler.HandlerContext,
+	rtCtx dto.RuntimeCtx,
+	outfile io.Writer,
+	outErrFile io.Writer,
+) *shellSession {
+	return &shellSession{
+		runner:       runner,
+		completer:    completer,
+		handlerCtx:   handlerCtx,
+		runtimeCtx:   rtCtx,
+		outErrFile:   outErrFile,
+		redirector:   metacommand.NewRedirector(outfile),
+		outputFormat: rtCtx.OutputFormat,
+	}
+}
+
+// runStatement preprocesses and then runs a single
+// statement, reporting elapsed time if so configured.
+func (ss *shellSession) runStatement(rawQuery string) {
+	queryToExecute, qErr := entryutil.PreprocessInline(ss.runtimeCtx, rawQuery)
+	if qErr != nil {
+		io.WriteString(ss.outErrFile, "\r\n"+qErr.Error()+"\r\n") //nolint:errcheck // TODO: investigate
+	}
+	start := time.Now()
+	ss.runner.RunCommand(queryToExecute)
+	if ss.completer != nil && isRegistryPull(queryToExecute) {
+		ss.completer.Invalidate()
+	}
+	if ss.isTiming {
+		//nolint:errcheck // outstream write
+		fmt.Fprintf(ss.outErrFile, "Time: %.3f ms\n", float64(time.Since(start))/float64(time.Millisecond))
+	}
+}
+
+// isRegistryPull is true of REGISTRY PULL, which
+// alters the providers and their documents.
+func isRegistryPull(query string) bool {
+	statement, err := sqlparser.Parse(query)
+	if err != nil {
+		return false
+	}
+	registry, ok := statement.(*sqlparser.Registry)
+	return ok && strings.EqualFold(registry.ActionType, "pull")
+}
+
+// handleMetaCommand handles a line commencing with a backslash.
+func (ss *shellSession) handleMetaCommand(line string) {
+	command, arg := metacommand.Parse(line)
+	var err error
+	switch command {
+	case `\d`, `\d+`:
+		err = ss.describe(arg, command == `\d+`)
+	case `\dt`:
+		err = ss.showResources(arg)
+	case `\x`:
+		err = ss.setExpanded(arg)
+	case `\timing`:
+		ss.isTiming, err = metacommand.Toggle(ss.isTiming, arg)
+		if err == nil {
+			ss.writeMessage("Timing is %s.", metacommand.OnOff(ss.isTiming))
+		}
+	case `\o`:
+		err = ss.redirectOutput(arg)
+	case `\i`:
+		err = ss.include(arg)
+	case `\set`:
+		err = ss.setVariable(arg)
+	case `\?`:
+		ss.writeMessage("%s", metaCommandHelpStr)
+	default:
+		err = fmt.Errorf("invalid command %s, try \\? for help", command)
+	}
+	if err != nil {
+		ss.writeMessage("%s", err.Error())
+	}
+}
+
+func (ss *shellSession) describe(resource string, isExtended bool) error {
+	if resource == "" {
+		return fmt.Errorf(`usage: \d provider.service.resource`)
+	}
+	if isExtended {
+		ss.runStatement(fmt.Sprintf("describe extended %s;", resource))
+		return nil
+	}
+	ss.runStatement(fmt.Sprintf("describe %s;", resource))
+	return nil
+}
+
+func (ss *shellSession) showResources(service string) error {
+	if service == "" {
+		return fmt.Errorf(`usage: \dt provider.service`)
+	}
+	ss.runStatement(fmt.Sprintf("show resources in %s;", service))
+	return nil
+}
+
+func (ss *shellSession) setExpanded(arg string) error {
+	isExpanded, err := metacommand.Toggle(ss.isExpanded, arg)
+	if err != nil {
+		return err
+	}
+	ss.isExpanded = isExpanded
+	if isExpanded {
+		ss.handlerCtx.SetOutputFormat(output.ExpandedStr)
+	} else {
+		ss.handlerCtx.SetOutputFormat(ss.outputFormat)
+	}
+	ss.writeMessage("Expanded display is %s.", metacommand.OnOff(isExpanded))
+	return nil
+}
+
+func (ss *shellSession) redirectOutput(filePath string) error {
+	outfile, err := ss.redirector.Redirect(filePath)
+	if err != nil {
+		return err
+	}
+	ss.handlerCtx.SetOutfile(outfile)
+	return nil
+}
+
+func (ss *shellSession) closeRedirect() {
+	ss.redirector.Close()
+}
+
+// include runs a script, handling meta-commands and preprocessing
+// each statement in the same manner as lines typed in the shell.
+func (ss *shellSession) include(filePath string) error {
+	if filePath == "" {
+		return fmt.Errorf(`usage: \i file`)
+	}
+	if ss.includeDepth >= maxIncludeDepth {
+		return fmt.Errorf(`\i nested too deeply, at '%s'`, filePath)
+	}
+	b, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	ss.includeDepth++
+	defer func() { ss.includeDepth-- }()
+	for _, entry := range metacommand.SplitScript(string(b)) {
+		if entry.IsMetaCommand {
+			ss.handleMetaCommand(entry.Text)
+			continue
+		}
+		ss.runStatement(entry.Text + ";")
+	}
+	return nil
+}
+
+// setVariable sets a variable in the preprocessor's variable
+// list, per the `--var` flag, for subsequent statements.
+func (ss *shellSession) setVariable(arg string) error {
+	if arg == "" {
+		for _, v := range metacommand.ListVariables(ss.runtimeCtx.VarList) {
+			ss.writeMessage("%s", v)
+		}
+		return nil
+	}
+	vars, err := metacommand.SetVariable(ss.runtimeCtx.VarList, arg)
+	if err != nil {
+		return err
+	}
+	ss.runtimeCtx.VarList = vars
+	return nil
+}
+
+func (ss *shellSession) writeMessage(format string, args ...interface{}) {
+	fmt.Fprintf(ss.outErrFile, format+"\n", args...) //nolint:errcheck // outstream write
+}
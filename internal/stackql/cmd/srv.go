@@ -18,10 +18,13 @@ package cmd
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/stackql/any-sdk/pkg/logging"
 	"github.com/stackql/stackql/internal/stackql/driver"
 	"github.com/stackql/stackql/internal/stackql/entryutil"
 	"github.com/stackql/stackql/internal/stackql/iqlerror"
+	"github.com/stackql/stackql/internal/stackql/metrics"
 	"github.com/stackql/stackql/internal/stackql/psqlwire"
+	"github.com/stackql/stackql/internal/stackql/srvauth"
 )
 
 const MIN = 1
@@ -46,8 +49,21 @@ var srvCmd = &cobra.Command{
 		handlerCtx, err := entryutil.BuildHandlerContextNoPreProcess(runtimeCtx, queryCache, inputBundle)
 		iqlerror.PrintErrorAndExitOneIfError(err)
 		sbe := driver.NewStackQLDriverFactory(handlerCtx)
-		server, err := psqlwire.MakeWireServer(sbe, runtimeCtx)
+		var users srvauth.UserRegistry
+		if pgSrvUsersFilePath != "" {
+			users, err = srvauth.NewUserRegistryFromFile(pgSrvUsersFilePath)
+			iqlerror.PrintErrorAndExitOneIfError(err)
+			sbe = driver.NewAuthorisingStackQLDriverFactory(handlerCtx, users)
+		}
+		server, err := psqlwire.MakeWireServer(sbe, runtimeCtx, users, pgSrvAllowInsecureAuth)
 		iqlerror.PrintErrorAndExitOneIfError(err)
+		if metricsAddress != "" {
+			go func() {
+				if metricsErr := metrics.ListenAndServe(metricsAddress); metricsErr != nil {
+					logging.GetLogger().Errorf("metrics listener failed: %v", metricsErr)
+				}
+			}()
+		}
 		server.Serve() //nolint:errcheck // TODO: investigate
 	},
 }
@@ -0,0 +1,204 @@
+package completion
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+//nolint:gochecknoglobals // constant lists
+var (
+	keywords = []string{
+		"AND", "AS", "ASC", "AUTH", "BEGIN", "BY", "COMMIT", "COUNT", "DELETE", "DESC",
+		"DESCRIBE", "DISTINCT", "EXEC", "EXTENDED", "FROM", "GROUP", "IN", "INSERT",
+		"INTO", "JOIN", "LIKE", "LIMIT", "LIST", "METHODS", "NOT", "NULL", "ON", "OR",
+		"ORDER", "PROVIDERS", "PULL", "REGISTRY", "RESOURCES", "ROLLBACK", "SELECT",
+		"SERVICES", "SET", "SHOW", "UNION", "UPDATE", "VALUES", "WHERE",
+	}
+	// The qualified name, `provider.service.resource`, of the statement's table.
+	tableNameRegexp = regexp.MustCompile(
+		`(?i)\b(?:from|into|update|describe(?:\s+extended)?)\s+([\w\-]+)\.([\w\-]+)\.([\w\-]+)`)
+)
+
+type completionContext int
+
+const (
+	keywordContext completionContext = iota
+	tableContext
+	execContext
+	selectContext
+	whereContext
+)
+
+// Completer completes keywords and, drawing upon provider
+// metadata, qualified resource names, columns, methods and
+// required parameters, per the position within the statement.
+// Only the current line is considered.
+type Completer struct {
+	source MetadataSource
+}
+
+func NewCompleter(source MetadataSource) *Completer {
+	return &Completer{source: source}
+}
+
+// Invalidate discards any names cached by the source,
+// eg once REGISTRY PULL has changed the providers.
+func (c *Completer) Invalidate() {
+	if invalidator, ok := c.source.(Invalidator); ok {
+		invalidator.Invalidate()
+	}
+}
+
+// Do returns suffixes completing the word preceding pos, and the
+// length of that word, per the readline AutoCompleter interface.
+func (c *Completer) Do(line []rune, pos int) ([][]rune, int) {
+	text := string(line[:pos])
+	word := currentWord(text)
+	ctx := getCompletionContext(text[:len(text)-len(word)])
+	var candidates []string
+	var prefix string
+	switch {
+	case !strings.Contains(word, "."):
+		candidates, prefix = c.complete(ctx, word, string(line)), word
+	case ctx == tableContext || ctx == execContext:
+		candidates, prefix = c.completeQualifiedName(word, ctx == execContext)
+	default:
+		// eg: an aliased column, which would otherwise be sought as a provider
+		return nil, 0
+	}
+	var rv [][]rune
+	for _, candidate := range candidates {
+		rv = append(rv, []rune(candidate[len(prefix):]))
+	}
+	return rv, len([]rune(prefix))
+}
+
+func (c *Completer) complete(ctx completionContext, word string, line string) []string {
+	switch ctx {
+	case tableContext, execContext:
+		providers, _ := c.source.GetProviders()
+		return matchPrefix(providers, word)
+	case selectContext:
+		columns := c.getTableNames(line, c.source.GetColumns)
+		return append(matchPrefix(columns, word), matchNonEmptyKeywords(word)...)
+	case whereContext:
+		names := c.getTableNames(line, c.source.GetRequiredParameters)
+		names = append(names, c.getTableNames(line, c.source.GetColumns)...)
+		return append(matchPrefix(uniqueNames(names), word), matchNonEmptyKeywords(word)...)
+	default:
+		return matchKeywords(word)
+	}
+}
+
+// completeQualifiedName completes the final segment
+// of `provider.service.resource[.method]`.
+func (c *Completer) completeQualifiedName(word string, isExec bool) ([]string, string) {
+	segments := strings.Split(word, ".")
+	prefix := segments[len(segments)-1]
+	var names []string
+	switch path := segments[:len(segments)-1]; len(path) {
+	case 1:
+		names, _ = c.source.GetServices(path[0])
+	case 2: //nolint:mnd // provider and service
+		names, _ = c.source.GetResources(path[0], path[1])
+	case 3: //nolint:mnd // provider, service and resource
+		if isExec {
+			names, _ = c.source.GetMethods(path[0], path[1], path[2])
+		}
+	}
+	return matchPrefix(names, prefix), prefix
+}
+
+func (c *Completer) getTableNames(
+	line string,
+	f func(providerName, serviceName, resourceName string) ([]string, error),
+) []string {
+	m := tableNameRegexp.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	names, _ := f(m[1], m[2], m[3])
+	return names
+}
+
+func isWordDelimiter(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("(),;='\"", r)
+}
+
+func currentWord(text string) string {
+	i := strings.LastIndexFunc(text, isWordDelimiter)
+	return text[i+1:]
+}
+
+// getCompletionContext infers context from the last
+// keyword preceding the word under completion.
+func getCompletionContext(text string) completionContext {
+	tokens := strings.FieldsFunc(strings.ToLower(text), isWordDelimiter)
+	for i := len(tokens) - 1; i >= 0; i-- {
+		switch tokens[i] {
+		case "from", "into", "update", "describe", "join", "in":
+			return tableContext
+		case "exec":
+			return execContext
+		case "select":
+			return selectContext
+		case "where", "and", "or":
+			return whereContext
+		case "set", "values", "show", "limit", "order", "group", "like":
+			return keywordContext
+		}
+	}
+	return keywordContext
+}
+
+func matchPrefix(names []string, prefix string) []string {
+	var rv []string
+	for _, n := range names {
+		if strings.HasPrefix(n, prefix) && n != prefix {
+			rv = append(rv, n)
+		}
+	}
+	return rv
+}
+
+// matchKeywords matches without regard to case, and
+// follows the case of the word under completion.
+func matchKeywords(word string) []string {
+	isLower := word != "" && unicode.IsLower([]rune(word)[0])
+	var rv []string
+	for _, k := range keywords {
+		if !strings.HasPrefix(k, strings.ToUpper(word)) {
+			continue
+		}
+		candidate := k
+		if isLower {
+			candidate = strings.ToLower(k)
+		}
+		rv = append(rv, word+candidate[len(strings.ToUpper(word)):]+" ")
+	}
+	return rv
+}
+
+// matchNonEmptyKeywords offers no keywords for an empty
+// word, so that they do not swamp column names.
+func matchNonEmptyKeywords(word string) []string {
+	if word == "" {
+		return nil
+	}
+	return matchKeywords(word)
+}
+
+// uniqueNames preserves order, so that required
+// parameters precede other columns.
+func uniqueNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	var rv []string
+	for _, n := range names {
+		if !seen[n] {
+			seen[n] = true
+			rv = append(rv, n)
+		}
+	}
+	return rv
+}
@@ -0,0 +1,99 @@
+package completion_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/stackql/stackql/internal/stackql/completion"
+)
+
+type fakeMetadataSource struct{}
+
+func (ms fakeMetadataSource) GetProviders() ([]string, error) {
+	return []string{"github", "google"}, nil
+}
+
+func (ms fakeMetadataSource) GetServices(providerName string) ([]string, error) {
+	if providerName != "google" {
+		return nil, fmt.Errorf("no such provider")
+	}
+	return []string{"compute", "container", "storage"}, nil
+}
+
+func (ms fakeMetadataSource) GetResources(_, serviceName string) ([]string, error) {
+	if serviceName != "compute" {
+		return nil, fmt.Errorf("no such service")
+	}
+	return []string{"disks", "instances"}, nil
+}
+
+func (ms fakeMetadataSource) GetColumns(_, _, resourceName string) ([]string, error) {
+	if resourceName != "instances" {
+		return nil, fmt.Errorf("no such resource")
+	}
+	return []string{"id", "name", "project", "status", "zone"}, nil
+}
+
+func (ms fakeMetadataSource) GetMethods(_, _, _ string) ([]string, error) {
+	return []string{"insert", "start", "stop"}, nil
+}
+
+func (ms fakeMetadataSource) GetRequiredParameters(_, _, _ string) ([]string, error) {
+	return []string{"zone", "project"}, nil
+}
+
+// complete completes at the cursor, '|', else at the end of the line.
+func complete(line string) []string {
+	pos := len([]rune(line))
+	if i := strings.Index(line, "|"); i >= 0 {
+		line = line[:i] + line[i+1:]
+		pos = len([]rune(line[:i]))
+	}
+	candidates, _ := NewCompleter(fakeMetadataSource{}).Do([]rune(line), pos)
+	var rv []string
+	for _, c := range candidates {
+		rv = append(rv, string(c))
+	}
+	return rv
+}
+
+func TestCompleter(t *testing.T) {
+	testCases := []struct {
+		line     string
+		expected []string
+	}{
+		{"sel", []string{"ect "}},
+		{"SEL", []string{"ECT "}},
+		{"select * fr", []string{"om "}},
+		{"SELECT * FROM g", []string{"ithub", "oogle"}},
+		{"select * from google.co", []string{"mpute", "ntainer"}},
+		{"select * from google.compute.i", []string{"nstances"}},
+		{"show resources in google.s", []string{"torage"}},
+		{"exec google.compute.instances.st", []string{"art", "op"}},
+		{"select * from google.compute.instances.st", nil},
+		{"select na| from google.compute.instances", []string{"me"}},
+		{"select * from google.compute.instances where ", []string{"zone", "project", "id", "name", "status"}},
+		{"select * from google.compute.instances where project = 'p' and z", []string{"one"}},
+		{"select * from google.compute.instances where st", []string{"atus"}},
+		{"select * from google.compute.instances where n", []string{"ame", "ot ", "ull "}},
+		{"select t.na| from google.compute.instances t", nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.line, func(t *testing.T) {
+			actual := complete(tc.line)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Fatalf("test failed: expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCompleterReturnsWordLength(t *testing.T) {
+	line := []rune("select * from google.compute.ins")
+	_, length := NewCompleter(fakeMetadataSource{}).Do(line, len(line))
+	if length != len("ins") {
+		t.Fatalf("test failed: expected length %d, got %d", len("ins"), length)
+	}
+}
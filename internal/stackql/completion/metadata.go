@@ -0,0 +1,297 @@
+package completion
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stackql/any-sdk/anysdk"
+	"github.com/stackql/stackql/internal/stackql/handler"
+)
+
+var (
+	_ MetadataSource = &handlerMetadataSource{}
+	_ MetadataSource = &cachedMetadataSource{}
+	_ Invalidator    = &cachedMetadataSource{}
+)
+
+// MetadataSource supplies the names offered as completions.
+// All lists are sorted.
+type MetadataSource interface {
+	GetProviders() ([]string, error)
+	GetServices(providerName string) ([]string, error)
+	GetResources(providerName, serviceName string) ([]string, error)
+	// GetColumns returns the columns of the resource's select method.
+	GetColumns(providerName, serviceName, resourceName string) ([]string, error)
+	GetMethods(providerName, serviceName, resourceName string) ([]string, error)
+	// GetRequiredParameters returns the parameters required
+	// in the WHERE clause of the resource's select method.
+	GetRequiredParameters(providerName, serviceName, resourceName string) ([]string, error)
+}
+
+// NewHandlerMetadataSource returns a source backed by provider
+// discovery documents, in which each lookup is cached.
+func NewHandlerMetadataSource(handlerCtx handler.HandlerContext) MetadataSource {
+	return newCachedMetadataSource(&handlerMetadataSource{handlerCtx: handlerCtx})
+}
+
+type handlerMetadataSource struct {
+	handlerCtx handler.HandlerContext
+}
+
+func (ms *handlerMetadataSource) GetProviders() ([]string, error) {
+	providers, err := ms.handlerCtx.GetSupportedProviders(false)
+	if err != nil {
+		return nil, err
+	}
+	var rv []string
+	for k := range providers {
+		rv = append(rv, k)
+	}
+	return sortedNames(rv), nil
+}
+
+func (ms *handlerMetadataSource) GetServices(providerName string) ([]string, error) {
+	prov, err := ms.handlerCtx.GetProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	services, err := prov.GetProviderServicesRedacted(ms.handlerCtx.GetRuntimeContext(), false)
+	if err != nil {
+		return nil, err
+	}
+	var rv []string
+	for _, svc := range services {
+		if ms.handlerCtx.GetRuntimeContext().UseNonPreferredAPIs || svc.IsPreferred() {
+			rv = append(rv, svc.GetName())
+		}
+	}
+	return sortedNames(rv), nil
+}
+
+func (ms *handlerMetadataSource) GetResources(providerName, serviceName string) ([]string, error) {
+	prov, err := ms.handlerCtx.GetProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := prov.GetResourcesRedacted(serviceName, ms.handlerCtx.GetRuntimeContext(), false)
+	if err != nil {
+		return nil, err
+	}
+	var rv []string
+	for k := range resources {
+		rv = append(rv, k)
+	}
+	return sortedNames(rv), nil
+}
+
+func (ms *handlerMetadataSource) GetColumns(providerName, serviceName, resourceName string) ([]string, error) {
+	method, err := ms.getSelectMethod(providerName, serviceName, resourceName)
+	if err != nil {
+		return nil, err
+	}
+	schema, _, err := method.GetSelectSchemaAndObjectPath()
+	if err != nil {
+		return nil, err
+	}
+	if schema == nil {
+		return nil, fmt.Errorf("no selectable schema for resource '%s'", resourceName)
+	}
+	properties, err := schema.GetProperties()
+	if err != nil {
+		return nil, err
+	}
+	var rv []string
+	for k := range properties {
+		rv = append(rv, k)
+	}
+	return sortedNames(rv), nil
+}
+
+func (ms *handlerMetadataSource) GetMethods(providerName, serviceName, resourceName string) ([]string, error) {
+	rsc, err := ms.getResource(providerName, serviceName, resourceName)
+	if err != nil {
+		return nil, err
+	}
+	var rv []string
+	for k := range rsc.GetMethodsMatched() {
+		rv = append(rv, k)
+	}
+	return sortedNames(rv), nil
+}
+
+func (ms *handlerMetadataSource) GetRequiredParameters(
+	providerName, serviceName, resourceName string,
+) ([]string, error) {
+	method, err := ms.getSelectMethod(providerName, serviceName, resourceName)
+	if err != nil {
+		return nil, err
+	}
+	var rv []string
+	for k := range method.GetRequiredNonBodyParameters() {
+		rv = append(rv, k)
+	}
+	return sortedNames(rv), nil
+}
+
+func (ms *handlerMetadataSource) getResource(
+	providerName, serviceName, resourceName string,
+) (anysdk.Resource, error) {
+	prov, err := ms.handlerCtx.GetProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	return prov.GetResource(serviceName, resourceName, ms.handlerCtx.GetRuntimeContext())
+}
+
+func (ms *handlerMetadataSource) getSelectMethod(
+	providerName, serviceName, resourceName string,
+) (anysdk.OperationStore, error) {
+	rsc, err := ms.getResource(providerName, serviceName, resourceName)
+	if err != nil {
+		return nil, err
+	}
+	method, _, ok := rsc.GetFirstMethodFromSQLVerb("select")
+	if !ok {
+		return nil, fmt.Errorf("no select method for resource '%s'", resourceName)
+	}
+	return method, nil
+}
+
+const (
+	// defaultLookupTimeout bounds the wait of the readline goroutine
+	// upon a lookup, which may download a discovery document.
+	defaultLookupTimeout = 250 * time.Millisecond
+	// defaultFailedLookupTTL bounds the caching of failed lookups.
+	defaultFailedLookupTTL = 30 * time.Second
+)
+
+var errLookupPending = errors.New("metadata lookup pending")
+
+// Invalidator is implemented by sources whose names
+// may go stale, eg upon REGISTRY PULL.
+type Invalidator interface {
+	Invalidate()
+}
+
+// cachedLookup is written once only, prior to done being closed.
+type cachedLookup struct {
+	done    chan struct{}
+	names   []string
+	err     error
+	expires time.Time
+}
+
+func (cl *cachedLookup) isExpired(now time.Time) bool {
+	select {
+	case <-cl.done:
+		return cl.err != nil && now.After(cl.expires)
+	default:
+		return false
+	}
+}
+
+// cachedMetadataSource fills its cache in the background, so that
+// completion never waits longer than lookupTimeout upon discovery;
+// lookups yet pending offer no names.  Failed lookups are cached
+// for failedLookupTTL, lest an unavailable document be sought
+// upon every keystroke, but not for the remainder of the session.
+type cachedMetadataSource struct {
+	delegate        MetadataSource
+	lookupTimeout   time.Duration
+	failedLookupTTL time.Duration
+	mutex           sync.Mutex
+	cache           map[string]*cachedLookup
+}
+
+func newCachedMetadataSource(delegate MetadataSource) *cachedMetadataSource {
+	return &cachedMetadataSource{
+		delegate:        delegate,
+		lookupTimeout:   defaultLookupTimeout,
+		failedLookupTTL: defaultFailedLookupTTL,
+		cache:           make(map[string]*cachedLookup),
+	}
+}
+
+// Invalidate discards all cached lookups.  Lookups
+// in flight complete into the discarded cache.
+func (ms *cachedMetadataSource) Invalidate() {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.cache = make(map[string]*cachedLookup)
+}
+
+func (ms *cachedMetadataSource) lookup(f func() ([]string, error), keys ...string) ([]string, error) {
+	key := strings.Join(keys, "\x00")
+	ms.mutex.Lock()
+	entry, ok := ms.cache[key]
+	if !ok || entry.isExpired(time.Now()) {
+		entry = &cachedLookup{done: make(chan struct{})}
+		ms.cache[key] = entry
+		go ms.fill(entry, f)
+	}
+	ms.mutex.Unlock()
+	timer := time.NewTimer(ms.lookupTimeout)
+	defer timer.Stop()
+	select {
+	case <-entry.done:
+		return entry.names, entry.err
+	case <-timer.C:
+		return nil, errLookupPending
+	}
+}
+
+func (ms *cachedMetadataSource) fill(entry *cachedLookup, f func() ([]string, error)) {
+	defer close(entry.done)
+	entry.names, entry.err = f()
+	if entry.err != nil {
+		entry.expires = time.Now().Add(ms.failedLookupTTL)
+	}
+}
+
+func (ms *cachedMetadataSource) GetProviders() ([]string, error) {
+	return ms.lookup(ms.delegate.GetProviders, "providers")
+}
+
+func (ms *cachedMetadataSource) GetServices(providerName string) ([]string, error) {
+	return ms.lookup(
+		func() ([]string, error) { return ms.delegate.GetServices(providerName) },
+		"services", providerName)
+}
+
+func (ms *cachedMetadataSource) GetResources(providerName, serviceName string) ([]string, error) {
+	return ms.lookup(
+		func() ([]string, error) { return ms.delegate.GetResources(providerName, serviceName) },
+		"resources", providerName, serviceName)
+}
+
+func (ms *cachedMetadataSource) GetColumns(providerName, serviceName, resourceName string) ([]string, error) {
+	return ms.lookup(
+		func() ([]string, error) { return ms.delegate.GetColumns(providerName, serviceName, resourceName) },
+		"columns", providerName, serviceName, resourceName)
+}
+
+func (ms *cachedMetadataSource) GetMethods(providerName, serviceName, resourceName string) ([]string, error) {
+	return ms.lookup(
+		func() ([]string, error) { return ms.delegate.GetMethods(providerName, serviceName, resourceName) },
+		"methods", providerName, serviceName, resourceName)
+}
+
+func (ms *cachedMetadataSource) GetRequiredParameters(
+	providerName, serviceName, resourceName string,
+) ([]string, error) {
+	return ms.lookup(
+		func() ([]string, error) {
+			return ms.delegate.GetRequiredParameters(providerName, serviceName, resourceName)
+		},
+		"parameters", providerName, serviceName, resourceName)
+}
+
+func sortedNames(names []string) []string {
+	rv := uniqueNames(names)
+	sort.Strings(rv)
+	return rv
+}
@@ -0,0 +1,92 @@
+package completion //nolint:testpackage // the cache timings are substituted
+
+import (
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingMetadataSource fails until succeeding is set, and
+// blocks each lookup until released, should release be non nil.
+type countingMetadataSource struct {
+	MetadataSource
+	calls      atomic.Int32
+	succeeding atomic.Bool
+	release    chan struct{}
+}
+
+func (ms *countingMetadataSource) GetProviders() ([]string, error) {
+	ms.calls.Add(1)
+	if ms.release != nil {
+		<-ms.release
+	}
+	if !ms.succeeding.Load() {
+		return nil, errors.New("registry unavailable")
+	}
+	return []string{"google"}, nil
+}
+
+func newTestCachedMetadataSource(delegate MetadataSource, failedLookupTTL time.Duration) *cachedMetadataSource {
+	ms := newCachedMetadataSource(delegate)
+	ms.lookupTimeout = time.Second
+	ms.failedLookupTTL = failedLookupTTL
+	return ms
+}
+
+func TestCachedMetadataSourceExpiresFailedLookups(t *testing.T) {
+	delegate := &countingMetadataSource{}
+	ms := newTestCachedMetadataSource(delegate, 50*time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if _, err := ms.GetProviders(); err == nil {
+			t.Fatalf("test failed: expected lookup error")
+		}
+	}
+	if calls := delegate.calls.Load(); calls != 1 {
+		t.Fatalf("test failed: failed lookup sought %d times within ttl, expected 1", calls)
+	}
+	delegate.succeeding.Store(true)
+	time.Sleep(100 * time.Millisecond)
+	providers, err := ms.GetProviders()
+	if err != nil || !reflect.DeepEqual(providers, []string{"google"}) {
+		t.Fatalf("test failed: providers = %v, error = %v after ttl", providers, err)
+	}
+	if _, err = ms.GetProviders(); err != nil || delegate.calls.Load() != 2 {
+		t.Fatalf("test failed: successful lookup not cached, calls = %d", delegate.calls.Load())
+	}
+}
+
+func TestCachedMetadataSourceInvalidate(t *testing.T) {
+	delegate := &countingMetadataSource{}
+	delegate.succeeding.Store(true)
+	ms := newTestCachedMetadataSource(delegate, time.Hour)
+	ms.GetProviders() //nolint:errcheck // fills the cache
+	NewCompleter(ms).Invalidate()
+	ms.GetProviders() //nolint:errcheck // refills the cache
+	if calls := delegate.calls.Load(); calls != 2 {
+		t.Fatalf("test failed: providers sought %d times, expected 2", calls)
+	}
+}
+
+func TestCachedMetadataSourceDoesNotBlockUponSlowLookup(t *testing.T) {
+	delegate := &countingMetadataSource{release: make(chan struct{})}
+	delegate.succeeding.Store(true)
+	ms := newTestCachedMetadataSource(delegate, time.Hour)
+	ms.lookupTimeout = 10 * time.Millisecond
+	if _, err := ms.GetProviders(); !errors.Is(err, errLookupPending) {
+		t.Fatalf("test failed: error = %v, expected pending lookup", err)
+	}
+	if _, err := ms.GetProviders(); !errors.Is(err, errLookupPending) {
+		t.Fatalf("test failed: error = %v, expected pending lookup", err)
+	}
+	close(delegate.release)
+	ms.lookupTimeout = time.Second
+	providers, err := ms.GetProviders()
+	if err != nil || !reflect.DeepEqual(providers, []string{"google"}) {
+		t.Fatalf("test failed: providers = %v, error = %v once filled", providers, err)
+	}
+	if calls := delegate.calls.Load(); calls != 1 {
+		t.Fatalf("test failed: providers sought %d times, expected 1", calls)
+	}
+}
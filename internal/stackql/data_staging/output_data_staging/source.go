@@ -1,6 +1,7 @@
 package output_data_staging //nolint:revive,stylecheck // package name is helpful
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/stackql/stackql/internal/stackql/drm"
@@ -9,10 +10,13 @@ import (
 
 type Source interface {
 	SourceSQLRows() (*sql.Rows, error)
+	// SourceSQLRowsContext sources rows which are
+	// closed upon cancellation of the context.
+	SourceSQLRowsContext(ctx context.Context) (*sql.Rows, error)
 }
 
 func NewNaiveSource(
-	querier sqlmachinery.Querier,
+	querier sqlmachinery.ContextQuerier,
 	stmtCtx drm.PreparedStatementParameterized,
 	drmCfg drm.Config,
 ) Source {
@@ -24,7 +28,7 @@ func NewNaiveSource(
 }
 
 type naiveSource struct {
-	querier sqlmachinery.Querier
+	querier sqlmachinery.ContextQuerier
 	stmtCtx drm.PreparedStatementParameterized
 	drmCfg  drm.Config
 }
@@ -36,3 +40,11 @@ func (st *naiveSource) SourceSQLRows() (*sql.Rows, error) {
 	)
 	return r, sqlErr
 }
+
+func (st *naiveSource) SourceSQLRowsContext(ctx context.Context) (*sql.Rows, error) {
+	return st.drmCfg.QueryDMLContext(
+		ctx,
+		st.querier,
+		st.stmtCtx,
+	)
+}
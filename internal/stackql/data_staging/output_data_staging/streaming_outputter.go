@@ -0,0 +1,207 @@
+package output_data_staging //nolint:revive,stylecheck // package name is helpful
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+
+	"github.com/stackql/any-sdk/pkg/logging"
+	"github.com/stackql/psql-wire/pkg/sqldata"
+	"github.com/stackql/stackql/internal/stackql/drm"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/streaming"
+	"github.com/stackql/stackql/internal/stackql/typing"
+	"github.com/stackql/stackql/internal/stackql/util"
+)
+
+const (
+	streamedBatchSize int = 100
+)
+
+var (
+	_ internaldto.StreamedSQLResult = &sqlRowsResultStream{}
+)
+
+// NewStreamingOutputter returns the rows of the source in batches,
+// as they are read from the backend, rather than materialising them.
+// The output must be read through, or else released; the
+// rows are closed upon cancellation of the context.
+func NewStreamingOutputter(
+	ctx context.Context,
+	source Source,
+	nonControlColumns []typing.ColumnMetadata,
+	stream streaming.MapStream,
+	drmCfg drm.Config,
+	typCfg typing.Config,
+) Outputter {
+	return &streamingOutputter{
+		ctx:               ctx,
+		source:            source,
+		nonControlColumns: nonControlColumns,
+		stream:            stream,
+		drmCfg:            drmCfg,
+		typCfg:            typCfg,
+	}
+}
+
+type streamingOutputter struct {
+	ctx               context.Context
+	source            Source
+	nonControlColumns []typing.ColumnMetadata
+	stream            streaming.MapStream
+	drmCfg            drm.Config
+	typCfg            typing.Config
+}
+
+func (st *streamingOutputter) OutputExecutorResult() internaldto.ExecutorOutput {
+	//nolint:rowserrcheck // checked upon release
+	r, err := st.source.SourceSQLRowsContext(st.ctx)
+	if err != nil {
+		return internaldto.NewErroneousExecutorOutput(fmt.Errorf("sql packet preparation error: %w", err))
+	}
+	resultStream := newSQLRowsResultStream(r, st.nonControlColumns, st.stream, st.drmCfg, st.typCfg)
+	rv := internaldto.NewExecutorOutput(resultStream, nil, nil, nil, nil)
+	// Raw results are sought by consuming primitives, which are
+	// ordinarily given materialised output.  Failing that, any
+	// rows not yet streamed are materialised upon request.
+	var rawRows internaldto.RawMap
+	rv.SetRawResultFn(func() internaldto.IRawResultStream {
+		if rawRows == nil {
+			rawRows = resultStream.readRaw()
+		}
+		return internaldto.NewSimpleRawResultStream(rawRows)
+	})
+	return rv
+}
+
+type sqlRowsResultStream struct {
+	rows      *sql.Rows
+	columns   []sqldata.ISQLColumn
+	cNames    []string
+	ifArr     []interface{}
+	stream    streaming.MapStream
+	drmCfg    drm.Config
+	isPending bool
+	isDone    bool
+	err       error
+	onRelease []func(error)
+}
+
+func newSQLRowsResultStream(
+	rows *sql.Rows,
+	nonControlColumns []typing.ColumnMetadata,
+	stream streaming.MapStream,
+	drmCfg drm.Config,
+	typCfg typing.Config,
+) *sqlRowsResultStream {
+	ifArr, cNames := drmCfg.GetGolangSlices(nonControlColumns)
+	table := sqldata.NewSQLTable(0, "meta_table")
+	columns := make([]sqldata.ISQLColumn, len(nonControlColumns))
+	for i, col := range nonControlColumns {
+		columns[i] = typCfg.GetPlaceholderColumn(table, cNames[i], col.GetColumnOID())
+	}
+	return &sqlRowsResultStream{
+		rows:    rows,
+		columns: columns,
+		cNames:  cNames,
+		ifArr:   ifArr,
+		stream:  stream,
+		drmCfg:  drmCfg,
+		isDone:  rows == nil,
+	}
+}
+
+// Read returns the next batch of rows.  The final
+// batch, which may be empty, accompanies io.EOF.
+func (rs *sqlRowsResultStream) Read() (sqldata.ISQLResult, error) {
+	if rs.err != nil {
+		return nil, rs.err
+	}
+	var rows []sqldata.ISQLRow
+	for len(rows) < streamedBatchSize && rs.next() {
+		rowVals := util.ArrangeOrderedColumnRow(rs.scan(), rs.columns, rs.cNames, len(rs.cNames))
+		rows = append(rows, sqldata.NewSQLRow(rowVals))
+	}
+	// Read ahead, so that the final batch is marked as such.
+	if !rs.next() {
+		if err := rs.Release(); err != nil {
+			rs.err = err
+			return nil, err
+		}
+		return sqldata.NewSQLResult(rs.columns, 0, 0, rows), io.EOF
+	}
+	return sqldata.NewSQLResult(rs.columns, 0, 0, rows), nil
+}
+
+func (rs *sqlRowsResultStream) Write(sqldata.ISQLResult) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (rs *sqlRowsResultStream) Close() error {
+	return rs.Release()
+}
+
+// Release closes the backend rows; it is idempotent.
+func (rs *sqlRowsResultStream) Release() error {
+	if rs.isDone {
+		return nil
+	}
+	rs.isDone = true
+	rs.isPending = false
+	closeErr := rs.rows.Close()
+	err := rs.rows.Err()
+	if err == nil {
+		err = closeErr
+	}
+	for _, fn := range rs.onRelease {
+		fn(err)
+	}
+	rs.onRelease = nil
+	return err
+}
+
+func (rs *sqlRowsResultStream) OnRelease(fn func(error)) {
+	if rs.isDone {
+		fn(rs.err)
+		return
+	}
+	rs.onRelease = append(rs.onRelease, fn)
+}
+
+func (rs *sqlRowsResultStream) readRaw() internaldto.RawMap {
+	rawRows := make(internaldto.RawMap)
+	for i := 0; rs.next(); i++ {
+		im := rs.scan()
+		imRaw := make(map[int]interface{}, len(rs.cNames))
+		for ord, key := range rs.cNames {
+			imRaw[ord] = im[key]
+		}
+		rawRows[i] = imRaw
+	}
+	if err := rs.Release(); err != nil {
+		rs.err = err
+	}
+	return rawRows
+}
+
+func (rs *sqlRowsResultStream) next() bool {
+	if !rs.isPending && !rs.isDone {
+		rs.isPending = rs.rows.Next()
+	}
+	return rs.isPending
+}
+
+func (rs *sqlRowsResultStream) scan() map[string]interface{} {
+	rs.isPending = false
+	errScan := rs.rows.Scan(rs.ifArr...)
+	if errScan != nil {
+		logging.GetLogger().Infoln(fmt.Sprintf("%v", errScan))
+	}
+	im := make(map[string]interface{}, len(rs.cNames))
+	for ord, key := range rs.cNames {
+		im[key] = rs.drmCfg.ExtractFromGolangValue(rs.ifArr[ord])
+	}
+	rs.stream.Write([]map[string]interface{}{im}) //nolint:errcheck // as per materialised output
+	return im
+}
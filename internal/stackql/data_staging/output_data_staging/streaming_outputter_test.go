@@ -0,0 +1,60 @@
+package output_data_staging //nolint:revive,stylecheck,testpackage // the stream is unexported
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stackql/stackql/internal/stackql/streaming"
+)
+
+func newReleaseTestStream(t *testing.T, rows *sqlmock.Rows) *sqlRowsResultStream {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	mock.ExpectQuery("SELECT").WillReturnRows(rows)
+	//nolint:rowserrcheck // checked upon release
+	r, err := db.Query("SELECT 1")
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	return &sqlRowsResultStream{rows: r, stream: streaming.NewNopMapStream()}
+}
+
+func TestStreamOnReleaseUponReadThrough(t *testing.T) {
+	rs := newReleaseTestStream(t, sqlmock.NewRows([]string{"a"}).AddRow(1))
+	var released []error
+	rs.OnRelease(func(err error) { released = append(released, err) })
+	if _, err := rs.Read(); !errors.Is(err, io.EOF) {
+		t.Fatalf("test failed: expected EOF, got %v", err)
+	}
+	rs.Release() //nolint:errcheck // idempotent
+	if len(released) != 1 || released[0] != nil {
+		t.Fatalf("test failed: expected a single clean release, got %v", released)
+	}
+	// Arranged after release, the function is called forthwith.
+	rs.OnRelease(func(err error) { released = append(released, err) })
+	if len(released) != 2 {
+		t.Fatalf("test failed: expected release forthwith, got %v", released)
+	}
+}
+
+func TestStreamOnReleaseUponReadError(t *testing.T) {
+	readErr := errors.New("connection lost")
+	rs := newReleaseTestStream(t, sqlmock.NewRows([]string{"a"}).AddRow(1).AddRow(2).RowError(1, readErr))
+	var released []error
+	rs.OnRelease(func(err error) { released = append(released, err) })
+	if _, err := rs.Read(); !errors.Is(err, readErr) {
+		t.Fatalf("test failed: expected read error, got %v", err)
+	}
+	if len(released) != 1 || !errors.Is(released[0], readErr) {
+		t.Fatalf("test failed: expected release with read error, got %v", released)
+	}
+	rs.OnRelease(func(err error) { released = append(released, err) })
+	if len(released) != 2 || !errors.Is(released[1], readErr) {
+		t.Fatalf("test failed: expected late release with read error, got %v", released)
+	}
+}
@@ -3,18 +3,36 @@ package driver
 import (
 	"context"
 	"fmt"
+	"sync"
 
+	"github.com/stackql/any-sdk/pkg/dto"
 	"github.com/stackql/any-sdk/pkg/logging"
 	"github.com/stackql/psql-wire/pkg/sqldata"
+	"github.com/stackql/stackql/internal/stackql/acid/recovery"
 	"github.com/stackql/stackql/internal/stackql/acid/tsm_physio"
+	"github.com/stackql/stackql/internal/stackql/acid/txn_context"
 	"github.com/stackql/stackql/internal/stackql/handler"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/metrics"
+	"github.com/stackql/stackql/internal/stackql/parser"
+	"github.com/stackql/stackql/internal/stackql/planbuilder"
+	"github.com/stackql/stackql/internal/stackql/preparedstatement"
 	"github.com/stackql/stackql/internal/stackql/responsehandler"
 	"github.com/stackql/stackql/internal/stackql/sqlengine"
+	"github.com/stackql/stackql/internal/stackql/srvauth"
+	"github.com/stackql/stackql/internal/stackql/tracing"
 	"github.com/stackql/stackql/internal/stackql/util"
 	"github.com/stackql/stackql/pkg/txncounter"
 
+	wire "github.com/stackql/psql-wire"
 	sqlbackend "github.com/stackql/psql-wire/pkg/sqlbackend"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// Connection startup parameter carrying provider credentials.
+	startupAuthParameter wire.ParameterStatus = "stackql.auth"
 )
 
 var (
@@ -29,6 +47,7 @@ type StackQLDriverFactory interface {
 
 type basicStackQLDriverFactory struct {
 	handlerCtx handler.HandlerContext
+	users      srvauth.UserRegistry
 }
 
 func (sdf *basicStackQLDriverFactory) NewSQLBackend() (sqlbackend.ISQLBackend, error) {
@@ -57,11 +76,14 @@ func (sdf *basicStackQLDriverFactory) newSQLDriver() (StackQLDriver, error) {
 		return nil, walError
 	}
 	sdf.handlerCtx.SetTSM(tsmInstance)
-	clonedCtx := sdf.handlerCtx.Clone()
+	// each connection is a distinct session
+	clonedCtx := sdf.handlerCtx.ForkSession()
 	clonedCtx.SetTxnCounterMgr(txCtr)
 	rv := &basicStackQLDriver{
-		handlerCtx:      clonedCtx,
-		txnOrchestrator: txnOrchestrator,
+		handlerCtx:         clonedCtx,
+		txnOrchestrator:    txnOrchestrator,
+		preparedStatements: preparedstatement.NewRegistry(),
+		users:              sdf.users,
 	}
 	return rv, nil
 }
@@ -72,6 +94,18 @@ func NewStackQLDriverFactory(handlerCtx handler.HandlerContext) sqlbackend.SQLBa
 	}
 }
 
+// NewAuthorisingStackQLDriverFactory yields drivers that apply
+// the policy of the authenticated user to each query.
+func NewAuthorisingStackQLDriverFactory(
+	handlerCtx handler.HandlerContext,
+	users srvauth.UserRegistry,
+) sqlbackend.SQLBackendFactory {
+	return &basicStackQLDriverFactory{
+		handlerCtx: handlerCtx,
+		users:      users,
+	}
+}
+
 func getTxnCounterManager(sqlEngine sqlengine.SQLEngine) (txncounter.Manager, error) {
 	genID, err := sqlEngine.GetCurrentGenerationID()
 	if err != nil {
@@ -92,8 +126,22 @@ func getTxnCounterManager(sqlEngine sqlengine.SQLEngine) (txncounter.Manager, er
 // and their bounding transactions.
 type StackQLDriver interface {
 	sqlbackend.ISQLBackend
+	// Statements of the extended query protocol
+	// are the prepared statements of the session.
+	sqlbackend.IExtendedQueryBackend
 	ProcessDryRun(string)
 	ProcessQuery(string)
+	// Process query, aborting execution
+	// upon cancellation of the supplied context.
+	ProcessQueryContext(context.Context, string)
+	// Process script statement by statement, per the error
+	// policy, summarising the outcome of each statement.
+	ProcessScript(context.Context, string, ErrorPolicy) (ScriptSummary, error)
+	// Prepared statements, per session, which back PREPARE,
+	// EXECUTE and DEALLOCATE, as well as the Parse, Bind and Close
+	// messages of the postgres extended query protocol.
+	PrepareStatement(name string, query string) (preparedstatement.PreparedStatement, error)
+	DeallocatePreparedStatement(name string) error
 }
 
 func (dr *basicStackQLDriver) ProcessDryRun(query string) {
@@ -110,62 +158,422 @@ func (dr *basicStackQLDriver) ProcessDryRun(query string) {
 }
 
 func (dr *basicStackQLDriver) ProcessQuery(query string) {
+	dr.ProcessQueryContext(context.Background(), query)
+}
+
+// ProcessQueryContext splits compound queries up front,
+// so that prepared statement commands are recognised.
+func (dr *basicStackQLDriver) ProcessQueryContext(ctx context.Context, query string) {
+	queries, _ := dr.SplitCompoundQuery(query)
+	for _, q := range queries {
+		dr.processStatement(ctx, q) //nolint:errcheck // errors are written to output
+	}
+}
+
+// processStatement roots the trace of a single statement.  It returns
+// the first error among the statement's outputs, and a count of
+// any rows returned, per countRows.
+func (dr *basicStackQLDriver) processStatement(ctx context.Context, query string) (*int64, error) {
+	ctx, span := startStatementSpan(ctx, query)
+	var err error
+	defer func() { tracing.End(span, err) }()
 	clonedCtx := dr.handlerCtx.Clone()
-	clonedCtx.SetRawQuery(query)
+	clonedCtx.SetContext(ctx)
+	boundQuery, isQuery, err := dr.handlePreparedStatementCommand(clonedCtx, query)
+	if err != nil {
+		//nolint:errcheck // TODO: investigate
+		responsehandler.HandleResponse(clonedCtx, internaldto.NewErroneousExecutorOutput(err))
+		return nil, err
+	}
+	if !isQuery {
+		return nil, nil
+	}
+	if output, isRecover := dr.handleRecoverCommand(clonedCtx, boundQuery); isRecover {
+		err = output.GetError()
+		responsehandler.HandleResponse(clonedCtx, output) //nolint:errcheck // TODO: investigate
+		return nil, err
+	}
+	clonedCtx.SetRawQuery(boundQuery)
 	responses, ok := dr.processQueryOrQueries(clonedCtx)
-	if ok {
-		for _, r := range responses {
-			responsehandler.HandleResponse(clonedCtx, r) //nolint:errcheck // TODO: investigate
+	if !ok {
+		return nil, nil
+	}
+	var rowCount *int64
+	for _, r := range responses {
+		if err == nil {
+			err = r.GetError()
+		}
+		rowCountFn := countRows(r)
+		responsehandler.HandleResponse(clonedCtx, r) //nolint:errcheck // TODO: investigate
+		if n := rowCountFn(); n != nil {
+			if rowCount == nil {
+				rowCount = new(int64)
+			}
+			*rowCount += *n
 		}
 	}
+	return rowCount, err
+}
+
+// startStatementSpan omits the query text, which may carry secrets.
+func startStatementSpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "statement", tracing.AttrStatementType.String(metrics.GetStatementType(query)))
 }
 
 type basicStackQLDriver struct {
-	handlerCtx      handler.HandlerContext
-	txnOrchestrator tsm_physio.Orchestrator
+	handlerCtx         handler.HandlerContext
+	txnOrchestrator    tsm_physio.Orchestrator
+	preparedStatements preparedstatement.Registry
+	users              srvauth.UserRegistry
+	sessionInitOnce    sync.Once
+	sessionInitErr     error
 }
 
 func (dr *basicStackQLDriver) CloneSQLBackend() sqlbackend.ISQLBackend {
 	return &basicStackQLDriver{
-		handlerCtx: dr.handlerCtx.Clone(),
+		handlerCtx:         dr.handlerCtx.Clone(),
+		preparedStatements: preparedstatement.NewRegistry(),
+		users:              dr.users,
+	}
+}
+
+func (dr *basicStackQLDriver) PrepareStatement(
+	name string,
+	query string,
+) (preparedstatement.PreparedStatement, error) {
+	ps, err := preparedstatement.NewCachedPreparedStatement(dr.handlerCtx.GetLRUCache(), name, query)
+	if err != nil {
+		return nil, err
+	}
+	if err = dr.preparedStatements.Put(ps); err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+func (dr *basicStackQLDriver) DeallocatePreparedStatement(name string) error {
+	return dr.preparedStatements.Delete(name)
+}
+
+// HandleParse prepares the statement of a Parse message.
+func (dr *basicStackQLDriver) HandleParse(ctx context.Context, name string, query string) (int, error) {
+	if err := dr.initSession(ctx); err != nil {
+		return 0, err
+	}
+	ps, err := dr.PrepareStatement(name, query)
+	if err != nil {
+		return 0, err
+	}
+	return ps.GetParameterCount(), nil
+}
+
+// HandleExecutePrepared binds wire format parameter values as
+// literals, whereupon the bound query is processed as a simple
+// query, save that any cached plan is that of the statement.
+func (dr *basicStackQLDriver) HandleExecutePrepared(
+	ctx context.Context,
+	name string,
+	args []interface{},
+) (sqldata.ISQLResultStream, error) {
+	if err := dr.initSession(ctx); err != nil {
+		return nil, err
+	}
+	ps, boundQuery, err := dr.bindPreparedStatement(name, args)
+	if err != nil {
+		return nil, err
+	}
+	clonedCtx := dr.handlerCtx.Clone()
+	clonedCtx.SetContext(ctx)
+	clonedCtx.SetStatementPlanCache(ps.GetPlanCache())
+	return dr.handleBoundQuery(ctx, clonedCtx, boundQuery)
+}
+
+// HandleDescribeStatement describes the statement of a Describe
+// message by planning it bound to NULL parameters.  Failure
+// to plan so signals only that the description is unknown.
+func (dr *basicStackQLDriver) HandleDescribeStatement(
+	ctx context.Context,
+	name string,
+) ([]sqldata.ISQLColumn, error) {
+	if err := dr.initSession(ctx); err != nil {
+		return nil, err
+	}
+	ps, err := dr.preparedStatements.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	_, boundQuery, err := dr.bindPreparedStatement(name, make([]interface{}, ps.GetParameterCount()))
+	if err != nil {
+		return nil, err
+	}
+	columns, _, err := dr.describeBoundQuery(ctx, ps, boundQuery)
+	if err != nil {
+		logging.GetLogger().Debugf("statement \"%s\" not described: %v", name, err)
+		return nil, nil
+	}
+	return columns, nil
+}
+
+// HandleDescribePortal describes the bound statement of a Describe
+// message by planning it, such that any cached plan serves Execute.
+func (dr *basicStackQLDriver) HandleDescribePortal(
+	ctx context.Context,
+	name string,
+	args []interface{},
+) ([]sqldata.ISQLColumn, bool, error) {
+	if err := dr.initSession(ctx); err != nil {
+		return nil, false, err
+	}
+	ps, boundQuery, err := dr.bindPreparedStatement(name, args)
+	if err != nil {
+		return nil, false, err
+	}
+	return dr.describeBoundQuery(ctx, ps, boundQuery)
+}
+
+func (dr *basicStackQLDriver) bindPreparedStatement(
+	name string,
+	args []interface{},
+) (preparedstatement.PreparedStatement, string, error) {
+	ps, err := dr.preparedStatements.Get(name)
+	if err != nil {
+		return nil, "", err
+	}
+	literals := make([]string, len(args))
+	for i, arg := range args {
+		literals[i] = preparedstatement.QuoteLiteral(arg)
+	}
+	boundQuery, err := ps.Bind(literals)
+	if err != nil {
+		return nil, "", err
+	}
+	return ps, boundQuery, nil
+}
+
+// describeBoundQuery plans, without executing, a bound query.  The
+// columns of SELECT statements are known from the plan, and other
+// mutations have none; the result of any other read only statement
+// is known only upon execution, as signalled by the boolean return.
+func (dr *basicStackQLDriver) describeBoundQuery(
+	ctx context.Context,
+	ps preparedstatement.PreparedStatement,
+	boundQuery string,
+) ([]sqldata.ISQLColumn, bool, error) {
+	queries := parser.SplitStatements(boundQuery)
+	if len(queries) != 1 {
+		return nil, false, nil
+	}
+	clonedCtx := dr.handlerCtx.Clone()
+	clonedCtx.SetContext(ctx)
+	clonedCtx.SetRawQuery(boundQuery)
+	clonedCtx.SetQuery(queries[0])
+	clonedCtx.SetStatementPlanCache(ps.GetPlanCache())
+	pl, err := planbuilder.NewPlanBuilder(txn_context.NewTransactionContext(0)).BuildPlanFromContext(clonedCtx)
+	if err != nil {
+		return nil, false, err
+	}
+	if planColumns, hasColumns := pl.GetColumns(); hasColumns {
+		typCfg := dr.handlerCtx.GetTypingConfig()
+		table := sqldata.NewSQLTable(0, "meta_table")
+		columns := make([]sqldata.ISQLColumn, len(planColumns))
+		for i, col := range planColumns {
+			columns[i] = typCfg.GetPlaceholderColumn(table, col.GetIdentifier(), col.GetColumnOID())
+		}
+		return columns, true, nil
+	}
+	if pl.IsReadOnly() {
+		return nil, false, nil
+	}
+	return nil, true, nil
+}
+
+// HandleCloseStatement deallocates the statement of a Close
+// message; per the protocol, closing an absent statement is no error.
+func (dr *basicStackQLDriver) HandleCloseStatement(_ context.Context, name string) error {
+	if _, err := dr.preparedStatements.Get(name); err != nil {
+		return nil //nolint:nilerr // per the protocol
+	}
+	return dr.DeallocatePreparedStatement(name)
+}
+
+// handlePreparedStatementCommand actions PREPARE and DEALLOCATE,
+// and binds EXECUTE against the plan cache of the statement.
+// The boolean return signals that the returned query
+// requires processing, per the handler context.
+func (dr *basicStackQLDriver) handlePreparedStatementCommand(
+	handlerCtx handler.HandlerContext,
+	query string,
+) (string, bool, error) {
+	cmd, isCmd, err := preparedstatement.ParseCommand(query)
+	if err != nil {
+		return "", false, err
+	}
+	if !isCmd {
+		return query, true, nil
+	}
+	switch cmd.GetType() {
+	case preparedstatement.PrepareCommand:
+		_, err = dr.PrepareStatement(cmd.GetName(), cmd.GetQuery())
+		return "", false, err
+	case preparedstatement.DeallocateCommand:
+		if cmd.IsDeallocateAll() {
+			dr.preparedStatements.Clear()
+			return "", false, nil
+		}
+		return "", false, dr.DeallocatePreparedStatement(cmd.GetName())
+	case preparedstatement.ExecuteCommand:
+		ps, getErr := dr.preparedStatements.Get(cmd.GetName())
+		if getErr != nil {
+			return "", false, getErr
+		}
+		boundQuery, bindErr := ps.Bind(cmd.GetArgs())
+		if bindErr != nil {
+			return "", false, bindErr
+		}
+		handlerCtx.SetStatementPlanCache(ps.GetPlanCache())
+		return boundQuery, true, nil
+	default:
+		return "", false, fmt.Errorf("unsupported prepared statement command")
 	}
 }
 
-//nolint:revive // TODO: review
+// handleRecoverCommand actions RECOVER, which operates upon
+// the WAL rather than being planned, and is not part of any
+// transaction of the session; see recovery.Command.  The
+// boolean return signals whether the query is a RECOVER statement.
+func (dr *basicStackQLDriver) handleRecoverCommand(
+	handlerCtx handler.HandlerContext,
+	query string,
+) (internaldto.ExecutorOutput, bool) {
+	cmd, isCmd, err := recovery.ParseCommand(query)
+	if !isCmd {
+		return nil, false
+	}
+	if err != nil {
+		return internaldto.NewErroneousExecutorOutput(err), true
+	}
+	if policy := handlerCtx.GetAuthorisationPolicy(); policy != nil && cmd.Type != recovery.ListCommand {
+		if err = policy.AuthoriseMutation("RECOVER"); err != nil {
+			return internaldto.NewErroneousExecutorOutput(err), true
+		}
+	}
+	return recovery.ExecuteCommand(handlerCtx, cmd), true
+}
+
 func (dr *basicStackQLDriver) HandleSimpleQuery(ctx context.Context, query string) (sqldata.ISQLResultStream, error) {
-	dr.handlerCtx.SetRawQuery(query)
+	if err := dr.initSession(ctx); err != nil {
+		return nil, err
+	}
+	clonedCtx := dr.handlerCtx.Clone()
+	clonedCtx.SetContext(ctx)
+	boundQuery, isQuery, err := dr.handlePreparedStatementCommand(clonedCtx, query)
+	if err != nil {
+		return nil, err
+	}
+	if !isQuery {
+		return nil, nil //nolint:nilnil // the wire server reports completion
+	}
+	return dr.handleBoundQuery(ctx, clonedCtx, boundQuery)
+}
+
+// handleBoundQuery processes a query, as bound
+// from any prepared statement, for the wire server.
+func (dr *basicStackQLDriver) handleBoundQuery(
+	ctx context.Context,
+	handlerCtx handler.HandlerContext,
+	query string,
+) (sqldata.ISQLResultStream, error) {
+	if output, isRecover := dr.handleRecoverCommand(handlerCtx, query); isRecover {
+		if output.GetError() != nil {
+			return nil, fmt.Errorf("query returns error: %w", output.GetError())
+		}
+		return output.GetSQLResult(), nil
+	}
+	return dr.handleSimpleQuery(ctx, handlerCtx, query)
+}
+
+// initSession runs upon the first query of a connection, since the
+// connection parameters are only available from the query context.
+func (dr *basicStackQLDriver) initSession(ctx context.Context) error {
+	dr.sessionInitOnce.Do(func() {
+		if dr.sessionInitErr = dr.authorise(ctx); dr.sessionInitErr != nil {
+			return
+		}
+		dr.sessionInitErr = dr.applyStartupAuth(ctx)
+	})
+	return dr.sessionInitErr
+}
+
+// authorise attaches the policy of the connection's user
+// to the session, if users are configured.
+func (dr *basicStackQLDriver) authorise(ctx context.Context) error {
+	if dr.users == nil {
+		return nil
+	}
+	username := wire.AuthenticatedUsername(ctx)
+	policy, ok := dr.users.GetPolicy(username)
+	if !ok {
+		return fmt.Errorf("permission denied for user '%s'", username)
+	}
+	dr.handlerCtx.SetAuthorisationPolicy(policy)
+	return nil
+}
+
+// applyStartupAuth accepts provider credentials for the connection
+// as a startup parameter, in the same form as the `--auth` flag.
+// Only inline credentials are accepted; files and environment
+// variables of the server are not for remote clients to name.
+func (dr *basicStackQLDriver) applyStartupAuth(ctx context.Context) error {
+	raw, ok := wire.ClientParameters(ctx)[startupAuthParameter]
+	if !ok || raw == "" {
+		return nil
+	}
+	authContexts := make(map[string]*dto.AuthCtx)
+	if err := yaml.Unmarshal([]byte(raw), authContexts); err != nil {
+		return fmt.Errorf("error unmarshalling '%s' startup parameter: %w", startupAuthParameter, err)
+	}
+	for providerName, authCtx := range authContexts {
+		if err := dr.handlerCtx.SetSessionAuthContext(providerName, authCtx); err != nil {
+			return fmt.Errorf("error in '%s' startup parameter for provider '%s': %w", startupAuthParameter, providerName, err)
+		}
+	}
+	return nil
+}
+
+func (dr *basicStackQLDriver) handleSimpleQuery(
+	ctx context.Context,
+	handlerCtx handler.HandlerContext,
+	query string,
+) (sqldata.ISQLResultStream, error) {
+	ctx, span := startStatementSpan(ctx, query)
+	var err error
+	defer func() { tracing.End(span, err) }()
+	handlerCtx.SetRawQuery(query)
+	handlerCtx.SetContext(ctx)
 	// if strings.Count(query, ";") > 1 {
 	// 	return nil, fmt.Errorf("only support single queries in server mode at this time")
 	// }
-	res, ok := dr.processQueryOrQueries(dr.handlerCtx)
+	res, ok := dr.processQueryOrQueries(handlerCtx)
 	if !ok {
-		return nil, fmt.Errorf("no SQLresults available")
+		err = fmt.Errorf("no SQLresults available")
+		return nil, err
+	}
+	for _, discarded := range res[1:] {
+		internaldto.ReleaseSQLResult(discarded) //nolint:errcheck // output discarded
 	}
 	r := res[0]
 	if r.GetError() != nil {
-		return nil, fmt.Errorf("query returns error: %w", r.GetError())
+		err = fmt.Errorf("query returns error: %w", r.GetError())
+		return nil, err
 	}
+	// Any streamed result is written to the wire chunk by chunk,
+	// the backend rows being held open until read through,
+	// or else released by the wire server upon error.
 	return r.GetSQLResult(), nil
 }
 
 func (dr *basicStackQLDriver) SplitCompoundQuery(s string) ([]string, error) {
-	res := []string{}
-	var beg int
-	var inDoubleQuotes bool
-
-	for i := 0; i < len(s); i++ {
-		if s[i] == ';' && !inDoubleQuotes {
-			res = append(res, s[beg:i])
-			beg = i + 1
-		} else if s[i] == '"' {
-			if !inDoubleQuotes {
-				inDoubleQuotes = true
-			} else if i > 0 && s[i-1] != '\\' {
-				inDoubleQuotes = false
-			}
-		}
-	}
-	return append(res, s[beg:]), nil
+	return parser.SplitStatements(s), nil
 }
 
 func NewStackQLDriver(handlerCtx handler.HandlerContext) (StackQLDriver, error) {
@@ -184,8 +592,9 @@ func NewStackQLDriver(handlerCtx handler.HandlerContext) (StackQLDriver, error)
 	}
 	handlerCtx.SetTSM(tsmInstance)
 	return &basicStackQLDriver{
-		handlerCtx:      handlerCtx,
-		txnOrchestrator: txnOrchestrator,
+		handlerCtx:         handlerCtx,
+		txnOrchestrator:    txnOrchestrator,
+		preparedStatements: preparedstatement.NewRegistry(),
 	}, nil
 }
 
@@ -0,0 +1,235 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/stackql/any-sdk/pkg/constants"
+	"github.com/stackql/psql-wire/pkg/sqldata"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/parser"
+)
+
+// ErrorPolicy governs how a script proceeds
+// once one of its statements fails.
+type ErrorPolicy string
+
+const (
+	// OnErrorContinue executes all statements regardless.
+	OnErrorContinue ErrorPolicy = "continue"
+	// OnErrorStop skips all statements following a failure.
+	OnErrorStop ErrorPolicy = "stop"
+	// OnErrorRollback runs the script within a transaction,
+	// which is rolled back upon failure.
+	OnErrorRollback ErrorPolicy = "rollback"
+)
+
+const (
+	StatementStatusOK         string = "ok"
+	StatementStatusError      string = "error"
+	StatementStatusSkipped    string = "skipped"
+	StatementStatusRolledBack string = "rolled_back"
+)
+
+const (
+	scriptBeginStatement    string = "begin"
+	scriptCommitStatement   string = "commit"
+	scriptRollbackStatement string = "rollback"
+)
+
+func ParseErrorPolicy(s string) (ErrorPolicy, error) {
+	switch p := ErrorPolicy(s); p {
+	case OnErrorContinue, OnErrorStop, OnErrorRollback:
+		return p, nil
+	default:
+		return "", fmt.Errorf(
+			"unsupported error policy '%s', must be (%s | %s | %s)",
+			s, OnErrorStop, OnErrorContinue, OnErrorRollback)
+	}
+}
+
+// StatementSummary records the outcome of a single script statement.
+// RowsReturned is the count of rows returned by a query, and is
+// nil where the statement returns no result set.
+type StatementSummary struct {
+	Index        int     `json:"index"`
+	Status       string  `json:"status"`
+	RowsReturned *int64  `json:"rows_returned"`
+	DurationMs   float64 `json:"duration_ms"`
+	Error        string  `json:"error,omitempty"`
+}
+
+type ScriptSummary struct {
+	Statements       []StatementSummary `json:"statements"`
+	RolledBack       bool               `json:"rolled_back,omitempty"`
+	TransactionError string             `json:"transaction_error,omitempty"`
+}
+
+// IsFailed is true where any statement, or the
+// closing of the script transaction, failed.
+func (s ScriptSummary) IsFailed() bool {
+	if s.TransactionError != "" {
+		return true
+	}
+	for _, stmt := range s.Statements {
+		if stmt.Status == StatementStatusError {
+			return true
+		}
+	}
+	return false
+}
+
+func (s ScriptSummary) Write(w io.Writer) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// ProcessScript processes the statements of a script one by one,
+// per the error policy.  The rollback policy relies upon the inverse
+// graphs of the eager rollback coordinator, and so is only
+// available to sessions configured with eager rollback.  Under that
+// policy, a failed commit is also rolled back, since statements
+// reported ok may have been queued rather than executed.
+//
+//nolint:funlen // sequential and clearer in one place
+func (dr *basicStackQLDriver) ProcessScript(
+	ctx context.Context,
+	script string,
+	onError ErrorPolicy,
+) (ScriptSummary, error) {
+	if onError == OnErrorRollback && dr.handlerCtx.GetRollbackType() != constants.EagerRollback {
+		return ScriptSummary{}, fmt.Errorf(
+			"error policy '%s' requires session rollback type '%s'", OnErrorRollback, constants.EagerRollbackStr)
+	}
+	statements := parser.SplitStatements(script)
+	summary := ScriptSummary{Statements: make([]StatementSummary, len(statements))}
+	if onError == OnErrorRollback {
+		if _, err := dr.processStatement(ctx, scriptBeginStatement); err != nil {
+			for i := range summary.Statements {
+				summary.Statements[i] = StatementSummary{Index: i + 1, Status: StatementStatusSkipped}
+			}
+			summary.TransactionError = fmt.Sprintf("begin failed: %s", err.Error())
+			return summary, err
+		}
+	}
+	isFailed := false
+	for i, q := range statements {
+		stmtSummary := &summary.Statements[i]
+		stmtSummary.Index = i + 1
+		if isFailed && onError != OnErrorContinue {
+			stmtSummary.Status = StatementStatusSkipped
+			continue
+		}
+		start := time.Now()
+		rowCount, err := dr.processStatement(ctx, q)
+		stmtSummary.DurationMs = float64(time.Since(start)) / float64(time.Millisecond)
+		stmtSummary.RowsReturned = rowCount
+		if err != nil {
+			isFailed = true
+			stmtSummary.Status = StatementStatusError
+			stmtSummary.Error = err.Error()
+			continue
+		}
+		stmtSummary.Status = StatementStatusOK
+	}
+	if onError != OnErrorRollback {
+		return summary, nil
+	}
+	if !isFailed {
+		_, commitErr := dr.processStatement(ctx, scriptCommitStatement)
+		if commitErr == nil {
+			return summary, nil
+		}
+		// queued statements execute upon commit, and so
+		// share its failure; the transaction remains open
+		// until rolled back
+		summary.TransactionError = fmt.Sprintf("commit failed: %s", commitErr.Error())
+	}
+	if _, err := dr.processStatement(ctx, scriptRollbackStatement); err != nil {
+		if summary.TransactionError == "" {
+			summary.TransactionError = err.Error()
+			return summary, nil
+		}
+		summary.TransactionError = fmt.Sprintf("%s; rollback failed: %s", summary.TransactionError, err.Error())
+		for i := range summary.Statements {
+			if summary.Statements[i].Status == StatementStatusOK {
+				summary.Statements[i].Status = StatementStatusError
+				summary.Statements[i].Error = summary.TransactionError
+			}
+		}
+		return summary, nil
+	}
+	summary.RolledBack = true
+	for i := range summary.Statements {
+		if summary.Statements[i].Status == StatementStatusOK {
+			summary.Statements[i].Status = StatementStatusRolledBack
+		}
+	}
+	return summary, nil
+}
+
+// countRows tallies the rows read from the output's result,
+// so long as it is a result set; the count is complete only
+// once the result is read through.
+func countRows(output internaldto.ExecutorOutput) func() *int64 {
+	if output.GetError() != nil || output.GetSQLResult() == nil {
+		return func() *int64 { return nil }
+	}
+	counter := &rowCountingResultStream{ISQLResultStream: output.GetSQLResult()}
+	output.SetSQLResultFn(func() sqldata.ISQLResultStream {
+		return counter
+	})
+	return func() *int64 {
+		if !counter.isResultSet {
+			return nil
+		}
+		rv := counter.rowCount
+		return &rv
+	}
+}
+
+type rowCountingResultStream struct {
+	sqldata.ISQLResultStream
+	rowCount    int64
+	isResultSet bool
+}
+
+func (rs *rowCountingResultStream) Read() (sqldata.ISQLResult, error) {
+	res, err := rs.ISQLResultStream.Read()
+	if res != nil && (err == nil || errors.Is(err, io.EOF)) {
+		if len(res.GetColumns()) > 0 {
+			rs.isResultSet = true
+		}
+		for _, row := range res.GetRows() {
+			// as per output writers, empty rows are not presented
+			if len(row.GetRowDataNaive()) > 0 {
+				rs.rowCount++
+			}
+		}
+	}
+	return res, err
+}
+
+// Release preserves the release semantic of any streamed result.
+func (rs *rowCountingResultStream) Release() error {
+	if streamed, isStreamed := rs.ISQLResultStream.(internaldto.StreamedSQLResult); isStreamed {
+		return streamed.Release()
+	}
+	return nil
+}
+
+func (rs *rowCountingResultStream) OnRelease(fn func(error)) {
+	if streamed, isStreamed := rs.ISQLResultStream.(internaldto.StreamedSQLResult); isStreamed {
+		streamed.OnRelease(fn)
+		return
+	}
+	fn(nil)
+}
@@ -0,0 +1,277 @@
+package driver //nolint:testpackage // the transaction orchestrator is substituted
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/stackql/any-sdk/pkg/constants"
+	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/preparedstatement"
+	"github.com/stackql/stackql/internal/stackql/srvauth"
+)
+
+type stubHandlerContext struct {
+	handler.HandlerContext
+	rawQuery     string
+	rollbackType constants.RollbackType
+	out          *bytes.Buffer
+}
+
+func (hc *stubHandlerContext) Clone() handler.HandlerContext {
+	rv := *hc
+	return &rv
+}
+
+func (hc *stubHandlerContext) SetContext(context.Context)              {}
+func (hc *stubHandlerContext) SetRawQuery(q string)                    { hc.rawQuery = q }
+func (hc *stubHandlerContext) GetRawQuery() string                     { return hc.rawQuery }
+func (hc *stubHandlerContext) GetRollbackType() constants.RollbackType { return hc.rollbackType }
+func (hc *stubHandlerContext) GetOutfile() io.Writer                   { return hc.out }
+func (hc *stubHandlerContext) GetOutErrFile() io.Writer                { return hc.out }
+func (hc *stubHandlerContext) GetErrorPresentation() string            { return "" }
+func (hc *stubHandlerContext) GetAuthorisationPolicy() srvauth.Policy  { return nil }
+
+func (hc *stubHandlerContext) GetRuntimeContext() dto.RuntimeCtx {
+	return dto.RuntimeCtx{OutputFormat: constants.JSONStr}
+}
+
+// stubOrchestrator records queries and, as per the eager rollback
+// coordinator, queues mutations within a transaction until commit,
+// and runs the compensation of each executed upon rollback.  Invalid
+// queries fail upon receipt, as does planning, whereas failing
+// queries fail only once executed.
+type stubOrchestrator struct {
+	queries     []string
+	executed    []string
+	invalid     map[string]bool
+	failing     map[string]bool
+	compensated []string
+	pending     []string
+	committed   []string
+	isInTxn     bool
+}
+
+func (o *stubOrchestrator) execute(q string) internaldto.ExecutorOutput {
+	o.executed = append(o.executed, q)
+	if o.failing[q] {
+		return internaldto.NewErroneousExecutorOutput(errors.New("failed: " + q))
+	}
+	return internaldto.NewEmptyExecutorOutput()
+}
+
+func (o *stubOrchestrator) commit() internaldto.ExecutorOutput {
+	for len(o.pending) > 0 {
+		q := o.pending[0]
+		o.pending = o.pending[1:]
+		o.committed = append(o.committed, q)
+		if output := o.execute(q); output.GetError() != nil {
+			return output
+		}
+	}
+	o.isInTxn = false
+	o.committed = nil
+	return internaldto.NewEmptyExecutorOutput()
+}
+
+func (o *stubOrchestrator) ProcessQueryOrQueries(
+	handlerCtx handler.HandlerContext,
+) ([]internaldto.ExecutorOutput, bool) {
+	q := handlerCtx.GetRawQuery()
+	o.queries = append(o.queries, q)
+	if o.invalid[q] {
+		return []internaldto.ExecutorOutput{internaldto.NewErroneousExecutorOutput(errors.New("invalid: " + q))}, true
+	}
+	switch q {
+	case scriptBeginStatement:
+		o.isInTxn = true
+	case scriptCommitStatement:
+		return []internaldto.ExecutorOutput{o.commit()}, true
+	case scriptRollbackStatement:
+		for i := len(o.committed) - 1; i >= 0; i-- {
+			o.compensated = append(o.compensated, o.committed[i])
+		}
+		o.isInTxn = false
+		o.pending = nil
+		o.committed = nil
+	default:
+		if o.isInTxn {
+			o.pending = append(o.pending, q)
+			break
+		}
+		return []internaldto.ExecutorOutput{o.execute(q)}, true
+	}
+	return []internaldto.ExecutorOutput{internaldto.NewEmptyExecutorOutput()}, true
+}
+
+func newStubScriptDriver(
+	rollbackType constants.RollbackType,
+	failing ...string,
+) (*basicStackQLDriver, *stubOrchestrator) {
+	orchestrator := &stubOrchestrator{invalid: map[string]bool{}, failing: map[string]bool{}}
+	for _, q := range failing {
+		orchestrator.failing[q] = true
+	}
+	return &basicStackQLDriver{
+		handlerCtx:         &stubHandlerContext{rollbackType: rollbackType, out: &bytes.Buffer{}},
+		txnOrchestrator:    orchestrator,
+		preparedStatements: preparedstatement.NewRegistry(),
+	}, orchestrator
+}
+
+const stubScript = "insert one; insert two; insert three"
+
+func statuses(summary ScriptSummary) []string {
+	var rv []string
+	for _, stmt := range summary.Statements {
+		rv = append(rv, stmt.Status)
+	}
+	return rv
+}
+
+func TestProcessScriptStopSkipsFollowingStatements(t *testing.T) {
+	dr, orchestrator := newStubScriptDriver(constants.NopRollback, "insert two")
+	summary, err := dr.ProcessScript(context.Background(), stubScript, OnErrorStop)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if expected := []string{"insert one", "insert two"}; !reflect.DeepEqual(orchestrator.queries, expected) {
+		t.Fatalf("test failed: executed %v, expected %v", orchestrator.queries, expected)
+	}
+	expected := []string{StatementStatusOK, StatementStatusError, StatementStatusSkipped}
+	if got := statuses(summary); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("test failed: statuses %v, expected %v", got, expected)
+	}
+	if !summary.IsFailed() || summary.RolledBack {
+		t.Fatalf("test failed: unexpected summary %+v", summary)
+	}
+}
+
+func TestProcessScriptContinueExecutesAllStatements(t *testing.T) {
+	dr, orchestrator := newStubScriptDriver(constants.NopRollback, "insert two")
+	summary, err := dr.ProcessScript(context.Background(), stubScript, OnErrorContinue)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if expected := []string{"insert one", "insert two", "insert three"}; !reflect.DeepEqual(orchestrator.queries, expected) {
+		t.Fatalf("test failed: executed %v, expected %v", orchestrator.queries, expected)
+	}
+	expected := []string{StatementStatusOK, StatementStatusError, StatementStatusOK}
+	if got := statuses(summary); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("test failed: statuses %v, expected %v", got, expected)
+	}
+}
+
+func TestProcessScriptRollbackDiscardsQueuedStatements(t *testing.T) {
+	dr, orchestrator := newStubScriptDriver(constants.EagerRollback)
+	orchestrator.invalid["insert three"] = true
+	summary, err := dr.ProcessScript(context.Background(), stubScript, OnErrorRollback)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	expectedQueries := []string{"begin", "insert one", "insert two", "insert three", "rollback"}
+	if !reflect.DeepEqual(orchestrator.queries, expectedQueries) {
+		t.Fatalf("test failed: received %v, expected %v", orchestrator.queries, expectedQueries)
+	}
+	if len(orchestrator.executed) != 0 || len(orchestrator.compensated) != 0 || orchestrator.isInTxn {
+		t.Fatalf("test failed: executed %v, compensated %v", orchestrator.executed, orchestrator.compensated)
+	}
+	expected := []string{StatementStatusRolledBack, StatementStatusRolledBack, StatementStatusError}
+	if got := statuses(summary); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("test failed: statuses %v, expected %v", got, expected)
+	}
+	if !summary.RolledBack || summary.TransactionError != "" {
+		t.Fatalf("test failed: unexpected summary %+v", summary)
+	}
+}
+
+func TestProcessScriptRollbackUponCommitFailure(t *testing.T) {
+	dr, orchestrator := newStubScriptDriver(constants.EagerRollback, "insert two")
+	summary, err := dr.ProcessScript(context.Background(), stubScript, OnErrorRollback)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	expectedQueries := []string{"begin", "insert one", "insert two", "insert three", "commit", "rollback"}
+	if !reflect.DeepEqual(orchestrator.queries, expectedQueries) {
+		t.Fatalf("test failed: received %v, expected %v", orchestrator.queries, expectedQueries)
+	}
+	if expected := []string{"insert one", "insert two"}; !reflect.DeepEqual(orchestrator.executed, expected) {
+		t.Fatalf("test failed: executed %v, expected %v", orchestrator.executed, expected)
+	}
+	if expected := []string{"insert two", "insert one"}; !reflect.DeepEqual(orchestrator.compensated, expected) {
+		t.Fatalf("test failed: compensated %v, expected %v", orchestrator.compensated, expected)
+	}
+	if orchestrator.isInTxn {
+		t.Fatalf("test failed: session left in transaction")
+	}
+	expected := []string{StatementStatusRolledBack, StatementStatusRolledBack, StatementStatusRolledBack}
+	if got := statuses(summary); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("test failed: statuses %v, expected %v", got, expected)
+	}
+	if !summary.RolledBack || summary.TransactionError != "commit failed: failed: insert two" || !summary.IsFailed() {
+		t.Fatalf("test failed: unexpected summary %+v", summary)
+	}
+}
+
+func TestProcessScriptCommitAndRollbackFailure(t *testing.T) {
+	dr, orchestrator := newStubScriptDriver(constants.EagerRollback, "insert two")
+	orchestrator.invalid[scriptRollbackStatement] = true
+	summary, err := dr.ProcessScript(context.Background(), stubScript, OnErrorRollback)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	expected := []string{StatementStatusError, StatementStatusError, StatementStatusError}
+	if got := statuses(summary); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("test failed: statuses %v, expected %v", got, expected)
+	}
+	expectedErr := "commit failed: failed: insert two; rollback failed: invalid: rollback"
+	if summary.RolledBack || summary.TransactionError != expectedErr {
+		t.Fatalf("test failed: unexpected summary %+v", summary)
+	}
+	for _, stmt := range summary.Statements {
+		if stmt.Error != expectedErr {
+			t.Fatalf("test failed: unexpected statement summary %+v", stmt)
+		}
+	}
+}
+
+func TestProcessScriptRollbackCommitsOnSuccess(t *testing.T) {
+	dr, orchestrator := newStubScriptDriver(constants.EagerRollback)
+	summary, err := dr.ProcessScript(context.Background(), stubScript, OnErrorRollback)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if last := orchestrator.queries[len(orchestrator.queries)-1]; last != scriptCommitStatement {
+		t.Fatalf("test failed: final statement '%s', expected '%s'", last, scriptCommitStatement)
+	}
+	if expected := []string{"insert one", "insert two", "insert three"}; !reflect.DeepEqual(orchestrator.executed, expected) {
+		t.Fatalf("test failed: executed %v, expected %v", orchestrator.executed, expected)
+	}
+	if len(orchestrator.compensated) != 0 || orchestrator.isInTxn || summary.IsFailed() || summary.RolledBack {
+		t.Fatalf("test failed: unexpected summary %+v", summary)
+	}
+}
+
+func TestProcessScriptBeginFailureSummarised(t *testing.T) {
+	dr, orchestrator := newStubScriptDriver(constants.EagerRollback)
+	orchestrator.invalid[scriptBeginStatement] = true
+	summary, err := dr.ProcessScript(context.Background(), stubScript, OnErrorRollback)
+	if err == nil {
+		t.Fatalf("test failed: expected begin error")
+	}
+	if expected := []string{scriptBeginStatement}; !reflect.DeepEqual(orchestrator.queries, expected) {
+		t.Fatalf("test failed: executed %v, expected %v", orchestrator.queries, expected)
+	}
+	expected := []string{StatementStatusSkipped, StatementStatusSkipped, StatementStatusSkipped}
+	if got := statuses(summary); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("test failed: statuses %v, expected %v", got, expected)
+	}
+	if !summary.IsFailed() {
+		t.Fatalf("test failed: summary of failed begin not failed")
+	}
+}
@@ -0,0 +1,50 @@
+package driver_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	. "github.com/stackql/stackql/internal/stackql/driver"
+)
+
+func TestParseErrorPolicy(t *testing.T) {
+	for _, s := range []string{"stop", "continue", "rollback"} {
+		p, err := ParseErrorPolicy(s)
+		if err != nil || string(p) != s {
+			t.Fatalf("test failed: policy '%s' parsed as '%s', error = %v", s, p, err)
+		}
+	}
+	if _, err := ParseErrorPolicy("abort"); err == nil {
+		t.Fatalf("test failed: expected error for unsupported policy")
+	}
+}
+
+func TestScriptSummary(t *testing.T) {
+	rowCount := int64(2)
+	summary := ScriptSummary{
+		Statements: []StatementSummary{
+			{Index: 1, Status: StatementStatusOK, RowsReturned: &rowCount},
+			{Index: 2, Status: StatementStatusError, Error: "boom"},
+			{Index: 3, Status: StatementStatusSkipped},
+		},
+	}
+	if !summary.IsFailed() {
+		t.Fatalf("test failed: summary with erroneous statement not failed")
+	}
+	var buf bytes.Buffer
+	if err := summary.Write(&buf); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	var decoded map[string][]map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("test failed: invalid JSON '%s': %v", buf.String(), err)
+	}
+	stmts := decoded["statements"]
+	if len(stmts) != 3 || stmts[0]["rows_returned"] != float64(2) || stmts[2]["rows_returned"] != nil {
+		t.Fatalf("test failed: unexpected summary '%s'", buf.String())
+	}
+	if (ScriptSummary{Statements: summary.Statements[:1]}).IsFailed() {
+		t.Fatalf("test failed: successful summary failed")
+	}
+}
@@ -1,6 +1,7 @@
 package drm
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -67,6 +68,11 @@ type Config interface {
 	OpenapiColumnsToRelationalColumns(cols []anysdk.ColumnDescriptor) []typing.RelationalColumn
 	OpenapiColumnsToRelationalColumn(col anysdk.ColumnDescriptor) typing.RelationalColumn
 	QueryDML(sqlmachinery.Querier, PreparedStatementParameterized) (*sql.Rows, error)
+	QueryDMLContext(
+		context.Context,
+		sqlmachinery.ContextQuerier,
+		PreparedStatementParameterized,
+	) (*sql.Rows, error)
 	ExecDDL(
 		querier sqlmachinery.ExecQuerier,
 		ctxParameterized PreparedStatementParameterized,
@@ -783,6 +789,21 @@ func (dc *staticDRMConfig) QueryDML(
 	return querier.Query(query, varArgs...)
 }
 
+func (dc *staticDRMConfig) QueryDMLContext(
+	ctx context.Context,
+	querier sqlmachinery.ContextQuerier,
+	ctxParameterized PreparedStatementParameterized,
+) (*sql.Rows, error) {
+	prepStmt, err := dc.prepareCtx(ctxParameterized)
+	if err != nil {
+		return nil, err
+	}
+	query := prepStmt.GetRawQuery()
+	varArgs := prepStmt.GetArgs()
+	logging.GetLogger().Infoln(fmt.Sprintf("query = %s, varArgs = %v", query, varArgs))
+	return querier.QueryContext(ctx, query, varArgs...)
+}
+
 func (dc *staticDRMConfig) prepareCtx(ctxParameterized PreparedStatementParameterized) (internaldto.PrepStmt, error) {
 	if ctxParameterized.GetCtx() == nil {
 		return nil, fmt.Errorf("cannot execute based upon nil PreparedStatementContext")
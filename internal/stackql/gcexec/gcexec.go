@@ -5,6 +5,7 @@ import (
 
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
 	"github.com/stackql/stackql/internal/stackql/kstore"
+	"github.com/stackql/stackql/internal/stackql/metrics"
 	"github.com/stackql/stackql/internal/stackql/sql_system"
 	"github.com/stackql/stackql/internal/stackql/sqlengine"
 	"github.com/stackql/stackql/internal/stackql/tablenamespace"
@@ -96,6 +97,7 @@ func (rc *basicGarbageCollectorExecutor) Update(tableName string, parentTcc, tcc
 func (rc *basicGarbageCollectorExecutor) Collect() error {
 	rc.gcMutex.Lock()
 	defer rc.gcMutex.Unlock()
+	metrics.AddGCRun()
 	minID, minValid := rc.txnStore.Min()
 	if !minValid {
 		return rc.sqlSystem.GCCollectAll()
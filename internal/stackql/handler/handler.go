@@ -1,17 +1,18 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"path"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/stackql/any-sdk/anysdk"
 	"github.com/stackql/any-sdk/pkg/constants"
 	"github.com/stackql/any-sdk/pkg/dto"
-	"github.com/stackql/any-sdk/pkg/jsonpath"
 	"github.com/stackql/any-sdk/pkg/nomenclature"
 	"github.com/stackql/stackql/internal/stackql/acid/tsm"
 	"github.com/stackql/stackql/internal/stackql/acid/txn_context"
@@ -26,6 +27,7 @@ import (
 	"github.com/stackql/stackql/internal/stackql/sql_system"
 	"github.com/stackql/stackql/internal/stackql/sqlcontrol"
 	"github.com/stackql/stackql/internal/stackql/sqlengine"
+	"github.com/stackql/stackql/internal/stackql/srvauth"
 	"github.com/stackql/stackql/internal/stackql/tablenamespace"
 	"github.com/stackql/stackql/internal/stackql/typing"
 	"github.com/stackql/stackql/pkg/txncounter"
@@ -56,11 +58,19 @@ type HandlerContext interface { //nolint:revive // don't mind stuttering this on
 	GetControlAttributes() sqlcontrol.ControlAttributes
 	GetCurrentProvider() string
 	GetAuthContexts() dto.AuthContexts
+	// Override the startup auth context
+	// for a provider, for this session only.
+	SetSessionAuthContext(providerName string, authCtx *dto.AuthCtx) error
+	HasSessionAuthContexts() bool
 	GetRegistry() anysdk.RegistryAPI
 	GetErrorPresentation() string
 	GetOutfile() io.Writer
 	GetOutErrFile() io.Writer
 	GetLRUCache() *lrucache.LRUCache
+	// The plan cache of the prepared statement under
+	// execution, if any, in lieu of the LRU cache.
+	GetStatementPlanCache() (*lrucache.LRUCache, bool)
+	SetStatementPlanCache(*lrucache.LRUCache)
 	GetSQLDataSource(name string) (sql_datasource.SQLDataSource, bool)
 	GetSQLEngine() sqlengine.SQLEngine
 	GetSQLSystem() sql_system.SQLSystem
@@ -76,6 +86,8 @@ type HandlerContext interface { //nolint:revive // don't mind stuttering this on
 	SetCurrentProvider(string)
 	SetOutfile(io.Writer)
 	SetOutErrFile(io.Writer)
+	// SetOutputFormat alters the output format of later statements.
+	SetOutputFormat(string)
 	SetQuery(string)
 	SetRawQuery(string)
 	//
@@ -84,6 +96,13 @@ type HandlerContext interface { //nolint:revive // don't mind stuttering this on
 	GetTypingConfig() typing.Config
 	GetIsolationLevel() constants.IsolationLevel
 	UpdateIsolationLevel(isolationLevelStr string) error
+	// SetNextTransactionIsolationLevel sets the isolation
+	// level of the next transaction to begin, as per SET TRANSACTION.
+	SetNextTransactionIsolationLevel(isolationLevelStr string) error
+	// TakeTransactionIsolationLevel returns the isolation level of a transaction
+	// now beginning, being that of SET TRANSACTION since the last BEGIN, if any,
+	// or else that of the session.
+	TakeTransactionIsolationLevel() constants.IsolationLevel
 
 	GetRollbackType() constants.RollbackType
 	UpdateRollbackType(rollbackTypeStr string) error
@@ -98,6 +117,35 @@ type HandlerContext interface { //nolint:revive // don't mind stuttering this on
 
 	//
 	SetConfigAtPath(path string, rhs interface{}, scope string) error
+
+	// The context bounding the current query;
+	// cancelled upon client interruption.
+	GetContext() context.Context
+	SetContext(context.Context)
+
+	GetStatementTimeout() time.Duration
+	SetStatementTimeout(time.Duration)
+	GetLockTimeout() time.Duration
+	SetLockTimeout(time.Duration)
+	GetAwaitTimeout() time.Duration
+	SetAwaitTimeout(time.Duration)
+	// IsExplainAnalyzeMutations is true where EXPLAIN ANALYZE may
+	// execute statements that mutate provider resources.
+	IsExplainAnalyzeMutations() bool
+	SetExplainAnalyzeMutations(bool)
+	GetHTTPRetryPolicy(providerName string) HTTPRetryPolicy
+	// GetHTTPRateLimiter returns nil for unlimited providers.
+	GetHTTPRateLimiter(providerName string) *netutils.ProviderRateLimiter
+
+	// The policy of the authenticated server user;
+	// nil implies unrestricted.
+	GetAuthorisationPolicy() srvauth.Policy
+	SetAuthorisationPolicy(srvauth.Policy)
+
+	// Clone, with session scoped settings detached
+	// from the original; for a new session of a remote
+	// client, whose credentials must be supplied inline.
+	ForkSession() HandlerContext
 }
 
 type standardHandlerContext struct {
@@ -119,6 +167,7 @@ type standardHandlerContext struct {
 	outfile             io.Writer
 	outErrFile          io.Writer
 	lRUCache            *lrucache.LRUCache
+	statementPlanCache  *lrucache.LRUCache
 	sqlEngine           sqlengine.SQLEngine
 	sqlSystem           sql_system.SQLSystem
 	garbageCollector    garbagecollector.GarbageCollector
@@ -133,6 +182,9 @@ type standardHandlerContext struct {
 	sessionContext      dto.SessionContext
 	walInstance         tsm.TSM
 	exportNamespace     string
+	ctx                 context.Context
+	sessionSettings     *sessionSettings
+	policy              srvauth.Policy
 }
 
 func (hc *standardHandlerContext) GetDataFlowCfg() dto.DataFlowCfg {
@@ -185,6 +237,10 @@ func (hc *standardHandlerContext) SetTxnCounterMgr(mgr txncounter.Manager) {
 func (hc *standardHandlerContext) SetOutfile(outFile io.Writer)       { hc.outfile = outFile }
 func (hc *standardHandlerContext) SetOutErrFile(outErrFile io.Writer) { hc.outErrFile = outErrFile }
 
+func (hc *standardHandlerContext) SetOutputFormat(outputFormat string) {
+	hc.runtimeContext.OutputFormat = outputFormat
+}
+
 func (hc *standardHandlerContext) GetRawQuery() string                         { return hc.rawQuery }
 func (hc *standardHandlerContext) GetQuery() string                            { return hc.query }
 func (hc *standardHandlerContext) GetRuntimeContext() dto.RuntimeCtx           { return hc.runtimeContext }
@@ -194,17 +250,27 @@ func (hc *standardHandlerContext) GetControlAttributes() sqlcontrol.ControlAttri
 }
 func (hc *standardHandlerContext) GetCurrentProvider() string { return hc.currentProvider }
 
+// GetAuthContexts merges session auth contexts
+// over those supplied at startup.
 func (hc *standardHandlerContext) GetAuthContexts() dto.AuthContexts {
 	hc.authMapMutex.Lock()
 	defer hc.authMapMutex.Unlock()
-	return hc.authContexts
+	return hc.sessionSettings.mergeAuthContexts(hc.authContexts)
+}
+
+func (hc *standardHandlerContext) GetRegistry() anysdk.RegistryAPI { return hc.registry }
+func (hc *standardHandlerContext) GetErrorPresentation() string    { return hc.errorPresentation }
+func (hc *standardHandlerContext) GetOutfile() io.Writer           { return hc.outfile }
+func (hc *standardHandlerContext) GetOutErrFile() io.Writer        { return hc.outErrFile }
+func (hc *standardHandlerContext) GetLRUCache() *lrucache.LRUCache { return hc.lRUCache }
+
+func (hc *standardHandlerContext) GetStatementPlanCache() (*lrucache.LRUCache, bool) {
+	return hc.statementPlanCache, hc.statementPlanCache != nil
 }
 
-func (hc *standardHandlerContext) GetRegistry() anysdk.RegistryAPI    { return hc.registry }
-func (hc *standardHandlerContext) GetErrorPresentation() string       { return hc.errorPresentation }
-func (hc *standardHandlerContext) GetOutfile() io.Writer              { return hc.outfile }
-func (hc *standardHandlerContext) GetOutErrFile() io.Writer           { return hc.outErrFile }
-func (hc *standardHandlerContext) GetLRUCache() *lrucache.LRUCache    { return hc.lRUCache }
+func (hc *standardHandlerContext) SetStatementPlanCache(planCache *lrucache.LRUCache) {
+	hc.statementPlanCache = planCache
+}
 func (hc *standardHandlerContext) GetSQLEngine() sqlengine.SQLEngine  { return hc.sqlEngine }
 func (hc *standardHandlerContext) GetSQLSystem() sql_system.SQLSystem { return hc.sqlSystem }
 func (hc *standardHandlerContext) GetGarbageCollector() garbagecollector.GarbageCollector {
@@ -366,6 +432,9 @@ func (hc *standardHandlerContext) GetAuthContext(providerName string) (*dto.Auth
 	if providerName == "" {
 		providerName = hc.runtimeContext.ProviderStr
 	}
+	if authCtx, ok := hc.sessionSettings.getAuthContext(providerName); ok {
+		return authCtx, nil
+	}
 	hc.authMapMutex.Lock()
 	defer hc.authMapMutex.Unlock()
 	authCtx, ok := hc.authContexts[providerName]
@@ -387,6 +456,22 @@ func (hc *standardHandlerContext) GetIsolationLevel() constants.IsolationLevel {
 	return hc.sessionContext.GetIsolationLevel()
 }
 
+func (hc *standardHandlerContext) SetNextTransactionIsolationLevel(isolationLevelStr string) error {
+	isolationLevel, err := ParseIsolationLevel(isolationLevelStr)
+	if err != nil {
+		return err
+	}
+	hc.sessionSettings.setNextIsolationLevel(isolationLevel)
+	return nil
+}
+
+func (hc *standardHandlerContext) TakeTransactionIsolationLevel() constants.IsolationLevel {
+	if isolationLevel, isSet := hc.sessionSettings.takeNextIsolationLevel(); isSet {
+		return isolationLevel
+	}
+	return hc.GetIsolationLevel()
+}
+
 func (hc *standardHandlerContext) UpdateRollbackType(rollbackTypeStr string) error {
 	hc.sessionCtxMutex.Lock()
 	defer hc.sessionCtxMutex.Unlock()
@@ -428,20 +513,32 @@ func (hc *standardHandlerContext) setConfigAtPath(path string, rhs interface{},
 	}
 }
 
-func (hc *standardHandlerContext) setAuthContextAtPath(path string, rhs interface{}, scope string) error {
-	hc.authMapMutex.Lock()
-	defer hc.authMapMutex.Unlock()
-	// TODO: review
-	if scope == "local" {
-		hc.authContexts = hc.authContexts.Clone()
-	}
+// setAuthContextAtPath is session scoped, irrespective of the
+// scope requested, so that in server mode one connection
+// cannot alter the credentials used by another.
+func (hc *standardHandlerContext) setAuthContextAtPath(path string, rhs interface{}, _ string) error {
 	searchPath, searchPathErr := composeSystemSearchPath(path)
 	if searchPathErr != nil {
 		return searchPathErr
 	}
-	authCtx := hc.authContexts[searchPath.GetSystem()]
-	rv := jsonpath.Set(authCtx, searchPath.GetRemainder(), rhs)
-	return rv
+	providerName := searchPath.GetSystem()
+	hc.authMapMutex.Lock()
+	startupAuthCtx := hc.authContexts[providerName]
+	hc.authMapMutex.Unlock()
+	return hc.sessionSettings.setAuthContextAtPath(
+		providerName,
+		searchPath.GetRemainder(),
+		rhs,
+		startupAuthCtx,
+	)
+}
+
+func (hc *standardHandlerContext) HasSessionAuthContexts() bool {
+	return hc.sessionSettings.hasAuthContexts()
+}
+
+func (hc *standardHandlerContext) SetSessionAuthContext(providerName string, authCtx *dto.AuthCtx) error {
+	return hc.sessionSettings.setAuthContext(providerName, authCtx)
 }
 
 func (hc *standardHandlerContext) GetNamespaceCollection() tablenamespace.Collection {
@@ -488,6 +585,7 @@ func (hc *standardHandlerContext) Clone() HandlerContext {
 		controlAttributes:   hc.controlAttributes,
 		errorPresentation:   hc.errorPresentation,
 		lRUCache:            hc.lRUCache,
+		statementPlanCache:  hc.statementPlanCache,
 		sqlEngine:           hc.sqlEngine,
 		sqlDataSources:      hc.sqlDataSources,
 		sqlSystem:           hc.sqlSystem,
@@ -503,10 +601,82 @@ func (hc *standardHandlerContext) Clone() HandlerContext {
 		typCfg:              hc.typCfg,
 		sessionContext:      hc.sessionContext,
 		exportNamespace:     hc.exportNamespace,
+		ctx:                 hc.ctx,
+		sessionSettings:     hc.sessionSettings,
+		policy:              hc.policy,
 	}
 	return &rv
 }
 
+func (hc *standardHandlerContext) ForkSession() HandlerContext {
+	rv := hc.Clone()
+	switch rv := rv.(type) { //nolint:gocritic // acceptable
+	case *standardHandlerContext:
+		rv.sessionSettings = hc.sessionSettings.clone()
+		rv.sessionSettings.isInlineAuthOnly = true
+	}
+	return rv
+}
+
+func (hc *standardHandlerContext) GetContext() context.Context {
+	if hc.ctx == nil {
+		return context.Background()
+	}
+	return hc.ctx
+}
+
+func (hc *standardHandlerContext) SetContext(ctx context.Context) {
+	hc.ctx = ctx
+}
+
+func (hc *standardHandlerContext) GetStatementTimeout() time.Duration {
+	return hc.sessionSettings.getStatementTimeout()
+}
+
+func (hc *standardHandlerContext) SetStatementTimeout(timeout time.Duration) {
+	hc.sessionSettings.setStatementTimeout(timeout)
+}
+
+func (hc *standardHandlerContext) GetLockTimeout() time.Duration {
+	return hc.sessionSettings.getLockTimeout()
+}
+
+func (hc *standardHandlerContext) SetLockTimeout(timeout time.Duration) {
+	hc.sessionSettings.setLockTimeout(timeout)
+}
+
+func (hc *standardHandlerContext) GetAwaitTimeout() time.Duration {
+	return hc.sessionSettings.getAwaitTimeout()
+}
+
+func (hc *standardHandlerContext) SetAwaitTimeout(timeout time.Duration) {
+	hc.sessionSettings.setAwaitTimeout(timeout)
+}
+
+func (hc *standardHandlerContext) IsExplainAnalyzeMutations() bool {
+	return hc.sessionSettings.isExplainAnalyzeMutationsPermitted()
+}
+
+func (hc *standardHandlerContext) SetExplainAnalyzeMutations(isPermitted bool) {
+	hc.sessionSettings.setExplainAnalyzeMutations(isPermitted)
+}
+
+func (hc *standardHandlerContext) GetHTTPRetryPolicy(providerName string) HTTPRetryPolicy {
+	return hc.sessionSettings.getHTTPRetryPolicy(providerName)
+}
+
+func (hc *standardHandlerContext) GetHTTPRateLimiter(providerName string) *netutils.ProviderRateLimiter {
+	return hc.sessionSettings.getHTTPRateLimiter(providerName)
+}
+
+func (hc *standardHandlerContext) GetAuthorisationPolicy() srvauth.Policy {
+	return hc.policy
+}
+
+func (hc *standardHandlerContext) SetAuthorisationPolicy(policy srvauth.Policy) {
+	hc.policy = policy
+}
+
 func GetHandlerCtx(
 	cmdString string,
 	runtimeCtx dto.RuntimeCtx,
@@ -517,6 +687,10 @@ func GetHandlerCtx(
 	if err != nil {
 		return nil, err
 	}
+	settings, err := newSessionSettings(runtimeCtx.SessionCtxRaw)
+	if err != nil {
+		return nil, err
+	}
 	providers := make(map[string]provider.IProvider)
 	controlAttributes := inputBundle.GetControlAttributes()
 	sqlEngine := inputBundle.GetSQLEngine()
@@ -546,6 +720,7 @@ func GetHandlerCtx(
 		typCfg:              inputBundle.GetTypingConfig(),
 		sessionContext:      inputBundle.GetSessionContext(),
 		exportNamespace:     runtimeCtx.ExportAlias,
+		sessionSettings:     settings,
 	}
 	drmCfg, err := drm.GetDRMConfig(inputBundle.GetSQLSystem(),
 		inputBundle.GetTypingConfig(),
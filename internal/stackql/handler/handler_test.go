@@ -0,0 +1,167 @@
+package handler //nolint:testpackage // sessions are forked from a context built without a bundle
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stackql/any-sdk/pkg/constants"
+	"github.com/stackql/any-sdk/pkg/dto"
+)
+
+// newForkTestHandlerContext holds startup credentials for google only,
+// as though supplied via `--auth`.
+func newForkTestHandlerContext(t *testing.T) *standardHandlerContext {
+	settings, err := newSessionSettings("")
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	sessionContext, err := dto.NewSessionContext("{}")
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	return &standardHandlerContext{
+		authMapMutex:    &sync.Mutex{},
+		sessionCtxMutex: &sync.Mutex{},
+		authContexts: dto.AuthContexts{
+			"google": &dto.AuthCtx{Type: "service_account", KeyFilePath: "/startup/key.json"},
+		},
+		sessionContext:  sessionContext,
+		sessionSettings: settings,
+	}
+}
+
+func TestForkedSessionsIsolateAuthContexts(t *testing.T) {
+	parent := newForkTestHandlerContext(t)
+	first := parent.ForkSession()
+	second := parent.ForkSession()
+
+	// As per the `stackql.auth` startup parameter.
+	if err := first.SetSessionAuthContext("okta", &dto.AuthCtx{Type: "api_key", APIKeyStr: "first-key"}); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	// As per `SET "$.auth.google.sub" = '...'`.
+	if err := second.SetConfigAtPath("auth.google.sub", "second@example.com", "session"); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+
+	firstGoogle, err := first.GetAuthContext("google")
+	if err != nil || firstGoogle.KeyFilePath != "/startup/key.json" {
+		t.Fatalf("test failed: first session sees google credentials '%v'", firstGoogle)
+	}
+	firstOkta, err := first.GetAuthContext("okta")
+	if err != nil || firstOkta.APIKeyStr != "first-key" {
+		t.Fatalf("test failed: first session sees okta credentials '%v'", firstOkta)
+	}
+	if !first.HasSessionAuthContexts() {
+		t.Fatal("test failed: first session has no session credentials")
+	}
+
+	secondGoogle, err := second.GetAuthContext("google")
+	if err != nil || secondGoogle.Subject != "second@example.com" || secondGoogle.KeyFilePath != "/startup/key.json" {
+		t.Fatalf("test failed: second session sees google credentials '%v'", secondGoogle)
+	}
+	if _, err = second.GetAuthContext("okta"); err == nil {
+		t.Fatal("test failed: second session sees okta credentials of the first")
+	}
+	if _, isPresent := second.GetAuthContexts()["okta"]; isPresent {
+		t.Fatal("test failed: merged auth contexts of second session include okta")
+	}
+
+	parentGoogle, err := parent.GetAuthContext("google")
+	if err != nil || parentGoogle.KeyFilePath != "/startup/key.json" || parentGoogle.Subject != "" {
+		t.Fatalf("test failed: startup google credentials altered to '%v'", parentGoogle)
+	}
+	if parent.HasSessionAuthContexts() {
+		t.Fatal("test failed: session credentials leaked to the parent context")
+	}
+
+	// Statements within a session run upon clones, which share its credentials.
+	clone := first.Clone()
+	cloneOkta, err := clone.GetAuthContext("okta")
+	if err != nil || cloneOkta.APIKeyStr != "first-key" {
+		t.Fatalf("test failed: clone of first session sees okta credentials '%v'", cloneOkta)
+	}
+}
+
+func TestForkedSessionsRefuseLocalCredentials(t *testing.T) {
+	parent := newForkTestHandlerContext(t)
+	session := parent.ForkSession()
+
+	if err := session.SetSessionAuthContext("okta", &dto.AuthCtx{Type: "api_key", KeyFilePath: "/etc/okta.json"}); err == nil {
+		t.Fatal("test failed: startup credentials file accepted")
+	}
+	if err := session.SetSessionAuthContext("okta", &dto.AuthCtx{
+		Type:      "api_key",
+		APIKeyStr: "key",
+		Successor: &dto.AuthCtx{Type: "basic", EnvVarPassword: "PASSWORD"},
+	}); err == nil {
+		t.Fatal("test failed: startup credentials environment variable of successor accepted")
+	}
+	for _, tc := range []struct {
+		path string
+		rhs  interface{}
+	}{
+		{"auth.google.credentialsfilepath", "/etc/shadow"},
+		{"auth.google.credentialsenvvar", "AWS_SECRET_ACCESS_KEY"},
+		{"auth.google", map[string]interface{}{"type": "service_account", "credentialsfilepath": "/etc/shadow"}},
+	} {
+		if err := session.SetConfigAtPath(tc.path, tc.rhs, "session"); err == nil {
+			t.Fatalf("test failed: SET of '%s' accepted", tc.path)
+		}
+	}
+	if _, err := session.GetAuthContext("okta"); err == nil {
+		t.Fatal("test failed: refused okta credentials retained")
+	}
+	google, err := session.GetAuthContext("google")
+	if err != nil || google.KeyFilePath != "/startup/key.json" || google.KeyEnvVar != "" || google.Successor != nil {
+		t.Fatalf("test failed: refused google credentials retained '%v'", google)
+	}
+
+	// Local sessions, as per the shell, are unrestricted.
+	if err = parent.SetConfigAtPath("auth.google.credentialsfilepath", "/local/key.json", "session"); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+}
+
+func TestTransactionIsolationLevelAppliedAtBegin(t *testing.T) {
+	session := newForkTestHandlerContext(t).ForkSession()
+	if err := session.SetNextTransactionIsolationLevel("bogus"); err == nil {
+		t.Fatal("test failed: expected error for invalid isolation level")
+	}
+	// As per `SET TRANSACTION ISOLATION LEVEL SERIALIZABLE`.
+	if err := session.SetNextTransactionIsolationLevel("SERIALIZABLE"); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if session.GetIsolationLevel() != constants.ReadUncommitted {
+		t.Fatalf("test failed: session isolation level altered to %d", session.GetIsolationLevel())
+	}
+	// Statements are planned upon clones.
+	if level := session.Clone().TakeTransactionIsolationLevel(); level != constants.Serializable {
+		t.Fatalf("test failed: expected serializable upon BEGIN, got %d", level)
+	}
+	if level := session.TakeTransactionIsolationLevel(); level != constants.ReadUncommitted {
+		t.Fatalf("test failed: expected the session isolation level upon later BEGIN, got %d", level)
+	}
+}
+
+func TestStartupAwaitTimeout(t *testing.T) {
+	// As per `--await-timeout=10m`.
+	SetStartupAwaitTimeout(10 * time.Minute)
+	t.Cleanup(func() { SetStartupAwaitTimeout(DefaultAwaitTimeout) })
+	settings, err := newSessionSettings("")
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if settings.getAwaitTimeout() != 10*time.Minute {
+		t.Fatalf("test failed: expected startup await timeout, got %v", settings.getAwaitTimeout())
+	}
+	// As per `--session='{ "await_timeout": "5m" }'`.
+	settings, err = newSessionSettings(`{ "await_timeout": "5m" }`)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if settings.getAwaitTimeout() != 5*time.Minute {
+		t.Fatalf("test failed: expected session await timeout, got %v", settings.getAwaitTimeout())
+	}
+}
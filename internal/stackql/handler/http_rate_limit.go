@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/stackql/stackql/internal/stackql/netutils"
+)
+
+const (
+	HTTPRateLimitSettingName string = "http_rate_limit"
+	// HTTPRateLimitDefaultKey keys the limit applicable
+	// to providers not otherwise configured.
+	HTTPRateLimitDefaultKey string = "default"
+)
+
+// httpRateLimitCfg configures a provider's rate limit, and
+// those of the hosts it calls; absent a provider entry,
+// that keyed by HTTPRateLimitDefaultKey applies.  Each provider has
+// its own buckets, shared by all sessions forked from the first.
+type httpRateLimitCfg struct {
+	RequestsPerSecond float64                         `json:"requests_per_second" yaml:"requests_per_second"`
+	Burst             int                             `json:"burst" yaml:"burst"`
+	Hosts             map[string]httpRateLimitHostCfg `json:"hosts" yaml:"hosts"`
+}
+
+type httpRateLimitHostCfg struct {
+	RequestsPerSecond float64 `json:"requests_per_second" yaml:"requests_per_second"`
+	Burst             int     `json:"burst" yaml:"burst"`
+}
+
+func validateRateLimit(key string, requestsPerSecond float64, burst int) error {
+	if requestsPerSecond < 0 {
+		return fmt.Errorf(
+			"invalid value for %s.%s.requests_per_second: %v", HTTPRateLimitSettingName, key, requestsPerSecond)
+	}
+	if burst < 0 {
+		return fmt.Errorf("invalid value for %s.%s.burst: %d", HTTPRateLimitSettingName, key, burst)
+	}
+	return nil
+}
+
+func (cfg httpRateLimitCfg) validate(key string) error {
+	if err := validateRateLimit(key, cfg.RequestsPerSecond, cfg.Burst); err != nil {
+		return err
+	}
+	for host, hostCfg := range cfg.Hosts {
+		if err := validateRateLimit(
+			fmt.Sprintf("%s.hosts.%s", key, host), hostCfg.RequestsPerSecond, hostCfg.Burst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newLimiter omits the provider wide bucket
+// where the rate is zero, ie: unlimited.
+func (cfg httpRateLimitCfg) newLimiter() *netutils.ProviderRateLimiter {
+	var providerLimit *netutils.RateLimit
+	if cfg.RequestsPerSecond > 0 {
+		providerLimit = &netutils.RateLimit{
+			RequestsPerSecond: cfg.RequestsPerSecond,
+			Burst:             cfg.Burst,
+		}
+	}
+	hostLimits := make(map[string]netutils.RateLimit, len(cfg.Hosts))
+	for host, hostCfg := range cfg.Hosts {
+		if hostCfg.RequestsPerSecond > 0 {
+			hostLimits[host] = netutils.RateLimit{
+				RequestsPerSecond: hostCfg.RequestsPerSecond,
+				Burst:             hostCfg.Burst,
+			}
+		}
+	}
+	return netutils.NewProviderRateLimiter(providerLimit, hostLimits)
+}
+
+// httpRateLimiters lazily instantiates per provider limiters.
+type httpRateLimiters struct {
+	mutex    sync.Mutex
+	cfg      map[string]httpRateLimitCfg
+	limiters map[string]*netutils.ProviderRateLimiter
+}
+
+func newHTTPRateLimiters(cfg map[string]httpRateLimitCfg) (*httpRateLimiters, error) {
+	for k, v := range cfg {
+		if err := v.validate(k); err != nil {
+			return nil, err
+		}
+	}
+	return &httpRateLimiters{
+		cfg:      cfg,
+		limiters: make(map[string]*netutils.ProviderRateLimiter),
+	}, nil
+}
+
+// get returns nil for unlimited providers.
+func (rl *httpRateLimiters) get(providerName string) *netutils.ProviderRateLimiter {
+	if rl == nil {
+		return nil
+	}
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	if rv, ok := rl.limiters[providerName]; ok {
+		return rv
+	}
+	cfg, ok := rl.cfg[providerName]
+	if !ok {
+		cfg, ok = rl.cfg[HTTPRateLimitDefaultKey]
+	}
+	var rv *netutils.ProviderRateLimiter
+	if ok {
+		rv = cfg.newLimiter()
+	}
+	rl.limiters[providerName] = rv
+	return rv
+}
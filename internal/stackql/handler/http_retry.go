@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	HTTPRetrySettingName string = "http_retry"
+	// HTTPRetryDefaultKey keys the policy applicable
+	// to providers not otherwise configured.
+	HTTPRetryDefaultKey string = "default"
+)
+
+const (
+	defaultHTTPMaxRetries     int           = 3
+	defaultHTTPInitialBackoff time.Duration = time.Second
+	defaultHTTPMaxBackoff     time.Duration = 30 * time.Second
+)
+
+// HTTPRetryPolicy governs the retry of provider requests failing transiently,
+// ie: upon connection reset or response status 429, 500, 502, 503 or 504.
+// Only requests of the listed methods are retried.
+// A MaxBackoff of zero leaves the backoff unbounded.
+type HTTPRetryPolicy struct {
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Methods        []string
+}
+
+func (p HTTPRetryPolicy) IsRetryableMethod(method string) bool {
+	for _, m := range p.Methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func GetDefaultHTTPRetryPolicy() HTTPRetryPolicy {
+	return HTTPRetryPolicy{
+		MaxRetries:     defaultHTTPMaxRetries,
+		InitialBackoff: defaultHTTPInitialBackoff,
+		MaxBackoff:     defaultHTTPMaxBackoff,
+		Methods:        []string{http.MethodGet, http.MethodHead, http.MethodOptions},
+	}
+}
+
+// httpRetryPolicyCfg overlays a policy; absent
+// fields are inherited from the default.
+type httpRetryPolicyCfg struct {
+	MaxRetries     *int     `json:"max_retries" yaml:"max_retries"`
+	InitialBackoff string   `json:"initial_backoff" yaml:"initial_backoff"`
+	MaxBackoff     string   `json:"max_backoff" yaml:"max_backoff"`
+	Methods        []string `json:"methods" yaml:"methods"`
+}
+
+func (cfg httpRetryPolicyCfg) overlay(key string, policy HTTPRetryPolicy) (HTTPRetryPolicy, error) {
+	if cfg.MaxRetries != nil {
+		if *cfg.MaxRetries < 0 {
+			return policy, fmt.Errorf("invalid value for %s.%s.max_retries: %d", HTTPRetrySettingName, key, *cfg.MaxRetries)
+		}
+		policy.MaxRetries = *cfg.MaxRetries
+	}
+	if cfg.InitialBackoff != "" {
+		backoff, err := parseTimeout(fmt.Sprintf("%s.%s.initial_backoff", HTTPRetrySettingName, key), cfg.InitialBackoff)
+		if err != nil {
+			return policy, err
+		}
+		policy.InitialBackoff = backoff
+	}
+	if cfg.MaxBackoff != "" {
+		backoff, err := parseTimeout(fmt.Sprintf("%s.%s.max_backoff", HTTPRetrySettingName, key), cfg.MaxBackoff)
+		if err != nil {
+			return policy, err
+		}
+		policy.MaxBackoff = backoff
+	}
+	if cfg.Methods != nil {
+		policy.Methods = cfg.Methods
+	}
+	return policy, nil
+}
+
+// newHTTPRetryPolicies resolves per provider policies, each
+// overlaying the default, which in turn overlays the built in default.
+func newHTTPRetryPolicies(cfg map[string]httpRetryPolicyCfg) (map[string]HTTPRetryPolicy, error) {
+	defaultPolicy, err := cfg[HTTPRetryDefaultKey].overlay(HTTPRetryDefaultKey, GetDefaultHTTPRetryPolicy())
+	if err != nil {
+		return nil, err
+	}
+	rv := map[string]HTTPRetryPolicy{
+		HTTPRetryDefaultKey: defaultPolicy,
+	}
+	for k, v := range cfg {
+		if k == HTTPRetryDefaultKey {
+			continue
+		}
+		rv[k], err = v.overlay(k, defaultPolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rv, nil
+}
@@ -0,0 +1,431 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stackql/any-sdk/pkg/constants"
+	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/any-sdk/pkg/jsonpath"
+	"github.com/stackql/stackql/internal/stackql/netutils"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	StatementTimeoutSettingName string = "statement_timeout"
+	LockTimeoutSettingName      string = "lock_timeout"
+	AwaitTimeoutSettingName     string = "await_timeout"
+	// ExplainAnalyzeMutationsSettingName permits EXPLAIN ANALYZE
+	// of statements that mutate provider resources.
+	ExplainAnalyzeMutationsSettingName string = "explain_analyze_mutations"
+	// DefaultLockTimeout bounds the wait for resource locks
+	// unless otherwise configured; zero waits indefinitely.
+	DefaultLockTimeout time.Duration = 30 * time.Second
+	// DefaultAwaitTimeout likewise bounds the wait
+	// for awaited asynchronous operations.
+	DefaultAwaitTimeout time.Duration = time.Hour
+)
+
+// startupAwaitTimeout is that set by the `--await-timeout` flag,
+// which the `await_timeout` session setting overrides.
+//
+//nolint:gochecknoglobals // set once, at startup
+var startupAwaitTimeout atomic.Int64
+
+func init() { //nolint:gochecknoinits // default prior to flag handling
+	startupAwaitTimeout.Store(int64(DefaultAwaitTimeout))
+}
+
+// SetStartupAwaitTimeout sets the await timeout
+// of sessions begun thereafter.
+func SetStartupAwaitTimeout(timeout time.Duration) {
+	startupAwaitTimeout.Store(int64(timeout))
+}
+
+// sessionSettingsCfg is the stackql managed subset of
+// session config, supplied alongside the any-sdk
+// session context via the `--session` flag.
+// The any-sdk keys are embedded solely so that
+// unknown keys are rejected as a whole.
+type sessionSettingsCfg struct {
+	dto.SessionCtxConfig `yaml:",inline"`
+	StatementTimeout     string `json:"statement_timeout" yaml:"statement_timeout"`
+	LockTimeout          string `json:"lock_timeout" yaml:"lock_timeout"`
+	AwaitTimeout         string `json:"await_timeout" yaml:"await_timeout"`
+	// ExplainAnalyzeMutations is off unless set.
+	ExplainAnalyzeMutations bool `json:"explain_analyze_mutations" yaml:"explain_analyze_mutations"`
+	// HTTPRetry is keyed by provider name, or HTTPRetryDefaultKey.
+	HTTPRetry map[string]httpRetryPolicyCfg `json:"http_retry" yaml:"http_retry"`
+	// HTTPRateLimit is keyed by provider name, or HTTPRateLimitDefaultKey.
+	HTTPRateLimit map[string]httpRateLimitCfg `json:"http_rate_limit" yaml:"http_rate_limit"`
+}
+
+// sessionSettings are session scoped settings, mutable via SET.
+// Shared by reference across handler clones, so that a setting
+// applies to all subsequent statements in the session.
+type sessionSettings struct {
+	mutex            sync.Mutex
+	statementTimeout time.Duration
+	lockTimeout      time.Duration
+	awaitTimeout     time.Duration
+	// isExplainAnalyzeMutations permits EXPLAIN ANALYZE of mutations.
+	isExplainAnalyzeMutations bool
+	// nextIsolationLevel is that set by SET TRANSACTION,
+	// pending the next BEGIN.  It is not inherited by forks.
+	nextIsolationLevel *constants.IsolationLevel
+	// httpRetryPolicies are immutable once configured.
+	httpRetryPolicies map[string]HTTPRetryPolicy
+	// httpRateLimiters are shared across clones, as are quotas.
+	httpRateLimiters *httpRateLimiters
+	// Auth contexts set during the session, which take
+	// precedence over those supplied at startup.
+	authContexts dto.AuthContexts
+	// isInlineAuthOnly is set for sessions of remote clients, whose
+	// auth contexts may not refer to files or environment variables
+	// of the server.  It is set upon forks.
+	isInlineAuthOnly bool
+}
+
+func newSessionSettings(cfgStr string) (*sessionSettings, error) {
+	var cfg sessionSettingsCfg
+	if cfgStr != "" {
+		err := yaml.UnmarshalStrict([]byte(cfgStr), &cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal session settings: %w", err)
+		}
+	}
+	rv := &sessionSettings{
+		lockTimeout:               DefaultLockTimeout,
+		awaitTimeout:              time.Duration(startupAwaitTimeout.Load()),
+		isExplainAnalyzeMutations: cfg.ExplainAnalyzeMutations,
+		authContexts:              make(dto.AuthContexts),
+	}
+	if cfg.StatementTimeout != "" {
+		timeout, err := ParseStatementTimeout(cfg.StatementTimeout)
+		if err != nil {
+			return nil, err
+		}
+		rv.statementTimeout = timeout
+	}
+	if cfg.LockTimeout != "" {
+		timeout, err := ParseLockTimeout(cfg.LockTimeout)
+		if err != nil {
+			return nil, err
+		}
+		rv.lockTimeout = timeout
+	}
+	if cfg.AwaitTimeout != "" {
+		timeout, err := ParseAwaitTimeout(cfg.AwaitTimeout)
+		if err != nil {
+			return nil, err
+		}
+		rv.awaitTimeout = timeout
+	}
+	httpRetryPolicies, err := newHTTPRetryPolicies(cfg.HTTPRetry)
+	if err != nil {
+		return nil, err
+	}
+	rv.httpRetryPolicies = httpRetryPolicies
+	httpRateLimiters, err := newHTTPRateLimiters(cfg.HTTPRateLimit)
+	if err != nil {
+		return nil, err
+	}
+	rv.httpRateLimiters = httpRateLimiters
+	return rv, nil
+}
+
+func (ss *sessionSettings) clone() *sessionSettings {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	authContexts := make(dto.AuthContexts, len(ss.authContexts))
+	for k, v := range ss.authContexts {
+		authContexts[k] = cloneAuthContext(v)
+	}
+	return &sessionSettings{
+		statementTimeout:          ss.statementTimeout,
+		lockTimeout:               ss.lockTimeout,
+		awaitTimeout:              ss.awaitTimeout,
+		isExplainAnalyzeMutations: ss.isExplainAnalyzeMutations,
+		httpRetryPolicies:         ss.httpRetryPolicies,
+		httpRateLimiters:          ss.httpRateLimiters,
+		authContexts:              authContexts,
+	}
+}
+
+func (ss *sessionSettings) getStatementTimeout() time.Duration {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	return ss.statementTimeout
+}
+
+func (ss *sessionSettings) setStatementTimeout(timeout time.Duration) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	ss.statementTimeout = timeout
+}
+
+func (ss *sessionSettings) getLockTimeout() time.Duration {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	return ss.lockTimeout
+}
+
+func (ss *sessionSettings) setLockTimeout(timeout time.Duration) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	ss.lockTimeout = timeout
+}
+
+func (ss *sessionSettings) getAwaitTimeout() time.Duration {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	return ss.awaitTimeout
+}
+
+func (ss *sessionSettings) setAwaitTimeout(timeout time.Duration) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	ss.awaitTimeout = timeout
+}
+
+func (ss *sessionSettings) isExplainAnalyzeMutationsPermitted() bool {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	return ss.isExplainAnalyzeMutations
+}
+
+func (ss *sessionSettings) setExplainAnalyzeMutations(isPermitted bool) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	ss.isExplainAnalyzeMutations = isPermitted
+}
+
+func (ss *sessionSettings) setNextIsolationLevel(isolationLevel constants.IsolationLevel) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	ss.nextIsolationLevel = &isolationLevel
+}
+
+// takeNextIsolationLevel clears the pending isolation level.
+func (ss *sessionSettings) takeNextIsolationLevel() (constants.IsolationLevel, bool) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if ss.nextIsolationLevel == nil {
+		return constants.ReadUncommitted, false
+	}
+	rv := *ss.nextIsolationLevel
+	ss.nextIsolationLevel = nil
+	return rv, true
+}
+
+func (ss *sessionSettings) getHTTPRetryPolicy(providerName string) HTTPRetryPolicy {
+	if rv, ok := ss.httpRetryPolicies[providerName]; ok {
+		return rv
+	}
+	if rv, ok := ss.httpRetryPolicies[HTTPRetryDefaultKey]; ok {
+		return rv
+	}
+	return GetDefaultHTTPRetryPolicy()
+}
+
+func (ss *sessionSettings) getHTTPRateLimiter(providerName string) *netutils.ProviderRateLimiter {
+	return ss.httpRateLimiters.get(providerName)
+}
+
+func (ss *sessionSettings) getAuthContext(providerName string) (*dto.AuthCtx, bool) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	rv, ok := ss.authContexts[providerName]
+	return rv, ok
+}
+
+func (ss *sessionSettings) hasAuthContexts() bool {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	return len(ss.authContexts) > 0
+}
+
+func (ss *sessionSettings) setAuthContext(providerName string, authCtx *dto.AuthCtx) error {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if ss.isInlineAuthOnly {
+		if err := checkInlineAuthContext(authCtx, nil); err != nil {
+			return err
+		}
+	}
+	ss.authContexts[providerName] = authCtx
+	return nil
+}
+
+// setAuthContextAtPath mutates the session copy of an auth context,
+// deriving it from the startup context upon first use.
+// An empty path replaces the auth context in its entirety.
+func (ss *sessionSettings) setAuthContextAtPath(
+	providerName string,
+	path string,
+	rhs interface{},
+	startupAuthCtx *dto.AuthCtx,
+) error {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	authCtx, ok := ss.authContexts[providerName]
+	switch {
+	case path == "":
+		authCtx = &dto.AuthCtx{}
+	case ok:
+		// Mutated upon a copy, lest a refused value persist.
+		authCtx = cloneAuthContext(authCtx)
+	case startupAuthCtx != nil:
+		authCtx = cloneAuthContext(startupAuthCtx)
+	default:
+		authCtx = &dto.AuthCtx{}
+	}
+	if err := jsonpath.Set(authCtx, path, rhs); err != nil {
+		return err
+	}
+	if ss.isInlineAuthOnly {
+		if err := checkInlineAuthContext(authCtx, startupAuthCtx); err != nil {
+			return err
+		}
+	}
+	ss.authContexts[providerName] = authCtx
+	return nil
+}
+
+// localAuthReferences are those attributes of an auth context
+// which name files or environment variables of the host.
+func localAuthReferences(authCtx *dto.AuthCtx) [][2]string {
+	if authCtx == nil {
+		return nil
+	}
+	return [][2]string{
+		{"credentialsfilepath", authCtx.KeyFilePath},
+		{"credentialsfilepathenvvar", authCtx.KeyFilePathEnvVar},
+		{"credentialsenvvar", authCtx.KeyEnvVar},
+		{"keyIDenvvar", authCtx.KeyIDEnvVar},
+		{"api_key_var", authCtx.EnvVarAPIKeyStr},
+		{"api_secret_var", authCtx.EnvVarAPISecretStr},
+		{"username_var", authCtx.EnvVarUsername},
+		{"password_var", authCtx.EnvVarPassword},
+		{"client_id_env_var", authCtx.ClientIDEnvVar},
+		{"client_secret_env_var", authCtx.ClientSecretEnvVar},
+		{"account_id_var", authCtx.AccoountIDEnvVar},
+	}
+}
+
+// checkInlineAuthContext refuses an auth context, or any successor
+// thereof, which refers to files, environment variables or SQL
+// data sources of the host.  References inherited from the startup
+// auth context, which the operator supplied, are permitted.
+func checkInlineAuthContext(authCtx *dto.AuthCtx, startupAuthCtx *dto.AuthCtx) error {
+	for authCtx != nil {
+		startupReferences := localAuthReferences(startupAuthCtx)
+		for i, ref := range localAuthReferences(authCtx) {
+			if ref[1] != "" && (startupReferences == nil || ref[1] != startupReferences[i][1]) {
+				return fmt.Errorf("auth attribute '%s' is not permitted for remote sessions; credentials must be supplied inline", ref[0])
+			}
+		}
+		var startupSQLCfg *dto.SQLBackendCfg
+		if startupAuthCtx != nil {
+			startupSQLCfg = startupAuthCtx.SQLCfg
+		}
+		if authCtx.SQLCfg != nil && authCtx.SQLCfg != startupSQLCfg {
+			return fmt.Errorf("auth attribute 'sqlDataSource' is not permitted for remote sessions")
+		}
+		authCtx = authCtx.Successor
+		if startupAuthCtx != nil {
+			startupAuthCtx = startupAuthCtx.Successor
+		}
+	}
+	return nil
+}
+
+func (ss *sessionSettings) mergeAuthContexts(startupAuthContexts dto.AuthContexts) dto.AuthContexts {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	rv := make(dto.AuthContexts, len(startupAuthContexts)+len(ss.authContexts))
+	for k, v := range startupAuthContexts {
+		rv[k] = v
+	}
+	for k, v := range ss.authContexts {
+		rv[k] = v
+	}
+	return rv
+}
+
+// cloneAuthContext is required because AuthCtx.Clone()
+// omits the SQL backend config and shares the successor.
+func cloneAuthContext(authCtx *dto.AuthCtx) *dto.AuthCtx {
+	rv := authCtx.Clone()
+	rv.SQLCfg = authCtx.SQLCfg
+	if authCtx.Successor != nil {
+		rv.Successor = cloneAuthContext(authCtx.Successor)
+	}
+	return rv
+}
+
+// ParseStatementTimeout follows postgres semantics:
+// a bare integer is milliseconds and zero disables the timeout.
+// Otherwise, a golang duration string such as "30s" is expected.
+func ParseStatementTimeout(s string) (time.Duration, error) {
+	return parseTimeout(StatementTimeoutSettingName, s)
+}
+
+// ParseLockTimeout shares the semantics of ParseStatementTimeout.
+func ParseLockTimeout(s string) (time.Duration, error) {
+	return parseTimeout(LockTimeoutSettingName, s)
+}
+
+// ParseAwaitTimeout shares the semantics of ParseStatementTimeout.
+func ParseAwaitTimeout(s string) (time.Duration, error) {
+	return parseTimeout(AwaitTimeoutSettingName, s)
+}
+
+// ParseExplainAnalyzeMutations accepts postgres style boolean values.
+func ParseExplainAnalyzeMutations(s string) (bool, error) {
+	switch strings.ToLower(strings.Trim(strings.TrimSpace(s), `'"`)) {
+	case "on", "true", "yes", "1":
+		return true, nil
+	case "off", "false", "no", "0":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid value for %s: '%s'", ExplainAnalyzeMutationsSettingName, s)
+	}
+}
+
+// ParseIsolationLevel accepts the isolation levels of SET TRANSACTION.
+func ParseIsolationLevel(s string) (constants.IsolationLevel, error) {
+	switch strings.Join(strings.Fields(strings.ToLower(s)), " ") {
+	case constants.ReadUncommittedStr:
+		return constants.ReadUncommitted, nil
+	case constants.ReadCommittedStr:
+		return constants.ReadCommitted, nil
+	case constants.RepeatableReadStr:
+		return constants.RepeatableRead, nil
+	case constants.SerializableStr:
+		return constants.Serializable, nil
+	default:
+		return constants.ReadUncommitted, fmt.Errorf("invalid isolation level: '%s'", s)
+	}
+}
+
+func parseTimeout(settingName string, s string) (time.Duration, error) {
+	trimmed := strings.Trim(strings.TrimSpace(s), `'"`)
+	var rv time.Duration
+	if millis, err := strconv.ParseInt(trimmed, 10, 64); err == nil {
+		rv = time.Duration(millis) * time.Millisecond
+	} else {
+		rv, err = time.ParseDuration(trimmed)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value for %s: '%s'", settingName, s)
+		}
+	}
+	if rv < 0 {
+		return 0, fmt.Errorf("invalid value for %s: '%s' is negative", settingName, s)
+	}
+	return rv, nil
+}
@@ -0,0 +1,185 @@
+package httpaudit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/stackql/stackql/internal/stackql/netutils"
+	"gopkg.in/yaml.v2"
+)
+
+// Record describes one provider call, inclusive of any retries.
+type Record struct {
+	Timestamp      time.Time       `json:"timestamp"`
+	GenerationID   int             `json:"generation_id"`
+	SessionID      int             `json:"session_id"`
+	TxnID          int             `json:"txn_id,omitempty"`
+	Provider       string          `json:"provider"`
+	Service        string          `json:"service,omitempty"`
+	Resource       string          `json:"resource,omitempty"`
+	Method         string          `json:"method"`
+	Verb           string          `json:"verb"`
+	URL            string          `json:"url"`
+	RequestHeaders http.Header     `json:"request_headers,omitempty"`
+	RequestBody    json.RawMessage `json:"request_body,omitempty"`
+	Status         int             `json:"status,omitempty"`
+	LatencyMillis  int64           `json:"latency_ms"`
+	ResponseSize   int64           `json:"response_size"`
+	Error          string          `json:"error,omitempty"`
+}
+
+// Sink persists records; Write must be safe for concurrent use.
+type Sink interface {
+	Write(Record) error
+	Close() error
+}
+
+// auditCfg is supplied via the `--http.audit` flag, eg:
+// `{ "sink": "file:///var/log/stackql/audit.jsonl", "redact_headers": [ "X-Org" ], "redact_fields": [ "password" ] }`.
+type auditCfg struct {
+	Sink string `json:"sink" yaml:"sink"`
+	// RedactHeaders supplement the
+	// always redacted credential headers.
+	RedactHeaders []string `json:"redact_headers" yaml:"redact_headers"`
+	// RedactFields name JSON request body fields, at any
+	// depth, and URL query parameters to be redacted.
+	RedactFields []string `json:"redact_fields" yaml:"redact_fields"`
+}
+
+// Auditor redacts and writes records to its sink.
+type Auditor struct {
+	sink          Sink
+	redactHeaders []string
+	redactFields  map[string]struct{}
+}
+
+// NewAuditor parses the JSON / YAML config.  Sinks are
+// `file://<path>`, a bare path being taken as a file,
+// or `udp://<host>:<port>` for syslog.
+func NewAuditor(cfgStr string) (*Auditor, error) {
+	var cfg auditCfg
+	if err := yaml.Unmarshal([]byte(cfgStr), &cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal http audit config: %w", err)
+	}
+	sink, err := newSink(cfg.Sink)
+	if err != nil {
+		return nil, err
+	}
+	return NewAuditorForSink(sink, cfg.RedactHeaders, cfg.RedactFields), nil
+}
+
+func NewAuditorForSink(sink Sink, redactHeaders []string, redactFields []string) *Auditor {
+	rv := &Auditor{
+		sink:          sink,
+		redactHeaders: redactHeaders,
+		redactFields:  make(map[string]struct{}, len(redactFields)),
+	}
+	for _, f := range redactFields {
+		rv.redactFields[strings.ToLower(f)] = struct{}{}
+	}
+	return rv
+}
+
+func newSink(raw string) (Sink, error) {
+	switch {
+	case raw == "":
+		return nil, fmt.Errorf("http audit sink is required")
+	case strings.HasPrefix(raw, "udp://"):
+		return newSyslogSink(strings.TrimPrefix(raw, "udp://"))
+	default:
+		return newFileSink(strings.TrimPrefix(raw, "file://"))
+	}
+}
+
+// Audit redacts the request aspects of the record,
+// which must therefore be unredacted on input.
+func (a *Auditor) Audit(rec Record) error {
+	rec.RequestHeaders = netutils.RedactHeaders(rec.RequestHeaders, a.redactHeaders...)
+	rec.URL = a.redactURL(rec.URL)
+	rec.RequestBody = a.redactBody(rec.RequestBody)
+	return a.sink.Write(rec)
+}
+
+func (a *Auditor) Close() error {
+	return a.sink.Close()
+}
+
+func (a *Auditor) isRedactedField(name string) bool {
+	_, ok := a.redactFields[strings.ToLower(name)]
+	return ok
+}
+
+func (a *Auditor) redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return rawURL
+	}
+	q := u.Query()
+	for k := range q {
+		if a.isRedactedField(k) {
+			q[k] = []string{netutils.RedactedValue}
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// redactBody retains non JSON bodies as JSON strings,
+// so that each record remains a single JSON object.
+func (a *Auditor) redactBody(body json.RawMessage) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	var parsed interface{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		rv, _ := json.Marshal(string(body))
+		return rv
+	}
+	rv, err := json.Marshal(a.redactValue(parsed))
+	if err != nil {
+		return nil
+	}
+	return rv
+}
+
+func (a *Auditor) redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if a.isRedactedField(k) {
+				v[k] = netutils.RedactedValue
+				continue
+			}
+			v[k] = a.redactValue(child)
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = a.redactValue(child)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+type txnIDContextKey struct{}
+
+// NewTxnIDContext associates provider calls
+// made under the context with the transaction.
+func NewTxnIDContext(ctx context.Context, txnID int) context.Context {
+	return context.WithValue(ctx, txnIDContextKey{}, txnID)
+}
+
+func TxnIDFromContext(ctx context.Context) (int, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	txnID, ok := ctx.Value(txnIDContextKey{}).(int)
+	return txnID, ok
+}
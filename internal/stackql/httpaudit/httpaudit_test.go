@@ -0,0 +1,59 @@
+package httpaudit_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/stackql/stackql/internal/stackql/httpaudit"
+)
+
+func TestAuditRedactsToJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditor, err := NewAuditor(fmt.Sprintf(
+		`{ "sink": "file://%s", "redact_headers": [ "X-Org-Id" ], "redact_fields": [ "password", "sig" ] }`, path))
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	defer auditor.Close()
+	for i := 0; i < 2; i++ {
+		auditErr := auditor.Audit(Record{
+			Timestamp: time.Now(),
+			Provider:  "okta",
+			Method:    "createUser",
+			Verb:      http.MethodPost,
+			URL:       "https://example.okta.com/api/v1/users?activate=true&sig=abc",
+			RequestHeaders: http.Header{
+				"Authorization": []string{"SSWS secret-value"},
+				"X-Org-Id":      []string{"secret-value"},
+				"Accept":        []string{"application/json"},
+			},
+			RequestBody: []byte(`{"profile": {"login": "a"}, "credentials": {"password": {"value": "secret-value"}}}`),
+			Status:      http.StatusOK,
+		})
+		if auditErr != nil {
+			t.Fatalf("test failed: %v", auditErr)
+		}
+	}
+	b, _ := os.ReadFile(path)
+	if strings.Contains(string(b), "secret-value") || strings.Contains(string(b), "sig=abc") {
+		t.Fatalf("test failed: unredacted audit log: %s", string(b))
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("test failed: expected 2 records, got %d", len(lines))
+	}
+	var rec Record
+	if err = json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if rec.Method != "createUser" || rec.RequestHeaders.Get("Accept") != "application/json" ||
+		!strings.Contains(string(rec.RequestBody), `"login":"a"`) {
+		t.Fatalf("test failed: unexpected record %s", lines[0])
+	}
+}
@@ -0,0 +1,93 @@
+package httpaudit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	auditFileMode = 0o600
+	// syslogPriority is facility local0 (16), severity info (6).
+	syslogPriority = 16*8 + 6
+)
+
+// fileSink appends one JSON object per line.
+type fileSink struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+func newFileSink(path string) (Sink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, auditFileMode)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{
+		file: f,
+	}, nil
+}
+
+func (s *fileSink) Write(rec Record) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, err = s.file.Write(append(b, '\n'))
+	return err
+}
+
+func (s *fileSink) Close() error {
+	return s.file.Close()
+}
+
+// syslogSink sends RFC 5424 messages, each
+// bearing a JSON record, over UDP.
+type syslogSink struct {
+	mutex    sync.Mutex
+	conn     net.Conn
+	hostname string
+}
+
+func newSyslogSink(address string) (Sink, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	return &syslogSink{
+		conn:     conn,
+		hostname: hostname,
+	}, nil
+}
+
+func (s *syslogSink) Write(rec Record) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf(
+		"<%d>1 %s %s stackql %d - - %s",
+		syslogPriority,
+		rec.Timestamp.UTC().Format(time.RFC3339Nano),
+		s.hostname,
+		os.Getpid(),
+		string(b),
+	)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, err = s.conn.Write([]byte(msg))
+	return err
+}
+
+func (s *syslogSink) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,129 @@
+package httpmiddleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/stackql/any-sdk/anysdk"
+	"github.com/stackql/any-sdk/pkg/logging"
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/httpaudit"
+	"github.com/stackql/stackql/internal/stackql/provider"
+)
+
+//nolint:gochecknoglobals // process wide, per flags
+var activeAuditor *httpaudit.Auditor
+
+// SetAuditor audits all subsequent provider
+// calls to the auditor; nil disables auditing.
+func SetAuditor(auditor *httpaudit.Auditor) {
+	activeAuditor = auditor
+}
+
+// newAuditRecord captures the request ahead of sending,
+// leaving its body intact, and starts the latency clock.
+func newAuditRecord(
+	ctx context.Context,
+	handlerCtx handler.HandlerContext,
+	prov provider.IProvider,
+	method anysdk.OperationStore,
+	request *http.Request,
+) (httpaudit.Record, error) {
+	rv := httpaudit.Record{
+		Timestamp:      time.Now(),
+		Provider:       prov.GetProviderString(),
+		Method:         method.GetName(),
+		Verb:           request.Method,
+		URL:            request.URL.String(),
+		RequestHeaders: request.Header.Clone(),
+	}
+	if svc := method.GetService(); svc != nil {
+		rv.Service = svc.GetName()
+	}
+	if rsc := method.GetResource(); rsc != nil {
+		rv.Resource = rsc.GetName()
+	}
+	if txnCounterMgr := handlerCtx.GetTxnCounterMgr(); txnCounterMgr != nil {
+		rv.GenerationID, _ = txnCounterMgr.GetCurrentGenerationID()
+		rv.SessionID, _ = txnCounterMgr.GetCurrentSessionID()
+	}
+	rv.TxnID, _ = httpaudit.TxnIDFromContext(ctx)
+	if request.Body != nil && request.Body != http.NoBody {
+		b, err := io.ReadAll(request.Body)
+		if err != nil {
+			return rv, err
+		}
+		request.Body.Close()
+		request.Body = io.NopCloser(bytes.NewReader(b))
+		rv.RequestBody = b
+	}
+	return rv, nil
+}
+
+// CloseAuditor closes the sink of any active
+// auditor, and disables auditing thereafter.
+func CloseAuditor() error {
+	if activeAuditor == nil {
+		return nil
+	}
+	auditor := activeAuditor
+	activeAuditor = nil
+	return auditor.Close()
+}
+
+// auditResponse audits the call once the response body is consumed,
+// so as to size it, or else immediately.  Audit failures are logged,
+// rather than failing a provider call which has already happened.
+func auditResponse(rec httpaudit.Record, response *http.Response, responseErr error) {
+	rec.LatencyMillis = time.Since(rec.Timestamp).Milliseconds()
+	if responseErr != nil {
+		rec.Error = responseErr.Error()
+	}
+	if response == nil || response.Body == nil || response.Body == http.NoBody {
+		if response != nil {
+			rec.Status = response.StatusCode
+		}
+		audit(rec)
+		return
+	}
+	rec.Status = response.StatusCode
+	response.Body = &auditedBody{
+		ReadCloser: response.Body,
+		rec:        rec,
+	}
+}
+
+func audit(rec httpaudit.Record) {
+	if activeAuditor == nil {
+		return
+	}
+	if err := activeAuditor.Audit(rec); err != nil {
+		logging.GetLogger().Errorf("failed to audit http call to '%s': %s", rec.URL, err.Error())
+	}
+}
+
+// auditedBody counts the bytes of a response body, auditing
+// the call upon end of file or close, whichever is first.
+type auditedBody struct {
+	io.ReadCloser
+	rec  httpaudit.Record
+	once sync.Once
+}
+
+func (b *auditedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.rec.ResponseSize += int64(n)
+	if err != nil {
+		b.once.Do(func() { audit(b.rec) })
+	}
+	return n, err
+}
+
+func (b *auditedBody) Close() error {
+	b.once.Do(func() { audit(b.rec) })
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,72 @@
+package httpmiddleware //nolint:testpackage // responses are audited unexported
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stackql/stackql/internal/stackql/httpaudit"
+)
+
+type auditTestSink struct {
+	records []httpaudit.Record
+	err     error
+	closed  bool
+}
+
+func (s *auditTestSink) Write(rec httpaudit.Record) error {
+	s.records = append(s.records, rec)
+	return s.err
+}
+
+func (s *auditTestSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestAuditResponseCountsConsumedBody(t *testing.T) {
+	sink := &auditTestSink{}
+	SetAuditor(httpaudit.NewAuditorForSink(sink, nil, nil))
+	t.Cleanup(func() { SetAuditor(nil) })
+	response := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader("0123456789")),
+	}
+	auditResponse(httpaudit.Record{Timestamp: time.Now()}, response, nil)
+	if len(sink.records) != 0 {
+		t.Fatal("test failed: call audited before its response body was consumed")
+	}
+	b, err := io.ReadAll(response.Body)
+	if err != nil || string(b) != "0123456789" {
+		t.Fatalf("test failed: response body altered to '%s', %v", string(b), err)
+	}
+	response.Body.Close()
+	if len(sink.records) != 1 || sink.records[0].ResponseSize != 10 || sink.records[0].Status != http.StatusOK {
+		t.Fatalf("test failed: expected one record of a 10 byte response, got %v", sink.records)
+	}
+}
+
+func TestAuditFailureSparesResponse(t *testing.T) {
+	sink := &auditTestSink{err: errors.New("disk full")}
+	SetAuditor(httpaudit.NewAuditorForSink(sink, nil, nil))
+	t.Cleanup(func() { SetAuditor(nil) })
+	response := &http.Response{
+		StatusCode: http.StatusCreated,
+		Body:       io.NopCloser(strings.NewReader(`{"id": "created"}`)),
+	}
+	auditResponse(httpaudit.Record{Timestamp: time.Now()}, response, nil)
+	b, err := io.ReadAll(response.Body)
+	if err != nil || string(b) != `{"id": "created"}` {
+		t.Fatalf("test failed: response of audit failure lost, '%s', %v", string(b), err)
+	}
+	auditResponse(httpaudit.Record{Timestamp: time.Now()}, nil, errors.New("connection reset"))
+	if len(sink.records) != 2 || sink.records[1].Error != "connection reset" {
+		t.Fatalf("test failed: expected failed call to be audited, got %v", sink.records)
+	}
+	if err = CloseAuditor(); err != nil || !sink.closed {
+		t.Fatalf("test failed: auditor not closed, %v", err)
+	}
+}
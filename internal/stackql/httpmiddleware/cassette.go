@@ -0,0 +1,214 @@
+package httpmiddleware
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/stackql/stackql/internal/stackql/netutils"
+)
+
+const (
+	cassetteFileName string = "cassette.jsonl"
+	cassetteFileMode        = 0o600
+	cassetteDirMode         = 0o755
+)
+
+//nolint:gochecknoglobals // process wide, per flags
+var activeCassette *Cassette
+
+// SetCassette directs all subsequent provider requests
+// through the cassette; nil restores live requests.
+func SetCassette(cassette *Cassette) {
+	activeCassette = cassette
+}
+
+// cassetteInteraction is a request / response pair;
+// Error records a transport failure in lieu of a response.
+type cassetteInteraction struct {
+	Method          string      `json:"method"`
+	URL             string      `json:"url"`
+	RequestHeaders  http.Header `json:"request_headers,omitempty"`
+	RequestBody     string      `json:"request_body,omitempty"`
+	StatusCode      int         `json:"status_code,omitempty"`
+	ResponseHeaders http.Header `json:"response_headers,omitempty"`
+	ResponseBody    string      `json:"response_body,omitempty"`
+	Error           string      `json:"error,omitempty"`
+}
+
+func (ci cassetteInteraction) key() string {
+	return interactionKey(ci.Method, ci.URL, ci.RequestBody)
+}
+
+func interactionKey(method, url, body string) string {
+	return fmt.Sprintf("%s %s\n%s", method, url, body)
+}
+
+// Cassette either records interactions, appending each to
+// `cassette.jsonl` in its directory as it completes, or else
+// replays them.  Identical requests replay in recorded order;
+// a request unmatched by any remaining interaction fails.
+type Cassette struct {
+	mutex     sync.Mutex
+	isReplay  bool
+	file      *os.File
+	remaining map[string][]cassetteInteraction
+}
+
+// NewRecordingCassette truncates any existing cassette in the directory.
+func NewRecordingCassette(dir string) (*Cassette, error) {
+	if err := os.MkdirAll(dir, cassetteDirMode); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(
+		filepath.Join(dir, cassetteFileName), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, cassetteFileMode)
+	if err != nil {
+		return nil, err
+	}
+	return &Cassette{
+		file: f,
+	}, nil
+}
+
+func NewReplayingCassette(dir string) (*Cassette, error) {
+	f, err := os.Open(filepath.Join(dir, cassetteFileName))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rv := &Cassette{
+		isReplay:  true,
+		remaining: make(map[string][]cassetteInteraction),
+	}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<30) //nolint:mnd // responses may be large
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		var ci cassetteInteraction
+		if unmarshalErr := json.Unmarshal(scanner.Bytes(), &ci); unmarshalErr != nil {
+			return nil, fmt.Errorf("malformed cassette interaction at line %d: %w", lineNo, unmarshalErr)
+		}
+		rv.remaining[ci.key()] = append(rv.remaining[ci.key()], ci)
+	}
+	if scanErr := scanner.Err(); scanErr != nil {
+		return nil, scanErr
+	}
+	return rv, nil
+}
+
+func (c *Cassette) IsReplay() bool {
+	return c.isReplay
+}
+
+// WrapHTTPClient returns a shallow copy of the client, its
+// transport recording to, or replaying from, the cassette.
+func (c *Cassette) WrapHTTPClient(existingClient *http.Client) *http.Client {
+	rv := *existingClient
+	inner := existingClient.Transport
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+	rv.Transport = &cassetteRoundTripper{
+		cassette: c,
+		inner:    inner,
+	}
+	return &rv
+}
+
+type cassetteRoundTripper struct {
+	cassette *Cassette
+	inner    http.RoundTripper
+}
+
+func (rt *cassetteRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	requestBody, err := readRequestBody(request)
+	if err != nil {
+		return nil, err
+	}
+	if rt.cassette.isReplay {
+		return rt.cassette.replay(request, requestBody)
+	}
+	response, responseErr := rt.inner.RoundTrip(request)
+	ci := cassetteInteraction{
+		Method:         request.Method,
+		URL:            request.URL.String(),
+		RequestHeaders: netutils.RedactHeaders(request.Header),
+		RequestBody:    requestBody,
+	}
+	if responseErr != nil {
+		ci.Error = responseErr.Error()
+		return nil, errors.Join(responseErr, rt.cassette.record(ci))
+	}
+	responseBody, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(responseBody))
+	ci.StatusCode = response.StatusCode
+	ci.ResponseHeaders = netutils.RedactHeaders(response.Header)
+	ci.ResponseBody = string(responseBody)
+	if recordErr := rt.cassette.record(ci); recordErr != nil {
+		return nil, recordErr
+	}
+	return response, nil
+}
+
+func (c *Cassette) record(ci cassetteInteraction) error {
+	b, err := json.Marshal(ci)
+	if err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	_, err = c.file.Write(append(b, '\n'))
+	return err
+}
+
+func (c *Cassette) replay(request *http.Request, requestBody string) (*http.Response, error) {
+	key := interactionKey(request.Method, request.URL.String(), requestBody)
+	c.mutex.Lock()
+	candidates := c.remaining[key]
+	if len(candidates) == 0 {
+		c.mutex.Unlock()
+		return nil, fmt.Errorf("no cassette interaction matches http request: %s", describeRequest(request))
+	}
+	ci := candidates[0]
+	c.remaining[key] = candidates[1:]
+	c.mutex.Unlock()
+	if ci.Error != "" {
+		return nil, fmt.Errorf("replayed http error: %s", ci.Error)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", ci.StatusCode, http.StatusText(ci.StatusCode)),
+		StatusCode:    ci.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        ci.ResponseHeaders.Clone(),
+		Body:          io.NopCloser(strings.NewReader(ci.ResponseBody)),
+		ContentLength: int64(len(ci.ResponseBody)),
+		Request:       request,
+	}, nil
+}
+
+// readRequestBody leaves the request body intact for the inner round tripper.
+func readRequestBody(request *http.Request) (string, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return "", nil
+	}
+	b, err := io.ReadAll(request.Body)
+	if err != nil {
+		return "", err
+	}
+	request.Body.Close()
+	request.Body = io.NopCloser(bytes.NewReader(b))
+	return string(b), nil
+}
@@ -0,0 +1,63 @@
+package httpmiddleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/stackql/stackql/internal/stackql/httpmiddleware"
+)
+
+func TestCassetteRecordThenReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"echo": "` + string(b) + `"}`)) //nolint:errcheck // test server
+	}))
+	dir := t.TempDir()
+	recorder, err := NewRecordingCassette(dir)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	client := recorder.WrapHTTPClient(srv.Client())
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/items?page=1", strings.NewReader("abc"))
+	req.Header.Set("Authorization", "Bearer secret-value")
+	req.Header.Set("X-Auth-Token", "secret-value")
+	response, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	response.Body.Close()
+	srv.Close()
+
+	b, _ := os.ReadFile(filepath.Join(dir, "cassette.jsonl"))
+	if strings.Contains(string(b), "secret-value") {
+		t.Fatalf("test failed: credentials persisted in cassette: %s", string(b))
+	}
+
+	replayer, err := NewReplayingCassette(dir)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	client = replayer.WrapHTTPClient(&http.Client{})
+	req, _ = http.NewRequest(http.MethodPost, srv.URL+"/items?page=1", strings.NewReader("abc"))
+	response, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+	if response.StatusCode != http.StatusOK || string(body) != `{"echo": "abc"}` ||
+		response.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("test failed: unexpected replayed response %d '%s'", response.StatusCode, string(body))
+	}
+	// Each interaction replays once only.
+	req, _ = http.NewRequest(http.MethodPost, srv.URL+"/items?page=1", strings.NewReader("abc"))
+	if _, err = client.Do(req); err == nil {
+		t.Fatalf("test failed: expected unmatched request to fail")
+	}
+}
@@ -2,15 +2,23 @@ package httpmiddleware
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/stackql/any-sdk/anysdk"
 	"github.com/stackql/any-sdk/pkg/logging"
 	"github.com/stackql/any-sdk/pkg/requesttranslate"
+	"github.com/stackql/stackql/internal/stackql/acid/binlog"
+	"github.com/stackql/stackql/internal/stackql/acid/locking"
 	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/httpaudit"
+	"github.com/stackql/stackql/internal/stackql/metrics"
+	"github.com/stackql/stackql/internal/stackql/netutils"
 	"github.com/stackql/stackql/internal/stackql/provider"
+	"github.com/stackql/stackql/internal/stackql/tracing"
 )
 
 func GetAuthenticatedClient(handlerCtx handler.HandlerContext, prov provider.IProvider) (*http.Client, error) {
@@ -29,6 +37,22 @@ func getAuthenticatedClient(handlerCtx handler.HandlerContext, prov provider.IPr
 	return httpClient, nil
 }
 
+// getCassetteClient routes requests through any active cassette.
+// Replay requires no credentials, and so skips authentication.
+func getCassetteClient(handlerCtx handler.HandlerContext, prov provider.IProvider) (*http.Client, error) {
+	if activeCassette == nil {
+		return getAuthenticatedClient(handlerCtx, prov)
+	}
+	if activeCassette.IsReplay() {
+		return activeCassette.WrapHTTPClient(&http.Client{}), nil
+	}
+	httpClient, err := getAuthenticatedClient(handlerCtx, prov)
+	if err != nil {
+		return nil, err
+	}
+	return activeCassette.WrapHTTPClient(httpClient), nil
+}
+
 //nolint:nestif,mnd // acceptable for now
 func parseReponseBodyIfErroneous(response *http.Response) (string, error) {
 	if response != nil {
@@ -50,6 +74,33 @@ func parseReponseBodyIfErroneous(response *http.Response) (string, error) {
 	return "", nil
 }
 
+// describeRequest omits the query string and body,
+// which may carry credentials.
+func describeRequest(request *http.Request) string {
+	if request == nil || request.URL == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s %s://%s%s", request.Method, request.URL.Scheme, request.URL.Host, request.URL.Path)
+}
+
+func describeResponse(request *http.Request, response *http.Response) string {
+	if response == nil {
+		return describeRequest(request)
+	}
+	return fmt.Sprintf("%s -> %d", describeRequest(request), response.StatusCode)
+}
+
+func lockKey(prov provider.IProvider, method anysdk.OperationStore, request *http.Request) (string, error) {
+	var serviceName, resourceName string
+	if svc := method.GetService(); svc != nil {
+		serviceName = svc.GetName()
+	}
+	if rsc := method.GetResource(); rsc != nil {
+		resourceName = rsc.GetName()
+	}
+	return locking.NewRequestKey(prov.GetProviderString(), serviceName, resourceName, request)
+}
+
 //nolint:nestif // acceptable for now
 func parseReponseBodyIfPresent(response *http.Response) (string, error) {
 	if response != nil {
@@ -69,13 +120,19 @@ func parseReponseBodyIfPresent(response *http.Response) (string, error) {
 	return "nil response", nil
 }
 
+// HTTPApiCallFromRequest issues the request bound to the supplied context,
+// so that cancellation of the calling primitive aborts the call.
 func HTTPApiCallFromRequest(
+	ctx context.Context,
 	handlerCtx handler.HandlerContext,
 	prov provider.IProvider,
 	method anysdk.OperationStore,
 	request *http.Request,
 ) (*http.Response, error) {
-	httpClient, httpClientErr := getAuthenticatedClient(handlerCtx, prov)
+	if ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
+	httpClient, httpClientErr := getCassetteClient(handlerCtx, prov)
 	if httpClientErr != nil {
 		return nil, httpClientErr
 	}
@@ -88,6 +145,7 @@ func HTTPApiCallFromRequest(
 	if err != nil {
 		return nil, err
 	}
+	translatedRequest = translatedRequest.WithContext(ctx)
 	if handlerCtx.GetRuntimeContext().HTTPLogEnabled {
 		urlStr := ""
 		methodStr := ""
@@ -114,7 +172,56 @@ func HTTPApiCallFromRequest(
 	logging.GetLogger().Debugf("Proof of invariant: walObj = %v", walObj)
 	urlString := translatedRequest.URL.String()
 	logging.GetLogger().Debugf("HTTP request: URL = '''%s'''", urlString)
-	r, err := httpClient.Do(translatedRequest)
+	if locker, isLocked := locking.LockerFromContext(ctx); isLocked {
+		key, keyErr := lockKey(prov, method, translatedRequest)
+		if keyErr != nil {
+			return nil, keyErr
+		}
+		if lockErr := locker.Lock(
+			ctx,
+			key,
+			locking.IsMutation(method.GetSQLVerb(), translatedRequest),
+		); lockErr != nil {
+			return nil, lockErr
+		}
+	}
+	recorder, isRecorded := binlog.RecorderFromContext(ctx)
+	if isRecorded {
+		if recordErr := recorder.RecordRequest(describeRequest(translatedRequest)); recordErr != nil {
+			return nil, recordErr
+		}
+	}
+	httpClient = tracing.GetTracedHTTPClient(httpClient)
+	if limiter := handlerCtx.GetHTTPRateLimiter(prov.GetProviderString()); limiter != nil &&
+		(activeCassette == nil || !activeCassette.IsReplay()) {
+		// Transports from netutils.GetRoundTripper limit per the request context;
+		// provider auth clients may bear others, and so are wrapped likewise.
+		translatedRequest = translatedRequest.WithContext(netutils.NewRateLimiterContext(ctx, limiter))
+		httpClient = netutils.GetRateLimitedHTTPClient(httpClient, nil)
+	}
+	var auditRec httpaudit.Record
+	if activeAuditor != nil {
+		auditRec, err = newAuditRecord(ctx, handlerCtx, prov, method, translatedRequest)
+		if err != nil {
+			return nil, err
+		}
+	}
+	retryPolicy := handlerCtx.GetHTTPRetryPolicy(prov.GetProviderString())
+	r, err := DoWithRetry(ctx, httpClient, translatedRequest, retryPolicy,
+		func(retry int, wait time.Duration, reason string) {
+			if handlerCtx.GetRuntimeContext().HTTPLogEnabled {
+				//nolint:errcheck // output stream
+				handlerCtx.GetOutErrFile().Write([]byte(
+					describeRetry(translatedRequest, retry, retryPolicy.MaxRetries, wait, reason)))
+			}
+		})
+	metrics.ObserveProviderHTTPCall(prov.GetProviderString(), r)
+	if isRecorded {
+		recorder.RecordResponse(describeResponse(translatedRequest, r), err)
+	}
+	if activeAuditor != nil {
+		auditResponse(auditRec, r, err)
+	}
 	responseErrorBodyToPublish, reponseParseErr := parseReponseBodyIfErroneous(r)
 	if reponseParseErr != nil {
 		return nil, reponseParseErr
@@ -128,6 +235,9 @@ func HTTPApiCallFromRequest(
 		handlerCtx.GetOutErrFile().Write([]byte(fmt.Sprintf("%s\n", reponseBodyStr)))
 	}
 	if err != nil {
+		if ctx.Err() != nil {
+			err = context.Cause(ctx)
+		}
 		if handlerCtx.GetRuntimeContext().HTTPLogEnabled {
 			//nolint:errcheck // output stream
 			handlerCtx.GetOutErrFile().Write([]byte(
@@ -0,0 +1,151 @@
+package httpmiddleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/stackql/stackql/internal/stackql/handler"
+)
+
+const (
+	retryBackoffFactor = 2
+)
+
+// RetryObserver is notified prior to each retry.
+type RetryObserver func(retry int, wait time.Duration, reason string)
+
+// DoWithRetry issues the request, retrying transient failures per the policy.
+// Waits back off exponentially, with jitter, save that a `Retry-After`
+// response header is honoured up to the policy's maximum backoff.  No wait
+// outlasts the deadline of ctx.  The request body is buffered for replay.
+// Upon exhaustion, the last response or error is returned.
+func DoWithRetry(
+	ctx context.Context,
+	httpClient *http.Client,
+	request *http.Request,
+	policy handler.HTTPRetryPolicy,
+	observer RetryObserver,
+) (*http.Response, error) {
+	if policy.MaxRetries <= 0 || !policy.IsRetryableMethod(request.Method) {
+		return httpClient.Do(request)
+	}
+	if err := makeRequestReplayable(request); err != nil {
+		return nil, err
+	}
+	backoff := policy.InitialBackoff
+	for retry := 1; ; retry++ {
+		response, err := httpClient.Do(request)
+		reason, isRetryable := getRetryReason(response, err)
+		if !isRetryable || retry > policy.MaxRetries || ctx.Err() != nil {
+			return response, err
+		}
+		wait := backoff - time.Duration(rand.Int63n(int64(backoff/retryBackoffFactor)+1)) //nolint:gosec // jitter only
+		if retryAfter, hasRetryAfter := parseRetryAfter(response, time.Now()); hasRetryAfter {
+			wait = retryAfter
+			if policy.MaxBackoff > 0 {
+				wait = min(wait, policy.MaxBackoff)
+			}
+		}
+		if deadline, hasDeadline := ctx.Deadline(); hasDeadline {
+			wait = max(min(wait, time.Until(deadline)), 0)
+		}
+		backoff *= retryBackoffFactor
+		if policy.MaxBackoff > 0 {
+			backoff = min(backoff, policy.MaxBackoff)
+		}
+		if response != nil && response.Body != nil {
+			io.Copy(io.Discard, response.Body) //nolint:errcheck // draining for connection reuse
+			response.Body.Close()
+		}
+		if observer != nil {
+			observer(retry, wait, reason)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, context.Cause(ctx)
+		case <-timer.C:
+		}
+		if request.GetBody != nil {
+			body, bodyErr := request.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			request.Body = body
+		}
+	}
+}
+
+func makeRequestReplayable(request *http.Request) error {
+	if request.Body == nil || request.Body == http.NoBody || request.GetBody != nil {
+		return nil
+	}
+	b, err := io.ReadAll(request.Body)
+	if err != nil {
+		return err
+	}
+	request.Body.Close()
+	request.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+	request.Body, _ = request.GetBody()
+	return nil
+}
+
+func getRetryReason(response *http.Response, err error) (string, bool) {
+	if err != nil {
+		if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+			return err.Error(), true
+		}
+		return "", false
+	}
+	switch response.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return response.Status, true
+	default:
+		return "", false
+	}
+}
+
+// parseRetryAfter accepts either delay seconds or an HTTP date.
+func parseRetryAfter(response *http.Response, now time.Time) (time.Duration, bool) {
+	if response == nil {
+		return 0, false
+	}
+	raw := response.Header.Get("Retry-After")
+	if raw == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(raw); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(raw); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func describeRetry(request *http.Request, retry int, maxRetries int, wait time.Duration, reason string) string {
+	return fmt.Sprintf(
+		"http request retry %d of %d in %s, url: '%s', method: '%s', reason: %s\n",
+		retry,
+		maxRetries,
+		wait.Round(time.Millisecond),
+		request.URL.String(),
+		request.Method,
+		reason,
+	)
+}
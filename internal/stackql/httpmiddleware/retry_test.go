@@ -0,0 +1,147 @@
+package httpmiddleware_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/stackql/stackql/internal/stackql/httpmiddleware"
+
+	"github.com/stackql/stackql/internal/stackql/handler"
+)
+
+func newFlakyServer(t *testing.T, failures int32, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if calls.Add(1) <= failures {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(b) //nolint:errcheck // test server
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestDoWithRetryHonoursPolicy(t *testing.T) {
+	srv, calls := newFlakyServer(t, 2, "0")
+	policy := handler.GetDefaultHTTPRetryPolicy()
+	policy.Methods = []string{http.MethodGet, http.MethodPut}
+	var retries []int
+	req, _ := http.NewRequest(http.MethodPut, srv.URL, io.NopCloser(strings.NewReader("payload")))
+	response, err := DoWithRetry(context.Background(), srv.Client(), req, policy,
+		func(retry int, wait time.Duration, _ string) {
+			if wait != 0 {
+				t.Fatalf("test failed: Retry-After not honoured, wait = %s", wait)
+			}
+			retries = append(retries, retry)
+		})
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	defer response.Body.Close()
+	b, _ := io.ReadAll(response.Body)
+	if response.StatusCode != http.StatusOK || string(b) != "payload" {
+		t.Fatalf("test failed: unexpected response %d '%s'", response.StatusCode, string(b))
+	}
+	if calls.Load() != 3 || len(retries) != 2 {
+		t.Fatalf("test failed: expected 3 calls and 2 retries, got %d calls and %v retries", calls.Load(), retries)
+	}
+}
+
+func TestDoWithRetryExhaustionAndMethods(t *testing.T) {
+	srv, calls := newFlakyServer(t, 10, "0")
+	policy := handler.GetDefaultHTTPRetryPolicy()
+	policy.MaxRetries = 1
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	response, err := DoWithRetry(context.Background(), srv.Client(), req, policy, nil)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusServiceUnavailable || calls.Load() != 2 {
+		t.Fatalf("test failed: expected final 503 after 2 calls, got %d after %d", response.StatusCode, calls.Load())
+	}
+	req, _ = http.NewRequest(http.MethodPost, srv.URL, nil)
+	response, err = DoWithRetry(context.Background(), srv.Client(), req, policy, nil)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	response.Body.Close()
+	if calls.Load() != 3 {
+		t.Fatalf("test failed: POST should not be retried by default, calls = %d", calls.Load())
+	}
+}
+
+func TestDoWithRetryBoundsRetryAfter(t *testing.T) {
+	srv, calls := newFlakyServer(t, 1, "86400")
+	policy := handler.GetDefaultHTTPRetryPolicy()
+	policy.MaxBackoff = 10 * time.Millisecond
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	response, err := DoWithRetry(context.Background(), srv.Client(), req, policy,
+		func(_ int, wait time.Duration, _ string) {
+			if wait > policy.MaxBackoff {
+				t.Fatalf("test failed: Retry-After not bounded by max backoff, wait = %s", wait)
+			}
+		})
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK || calls.Load() != 2 {
+		t.Fatalf("test failed: expected 200 after 2 calls, got %d after %d", response.StatusCode, calls.Load())
+	}
+
+	// The wait is further bounded by the statement deadline.
+	srv, _ = newFlakyServer(t, 10, "86400")
+	policy.MaxBackoff = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	start := time.Now()
+	_, err = DoWithRetry(ctx, srv.Client(), req, policy,
+		func(_ int, wait time.Duration, _ string) {
+			if wait > 50*time.Millisecond {
+				t.Fatalf("test failed: Retry-After not bounded by deadline, wait = %s", wait)
+			}
+		})
+	if err == nil {
+		t.Fatal("test failed: expected deadline error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("test failed: retry outlasted the deadline by %s", elapsed)
+	}
+}
+
+func TestDoWithRetryUnboundedBackoff(t *testing.T) {
+	srv, calls := newFlakyServer(t, 3, "")
+	policy := handler.GetDefaultHTTPRetryPolicy()
+	policy.InitialBackoff = 4 * time.Millisecond
+	policy.MaxBackoff = 0
+	var waits []time.Duration
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	response, err := DoWithRetry(context.Background(), srv.Client(), req, policy,
+		func(_ int, wait time.Duration, _ string) {
+			waits = append(waits, wait)
+		})
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK || calls.Load() != 4 {
+		t.Fatalf("test failed: expected 200 after 4 calls, got %d after %d", response.StatusCode, calls.Load())
+	}
+	// Jitter takes at most half, so each wait is at least half its backoff.
+	for i, wait := range waits {
+		if floor := policy.InitialBackoff << i / 2; wait < floor {
+			t.Fatalf("test failed: wait %d of %s below %s, backoff collapsed", i, wait, floor)
+		}
+	}
+}
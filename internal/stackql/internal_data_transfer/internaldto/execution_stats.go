@@ -0,0 +1,109 @@
+package internaldto
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	_ ExecutionStats = &standardExecutionStats{}
+)
+
+// ExecutionStats accumulates runtime counters for
+// a primitive, as surfaced by EXPLAIN ANALYZE.
+// Nested primitives, such as graph nodes,
+// are tracked as children keyed on node ID.
+// Implementations must be safe for concurrent use.
+type ExecutionStats interface {
+	AddHTTPRequests(int64)
+	AddPages(int64)
+	AddRowsInserted(int64)
+	Child(id int64) ExecutionStats
+	GetChild(id int64) (ExecutionStats, bool)
+	GetElapsed() time.Duration
+	GetHTTPRequests() int64
+	GetPages() int64
+	GetRowsInserted() int64
+	GetRowsReturned() (int64, bool)
+	SetElapsed(time.Duration)
+	SetRowsReturned(int64)
+}
+
+type standardExecutionStats struct {
+	httpRequests   int64
+	pages          int64
+	rowsInserted   int64
+	rowsReturned   int64
+	isRowsReturned int32
+	elapsed        int64
+	childrenMutex  sync.Mutex
+	children       map[int64]ExecutionStats
+}
+
+func NewExecutionStats() ExecutionStats {
+	return &standardExecutionStats{
+		children: make(map[int64]ExecutionStats),
+	}
+}
+
+func (es *standardExecutionStats) AddHTTPRequests(n int64) {
+	atomic.AddInt64(&es.httpRequests, n)
+}
+
+func (es *standardExecutionStats) AddPages(n int64) {
+	atomic.AddInt64(&es.pages, n)
+}
+
+func (es *standardExecutionStats) AddRowsInserted(n int64) {
+	atomic.AddInt64(&es.rowsInserted, n)
+}
+
+func (es *standardExecutionStats) SetRowsReturned(n int64) {
+	atomic.StoreInt64(&es.rowsReturned, n)
+	atomic.StoreInt32(&es.isRowsReturned, 1)
+}
+
+func (es *standardExecutionStats) SetElapsed(d time.Duration) {
+	atomic.StoreInt64(&es.elapsed, int64(d))
+}
+
+func (es *standardExecutionStats) GetHTTPRequests() int64 {
+	return atomic.LoadInt64(&es.httpRequests)
+}
+
+func (es *standardExecutionStats) GetPages() int64 {
+	return atomic.LoadInt64(&es.pages)
+}
+
+func (es *standardExecutionStats) GetRowsInserted() int64 {
+	return atomic.LoadInt64(&es.rowsInserted)
+}
+
+func (es *standardExecutionStats) GetRowsReturned() (int64, bool) {
+	return atomic.LoadInt64(&es.rowsReturned), atomic.LoadInt32(&es.isRowsReturned) != 0
+}
+
+func (es *standardExecutionStats) GetElapsed() time.Duration {
+	return time.Duration(atomic.LoadInt64(&es.elapsed))
+}
+
+// Child returns the statistics for the nested
+// primitive with supplied ID, creating them if absent.
+func (es *standardExecutionStats) Child(id int64) ExecutionStats {
+	es.childrenMutex.Lock()
+	defer es.childrenMutex.Unlock()
+	if child, ok := es.children[id]; ok {
+		return child
+	}
+	child := NewExecutionStats()
+	es.children[id] = child
+	return child
+}
+
+func (es *standardExecutionStats) GetChild(id int64) (ExecutionStats, bool) {
+	es.childrenMutex.Lock()
+	defer es.childrenMutex.Unlock()
+	child, ok := es.children[id]
+	return child, ok
+}
@@ -1,6 +1,8 @@
 package internaldto
 
 import (
+	"net/http"
+
 	"github.com/stackql/psql-wire/pkg/sqldata"
 	"github.com/stackql/stackql/internal/stackql/acid/binlog"
 	"github.com/stackql/stackql/internal/stackql/streaming"
@@ -57,6 +59,10 @@ type ExecutorOutput interface {
 	SetRedoLog(binlog.LogEntry)
 	WithUndoLog(binlog.LogEntry) ExecutorOutput
 	WithRedoLog(binlog.LogEntry) ExecutorOutput
+	// GetHTTPResponse returns the response from which the
+	// output arose, if any; its body is already consumed.
+	GetHTTPResponse() (*http.Response, bool)
+	WithHTTPResponse(*http.Response) ExecutorOutput
 }
 
 type standardExecutorOutput struct {
@@ -67,6 +73,7 @@ type standardExecutorOutput struct {
 	Msg           BackendMessages
 	redoLog       binlog.LogEntry
 	undoLog       binlog.LogEntry
+	httpResponse  *http.Response
 	Err           error
 }
 
@@ -96,6 +103,15 @@ func (ex *standardExecutorOutput) GetUndoLog() (binlog.LogEntry, bool) {
 	return ex.undoLog, ex.undoLog != nil
 }
 
+func (ex *standardExecutorOutput) GetHTTPResponse() (*http.Response, bool) {
+	return ex.httpResponse, ex.httpResponse != nil
+}
+
+func (ex *standardExecutorOutput) WithHTTPResponse(response *http.Response) ExecutorOutput {
+	ex.httpResponse = response
+	return ex
+}
+
 func (ex *standardExecutorOutput) GetSQLResult() sqldata.ISQLResultStream {
 	return ex.getSQLResult()
 }
@@ -1,6 +1,7 @@
 package internaldto
 
 import (
+	"context"
 	"io"
 
 	"github.com/stackql/any-sdk/pkg/dto"
@@ -12,14 +13,18 @@ var (
 
 type BasicPrimitiveContext interface {
 	GetAuthContext(prov string) (*dto.AuthCtx, error)
+	GetContext() context.Context
 	GetErrWriter() io.Writer
 	GetWriter() io.Writer
+	GetExecutionStats() (ExecutionStats, bool)
+	WithContext(context.Context) BasicPrimitiveContext
 }
 
 type standardBasicPrimitiveContext struct {
 	authCtx   func(string) (*dto.AuthCtx, error)
 	writer    io.Writer
 	errWriter io.Writer
+	ctx       context.Context
 }
 
 func NewBasicPrimitiveContext(
@@ -45,3 +50,21 @@ func (bpp *standardBasicPrimitiveContext) GetWriter() io.Writer {
 func (bpp *standardBasicPrimitiveContext) GetErrWriter() io.Writer {
 	return bpp.errWriter
 }
+
+func (bpp *standardBasicPrimitiveContext) GetExecutionStats() (ExecutionStats, bool) {
+	return nil, false
+}
+
+// GetContext returns the context bounding execution,
+// which is cancelled upon timeout or client interruption.
+func (bpp *standardBasicPrimitiveContext) GetContext() context.Context {
+	if bpp.ctx == nil {
+		return context.Background()
+	}
+	return bpp.ctx
+}
+
+func (bpp *standardBasicPrimitiveContext) WithContext(ctx context.Context) BasicPrimitiveContext {
+	bpp.ctx = ctx
+	return bpp
+}
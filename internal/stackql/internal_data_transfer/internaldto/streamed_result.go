@@ -0,0 +1,88 @@
+package internaldto
+
+import (
+	"errors"
+	"io"
+
+	"github.com/stackql/psql-wire/pkg/sqldata"
+)
+
+// StreamedSQLResult is a SQL result read lazily from
+// the backend.  Backend resources are held until the
+// stream is read through to EOF or error, or else released.
+// Any error recurs upon subsequent reads.
+type StreamedSQLResult interface {
+	sqldata.ISQLResultStream
+	Release() error
+	// OnRelease arranges for the function to be called once the
+	// backend resources are released, with any error of reading
+	// them; forthwith, if already released.
+	OnRelease(func(error))
+}
+
+func NewSimpleRawResultStream(m RawMap) IRawResultStream {
+	return createSimpleRawResultStream(m)
+}
+
+// ReleaseSQLResult releases any streamed result
+// that will not be read.
+func ReleaseSQLResult(output ExecutorOutput) error {
+	if output == nil {
+		return nil
+	}
+	streamed, isStreamed := output.GetSQLResult().(StreamedSQLResult)
+	if !isStreamed {
+		return nil
+	}
+	return streamed.Release()
+}
+
+// OnSQLResultReleased calls the function once any streamed result
+// of the output is released, and otherwise forthwith, such that
+// resources outliving the statement may be reclaimed.
+func OnSQLResultReleased(output ExecutorOutput, fn func(error)) {
+	if output != nil {
+		if streamed, isStreamed := output.GetSQLResult().(StreamedSQLResult); isStreamed {
+			streamed.OnRelease(fn)
+			return
+		}
+	}
+	fn(nil)
+}
+
+// BufferSQLResult reads any streamed result through to a single
+// in memory result, for those consumers that cannot proceed
+// chunk by chunk, or that must not hold backend resources
+// while later statements execute.
+func BufferSQLResult(output ExecutorOutput) error {
+	if output == nil {
+		return nil
+	}
+	streamed, isStreamed := output.GetSQLResult().(StreamedSQLResult)
+	if !isStreamed {
+		return nil
+	}
+	var columns []sqldata.ISQLColumn
+	var rows []sqldata.ISQLRow
+	for {
+		res, err := streamed.Read()
+		if err != nil && !errors.Is(err, io.EOF) {
+			// the error persists for subsequent readers
+			return err
+		}
+		if res != nil {
+			if columns == nil {
+				columns = res.GetColumns()
+			}
+			rows = append(rows, res.GetRows()...)
+		}
+		if err != nil {
+			break
+		}
+	}
+	buffered := sqldata.NewSQLResult(columns, 0, 0, rows)
+	output.SetSQLResultFn(func() sqldata.ISQLResultStream {
+		return sqldata.NewSimpleSQLResultStream(buffered)
+	})
+	return nil
+}
@@ -4,8 +4,42 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
 )
 
+//nolint:gochecknoglobals // process wide
+var (
+	exitHooksMutex sync.Mutex
+	exitHooks      []func()
+)
+
+// RegisterExitHook registers a function, such as a flush
+// of buffered telemetry, to run prior to exit via Exit.
+func RegisterExitHook(hook func()) {
+	exitHooksMutex.Lock()
+	defer exitHooksMutex.Unlock()
+	exitHooks = append(exitHooks, hook)
+}
+
+// RunExitHooks runs the registered hooks, in reverse order
+// of registration.  Each hook runs at most once.
+func RunExitHooks() {
+	exitHooksMutex.Lock()
+	hooks := exitHooks
+	exitHooks = nil
+	exitHooksMutex.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+}
+
+// Exit runs the registered hooks and then exits
+// the process; it is the sole means of exit.
+func Exit(code int) {
+	RunExitHooks()
+	os.Exit(code) //nolint:revive // sole exit point
+}
+
 func GetStatementNotSupportedError(stmtName string) error {
 	return fmt.Errorf("statement type = '%s' not yet supported", stmtName)
 }
@@ -13,19 +47,19 @@ func GetStatementNotSupportedError(stmtName string) error {
 func PrintErrorAndExitOneIfNil(subject interface{}, msg string) {
 	if subject == nil {
 		fmt.Fprintln(os.Stderr, fmt.Sprintln(msg))
-		os.Exit(1)
+		Exit(1)
 	}
 }
 
 func PrintErrorAndExitOneWithMessage(msg string) {
 	fmt.Fprintln(os.Stderr, fmt.Sprintln(msg))
-	os.Exit(1)
+	Exit(1)
 }
 
 func PrintErrorAndExitOneIfError(err error) {
 	if err != nil {
 		fmt.Fprintln(os.Stderr, fmt.Sprintln(err.Error()))
-		os.Exit(1)
+		Exit(1)
 	}
 }
 
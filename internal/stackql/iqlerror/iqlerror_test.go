@@ -0,0 +1,20 @@
+package iqlerror_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/stackql/stackql/internal/stackql/iqlerror"
+)
+
+func TestExitHooksRunOnceInReverse(t *testing.T) {
+	var ran []string
+	RegisterExitHook(func() { ran = append(ran, "flush telemetry") })
+	RegisterExitHook(func() { ran = append(ran, "stop profile") })
+	RunExitHooks()
+	RunExitHooks()
+	expected := []string{"stop profile", "flush telemetry"}
+	if !reflect.DeepEqual(ran, expected) {
+		t.Fatalf("test failed: expected hooks %v, got %v", expected, ran)
+	}
+}
@@ -0,0 +1,147 @@
+package metacommand
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/stackql/stackql/internal/stackql/parser"
+)
+
+const (
+	metaCommandPrefix string = `\`
+)
+
+// IsMetaCommand is true of a line commencing with a backslash.
+func IsMetaCommand(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), metaCommandPrefix)
+}
+
+// Parse splits a meta-command line into the command,
+// eg: `\set`, and its whitespace trimmed argument.
+func Parse(line string) (string, string) {
+	command, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	return command, strings.TrimSpace(arg)
+}
+
+// Toggle inverts the setting, unless 'on' or 'off' is specified.
+func Toggle(setting bool, arg string) (bool, error) {
+	switch strings.ToLower(arg) {
+	case "":
+		return !setting, nil
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	default:
+		return setting, fmt.Errorf("unrecognized value '%s', expected 'on' or 'off'", arg)
+	}
+}
+
+func OnOff(setting bool) string {
+	if setting {
+		return "on"
+	}
+	return "off"
+}
+
+// SetVariable returns the variable list, per the `--var` flag,
+// with NAME set to VALUE, replacing any prior value, from an
+// argument of the form `NAME [VALUE]`.
+func SetVariable(vars []string, arg string) ([]string, error) {
+	name, value, _ := strings.Cut(strings.TrimSpace(arg), " ")
+	if name == "" || strings.Contains(name, "=") {
+		return vars, fmt.Errorf("invalid variable name '%s'", name)
+	}
+	var rv []string
+	for _, v := range vars {
+		if !strings.HasPrefix(v, name+"=") {
+			rv = append(rv, v)
+		}
+	}
+	return append(rv, fmt.Sprintf("%s=%s", name, strings.TrimSpace(value))), nil
+}
+
+// ListVariables presents the variable list sorted by name.
+func ListVariables(vars []string) []string {
+	sorted := append([]string{}, vars...)
+	sort.Strings(sorted)
+	rv := make([]string, len(sorted))
+	for i, v := range sorted {
+		name, value, _ := strings.Cut(v, "=")
+		rv[i] = fmt.Sprintf("%s = '%s'", name, value)
+	}
+	return rv
+}
+
+// Redirector switches output between the default
+// writer and, per `\o FILE`, a file it owns.
+type Redirector struct {
+	defaultOutfile  io.Writer
+	redirectOutfile *os.File
+}
+
+func NewRedirector(defaultOutfile io.Writer) *Redirector {
+	return &Redirector{defaultOutfile: defaultOutfile}
+}
+
+// Redirect returns the writer for subsequent output: the file
+// created at filePath or, where filePath is empty, the default.
+// Any prior file is closed once the new writer is obtained.
+func (r *Redirector) Redirect(filePath string) (io.Writer, error) {
+	var outfile io.Writer = r.defaultOutfile
+	var redirectOutfile *os.File
+	if filePath != "" {
+		f, err := os.Create(filePath)
+		if err != nil {
+			return nil, err
+		}
+		outfile, redirectOutfile = f, f
+	}
+	r.Close()
+	r.redirectOutfile = redirectOutfile
+	return outfile, nil
+}
+
+func (r *Redirector) Close() {
+	if r.redirectOutfile != nil {
+		r.redirectOutfile.Close()
+		r.redirectOutfile = nil
+	}
+}
+
+// Entry is one element of a script run by `\i`: either a
+// meta-command line, or a statement sans terminating semicolon.
+type Entry struct {
+	IsMetaCommand bool
+	Text          string
+}
+
+// SplitScript splits a script into statements and, as per lines
+// typed in the shell, meta-commands.  A backslash line is a
+// meta-command only where it does not continue a statement,
+// such as a multi line string literal.
+func SplitScript(script string) []Entry {
+	var rv []Entry
+	var sb strings.Builder
+	for _, line := range strings.SplitAfter(script, "\n") {
+		if IsMetaCommand(line) && len(parser.SplitStatements(sb.String())) == 0 {
+			sb.Reset()
+			rv = append(rv, Entry{IsMetaCommand: true, Text: strings.TrimSpace(line)})
+			continue
+		}
+		sb.WriteString(line)
+		statements, remainder := parser.SplitTerminatedStatements(sb.String())
+		sb.Reset()
+		sb.WriteString(remainder)
+		for _, statement := range statements {
+			rv = append(rv, Entry{Text: statement})
+		}
+	}
+	for _, statement := range parser.SplitStatements(sb.String()) {
+		rv = append(rv, Entry{Text: statement})
+	}
+	return rv
+}
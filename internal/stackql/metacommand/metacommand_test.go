@@ -0,0 +1,115 @@
+package metacommand_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "github.com/stackql/stackql/internal/stackql/metacommand"
+)
+
+func TestParse(t *testing.T) {
+	command, arg := Parse(`  \set  region   us-east-1 `)
+	if command != `\set` || arg != "region   us-east-1" {
+		t.Fatalf("test failed: parsed '%s', '%s'", command, arg)
+	}
+	if command, arg = Parse(`\x`); command != `\x` || arg != "" {
+		t.Fatalf("test failed: parsed '%s', '%s'", command, arg)
+	}
+}
+
+func TestToggle(t *testing.T) {
+	for _, tc := range []struct {
+		setting  bool
+		arg      string
+		expected bool
+	}{
+		{false, "", true},
+		{true, "", false},
+		{false, "on", true},
+		{true, "ON", true},
+		{true, "off", false},
+		{false, "off", false},
+	} {
+		rv, err := Toggle(tc.setting, tc.arg)
+		if err != nil || rv != tc.expected {
+			t.Fatalf("test failed: toggle(%t, '%s') = %t, %v", tc.setting, tc.arg, rv, err)
+		}
+	}
+	if rv, err := Toggle(true, "maybe"); err == nil || !rv {
+		t.Fatalf("test failed: expected error and unaltered setting for unrecognized value")
+	}
+}
+
+func TestSetVariable(t *testing.T) {
+	vars, err := SetVariable([]string{"project=p1", "zone=z1"}, "project p2")
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if expected := []string{"zone=z1", "project=p2"}; !reflect.DeepEqual(vars, expected) {
+		t.Fatalf("test failed: vars = %v, expected %v", vars, expected)
+	}
+	if vars, err = SetVariable(vars, "empty"); err != nil || vars[len(vars)-1] != "empty=" {
+		t.Fatalf("test failed: vars = %v, error = %v", vars, err)
+	}
+	if _, err = SetVariable(vars, "a=b c"); err == nil {
+		t.Fatalf("test failed: expected error for invalid variable name")
+	}
+}
+
+func TestListVariables(t *testing.T) {
+	listed := ListVariables([]string{"zone=z1", "project=p=1"})
+	if expected := []string{"project = 'p=1'", "zone = 'z1'"}; !reflect.DeepEqual(listed, expected) {
+		t.Fatalf("test failed: listed %v, expected %v", listed, expected)
+	}
+}
+
+func TestRedirectorRestoresDefault(t *testing.T) {
+	var defaultOutfile bytes.Buffer
+	r := NewRedirector(&defaultOutfile)
+	filePath := filepath.Join(t.TempDir(), "out.txt")
+	w, err := r.Redirect(filePath)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	io.WriteString(w, "redirected") //nolint:errcheck // test
+	w, err = r.Redirect("")
+	if err != nil || w != &defaultOutfile {
+		t.Fatalf("test failed: default writer not restored, error = %v", err)
+	}
+	b, err := os.ReadFile(filePath)
+	if err != nil || string(b) != "redirected" {
+		t.Fatalf("test failed: file content '%s', error = %v", string(b), err)
+	}
+	if _, err = r.Redirect(filepath.Join(t.TempDir(), "absent", "out.txt")); err == nil {
+		t.Fatalf("test failed: expected error for uncreatable file")
+	}
+}
+
+func TestSplitScript(t *testing.T) {
+	script := `-- setup
+\set project p1
+select 1;
+\x on
+select 'a
+\not a command'
+  from t; select 2
+;
+  \timing
+select 3`
+	expected := []Entry{
+		{IsMetaCommand: true, Text: `\set project p1`},
+		{Text: "select 1"},
+		{IsMetaCommand: true, Text: `\x on`},
+		{Text: "select 'a\n\\not a command'\n  from t"},
+		{Text: "select 2"},
+		{IsMetaCommand: true, Text: `\timing`},
+		{Text: "select 3"},
+	}
+	if entries := SplitScript(script); !reflect.DeepEqual(entries, expected) {
+		t.Fatalf("test failed: entries = %#v, expected %#v", entries, expected)
+	}
+}
@@ -0,0 +1,211 @@
+package metrics
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "stackql"
+
+	OutcomeSuccess string = "success"
+	OutcomeError   string = "error"
+
+	// StatementTypeOther covers statements
+	// not led by a recognised keyword.
+	StatementTypeOther string = "other"
+
+	httpStatusError string = "error"
+)
+
+// Metrics are always collected, being cheap,
+// and exposed only where a listener is configured.
+//
+//nolint:gochecknoglobals // process wide, per prometheus convention
+var (
+	registry = prometheus.NewRegistry()
+
+	activeSessions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "wire_sessions_active",
+		Help:      "Active wire protocol client connections.",
+	})
+	queries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "queries_total",
+		Help:      "Statements executed, by statement type and outcome.",
+	}, []string{"statement_type", "outcome"})
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "query_duration_seconds",
+		Help:      "Statement execution latency, by statement type.",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300},
+	}, []string{"statement_type"})
+	providerHTTPCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "provider_http_calls_total",
+		Help:      "Provider http calls, inclusive of retries, by provider and final status.",
+	}, []string{"provider", "status"})
+	paginationPages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pagination_pages_fetched_total",
+		Help:      "Pages of results fetched during resource acquisition, by provider.",
+	}, []string{"provider"})
+	gcRuns = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gc_runs_total",
+		Help:      "Garbage collection runs.",
+	})
+	gcRowsCollected = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gc_rows_collected_total",
+		Help:      "Rows deleted by garbage collection.",
+	})
+	analyticsCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "analytics_cache_lookups_total",
+		Help:      "Analytics cache lookups, by result: hit or miss.",
+	}, []string{"result"})
+
+	statementTypes = map[string]struct{}{
+		"select": {}, "insert": {}, "update": {}, "replace": {}, "delete": {}, "exec": {},
+		"show": {}, "describe": {}, "explain": {}, "use": {}, "set": {}, "auth": {},
+		"registry": {}, "purge": {}, "nop": {}, "create": {}, "drop": {}, "recover": {},
+		"begin": {}, "commit": {}, "rollback": {}, "savepoint": {}, "release": {},
+		"prepare": {}, "execute": {}, "deallocate": {},
+	}
+)
+
+//nolint:gochecknoinits // registration per prometheus convention
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		activeSessions,
+		queries,
+		queryDuration,
+		providerHTTPCalls,
+		paginationPages,
+		gcRuns,
+		gcRowsCollected,
+		analyticsCacheLookups,
+	)
+}
+
+// GetStatementType is the leading keyword of the statement, lower
+// cased, or else StatementTypeOther, so that cardinality is bounded.
+func GetStatementType(query string) string {
+	query = strings.TrimSpace(query)
+	// Skip leading comments, such as hints.
+	for strings.HasPrefix(query, "/*") {
+		end := strings.Index(query, "*/")
+		if end < 0 {
+			return StatementTypeOther
+		}
+		query = strings.TrimSpace(query[end+2:])
+	}
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return StatementTypeOther
+	}
+	keyword := strings.ToLower(fields[0])
+	if _, ok := statementTypes[keyword]; ok {
+		return keyword
+	}
+	return StatementTypeOther
+}
+
+func ObserveQuery(statementType string, isError bool, duration time.Duration) {
+	outcome := OutcomeSuccess
+	if isError {
+		outcome = OutcomeError
+	}
+	queries.WithLabelValues(statementType, outcome).Inc()
+	queryDuration.WithLabelValues(statementType).Observe(duration.Seconds())
+}
+
+// ObserveProviderHTTPCall labels transport failures, absent a response, as "error".
+func ObserveProviderHTTPCall(providerName string, response *http.Response) {
+	status := httpStatusError
+	if response != nil {
+		status = strconv.Itoa(response.StatusCode)
+	}
+	providerHTTPCalls.WithLabelValues(providerName, status).Inc()
+}
+
+func AddPaginationPage(providerName string) {
+	paginationPages.WithLabelValues(providerName).Inc()
+}
+
+func AddGCRun() {
+	gcRuns.Inc()
+}
+
+func AddGCRowsCollected(rowCount int64) {
+	gcRowsCollected.Add(float64(rowCount))
+}
+
+func ObserveAnalyticsCacheLookup(isHit bool) {
+	result := "miss"
+	if isHit {
+		result = "hit"
+	}
+	analyticsCacheLookups.WithLabelValues(result).Inc()
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ListenAndServe exposes metrics at `/metrics`; it blocks.
+func ListenAndServe(address string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	srv := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second, //nolint:mnd // ample for scrapes
+	}
+	return srv.ListenAndServe()
+}
+
+// NewSessionCountingListener gauges accepted
+// connections until each is closed.
+func NewSessionCountingListener(listener net.Listener) net.Listener {
+	return &sessionCountingListener{
+		Listener: listener,
+	}
+}
+
+type sessionCountingListener struct {
+	net.Listener
+}
+
+func (l *sessionCountingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	activeSessions.Inc()
+	return &sessionCountingConn{
+		Conn: conn,
+	}, nil
+}
+
+type sessionCountingConn struct {
+	net.Conn
+	closeOnce sync.Once
+}
+
+func (c *sessionCountingConn) Close() error {
+	c.closeOnce.Do(activeSessions.Dec)
+	return c.Conn.Close()
+}
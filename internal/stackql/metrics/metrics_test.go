@@ -0,0 +1,74 @@
+package metrics_test
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/stackql/stackql/internal/stackql/metrics"
+)
+
+func TestGetStatementType(t *testing.T) {
+	for query, expected := range map[string]string{
+		"  SELECT * FROM google.compute.instances":         "select",
+		"/*+ AWAIT */ exec google.compute.instances.start": "exec",
+		"insert into t select 1":                           "insert",
+		"frobnicate the widgets":                           StatementTypeOther,
+		"":                                                 StatementTypeOther,
+	} {
+		if actual := GetStatementType(query); actual != expected {
+			t.Fatalf("test failed: expected '%s' for '%s', got '%s'", expected, query, actual)
+		}
+	}
+}
+
+func scrape() string {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	b, _ := io.ReadAll(rec.Body)
+	return string(b)
+}
+
+func TestMetricsExposition(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	counted := NewSessionCountingListener(listener)
+	defer counted.Close()
+	go func() {
+		conn, _ := net.Dial("tcp", listener.Addr().String())
+		if conn != nil {
+			defer conn.Close()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}()
+	conn, err := counted.Accept()
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	ObserveQuery("select", false, 20*time.Millisecond)
+	ObserveProviderHTTPCall("google", &http.Response{StatusCode: http.StatusNotFound})
+	ObserveAnalyticsCacheLookup(true)
+	exposition := scrape()
+	for _, expected := range []string{
+		"stackql_wire_sessions_active 1",
+		`stackql_queries_total{outcome="success",statement_type="select"} 1`,
+		`stackql_query_duration_seconds_count{statement_type="select"} 1`,
+		`stackql_provider_http_calls_total{provider="google",status="404"} 1`,
+		`stackql_analytics_cache_lookups_total{result="hit"} 1`,
+	} {
+		if !strings.Contains(exposition, expected) {
+			t.Fatalf("test failed: '%s' absent from exposition:\n%s", expected, exposition)
+		}
+	}
+	conn.Close()
+	conn.Close()
+	if exposition = scrape(); !strings.Contains(exposition, "stackql_wire_sessions_active 0") {
+		t.Fatalf("test failed: session not released:\n%s", exposition)
+	}
+}
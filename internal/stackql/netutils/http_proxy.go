@@ -12,14 +12,19 @@ import (
 	"github.com/stackql/any-sdk/pkg/dto"
 )
 
+// GetRoundTripper returns a transport per the runtime context, rate limited
+// per any limiter supplied with the request context via NewRateLimiterContext.
 func GetRoundTripper(runtimeCtx dto.RuntimeCtx, existingTransport http.RoundTripper) http.RoundTripper {
-	return getRoundTripper(runtimeCtx, existingTransport)
+	return NewRateLimitedRoundTripper(getRoundTripper(runtimeCtx, existingTransport), nil)
 }
 
 func getRoundTripper(runtimeCtx dto.RuntimeCtx, existingTransport http.RoundTripper) http.RoundTripper {
 	var tr *http.Transport
 	var rt http.RoundTripper
 	if existingTransport != nil {
+		if limited, isLimited := existingTransport.(*rateLimitedRoundTripper); isLimited && limited.limiter == nil {
+			existingTransport = limited.inner
+		}
 		switch exTR := existingTransport.(type) {
 		case *http.Transport:
 			tr = exTR.Clone()
@@ -79,7 +84,7 @@ func getHTTPClient(runtimeCtx dto.RuntimeCtx, existingClient *http.Client) *http
 	}
 	return &http.Client{
 		Timeout:   time.Second * time.Duration(runtimeCtx.APIRequestTimeout),
-		Transport: getRoundTripper(runtimeCtx, rt),
+		Transport: GetRoundTripper(runtimeCtx, rt),
 	}
 }
 
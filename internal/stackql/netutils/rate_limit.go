@@ -0,0 +1,262 @@
+package netutils
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	rateLimitRemainingHeader = "X-RateLimit-Remaining"
+	rateLimitResetHeader     = "X-RateLimit-Reset"
+)
+
+// RateLimit is a sustained rate of requests per second,
+// permitting bursts of up to Burst requests.
+type RateLimit struct {
+	RequestsPerSecond float64
+	Burst             int
+}
+
+// TokenBucket admits requests at a sustained rate.
+// Waiters reserve tokens in arrival order, such that
+// the balance may go negative; a cancelled waiter
+// returns its token.  The rate is additionally tempered
+// by quota response headers, per Adapt.
+type TokenBucket struct {
+	mutex  sync.Mutex
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+	// Until adaptiveUntil, the rate is reduced
+	// so as to eke out the remaining quota.
+	adaptiveRate  float64
+	adaptiveUntil time.Time
+	// Until pausedUntil, no requests are admitted.
+	pausedUntil time.Time
+	now         func() time.Time
+}
+
+func NewTokenBucket(limit RateLimit) *TokenBucket {
+	if limit.Burst < 1 {
+		limit.Burst = max(1, int(math.Ceil(limit.RequestsPerSecond)))
+	}
+	return &TokenBucket{
+		limit:  limit,
+		tokens: float64(limit.Burst),
+		now:    time.Now,
+	}
+}
+
+func (b *TokenBucket) rate(now time.Time) float64 {
+	if now.Before(b.adaptiveUntil) && b.adaptiveRate < b.limit.RequestsPerSecond {
+		return b.adaptiveRate
+	}
+	return b.limit.RequestsPerSecond
+}
+
+// Reservation is a token taken from a bucket,
+// due after Delay.  Should the request be abandoned
+// before then, Cancel returns the token.
+type Reservation struct {
+	bucket *TokenBucket
+	delay  time.Duration
+}
+
+func (r *Reservation) Delay() time.Duration {
+	return r.delay
+}
+
+func (r *Reservation) Cancel() {
+	r.bucket.mutex.Lock()
+	defer r.bucket.mutex.Unlock()
+	r.bucket.tokens = min(float64(r.bucket.limit.Burst), r.bucket.tokens+1)
+}
+
+// Reserve takes a token, which is due after the
+// returned reservation's delay.
+func (b *TokenBucket) Reserve() *Reservation {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	now := b.now()
+	rate := b.rate(now)
+	if !b.last.IsZero() {
+		b.tokens = min(float64(b.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	}
+	b.last = now
+	b.tokens--
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / rate * float64(time.Second))
+	}
+	if paused := b.pausedUntil.Sub(now); paused > wait {
+		wait = paused
+	}
+	return &Reservation{bucket: b, delay: wait}
+}
+
+// Wait blocks until a request is admitted,
+// or else the context is done.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	return waitReservations(ctx, []*Reservation{b.Reserve()})
+}
+
+// waitReservations blocks until all reservations are due.  Should
+// the context be done first, every reservation is cancelled, so
+// that no bucket is left short of a token for an abandoned request.
+func waitReservations(ctx context.Context, reservations []*Reservation) error {
+	var wait time.Duration
+	for _, r := range reservations {
+		wait = max(wait, r.Delay())
+	}
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		for _, r := range reservations {
+			r.Cancel()
+		}
+		return context.Cause(ctx)
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Adapt tempers the rate per `X-RateLimit-Remaining` and
+// `X-RateLimit-Reset` response headers, the latter being
+// in epoch seconds.  An exhausted quota pauses admission until
+// reset; otherwise, the remaining quota is spread over the
+// interval to reset, should that be slower than the configured rate.
+func (b *TokenBucket) Adapt(header http.Header) {
+	remaining, remainingErr := strconv.Atoi(header.Get(rateLimitRemainingHeader))
+	resetEpoch, resetErr := strconv.ParseInt(header.Get(rateLimitResetHeader), 10, 64)
+	if remainingErr != nil || resetErr != nil || remaining < 0 {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	reset := time.Unix(resetEpoch, 0)
+	untilReset := reset.Sub(b.now())
+	if untilReset <= 0 {
+		return
+	}
+	if remaining == 0 {
+		b.pausedUntil = reset
+		return
+	}
+	b.adaptiveRate = float64(remaining) / untilReset.Seconds()
+	b.adaptiveUntil = reset
+}
+
+// ProviderRateLimiter holds the buckets applicable to
+// a provider's requests: one for the provider as a whole,
+// and one per configured host.  Either may be absent.
+type ProviderRateLimiter struct {
+	providerBucket *TokenBucket
+	hostBuckets    map[string]*TokenBucket
+}
+
+func NewProviderRateLimiter(providerLimit *RateLimit, hostLimits map[string]RateLimit) *ProviderRateLimiter {
+	rv := &ProviderRateLimiter{
+		hostBuckets: make(map[string]*TokenBucket, len(hostLimits)),
+	}
+	if providerLimit != nil {
+		rv.providerBucket = NewTokenBucket(*providerLimit)
+	}
+	for k, v := range hostLimits {
+		rv.hostBuckets[k] = NewTokenBucket(v)
+	}
+	return rv
+}
+
+func (l *ProviderRateLimiter) getBuckets(host string) []*TokenBucket {
+	var rv []*TokenBucket
+	if l.providerBucket != nil {
+		rv = append(rv, l.providerBucket)
+	}
+	if hostBucket, ok := l.hostBuckets[host]; ok {
+		rv = append(rv, hostBucket)
+	}
+	return rv
+}
+
+type (
+	rateLimiterContextKey struct{}
+	rateLimitAdmittedKey  struct{}
+)
+
+// NewRateLimiterContext supplies the limiter to round trippers
+// from GetRoundTripper, for requests bearing the context.
+func NewRateLimiterContext(ctx context.Context, limiter *ProviderRateLimiter) context.Context {
+	return context.WithValue(ctx, rateLimiterContextKey{}, limiter)
+}
+
+func rateLimiterFromContext(ctx context.Context) (*ProviderRateLimiter, bool) {
+	limiter, ok := ctx.Value(rateLimiterContextKey{}).(*ProviderRateLimiter)
+	return limiter, ok && limiter != nil
+}
+
+// rateLimitedRoundTripper admits requests per its limiter or,
+// absent one, that of the request context.  A request is admitted
+// once only, however many such round trippers it passes through.
+type rateLimitedRoundTripper struct {
+	inner   http.RoundTripper
+	limiter *ProviderRateLimiter
+}
+
+func (rt *rateLimitedRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	ctx := request.Context()
+	if ctx.Value(rateLimitAdmittedKey{}) != nil {
+		return rt.inner.RoundTrip(request)
+	}
+	limiter := rt.limiter
+	if limiter == nil {
+		var hasLimiter bool
+		if limiter, hasLimiter = rateLimiterFromContext(ctx); !hasLimiter {
+			return rt.inner.RoundTrip(request)
+		}
+	}
+	buckets := limiter.getBuckets(request.URL.Hostname())
+	reservations := make([]*Reservation, len(buckets))
+	for i, bucket := range buckets {
+		reservations[i] = bucket.Reserve()
+	}
+	if err := waitReservations(ctx, reservations); err != nil {
+		return nil, err
+	}
+	response, err := rt.inner.RoundTrip(request.WithContext(context.WithValue(ctx, rateLimitAdmittedKey{}, true)))
+	if response != nil {
+		for _, bucket := range buckets {
+			bucket.Adapt(response.Header)
+		}
+	}
+	return response, err
+}
+
+// NewRateLimitedRoundTripper wraps the round tripper such that requests
+// queue for admission per the limiter or, if nil, that supplied
+// via NewRateLimiterContext, respecting cancellation of the request context.
+func NewRateLimitedRoundTripper(inner http.RoundTripper, limiter *ProviderRateLimiter) http.RoundTripper {
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+	return &rateLimitedRoundTripper{
+		inner:   inner,
+		limiter: limiter,
+	}
+}
+
+// GetRateLimitedHTTPClient returns a shallow copy of the client,
+// with its transport rate limited per NewRateLimitedRoundTripper.
+// It serves clients whose transports are not from GetRoundTripper.
+func GetRateLimitedHTTPClient(existingClient *http.Client, limiter *ProviderRateLimiter) *http.Client {
+	rv := *existingClient
+	rv.Transport = NewRateLimitedRoundTripper(existingClient.Transport, limiter)
+	return &rv
+}
@@ -0,0 +1,132 @@
+package netutils_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stackql/any-sdk/pkg/dto"
+
+	. "github.com/stackql/stackql/internal/stackql/netutils"
+)
+
+func TestTokenBucketBurstThenRate(t *testing.T) {
+	bucket := NewTokenBucket(RateLimit{RequestsPerSecond: 20, Burst: 2})
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := bucket.Wait(context.Background()); err != nil {
+			t.Fatalf("test failed: %v", err)
+		}
+	}
+	// Two admitted immediately, two more at 50ms intervals.
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("test failed: rate not enforced, elapsed = %s", elapsed)
+	}
+}
+
+func TestTokenBucketWaitRespectsCancellation(t *testing.T) {
+	bucket := NewTokenBucket(RateLimit{RequestsPerSecond: 0.1, Burst: 1})
+	if err := bucket.Wait(context.Background()); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := bucket.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("test failed: expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestRateLimitedRoundTripperPausesOnExhaustedQuota(t *testing.T) {
+	reset := time.Now().Add(2 * time.Second).Unix()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+	}))
+	defer srv.Close()
+	limiter := NewProviderRateLimiter(&RateLimit{RequestsPerSecond: 100, Burst: 10}, nil)
+	client := GetRateLimitedHTTPClient(srv.Client(), limiter)
+	response, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	response.Body.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err = client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("test failed: expected request to queue until reset, got %v", err)
+	}
+}
+
+func TestCancelledHostWaitRefundsProviderToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	limiter := NewProviderRateLimiter(
+		&RateLimit{RequestsPerSecond: 0.1, Burst: 2},
+		map[string]RateLimit{u.Hostname(): {RequestsPerSecond: 0.1, Burst: 1}},
+	)
+	client := GetRateLimitedHTTPClient(srv.Client(), limiter)
+	get := func(rawURL string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		response, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		return response.Body.Close()
+	}
+	if err := get(srv.URL); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	// The provider bucket admits this request, but the host bucket does not.
+	if err := get(srv.URL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("test failed: expected deadline exceeded, got %v", err)
+	}
+	// Another host, subject to the provider bucket alone, takes the refunded token.
+	if err := get(fmt.Sprintf("http://localhost:%s", u.Port())); err != nil {
+		t.Fatalf("test failed: provider token of abandoned request not refunded: %v", err)
+	}
+}
+
+func TestGetRoundTripperLimitsPerRequestContext(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		calls.Add(1)
+	}))
+	defer srv.Close()
+	limiter := NewProviderRateLimiter(&RateLimit{RequestsPerSecond: 0.1, Burst: 1}, nil)
+	// As per a provider client, itself wrapped by the http middleware.
+	client := GetRateLimitedHTTPClient(&http.Client{Transport: GetRoundTripper(dto.RuntimeCtx{}, nil)}, nil)
+	ctx, cancel := context.WithTimeout(NewRateLimiterContext(context.Background(), limiter), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	response, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("test failed: request admitted more than once, %v", err)
+	}
+	response.Body.Close()
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err = client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("test failed: expected request to queue, got %v", err)
+	}
+	// Requests without a limiter are unaffected.
+	for i := 0; i < 3; i++ {
+		response, err = client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("test failed: %v", err)
+		}
+		response.Body.Close()
+	}
+	if calls.Load() != 4 {
+		t.Fatalf("test failed: expected 4 calls, got %d", calls.Load())
+	}
+}
@@ -0,0 +1,52 @@
+package netutils
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	RedactedValue string = "REDACTED"
+)
+
+//nolint:gochecknoglobals // constant lookup
+var credentialHeaders = map[string]struct{}{
+	"Authorization":        {},
+	"Proxy-Authorization":  {},
+	"Cookie":               {},
+	"Set-Cookie":           {},
+	"X-Amz-Security-Token": {},
+}
+
+// IsCredentialHeader is true for well known credential headers,
+// and for those named like tokens, secrets or API keys.
+func IsCredentialHeader(name string) bool {
+	canonical := http.CanonicalHeaderKey(name)
+	if _, ok := credentialHeaders[canonical]; ok {
+		return true
+	}
+	lower := strings.ToLower(canonical)
+	return strings.Contains(lower, "token") ||
+		strings.Contains(lower, "secret") ||
+		strings.Contains(lower, "api-key") ||
+		strings.Contains(lower, "apikey")
+}
+
+// RedactHeaders returns a copy of the headers, with credential
+// headers and those additionally named, case insensitively, redacted.
+func RedactHeaders(header http.Header, additional ...string) http.Header {
+	rv := header.Clone()
+	for k := range rv {
+		if IsCredentialHeader(k) {
+			rv[k] = []string{RedactedValue}
+			continue
+		}
+		for _, name := range additional {
+			if strings.EqualFold(k, name) {
+				rv[k] = []string{RedactedValue}
+				break
+			}
+		}
+	}
+	return rv
+}
@@ -0,0 +1,81 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stackql/psql-wire/pkg/sqldata"
+)
+
+// ExpandedStr is the output format in which each record is written
+// vertically, one column per line, as per psql expanded display.
+const ExpandedStr string = "expanded"
+
+type ExpandedWriter struct {
+	AbstractTabularWriter
+	writer    io.Writer
+	errWriter io.Writer
+}
+
+func (ew *ExpandedWriter) Write(res sqldata.ISQLResultStream) error {
+	var recordCount int
+	for {
+		r, err := res.Read()
+		isEOF := errors.Is(err, io.EOF)
+		if err != nil && !isEOF {
+			return err
+		}
+		if r != nil {
+			rowsArr, tabulateErr := tabulateResults(r, ew.ci)
+			if tabulateErr != nil {
+				return tabulateErr
+			}
+			header := ew.getHeader(r)
+			for _, row := range rowsArr {
+				recordCount++
+				ew.writeRecord(recordCount, header, row)
+			}
+		}
+		if isEOF {
+			if recordCount == 0 {
+				fmt.Fprintln(ew.writer, "(0 rows)") //nolint:errcheck // output stream is not critical
+			}
+			return nil
+		}
+	}
+}
+
+func (ew *ExpandedWriter) writeRecord(recordNumber int, header []string, row []string) {
+	var nameWidth, valueWidth int
+	for i, name := range header {
+		nameWidth = max(nameWidth, len(name))
+		if i < len(row) {
+			valueWidth = max(valueWidth, len(row[i]))
+		}
+	}
+	recordHeader := fmt.Sprintf("-[ RECORD %d ]", recordNumber)
+	// The separator is marked where the record header leaves room.
+	if len(recordHeader) <= nameWidth+1 {
+		recordHeader += strings.Repeat("-", nameWidth+1-len(recordHeader)) + "+" + strings.Repeat("-", valueWidth+1)
+	} else {
+		recordHeader += strings.Repeat("-", max(0, nameWidth+valueWidth+3-len(recordHeader)))
+	}
+	fmt.Fprintln(ew.writer, recordHeader) //nolint:errcheck // output stream is not critical
+	for i, name := range header {
+		var value string
+		if i < len(row) {
+			value = row[i]
+		}
+		fmt.Fprintf(ew.writer, "%-*s | %s\n", nameWidth, name, value) //nolint:errcheck // output stream is not critical
+	}
+}
+
+func (ew *ExpandedWriter) WriteError(err error, errorPresentation string) error {
+	if errorPresentation == stderrPressentationStr {
+		return writeStderrError(ew.errWriter, err)
+	}
+	ew.writeRecord(1, []string{errorKey}, []string{err.Error()})
+	return nil
+}
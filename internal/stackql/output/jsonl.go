@@ -0,0 +1,60 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/stackql/psql-wire/pkg/sqldata"
+)
+
+// JSONLinesStr is the output format in which each row
+// is written as a single JSON object on its own line.
+const JSONLinesStr string = "jsonl"
+
+type JSONLinesWriter struct {
+	AbstractTabularWriter
+	writer    io.Writer
+	errWriter io.Writer
+}
+
+// Write writes each chunk of the result as it arrives,
+// so that consumers such as jq need not await the whole result.
+func (jlw *JSONLinesWriter) Write(res sqldata.ISQLResultStream) error {
+	for {
+		r, err := res.Read()
+		isEOF := errors.Is(err, io.EOF)
+		if err != nil && !isEOF {
+			return err
+		}
+		if r != nil {
+			for _, row := range resToArr(r) {
+				if writeErr := jlw.writeLine(row); writeErr != nil {
+					return writeErr
+				}
+			}
+		}
+		if isEOF {
+			return nil
+		}
+	}
+}
+
+func (jlw *JSONLinesWriter) writeLine(row map[string]interface{}) error {
+	for k, v := range row {
+		row[k] = naiveValue(v)
+	}
+	jsonBytes, jsonErr := json.Marshal(row)
+	if jsonErr != nil {
+		return jsonErr
+	}
+	_, writeErr := jlw.writer.Write(append(jsonBytes, '\n'))
+	return writeErr
+}
+
+func (jlw *JSONLinesWriter) WriteError(err error, errorPresentation string) error {
+	if errorPresentation == stderrPressentationStr {
+		return writeStderrError(jlw.errWriter, err)
+	}
+	return jlw.writeLine(map[string]interface{}{errorKey: err.Error()})
+}
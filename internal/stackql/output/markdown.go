@@ -0,0 +1,82 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stackql/psql-wire/pkg/sqldata"
+)
+
+// MarkdownStr is the output format in which the
+// result is written as a GitHub flavoured markdown table.
+const MarkdownStr string = "markdown"
+
+//nolint:gochecknoglobals // replacer is immutable
+var markdownCellReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	"|", `\|`,
+	"\r\n", "<br>",
+	"\n", "<br>",
+	"\r", "<br>",
+)
+
+type MarkdownWriter struct {
+	AbstractTabularWriter
+	writer    io.Writer
+	errWriter io.Writer
+}
+
+func (mw *MarkdownWriter) Write(res sqldata.ISQLResultStream) error {
+	var isHeaderWritten bool
+	for {
+		r, err := res.Read()
+		isEOF := errors.Is(err, io.EOF)
+		if err != nil && !isEOF {
+			return err
+		}
+		if r != nil {
+			if !isHeaderWritten && len(r.GetColumns()) > 0 {
+				mw.writeHeader(mw.getHeader(r))
+				isHeaderWritten = true
+			}
+			rowsArr, tabulateErr := tabulateResults(r, mw.ci)
+			if tabulateErr != nil {
+				return tabulateErr
+			}
+			for _, row := range rowsArr {
+				mw.writeRow(row)
+			}
+		}
+		if isEOF {
+			return nil
+		}
+	}
+}
+
+func (mw *MarkdownWriter) writeHeader(header []string) {
+	mw.writeRow(header)
+	separator := make([]string, len(header))
+	for i := range separator {
+		separator[i] = "---"
+	}
+	mw.writeRow(separator)
+}
+
+func (mw *MarkdownWriter) writeRow(row []string) {
+	cells := make([]string, len(row))
+	for i, c := range row {
+		cells[i] = markdownCellReplacer.Replace(c)
+	}
+	fmt.Fprintf(mw.writer, "| %s |\n", strings.Join(cells, " | ")) //nolint:errcheck // output stream is not critical
+}
+
+func (mw *MarkdownWriter) WriteError(err error, errorPresentation string) error {
+	if errorPresentation == stderrPressentationStr {
+		return writeStderrError(mw.errWriter, err)
+	}
+	mw.writeHeader([]string{errorKey})
+	mw.writeRow([]string{err.Error()})
+	return nil
+}
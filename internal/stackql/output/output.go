@@ -1,6 +1,7 @@
 package output
 
 import (
+	"database/sql/driver"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
@@ -105,6 +106,46 @@ func GetOutputWriter(
 			errWriter,
 		}
 		return &prettyWriter, nil
+	case ExpandedStr:
+		expandedWriter := ExpandedWriter{
+			AbstractTabularWriter{
+				ci:        ci,
+				outputCtx: outputCtx,
+			},
+			writer,
+			errWriter,
+		}
+		return &expandedWriter, nil
+	case JSONLinesStr:
+		jsonLinesWriter := JSONLinesWriter{
+			AbstractTabularWriter{
+				ci:        ci,
+				outputCtx: outputCtx,
+			},
+			writer,
+			errWriter,
+		}
+		return &jsonLinesWriter, nil
+	case YAMLStr:
+		yamlWriter := YAMLWriter{
+			AbstractTabularWriter{
+				ci:        ci,
+				outputCtx: outputCtx,
+			},
+			writer,
+			errWriter,
+		}
+		return &yamlWriter, nil
+	case MarkdownStr:
+		markdownWriter := MarkdownWriter{
+			AbstractTabularWriter{
+				ci:        ci,
+				outputCtx: outputCtx,
+			},
+			writer,
+			errWriter,
+		}
+		return &markdownWriter, nil
 	}
 	return nil, fmt.Errorf(
 		"unable to create output writer for output format = '%s'",
@@ -172,21 +213,58 @@ func resToArr(res sqldata.ISQLResult) []map[string]interface{} {
 	return retVal
 }
 
+// naiveValue unwraps values such as sql.NullString,
+// which would otherwise be written as structs.
+func naiveValue(v interface{}) interface{} {
+	if valuer, isValuer := v.(driver.Valuer); isValuer {
+		if rv, err := valuer.Value(); err == nil {
+			v = rv
+		}
+	}
+	if b, isBytes := v.([]byte); isBytes {
+		return string(b)
+	}
+	return v
+}
+
+// writeRowsFromResult writes a single JSON array.  Where the result
+// arrives in several chunks, the array is written incrementally.
 func (jw *JSONWriter) writeRowsFromResult(res sqldata.ISQLResultStream) error {
+	var rowsWritten int
 	for {
 		r, err := res.Read()
 		logging.GetLogger().Debugln(fmt.Sprintf("result from stream: %v", r))
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				rowsArr := resToArr(r)
-				jw.writeRows(rowsArr) //nolint:errcheck // output stream is not critical
-				return nil
-			}
+		isEOF := errors.Is(err, io.EOF)
+		if err != nil && !isEOF {
 			return err
 		}
 		rowsArr := resToArr(r)
-		jw.writeRows(rowsArr) //nolint:errcheck // output stream is not critical
+		if isEOF && rowsWritten == 0 {
+			jw.writeRows(rowsArr) //nolint:errcheck // output stream is not critical
+			return nil
+		}
+		for _, row := range rowsArr {
+			delimiter := ","
+			if rowsWritten == 0 {
+				delimiter = "["
+			}
+			jw.writeRowElement(delimiter, row) //nolint:errcheck // output stream is not critical
+			rowsWritten++
+		}
+		if isEOF {
+			_, writeErr := jw.writer.Write([]byte("]"))
+			return writeErr
+		}
+	}
+}
+
+func (jw *JSONWriter) writeRowElement(delimiter string, row map[string]interface{}) error {
+	jsonBytes, jsonErr := json.Marshal(row)
+	if jsonErr != nil {
+		return jsonErr
 	}
+	_, writeErr := jw.writer.Write(append([]byte(delimiter), jsonBytes...))
+	return writeErr
 }
 
 func (jw *JSONWriter) writeRows(rows []map[string]interface{}) error {
@@ -366,6 +444,7 @@ func (csvw *CSVWriter) Write(res sqldata.ISQLResultStream) error {
 		for _, rs := range rowsArr {
 			w.Write(rs) //nolint:errcheck // output stream is not critical
 		}
+		w.Flush()
 	}
 }
 
@@ -0,0 +1,181 @@
+package output_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/lib/pq/oid"
+	"github.com/stackql/any-sdk/pkg/constants"
+	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/psql-wire/pkg/sqldata"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+
+	. "github.com/stackql/stackql/internal/stackql/output"
+)
+
+// chunkedResultStream mimics a result streamed in
+// batches, the last of which accompanies io.EOF.
+type chunkedResultStream struct {
+	chunks []sqldata.ISQLResult
+}
+
+func (rs *chunkedResultStream) Read() (sqldata.ISQLResult, error) {
+	rv := rs.chunks[0]
+	rs.chunks = rs.chunks[1:]
+	if len(rs.chunks) == 0 {
+		return rv, io.EOF
+	}
+	return rv, nil
+}
+
+func (rs *chunkedResultStream) Write(sqldata.ISQLResult) error { return nil }
+
+func (rs *chunkedResultStream) Close() error { return nil }
+
+func newChunkedResultStream(chunkedNames ...[]string) sqldata.ISQLResultStream {
+	columns := []sqldata.ISQLColumn{
+		sqldata.NewSQLColumn(sqldata.NewSQLTable(0, "meta_table"), "name", 0, uint32(oid.T_text), 1024, 0, "TextFormat"),
+	}
+	rv := &chunkedResultStream{}
+	for _, names := range chunkedNames {
+		var rows []sqldata.ISQLRow
+		for _, name := range names {
+			rows = append(rows, sqldata.NewSQLRow([]interface{}{[]byte(name)}))
+		}
+		rv.chunks = append(rv.chunks, sqldata.NewSQLResult(columns, 0, 0, rows))
+	}
+	return rv
+}
+
+func writeResult(t *testing.T, outputFormat string, res sqldata.ISQLResultStream) string {
+	var buf bytes.Buffer
+	outputWriter, err := GetOutputWriter(
+		&buf,
+		&buf,
+		internaldto.OutputContext{
+			RuntimeContext: dto.RuntimeCtx{OutputFormat: outputFormat, Delimiter: ","},
+			Result:         res,
+		},
+	)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if err = outputWriter.Write(res); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	return buf.String()
+}
+
+func TestJSONWriterChunkedResult(t *testing.T) {
+	out := writeResult(t, constants.JSONStr, newChunkedResultStream([]string{"a", "b"}, nil, []string{"c"}))
+	var rows []map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("test failed: invalid JSON '%s': %v", out, err)
+	}
+	if len(rows) != 3 || rows[2]["name"] != "c" {
+		t.Fatalf("test failed: unexpected rows %v", rows)
+	}
+}
+
+func TestJSONWriterChunkedResultEmptyFinalChunk(t *testing.T) {
+	out := writeResult(t, constants.JSONStr, newChunkedResultStream([]string{"a"}, nil))
+	if out != `[{"name":"a"}]` {
+		t.Fatalf("test failed: unexpected output '%s'", out)
+	}
+}
+
+func TestCSVWriterChunkedResult(t *testing.T) {
+	out := writeResult(t, constants.CSVStr, newChunkedResultStream([]string{"a"}, []string{"b"}))
+	if out != "name\na\nb\n" {
+		t.Fatalf("test failed: unexpected output '%s'", out)
+	}
+}
+
+func TestExpandedWriterChunkedResult(t *testing.T) {
+	out := writeResult(t, ExpandedStr, newChunkedResultStream([]string{"a"}, []string{"longer name"}))
+	expected := "-[ RECORD 1 ]\nname | a\n-[ RECORD 2 ]-----\nname | longer name\n"
+	if out != expected {
+		t.Fatalf("test failed: unexpected output '%s'", out)
+	}
+}
+
+func TestExpandedWriterEmptyResult(t *testing.T) {
+	out := writeResult(t, ExpandedStr, newChunkedResultStream(nil))
+	if out != "(0 rows)\n" {
+		t.Fatalf("test failed: unexpected output '%s'", out)
+	}
+}
+
+func TestJSONLinesWriterChunkedResult(t *testing.T) {
+	out := writeResult(t, JSONLinesStr, newChunkedResultStream([]string{"a", "b"}, nil, []string{"c"}))
+	if out != "{\"name\":\"a\"}\n{\"name\":\"b\"}\n{\"name\":\"c\"}\n" {
+		t.Fatalf("test failed: unexpected output '%s'", out)
+	}
+}
+
+func TestYAMLWriterChunkedResult(t *testing.T) {
+	out := writeResult(t, YAMLStr, newChunkedResultStream([]string{"a"}, []string{`{"zone": "z1", "tags": ["x"]}`}))
+	expected := "- name: a\n- name:\n    tags:\n    - x\n    zone: z1\n"
+	if out != expected {
+		t.Fatalf("test failed: unexpected output '%s'", out)
+	}
+}
+
+func TestYAMLWriterLargeIntegers(t *testing.T) {
+	out := writeResult(t, YAMLStr, newChunkedResultStream(
+		[]string{`{"size": 10000000, "id": 1234567890123456789, "big": 18446744073709551615, "ratio": 0.5}`}))
+	expected := "- name:\n    big: 18446744073709551615\n    id: 1234567890123456789\n    ratio: 0.5\n    size: 10000000\n"
+	if out != expected {
+		t.Fatalf("test failed: unexpected output '%s'", out)
+	}
+}
+
+func TestYAMLWriterEmptyResult(t *testing.T) {
+	out := writeResult(t, YAMLStr, newChunkedResultStream(nil))
+	if out != "[]\n" {
+		t.Fatalf("test failed: unexpected output '%s'", out)
+	}
+}
+
+func TestMarkdownWriterChunkedResult(t *testing.T) {
+	out := writeResult(t, MarkdownStr, newChunkedResultStream([]string{"a|b"}, []string{"c\nd"}))
+	expected := "| name |\n| --- |\n| a\\|b |\n| c<br>d |\n"
+	if out != expected {
+		t.Fatalf("test failed: unexpected output '%s'", out)
+	}
+}
+
+func TestWriteErrorPresentation(t *testing.T) {
+	testCases := []struct {
+		outputFormat string
+		expected     string
+	}{
+		{JSONLinesStr, "{\"error\":\"broken\"}\n"},
+		{YAMLStr, "- error: broken\n"},
+		{MarkdownStr, "| error |\n| --- |\n| broken |\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.outputFormat, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			outputWriter, err := GetOutputWriter(
+				&out,
+				&errOut,
+				internaldto.OutputContext{RuntimeContext: dto.RuntimeCtx{OutputFormat: tc.outputFormat}},
+			)
+			if err != nil {
+				t.Fatalf("test failed: %v", err)
+			}
+			outputWriter.WriteError(errors.New("broken"), "") //nolint:errcheck // checked via output
+			if out.String() != tc.expected {
+				t.Fatalf("test failed: unexpected output '%s'", out.String())
+			}
+			outputWriter.WriteError(errors.New("broken"), "stderr") //nolint:errcheck // checked via output
+			if errOut.String() != "broken\n" {
+				t.Fatalf("test failed: unexpected error output '%s'", errOut.String())
+			}
+		})
+	}
+}
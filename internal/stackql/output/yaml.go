@@ -0,0 +1,150 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/stackql/psql-wire/pkg/sqldata"
+	"gopkg.in/yaml.v2"
+)
+
+// YAMLStr is the output format in which the result is written as
+// a YAML sequence of rows, with JSON valued columns as nested objects.
+const YAMLStr string = "yaml"
+
+type YAMLWriter struct {
+	AbstractTabularWriter
+	writer    io.Writer
+	errWriter io.Writer
+}
+
+func (yw *YAMLWriter) Write(res sqldata.ISQLResultStream) error {
+	var rowsWritten int
+	for {
+		r, err := res.Read()
+		isEOF := errors.Is(err, io.EOF)
+		if err != nil && !isEOF {
+			return err
+		}
+		if r != nil {
+			rows := resToYAMLRows(r)
+			if writeErr := yw.writeRows(rows); writeErr != nil {
+				return writeErr
+			}
+			rowsWritten += len(rows)
+		}
+		if isEOF {
+			if rowsWritten == 0 {
+				_, writeErr := io.WriteString(yw.writer, "[]\n")
+				return writeErr
+			}
+			return nil
+		}
+	}
+}
+
+// writeRows writes rows as sequence items, which concatenate
+// across chunks into a single sequence.
+func (yw *YAMLWriter) writeRows(rows []yaml.MapSlice) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	yamlBytes, yamlErr := yaml.Marshal(rows)
+	if yamlErr != nil {
+		return yamlErr
+	}
+	_, writeErr := yw.writer.Write(yamlBytes)
+	return writeErr
+}
+
+// resToYAMLRows retains column order, unlike resToArr.
+func resToYAMLRows(res sqldata.ISQLResult) []yaml.MapSlice {
+	colz := res.GetColumns()
+	var retVal []yaml.MapSlice
+	for _, r := range res.GetRows() {
+		rowArr := r.GetRowDataNaive()
+		if len(rowArr) == 0 {
+			continue
+		}
+		row := make(yaml.MapSlice, 0, len(colz))
+		for i, col := range colz {
+			var v interface{}
+			if i < len(rowArr) {
+				v = rowArr[i]
+			}
+			v = naiveValue(v)
+			if s, isString := v.(string); isString {
+				v = expandJSONString(s)
+			}
+			row = append(row, yaml.MapItem{Key: col.GetName(), Value: v})
+		}
+		retVal = append(retVal, row)
+	}
+	return retVal
+}
+
+// expandJSONString decodes a JSON object or array, such as a
+// provider's nested response object, and otherwise returns s as is.
+// Numbers are decoded as per numberValue, lest large integers,
+// such as identifiers, be presented in exponent form.
+func expandJSONString(s string) interface{} {
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return s
+	}
+	decoder := json.NewDecoder(strings.NewReader(trimmed))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return s
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return s
+	}
+	return convertJSONNumbers(v)
+}
+
+func convertJSONNumbers(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = convertJSONNumbers(item)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = convertJSONNumbers(item)
+		}
+		return val
+	case json.Number:
+		return numberValue(val)
+	default:
+		return v
+	}
+}
+
+// numberValue returns integers as int64 or, beyond
+// its range, uint64, and all else as float64.
+func numberValue(n json.Number) interface{} {
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	if u, err := strconv.ParseUint(n.String(), 10, 64); err == nil {
+		return u
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return n.String()
+	}
+	return f
+}
+
+func (yw *YAMLWriter) WriteError(err error, errorPresentation string) error {
+	if errorPresentation == stderrPressentationStr {
+		return writeStderrError(yw.errWriter, err)
+	}
+	return yw.writeRows([]yaml.MapSlice{{{Key: errorKey, Value: err.Error()}}})
+}
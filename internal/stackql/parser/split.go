@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+)
+
+//nolint:gochecknoglobals // regexp
+var dollarQuoteTagRegexp = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+// SplitStatements splits a script into its statements, sans terminating
+// semicolons.  Semicolons within quotes, dollar quoted strings and
+// comments do not delimit statements.  Statements bearing only
+// whitespace and comments are omitted.
+func SplitStatements(script string) []string {
+	statements, remainder, isRemainderStatement := splitStatements(script)
+	if isRemainderStatement {
+		statements = append(statements, strings.TrimSpace(remainder))
+	}
+	return statements
+}
+
+// SplitTerminatedStatements is as SplitStatements, save that the
+// remainder following the final semicolon, such as a partially entered
+// statement or an unclosed quote, is returned verbatim and separately.
+func SplitTerminatedStatements(script string) ([]string, string) {
+	statements, remainder, _ := splitStatements(script)
+	return statements, remainder
+}
+
+// splitStatements scans the script with the stackql lexer.  Backtick and
+// dollar quoted strings, which the lexer does not support, are skipped
+// over, and then lexing resumes afresh.
+//
+//nolint:gocognit // a single scan is clearer
+func splitStatements(script string) ([]string, string, bool) {
+	var statements []string
+	stmtBegin := 0
+	isStatement := false
+	offset := 0
+	tokenizer := newSplitTokenizer(script)
+	for {
+		typ, val := tokenizer.Scan()
+		// The tokenizer has read one character beyond the token.
+		end := offset + tokenizer.Position - 1
+		switch {
+		case typ == 0:
+			return statements, script[stmtBegin:], isStatement
+		case typ == ';':
+			if isStatement {
+				statements = append(statements, strings.TrimSpace(script[stmtBegin:end-1]))
+			}
+			stmtBegin = end
+			isStatement = false
+			continue
+		case typ == sqlparser.COMMENT:
+			continue
+		}
+		isStatement = true
+		var quoteEnd int
+		switch {
+		case typ == sqlparser.LEX_ERROR && string(val) == "`":
+			quoteEnd = closingQuoteEnd(script, end, "`")
+		case typ == sqlparser.ID && strings.HasPrefix(string(val), "$"):
+			start := end - len(val)
+			tag := dollarQuoteTagRegexp.FindString(script[start:])
+			if tag == "" {
+				continue
+			}
+			quoteEnd = closingQuoteEnd(script, start+len(tag), tag)
+		default:
+			continue
+		}
+		if quoteEnd < 0 {
+			return statements, script[stmtBegin:], isStatement
+		}
+		offset = quoteEnd
+		tokenizer = newSplitTokenizer(script[offset:])
+	}
+}
+
+func newSplitTokenizer(s string) *sqlparser.Tokenizer {
+	tokenizer := sqlparser.NewStringTokenizer(s)
+	// Otherwise, '/*! ... */' is lexed as statement text.
+	tokenizer.SkipSpecialComments = true
+	return tokenizer
+}
+
+// closingQuoteEnd returns the index following the closing
+// quote, or -1 where the quote is unclosed.
+func closingQuoteEnd(script string, from int, quote string) int {
+	idx := strings.Index(script[from:], quote)
+	if idx < 0 {
+		return -1
+	}
+	return from + idx + len(quote)
+}
@@ -0,0 +1,80 @@
+package parser_test
+
+import (
+	"testing"
+
+	. "github.com/stackql/stackql/internal/stackql/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitStatements(t *testing.T) {
+	testCases := []struct {
+		name     string
+		script   string
+		expected []string
+	}{
+		{
+			name:     "Simple statements",
+			script:   "select 1; select 2;\n",
+			expected: []string{"select 1", "select 2"},
+		},
+		{
+			name:     "Unterminated final statement",
+			script:   "select 1;\n select 2",
+			expected: []string{"select 1", "select 2"},
+		},
+		{
+			name:     "Single quoted JSON",
+			script:   `insert into t(data__body) select '{"a": "x;y", "b": "--z"}'; select 2;`,
+			expected: []string{`insert into t(data__body) select '{"a": "x;y", "b": "--z"}'`, "select 2"},
+		},
+		{
+			name:     "Escaped single quotes",
+			script:   `select 'it''s; \'fine\';'; select 2`,
+			expected: []string{`select 'it''s; \'fine\';'`, "select 2"},
+		},
+		{
+			name:     "Double and back quotes",
+			script:   "select \"a;b\", `c;d` from t; select 2",
+			expected: []string{"select \"a;b\", `c;d` from t", "select 2"},
+		},
+		{
+			name:     "Dollar quotes",
+			script:   "select $$a; 'b$$; select $tag$ $$; $tag$; select $1;",
+			expected: []string{"select $$a; 'b$$", "select $tag$ $$; $tag$", "select $1"},
+		},
+		{
+			name:     "Comments",
+			script:   "-- leading; comment\nselect 1; /* block; comment */ select 2 -- trailing; comment\n;",
+			expected: []string{"-- leading; comment\nselect 1", "/* block; comment */ select 2 -- trailing; comment"},
+		},
+		{
+			name:     "Comment directives",
+			script:   "/*+ AWAIT */ insert into t select 1; /*! special; */ select 2;",
+			expected: []string{"/*+ AWAIT */ insert into t select 1", "/*! special; */ select 2"},
+		},
+		{
+			name:     "Blank and comment only statements",
+			script:   " ; ;\n-- only a comment\n",
+			expected: nil,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, SplitStatements(tc.script))
+		})
+	}
+}
+
+func TestSplitTerminatedStatements(t *testing.T) {
+	t.Run("Partial statement", func(t *testing.T) {
+		statements, remainder := SplitTerminatedStatements("select 1; /*+ AWAIT */\ninsert into t select ';")
+		assert.Equal(t, []string{"select 1"}, statements)
+		assert.Equal(t, " /*+ AWAIT */\ninsert into t select ';", remainder)
+	})
+	t.Run("Completed statement", func(t *testing.T) {
+		statements, remainder := SplitTerminatedStatements(" /*+ AWAIT */\ninsert into t select ';';\n")
+		assert.Equal(t, []string{"/*+ AWAIT */\ninsert into t select ';'"}, statements)
+		assert.Equal(t, "\n", remainder)
+	})
+}
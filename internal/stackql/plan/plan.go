@@ -5,6 +5,7 @@ import (
 
 	"github.com/stackql/stackql/internal/stackql/acid/binlog"
 	"github.com/stackql/stackql/internal/stackql/primitivegraph"
+	"github.com/stackql/stackql/internal/stackql/typing"
 
 	"github.com/stackql/stackql-parser/go/vt/sqlparser"
 )
@@ -21,6 +22,9 @@ type Plan interface {
 	GetOriginal() string
 	GetInstructions() primitivegraph.PrimitiveGraphHolder
 	GetBindVarNeeds() sqlparser.BindVarNeeds
+	// The columns of the result, where known while
+	// planning, as they are of SELECT statements.
+	GetColumns() ([]typing.ColumnMetadata, bool)
 
 	// Signals whether the plan is worthy to place in `cache.LRUCache`.
 	IsCacheable() bool
@@ -36,6 +40,7 @@ type Plan interface {
 	SetOriginal(original string)
 	SetInstructions(instructions primitivegraph.PrimitiveGraphHolder)
 	SetBindVarNeeds(bindVarNeeds sqlparser.BindVarNeeds)
+	SetColumns([]typing.ColumnMetadata)
 	SetCacheable(isCacheable bool)
 	SetTxnID(txnID int)
 
@@ -66,6 +71,7 @@ type standardPlan struct {
 	Errors       uint64        // Total number of errors
 	isCacheable  bool
 	isReadOnly   bool
+	columns      []typing.ColumnMetadata
 }
 
 func NewPlan(
@@ -136,6 +142,14 @@ func (p *standardPlan) GetBindVarNeeds() sqlparser.BindVarNeeds {
 	return p.BindVarNeeds
 }
 
+func (p *standardPlan) GetColumns() ([]typing.ColumnMetadata, bool) {
+	return p.columns, len(p.columns) > 0
+}
+
+func (p *standardPlan) SetColumns(columns []typing.ColumnMetadata) {
+	p.columns = columns
+}
+
 func (p *standardPlan) SetType(t sqlparser.StatementType) {
 	p.Type = t
 }
@@ -0,0 +1,60 @@
+package planbuilder
+
+import (
+	"strings"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"github.com/stackql/stackql/internal/stackql/handler"
+)
+
+// authoriseStatement applies any user policy to mutating statements,
+// and to those which act upon the server as a whole.  Resource and
+// table scope are enforced as the statement heirarchy is inferred,
+// so that both occur before any provider API is called; NATIVEQUERY
+// passes its query straight to the backend, and so bypasses both.
+func authoriseStatement(handlerCtx handler.HandlerContext, statement sqlparser.Statement) error {
+	policy := handlerCtx.GetAuthorisationPolicy()
+	if policy == nil {
+		return nil
+	}
+	switch stmt := statement.(type) {
+	case *sqlparser.Insert:
+		return policy.AuthoriseMutation("INSERT")
+	case *sqlparser.Update:
+		return policy.AuthoriseMutation("UPDATE")
+	case *sqlparser.Delete:
+		return policy.AuthoriseMutation("DELETE")
+	case *sqlparser.Exec:
+		return policy.AuthoriseMutation("EXEC")
+	case *sqlparser.NativeQuery:
+		return policy.AuthoriseAdministration("NATIVEQUERY")
+	case *sqlparser.DDL, *sqlparser.DBDDL:
+		return policy.AuthoriseAdministration("DDL")
+	case *sqlparser.Purge:
+		return policy.AuthoriseAdministration("PURGE")
+	case *sqlparser.Registry:
+		// Listing is harmless, whereas pulling alters
+		// the providers available to every user.
+		if strings.EqualFold(stmt.ActionType, "list") {
+			return nil
+		}
+		return policy.AuthoriseAdministration("REGISTRY " + strings.ToUpper(stmt.ActionType))
+	default:
+		return nil
+	}
+}
+
+// getPlanCacheKey segregates cached plans by policy, since
+// a cached plan has already passed authorisation.  Plans are
+// not shared with sessions holding their own credentials,
+// since plans capture the handler context of their session.
+func getPlanCacheKey(handlerCtx handler.HandlerContext) (string, bool) {
+	if handlerCtx.HasSessionAuthContexts() {
+		return "", false
+	}
+	policy := handlerCtx.GetAuthorisationPolicy()
+	if policy == nil {
+		return handlerCtx.GetQuery(), true
+	}
+	return policy.GetUsername() + "\x00" + handlerCtx.GetQuery(), true
+}
@@ -0,0 +1,89 @@
+package planbuilder //nolint:testpackage // authoriseStatement is unexported
+
+import (
+	"testing"
+
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/parser"
+	"github.com/stackql/stackql/internal/stackql/srvauth"
+)
+
+type policyHandlerContext struct {
+	handler.HandlerContext
+	policy srvauth.Policy
+}
+
+func (hc *policyHandlerContext) GetAuthorisationPolicy() srvauth.Policy { return hc.policy }
+
+func newPolicyHandlerContext(t *testing.T, username string) handler.HandlerContext {
+	reg, err := srvauth.NewUserRegistry([]byte(`
+users:
+  - username: reader
+    password: SCRAM-SHA-256$4096:W22ZaJ0SNY7soEsUEjb6gQ==$WG5d8oPm3OtcPnkdi4Uo7BkeZkBFzpcXkuLmtbsT4qY=:wfPLwcE6nTWhTAmQ7tl2KeoiWGPlZqQxSrmfPwDl2dU=
+    policy:
+      select: [ "*" ]
+      mutate: true
+  - username: admin
+    password: SCRAM-SHA-256$4096:W22ZaJ0SNY7soEsUEjb6gQ==$WG5d8oPm3OtcPnkdi4Uo7BkeZkBFzpcXkuLmtbsT4qY=:wfPLwcE6nTWhTAmQ7tl2KeoiWGPlZqQxSrmfPwDl2dU=
+    policy:
+      select: [ "*" ]
+      admin: true
+`))
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	policy, ok := reg.GetPolicy(username)
+	if !ok {
+		t.Fatalf("test failed: no policy for '%s'", username)
+	}
+	return &policyHandlerContext{policy: policy}
+}
+
+func TestAuthoriseStatementAdministration(t *testing.T) {
+	p, err := parser.NewParser()
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	reader := newPolicyHandlerContext(t, "reader")
+	admin := newPolicyHandlerContext(t, "admin")
+	for _, tc := range []struct {
+		query    string
+		isDenied bool
+	}{
+		{`NATIVEQUERY 'SELECT * FROM pg_authid'`, true},
+		{`create view v1 as select 1 as x`, true},
+		{`drop view v1`, true},
+		{`create materialized view mv1 as select 1 as x`, true},
+		{`create table t1 (x int)`, true},
+		{`registry pull google`, true},
+		{`registry list`, false},
+		{`purge`, true},
+		{`purge conservative`, true},
+		{`select 1`, false},
+	} {
+		statement, parseErr := p.ParseQuery(tc.query)
+		if parseErr != nil {
+			t.Fatalf("test failed: could not parse '%s': %v", tc.query, parseErr)
+		}
+		if err = authoriseStatement(reader, statement); (err != nil) != tc.isDenied {
+			t.Fatalf("test failed: '%s' denied = %v, expected %v", tc.query, err != nil, tc.isDenied)
+		}
+		if err = authoriseStatement(admin, statement); err != nil {
+			t.Fatalf("test failed: '%s' denied for admin: %v", tc.query, err)
+		}
+	}
+}
+
+func TestAuthoriseStatementWithoutPolicy(t *testing.T) {
+	p, err := parser.NewParser()
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	statement, err := p.ParseQuery(`NATIVEQUERY 'SELECT 1'`)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if err = authoriseStatement(&policyHandlerContext{}, statement); err != nil {
+		t.Fatalf("test failed: statement denied without policy: %v", err)
+	}
+}
@@ -1,9 +1,11 @@
 package planbuilder
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/stackql/any-sdk/pkg/logging"
+	lrucache "github.com/stackql/stackql-parser/go/cache"
 	"github.com/stackql/stackql-parser/go/vt/sqlparser"
 	"github.com/stackql/stackql/internal/stackql/acid/txn_context"
 	"github.com/stackql/stackql/internal/stackql/astanalysis/earlyanalysis"
@@ -13,6 +15,7 @@ import (
 	"github.com/stackql/stackql/internal/stackql/parserutil"
 	"github.com/stackql/stackql/internal/stackql/plan"
 	"github.com/stackql/stackql/internal/stackql/primitivegenerator"
+	"github.com/stackql/stackql/internal/stackql/tracing"
 )
 
 var (
@@ -38,8 +41,18 @@ func (pb *standardPlanBuilder) BuildUndoPlanFromContext(_ handler.HandlerContext
 	return nil, nil
 }
 
-//nolint:funlen,gocognit // no big deal
 func (pb *standardPlanBuilder) BuildPlanFromContext(handlerCtx handler.HandlerContext) (plan.Plan, error) {
+	ctx, span := tracing.Start(handlerCtx.GetContext(), "plan")
+	rv, err := pb.buildPlanFromContext(ctx, handlerCtx)
+	tracing.End(span, err)
+	return rv, err
+}
+
+//nolint:funlen,gocognit // no big deal
+func (pb *standardPlanBuilder) buildPlanFromContext(
+	ctx context.Context,
+	handlerCtx handler.HandlerContext,
+) (plan.Plan, error) {
 	defer handlerCtx.GetGarbageCollector().Close()
 	tcc, err := internaldto.NewTxnControlCounters(handlerCtx.GetTxnCounterMgr())
 	handlerCtx.GetTxnStore().Put(tcc.GetTxnID())
@@ -48,19 +61,12 @@ func (pb *standardPlanBuilder) BuildPlanFromContext(handlerCtx handler.HandlerCo
 	if err != nil {
 		return nil, err
 	}
-	planKey := handlerCtx.GetQuery()
-	if qp, ok := handlerCtx.GetLRUCache().Get(planKey); ok && isPlanCacheEnabled() {
-		logging.GetLogger().Infoln("retrieving query plan from cache")
-		pl, plOk := qp.(plan.Plan)
-		if plOk {
-			txnID, tErr := handlerCtx.GetTxnCounterMgr().GetNextTxnID()
-			if tErr != nil {
-				return nil, tErr
-			}
-			pl.SetTxnID(txnID)
-			return pl, nil
+	planKey, isPlanKeyCacheable := getPlanCacheKey(handlerCtx)
+	planCache := getPlanCache(handlerCtx)
+	if isPlanKeyCacheable {
+		if cachedPlan, isCached, cacheErr := getCachedPlan(handlerCtx, planCache, planKey); isCached || cacheErr != nil {
+			return cachedPlan, cacheErr
 		}
-		return qp.(plan.Plan), nil
 	}
 	qPlan := plan.NewPlan(
 		handlerCtx.GetRawQuery(),
@@ -71,12 +77,18 @@ func (pb *standardPlanBuilder) BuildPlanFromContext(handlerCtx handler.HandlerCo
 	if err != nil {
 		return nil, err
 	}
+	_, parseSpan := tracing.Start(ctx, "parse")
 	statement, err := sqlParser.ParseQuery(handlerCtx.GetQuery())
+	tracing.End(parseSpan, err)
 	if err != nil {
 		return createErroneousPlan(handlerCtx, qPlan, rowSort, err)
 	}
-	//nolint:gocritic // acceptable
+	if err = authoriseStatement(handlerCtx, statement); err != nil {
+		return createErroneousPlan(handlerCtx, qPlan, rowSort, err)
+	}
 	switch stmt := statement.(type) {
+	case *sqlparser.Explain:
+		return pb.buildExplainPlan(handlerCtx, stmt, qPlan)
 	case *sqlparser.RefreshMaterializedView:
 		relationName := stmt.ViewName.GetRawVal()
 		catalogueEntry, catalogueEntryExists := handlerCtx.GetSQLSystem().GetMaterializedViewByName(relationName)
@@ -149,6 +161,9 @@ func (pb *standardPlanBuilder) BuildPlanFromContext(handlerCtx handler.HandlerCo
 		if createInstructionError != nil {
 			return nil, createInstructionError
 		}
+		if statementType == sqlparser.StmtSelect {
+			qPlan.SetColumns(primitiveGenerator.GetPrimitiveComposer().GetNonControlColumns())
+		}
 	case earlyanalysis.DummiedPGInstruction:
 		qPlan.SetReadOnly(true)
 		createInstructionError := pGBuilder.createInstructionFor(earlyPassScreenerAnalyzer.GetPlanBuilderInput())
@@ -178,10 +193,48 @@ func (pb *standardPlanBuilder) BuildPlanFromContext(handlerCtx handler.HandlerCo
 		if err != nil {
 			return createErroneousPlan(handlerCtx, qPlan, rowSort, err)
 		}
-		if qPlan.IsCacheable() {
-			handlerCtx.GetLRUCache().Set(planKey, qPlan)
+		if qPlan.IsCacheable() && isPlanKeyCacheable {
+			planCache.Set(planKey, qPlan)
 		}
 	}
 
 	return qPlan, err
 }
+
+// getPlanCache returns the plan cache of the prepared
+// statement being executed, if any, else the query cache.
+func getPlanCache(handlerCtx handler.HandlerContext) *lrucache.LRUCache {
+	if planCache, isStatementPlanCache := handlerCtx.GetStatementPlanCache(); isStatementPlanCache {
+		return planCache
+	}
+	return handlerCtx.GetLRUCache()
+}
+
+// getCachedPlan reads a cached plan only where plan caching is enabled,
+// for prepared statements as for any other query, since a plan captures
+// the handler context, transaction counters and primitive state of the
+// statement for which it was built.
+func getCachedPlan(
+	handlerCtx handler.HandlerContext,
+	planCache *lrucache.LRUCache,
+	planKey string,
+) (plan.Plan, bool, error) {
+	if !isPlanCacheEnabled() {
+		return nil, false, nil
+	}
+	qp, ok := planCache.Get(planKey)
+	if !ok {
+		return nil, false, nil
+	}
+	logging.GetLogger().Infoln("retrieving query plan from cache")
+	pl, plOk := qp.(plan.Plan)
+	if !plOk {
+		return nil, false, nil
+	}
+	txnID, err := handlerCtx.GetTxnCounterMgr().GetNextTxnID()
+	if err != nil {
+		return nil, true, err
+	}
+	pl.SetTxnID(txnID)
+	return pl, true, nil
+}
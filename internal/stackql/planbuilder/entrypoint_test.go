@@ -0,0 +1,84 @@
+package planbuilder //nolint:testpackage // plan cache helpers are unexported
+
+import (
+	"testing"
+
+	lrucache "github.com/stackql/stackql-parser/go/cache"
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/plan"
+	"github.com/stackql/stackql/internal/stackql/primitivegraph"
+	"github.com/stackql/stackql/pkg/txncounter"
+)
+
+type planCacheHandlerContext struct {
+	handler.HandlerContext
+	queryCache         *lrucache.LRUCache
+	statementPlanCache *lrucache.LRUCache
+	txnCounterMgr      txncounter.Manager
+}
+
+func (hc *planCacheHandlerContext) GetLRUCache() *lrucache.LRUCache { return hc.queryCache }
+
+func (hc *planCacheHandlerContext) GetStatementPlanCache() (*lrucache.LRUCache, bool) {
+	return hc.statementPlanCache, hc.statementPlanCache != nil
+}
+
+func (hc *planCacheHandlerContext) GetTxnCounterMgr() txncounter.Manager { return hc.txnCounterMgr }
+
+func newPlanCacheHandlerContext() *planCacheHandlerContext {
+	return &planCacheHandlerContext{
+		queryCache:         lrucache.NewLRUCache(16),
+		statementPlanCache: lrucache.NewLRUCache(16),
+		txnCounterMgr:      txncounter.NewTxnCounterManager(1, 1),
+	}
+}
+
+// newCachedPlan is as per a built plan, which bears instructions.
+func newCachedPlan(query string) plan.Plan {
+	rv := plan.NewPlan(query)
+	rv.SetInstructions(primitivegraph.NewPrimitiveGraphHolder(1))
+	return rv
+}
+
+func TestPreparedStatementPlansRebuiltWithPlanCacheDisabled(t *testing.T) {
+	if isPlanCacheEnabled() {
+		t.Fatalf("test failed: plan cache enabled by default")
+	}
+	const query = "SELECT 1"
+	hc := newPlanCacheHandlerContext()
+	if planCache := getPlanCache(hc); planCache != hc.statementPlanCache {
+		t.Fatalf("test failed: prepared statement planned against the query cache")
+	}
+	// describe plans the statement, storing the plan as per any build
+	describePlan := newCachedPlan(query)
+	getPlanCache(hc).Set(query, describePlan)
+	// whereupon each execute plans afresh
+	for i := 0; i < 2; i++ {
+		pl, isCached, err := getCachedPlan(hc, getPlanCache(hc), query)
+		if err != nil {
+			t.Fatalf("test failed: %v", err)
+		}
+		if isCached || pl != nil {
+			t.Fatalf("test failed: execute %d reused the cached plan", i+1)
+		}
+	}
+}
+
+func TestPreparedStatementPlansCachedWithPlanCacheEnabled(t *testing.T) {
+	defer func(enabled string) { PlanCacheEnabled = enabled }(PlanCacheEnabled)
+	PlanCacheEnabled = "true"
+	const query = "SELECT 1"
+	hc := newPlanCacheHandlerContext()
+	describePlan := newCachedPlan(query)
+	getPlanCache(hc).Set(query, describePlan)
+	pl, isCached, err := getCachedPlan(hc, getPlanCache(hc), query)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if !isCached || pl != describePlan {
+		t.Fatalf("test failed: cached plan not reused")
+	}
+	if _, isCached, _ = getCachedPlan(hc, hc.GetLRUCache(), query); isCached {
+		t.Fatalf("test failed: prepared statement plan shared with the query cache")
+	}
+}
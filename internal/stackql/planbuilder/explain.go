@@ -0,0 +1,348 @@
+package planbuilder
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/plan"
+	"github.com/stackql/stackql/internal/stackql/primitive"
+	"github.com/stackql/stackql/internal/stackql/primitivegraph"
+	"github.com/stackql/stackql/internal/stackql/typing"
+	"github.com/stackql/stackql/internal/stackql/util"
+)
+
+const (
+	explainForwardGraph = "forward"
+	explainInverseGraph = "inverse"
+)
+
+var (
+	//nolint:gochecknoglobals // acceptable
+	errExplainAnalyzeMutation = fmt.Errorf(
+		"EXPLAIN ANALYZE executes the explained statement, which mutates provider resources; "+
+			"to permit this, SET %s = on", handler.ExplainAnalyzeMutationsSettingName)
+	// As per the grammar of explain statements, save
+	// DESCRIBE <table>, which is not an explain statement.
+	//nolint:gochecknoglobals // acceptable
+	explainPrefixRegex = regexp.MustCompile(
+		`(?is)^\s*(?:explain|describe|desc)\s+(?:/\*.*?\*/\s*)*` +
+			`(?:(?:analyze|format\s*=\s*(?:json|tree|vitess|traditional))\s+(?:/\*.*?\*/\s*)*)?` +
+			`(select|insert|update|delete)\b`)
+	//nolint:gochecknoglobals // acceptable
+	explainColumns = []string{
+		"id",
+		"graph",
+		"builder",
+		"provider",
+		"service",
+		"resource",
+		"method",
+		"runs_after",
+		"query",
+	}
+	//nolint:gochecknoglobals // acceptable
+	explainAnalyzeColumns = []string{
+		"id",
+		"graph",
+		"builder",
+		"provider",
+		"service",
+		"resource",
+		"method",
+		"runs_after",
+		"elapsed_ms",
+		"http_requests",
+		"pages",
+		"rows_inserted",
+		"rows_returned",
+		"query",
+	}
+)
+
+// explainRow is a single planned primitive, as rendered by EXPLAIN.
+type explainRow struct {
+	graphName   string
+	node        primitivegraph.PrimitiveNode
+	description primitive.Description
+	runsAfter   []int64
+	stats       internaldto.ExecutionStats
+}
+
+func (er explainRow) toMap() map[string]interface{} {
+	runsAfter := make([]string, len(er.runsAfter))
+	for i, id := range er.runsAfter {
+		runsAfter[i] = strconv.FormatInt(id, 10)
+	}
+	rv := map[string]interface{}{
+		"id":         er.node.ID(),
+		"graph":      er.graphName,
+		"builder":    er.description.GetBuilderName(),
+		"provider":   er.description.GetProvider(),
+		"service":    er.description.GetService(),
+		"resource":   er.description.GetResource(),
+		"method":     er.description.GetMethod(),
+		"runs_after": strings.Join(runsAfter, ","),
+		"query":      er.description.GetQuery(),
+	}
+	if er.stats != nil {
+		rv["elapsed_ms"] = formatElapsedMillis(er.stats.GetElapsed())
+		rv["http_requests"] = er.stats.GetHTTPRequests()
+		rv["pages"] = er.stats.GetPages()
+		rv["rows_inserted"] = er.stats.GetRowsInserted()
+		rv["rows_returned"] = ""
+		if rowsReturned, isRowsReturned := er.stats.GetRowsReturned(); isRowsReturned {
+			rv["rows_returned"] = rowsReturned
+		}
+	}
+	return rv
+}
+
+func formatElapsedMillis(d time.Duration) string {
+	return strconv.FormatFloat(float64(d.Microseconds())/1000.0, 'f', 3, 64)
+}
+
+// extractExplainedQuery strips the EXPLAIN prefix,
+// so that the explained statement may be planned verbatim.
+func extractExplainedQuery(query string) (string, error) {
+	loc := explainPrefixRegex.FindStringSubmatchIndex(query)
+	if loc == nil {
+		return "", fmt.Errorf("could not infer explained statement from query '%s'", query)
+	}
+	return query[loc[2]:], nil
+}
+
+// checkExplainType admits ANALYZE and those formats
+// rendered, which is to say only the tabular plan.
+func checkExplainType(explainType string) error {
+	switch explainType {
+	case "", sqlparser.AnalyzeStr, sqlparser.TraditionalStr:
+		return nil
+	default:
+		return fmt.Errorf(
+			"unsupported EXPLAIN FORMAT = %s, only FORMAT = TRADITIONAL is supported",
+			strings.ToUpper(explainType))
+	}
+}
+
+// buildExplainPlan plans the explained statement and then
+// wraps it in a single primitive that renders the plan.
+// For EXPLAIN ANALYZE, the wrapping primitive first executes
+// the explained plan with instrumentation.
+func (pb *standardPlanBuilder) buildExplainPlan(
+	handlerCtx handler.HandlerContext,
+	stmt *sqlparser.Explain,
+	qPlan plan.Plan,
+) (plan.Plan, error) {
+	qPlan.SetType(sqlparser.StmtExplain)
+	qPlan.SetStatement(stmt)
+	qPlan.SetCacheable(false)
+	qPlan.SetReadOnly(true)
+	isAnalyze := stmt.Type == sqlparser.AnalyzeStr
+	if err := checkExplainType(stmt.Type); err != nil {
+		return createErroneousPlan(handlerCtx, qPlan, nil, err)
+	}
+	innerQuery, err := extractExplainedQuery(handlerCtx.GetQuery())
+	if err != nil {
+		return createErroneousPlan(handlerCtx, qPlan, nil, err)
+	}
+	innerRawQuery, err := extractExplainedQuery(handlerCtx.GetRawQuery())
+	if err != nil {
+		innerRawQuery = innerQuery
+	}
+	innerCtx := handlerCtx.Clone()
+	innerCtx.SetQuery(innerQuery)
+	innerCtx.SetRawQuery(innerRawQuery)
+	innerPlan, err := pb.BuildPlanFromContext(innerCtx)
+	if err != nil {
+		return createErroneousPlan(handlerCtx, qPlan, nil, err)
+	}
+	innerInstructions := innerPlan.GetInstructions()
+	if innerInstructions == nil {
+		return createErroneousPlan(
+			handlerCtx, qPlan, nil,
+			fmt.Errorf("no plan available for explained statement"))
+	}
+	if isAnalyze && !innerPlan.IsReadOnly() {
+		// Mutations are not undone upon completion, as they might be in postgres.
+		if !handlerCtx.IsExplainAnalyzeMutations() {
+			return createErroneousPlan(handlerCtx, qPlan, nil, errExplainAnalyzeMutation)
+		}
+		qPlan.SetReadOnly(false)
+	}
+	typingCfg := handlerCtx.GetTypingConfig()
+	instructions := primitivegraph.NewPrimitiveGraphHolder(
+		handlerCtx.GetRuntimeContext().ExecutionConcurrencyLimit,
+	)
+	instructions.CreatePrimitiveNode(
+		//nolint:revive // acceptable for now
+		primitive.NewLocalPrimitive(func(pc primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
+			if isAnalyze {
+				return analyzeInstructions(pc, innerInstructions, typingCfg)
+			}
+			rows, rowsErr := describeInstructions(innerInstructions)
+			if rowsErr != nil {
+				return util.GenerateSimpleErroneousOutput(rowsErr, typingCfg)
+			}
+			return renderExplainRows(rows, explainColumns, nil, typingCfg)
+		}).WithDescription(primitive.NewDescription("Explain")),
+	)
+	qPlan.SetInstructions(instructions)
+	err = instructions.GetPrimitiveGraph().Optimise()
+	if err != nil {
+		return createErroneousPlan(handlerCtx, qPlan, nil, err)
+	}
+	return qPlan, nil
+}
+
+// describeInstructions lists forward graph primitives in execution order,
+// followed by any inverse (rollback) primitives.
+func describeInstructions(instructions primitivegraph.PrimitiveGraphHolder) ([]explainRow, error) {
+	rv, err := describeGraph(explainForwardGraph, instructions.GetPrimitiveGraph())
+	if err != nil {
+		return nil, err
+	}
+	inverseGraph := instructions.GetInversePrimitiveGraph()
+	if inverseGraph == nil || inverseGraph.Size() == 0 {
+		return rv, nil
+	}
+	inverseRows, err := describeGraph(explainInverseGraph, inverseGraph)
+	if err != nil {
+		return nil, err
+	}
+	return append(rv, inverseRows...), nil
+}
+
+// analyzeInstructions executes the forward graph, instrumented,
+// and then reports per primitive statistics. Returned rows are
+// attributed to the final primitive in execution order, whose
+// output is the result.
+func analyzeInstructions(
+	pc primitive.IPrimitiveCtx,
+	instructions primitivegraph.PrimitiveGraphHolder,
+	typingCfg typing.Config,
+) internaldto.ExecutorOutput {
+	pg := instructions.GetPrimitiveGraph()
+	stats := internaldto.NewExecutionStats()
+	start := time.Now()
+	output := pg.Execute(primitive.NewInstrumentedPrimitiveCtx(pc, stats))
+	if output != nil && output.GetError() != nil {
+		return util.GenerateSimpleErroneousOutput(output.GetError(), typingCfg)
+	}
+	rowsReturned, err := countResultRows(output)
+	if err != nil {
+		return util.GenerateSimpleErroneousOutput(err, typingCfg)
+	}
+	elapsed := time.Since(start)
+	rows, err := describeGraph(explainForwardGraph, pg)
+	if err != nil {
+		return util.GenerateSimpleErroneousOutput(err, typingCfg)
+	}
+	for i := range rows {
+		rows[i].stats = stats.Child(rows[i].node.ID())
+	}
+	if len(rows) > 0 {
+		rows[len(rows)-1].stats.SetRowsReturned(rowsReturned)
+	}
+	var messages []string
+	if output != nil {
+		messages = append(messages, output.GetMessages()...)
+	}
+	messages = append(
+		messages,
+		fmt.Sprintf("Execution time: %s ms, rows returned: %d", formatElapsedMillis(elapsed), rowsReturned),
+	)
+	return renderExplainRows(rows, explainAnalyzeColumns, internaldto.NewBackendMessages(messages), typingCfg)
+}
+
+// countResultRows drains the result of the explained statement.
+func countResultRows(output internaldto.ExecutorOutput) (int64, error) {
+	if output == nil {
+		return 0, nil
+	}
+	res := output.GetSQLResult()
+	if res == nil {
+		return 0, nil
+	}
+	var rv int64
+	for {
+		r, err := res.Read()
+		if r != nil {
+			rv += int64(len(r.GetRows()))
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return rv, nil
+			}
+			return rv, err
+		}
+	}
+}
+
+func describeGraph(graphName string, pg primitivegraph.PrimitiveGraph) ([]explainRow, error) {
+	sorted, err := pg.Sort()
+	if err != nil {
+		return nil, err
+	}
+	var rv []explainRow
+	for _, n := range sorted {
+		node, isPrimitiveNode := n.(primitivegraph.PrimitiveNode)
+		if !isPrimitiveNode {
+			continue
+		}
+		// Edges of the primitive graph order execution; they are not
+		// the dataflow edges which bind the parameters of dependent
+		// acquisitions, which are not retained in the plan.
+		var runsAfter []int64
+		predecessors := pg.To(node.ID())
+		for predecessors.Next() {
+			runsAfter = append(runsAfter, predecessors.Node().ID())
+		}
+		sort.Slice(runsAfter, func(i, j int) bool { return runsAfter[i] < runsAfter[j] })
+		rv = append(rv, explainRow{
+			graphName:   graphName,
+			node:        node,
+			description: node.GetOperation().GetDescription(),
+			runsAfter:   runsAfter,
+		})
+	}
+	return rv, nil
+}
+
+func renderExplainRows(
+	rows []explainRow,
+	columns []string,
+	messages internaldto.BackendMessages,
+	typingCfg typing.Config,
+) internaldto.ExecutorOutput {
+	rowMap := make(map[string]map[string]interface{}, len(rows))
+	rowKeys := make([]string, len(rows))
+	for i, row := range rows {
+		key := strconv.Itoa(i)
+		rowKeys[i] = key
+		rowMap[key] = row.toMap()
+	}
+	rv := util.PrepareResultSet(
+		internaldto.NewPrepareResultSetDTO(
+			nil,
+			rowMap,
+			columns,
+			func(map[string]map[string]interface{}) []string { return rowKeys },
+			nil,
+			messages,
+			typingCfg,
+		),
+	)
+	if len(rowMap) > 0 {
+		return rv
+	}
+	return util.EmptyProtectResultSet(rv, columns, typingCfg)
+}
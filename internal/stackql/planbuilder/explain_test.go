@@ -0,0 +1,327 @@
+package planbuilder //nolint:testpackage // rendering helpers are unexported
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stackql/any-sdk/pkg/constants"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/primitive"
+	"github.com/stackql/stackql/internal/stackql/primitivegraph"
+	"github.com/stackql/stackql/internal/stackql/typing"
+)
+
+func newNopPrimitive(description primitive.Description) primitive.IPrimitive {
+	return primitive.NewLocalPrimitive(func(pc primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
+		return internaldto.NewEmptyExecutorOutput()
+	}).WithDescription(description)
+}
+
+// newExplainTestInstructions plans an acquisition feeding a
+// select, with a single inverse primitive, as per a mutation.
+func newExplainTestInstructions(t *testing.T) (primitivegraph.PrimitiveGraphHolder, []int64) {
+	holder := primitivegraph.NewPrimitiveGraphHolder(1)
+	pg := holder.GetPrimitiveGraph()
+	acquire := pg.CreatePrimitiveNode(newNopPrimitive(
+		primitive.NewDescription("SingleSelectAcquire").
+			WithProvider("google").
+			WithService("compute").
+			WithResource("instances").
+			WithMethod("list"),
+	))
+	selection := pg.CreatePrimitiveNode(newNopPrimitive(
+		primitive.NewDescription("SingleSelect").WithQuery(`SELECT "name" FROM "google.compute.instances"`),
+	))
+	pg.NewDependency(acquire, selection, 1.0)
+	if err := pg.Optimise(); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	inverse := holder.GetInversePrimitiveGraph().CreatePrimitiveNode(newNopPrimitive(
+		primitive.NewDescription("Delete").WithProvider("google").WithMethod("delete"),
+	))
+	return holder, []int64{acquire.ID(), selection.ID(), inverse.ID()}
+}
+
+func TestDescribeInstructions(t *testing.T) {
+	holder, ids := newExplainTestInstructions(t)
+	rows, err := describeInstructions(holder)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	expected := []map[string]interface{}{
+		{
+			"id":         ids[0],
+			"graph":      explainForwardGraph,
+			"builder":    "SingleSelectAcquire",
+			"provider":   "google",
+			"service":    "compute",
+			"resource":   "instances",
+			"method":     "list",
+			"runs_after": "",
+			"query":      "",
+		},
+		{
+			"id":         ids[1],
+			"graph":      explainForwardGraph,
+			"builder":    "SingleSelect",
+			"provider":   "",
+			"service":    "",
+			"resource":   "",
+			"method":     "",
+			"runs_after": formatID(ids[0]),
+			"query":      `SELECT "name" FROM "google.compute.instances"`,
+		},
+		{
+			"id":         ids[2],
+			"graph":      explainInverseGraph,
+			"builder":    "Delete",
+			"provider":   "google",
+			"service":    "",
+			"resource":   "",
+			"method":     "delete",
+			"runs_after": "",
+			"query":      "",
+		},
+	}
+	if len(rows) != len(expected) {
+		t.Fatalf("test failed: expected %d rows, got %d", len(expected), len(rows))
+	}
+	for i, row := range rows {
+		actual := row.toMap()
+		for k, v := range expected[i] {
+			if actual[k] != v {
+				t.Fatalf("test failed: row %d column '%s': expected '%v', got '%v'", i, k, v, actual[k])
+			}
+		}
+		if _, hasStats := actual["elapsed_ms"]; hasStats {
+			t.Fatalf("test failed: row %d has analyze columns without analysis", i)
+		}
+	}
+}
+
+func TestDescribeInstructionsOmitsEmptyInverseGraph(t *testing.T) {
+	holder := primitivegraph.NewPrimitiveGraphHolder(1)
+	holder.GetPrimitiveGraph().CreatePrimitiveNode(newNopPrimitive(primitive.NewDescription("Nop")))
+	rows, err := describeInstructions(holder)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if len(rows) != 1 || rows[0].graphName != explainForwardGraph {
+		t.Fatalf("test failed: unexpected rows %v", rows)
+	}
+}
+
+func TestRenderExplainRowsColumns(t *testing.T) {
+	typingCfg := newExplainTestTypingConfig(t)
+	holder, _ := newExplainTestInstructions(t)
+	rows, err := describeInstructions(holder)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	rendered := readExplainOutput(t, renderExplainRows(rows, explainColumns, nil, typingCfg))
+	if len(rendered) != len(rows) {
+		t.Fatalf("test failed: expected %d rendered rows, got %d", len(rows), len(rendered))
+	}
+	if rendered[1]["builder"] != "SingleSelect" || rendered[2]["graph"] != explainInverseGraph {
+		t.Fatalf("test failed: unexpected rendered rows %v", rendered)
+	}
+}
+
+func newExplainTestTypingConfig(t *testing.T) typing.Config {
+	typingCfg, err := typing.NewTypingConfig(constants.SQLDialectSQLite3)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	return typingCfg
+}
+
+// readExplainOutput drains rendered rows, keyed on column name.
+func readExplainOutput(t *testing.T, output internaldto.ExecutorOutput) []map[string]string {
+	if output.GetError() != nil {
+		t.Fatalf("test failed: %v", output.GetError())
+	}
+	var rv []map[string]string
+	res := output.GetSQLResult()
+	for {
+		r, err := res.Read()
+		if r != nil {
+			columns := r.GetColumns()
+			for _, row := range r.GetRows() {
+				rowData := row.GetRowDataNaive()
+				if len(rowData) == 0 {
+					continue
+				}
+				m := make(map[string]string, len(columns))
+				for i, col := range columns {
+					m[col.GetName()] = fmt.Sprintf("%v", naiveCell(rowData[i]))
+				}
+				rv = append(rv, m)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return rv
+		}
+		if err != nil {
+			t.Fatalf("test failed: %v", err)
+		}
+	}
+}
+
+func naiveCell(v interface{}) interface{} {
+	if b, isBytes := v.([]byte); isBytes {
+		return string(b)
+	}
+	return v
+}
+
+func formatID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+func TestAnalyzeInstructionsCounters(t *testing.T) {
+	typingCfg := newExplainTestTypingConfig(t)
+	holder := primitivegraph.NewPrimitiveGraphHolder(1)
+	pg := holder.GetPrimitiveGraph()
+	acquire := pg.CreatePrimitiveNode(
+		primitive.NewLocalPrimitive(func(pc primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
+			stats, isInstrumented := pc.GetExecutionStats()
+			if !isInstrumented {
+				return internaldto.NewErroneousExecutorOutput(errors.New("primitive context not instrumented"))
+			}
+			stats.AddHTTPRequests(3)
+			stats.AddPages(2)
+			stats.AddRowsInserted(5)
+			return internaldto.NewEmptyExecutorOutput()
+		}).WithDescription(primitive.NewDescription("SingleSelectAcquire")),
+	)
+	selection := pg.CreatePrimitiveNode(
+		primitive.NewLocalPrimitive(func(pc primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
+			return renderExplainRows(
+				[]explainRow{
+					{node: acquire, description: primitive.NewDescription("a")},
+					{node: acquire, description: primitive.NewDescription("b")},
+				},
+				explainColumns, nil, typingCfg,
+			)
+		}).WithDescription(primitive.NewDescription("SingleSelect")),
+	)
+	pg.NewDependency(acquire, selection, 1.0)
+	if err := pg.Optimise(); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	output := analyzeInstructions(internaldto.NewBasicPrimitiveContext(nil, nil, nil), holder, typingCfg)
+	rendered := readExplainOutput(t, output)
+	if len(rendered) != 2 {
+		t.Fatalf("test failed: expected 2 rows, got %v", rendered)
+	}
+	expected := []map[string]string{
+		{"builder": "SingleSelectAcquire", "http_requests": "3", "pages": "2", "rows_inserted": "5", "rows_returned": ""},
+		{"builder": "SingleSelect", "http_requests": "0", "pages": "0", "rows_inserted": "0", "rows_returned": "2"},
+	}
+	for i, row := range rendered {
+		for k, v := range expected[i] {
+			if row[k] != v {
+				t.Fatalf("test failed: row %d column '%s': expected '%s', got '%s'", i, k, v, row[k])
+			}
+		}
+		if _, err := strconv.ParseFloat(row["elapsed_ms"], 64); err != nil {
+			t.Fatalf("test failed: row %d has invalid elapsed_ms '%s'", i, row["elapsed_ms"])
+		}
+	}
+	messages := output.GetMessages()
+	if len(messages) == 0 || !strings.HasPrefix(messages[len(messages)-1], "Execution time: ") {
+		t.Fatalf("test failed: unexpected messages %v", messages)
+	}
+}
+
+func TestAnalyzeInstructionsError(t *testing.T) {
+	typingCfg := newExplainTestTypingConfig(t)
+	holder := primitivegraph.NewPrimitiveGraphHolder(1)
+	holder.GetPrimitiveGraph().CreatePrimitiveNode(
+		primitive.NewLocalPrimitive(func(pc primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
+			return internaldto.NewErroneousExecutorOutput(errors.New("provider failure"))
+		}),
+	)
+	if err := holder.GetPrimitiveGraph().Optimise(); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	output := analyzeInstructions(internaldto.NewBasicPrimitiveContext(nil, nil, nil), holder, typingCfg)
+	if output.GetError() == nil || output.GetError().Error() != "provider failure" {
+		t.Fatalf("test failed: expected provider failure, got %v", output.GetError())
+	}
+}
+
+func TestAnalyzeInstructionsAttributesRowsToOutputNode(t *testing.T) {
+	typingCfg := newExplainTestTypingConfig(t)
+	// Absent dependencies, the execution order is arbitrary.
+	for i := 0; i < 20; i++ {
+		holder := primitivegraph.NewPrimitiveGraphHolder(1)
+		pg := holder.GetPrimitiveGraph()
+		for k := 1; k <= 4; k++ {
+			var node primitivegraph.PrimitiveNode
+			node = pg.CreatePrimitiveNode(
+				primitive.NewLocalPrimitive(func(pc primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
+					rows := make([]explainRow, k)
+					for j := range rows {
+						rows[j] = explainRow{node: node, description: primitive.NewDescription("row")}
+					}
+					return renderExplainRows(rows, explainColumns, nil, typingCfg)
+				}).WithDescription(primitive.NewDescription(strconv.Itoa(k))),
+			)
+		}
+		if err := pg.Optimise(); err != nil {
+			t.Fatalf("test failed: %v", err)
+		}
+		output := analyzeInstructions(internaldto.NewBasicPrimitiveContext(nil, nil, nil), holder, typingCfg)
+		for _, row := range readExplainOutput(t, output) {
+			if row["rows_returned"] != "" && row["rows_returned"] != row["builder"] {
+				t.Fatalf("test failed: %s rows returned attributed to primitive %s", row["rows_returned"], row["builder"])
+			}
+		}
+	}
+}
+
+func TestExtractExplainedQuery(t *testing.T) {
+	for query, expected := range map[string]string{
+		"EXPLAIN SELECT 1":                                      "SELECT 1",
+		"  desc\n\tselect 1":                                    "select 1",
+		"DESCRIBE DELETE FROM google.compute.instances":         "DELETE FROM google.compute.instances",
+		"explain analyze update t set a = 1":                    "update t set a = 1",
+		"EXPLAIN FORMAT=TRADITIONAL INSERT INTO t SELECT 1":     "INSERT INTO t SELECT 1",
+		"EXPLAIN /* plan */ ANALYZE /* run */ SELECT 1":         "SELECT 1",
+		"EXPLAIN FORMAT = json SELECT selected FROM selections": "SELECT selected FROM selections",
+	} {
+		actual, err := extractExplainedQuery(query)
+		if err != nil || actual != expected {
+			t.Fatalf("test failed: query '%s': expected '%s', got '%s' and error %v", query, expected, actual, err)
+		}
+	}
+	for _, query := range []string{
+		"DESCRIBE google.compute.instances",
+		"DESCRIBE EXTENDED google.compute.instances",
+		"EXPLAIN FORMAT = foo SELECT 1",
+		"EXPLAIN selections",
+	} {
+		if actual, err := extractExplainedQuery(query); err == nil {
+			t.Fatalf("test failed: query '%s' is not an explain statement, yet extracted '%s'", query, actual)
+		}
+	}
+}
+
+func TestCheckExplainType(t *testing.T) {
+	for _, explainType := range []string{"", "analyze", "traditional"} {
+		if err := checkExplainType(explainType); err != nil {
+			t.Fatalf("test failed: explain type '%s' refused: %v", explainType, err)
+		}
+	}
+	for _, explainType := range []string{"json", "tree", "vitess"} {
+		err := checkExplainType(explainType)
+		if err == nil || !strings.Contains(err.Error(), "FORMAT = "+strings.ToUpper(explainType)) {
+			t.Fatalf("test failed: expected explain type '%s' to be refused, got %v", explainType, err)
+		}
+	}
+}
@@ -132,7 +132,7 @@ func (pgb *standardPlanGraphBuilder) createInstructionFor(pbi planbuilderinput.P
 	case *sqlparser.Set:
 		return pgb.handleSet(pbi)
 	case *sqlparser.SetTransaction:
-		return pgb.nop(pbi)
+		return pgb.handleSetTransaction(pbi)
 	case *sqlparser.Show:
 		return pgb.handleShow(pbi)
 	case *sqlparser.Sleep:
@@ -168,11 +168,47 @@ func (pgb *standardPlanGraphBuilder) nop(pbi planbuilderinput.PlanBuilderInput)
 
 func setLogic(pbi planbuilderinput.PlanBuilderInput, setExpr *sqlparser.SetExpr) error {
 	lhsRaw := setExpr.Name.GetRawVal()
+	if strings.EqualFold(lhsRaw, handler.StatementTimeoutSettingName) {
+		timeout, err := handler.ParseStatementTimeout(sqlparser.String(setExpr.Expr))
+		if err != nil {
+			return err
+		}
+		pbi.GetHandlerCtx().SetStatementTimeout(timeout)
+		return nil
+	}
+	if strings.EqualFold(lhsRaw, handler.LockTimeoutSettingName) {
+		timeout, err := handler.ParseLockTimeout(sqlparser.String(setExpr.Expr))
+		if err != nil {
+			return err
+		}
+		pbi.GetHandlerCtx().SetLockTimeout(timeout)
+		return nil
+	}
+	if strings.EqualFold(lhsRaw, handler.AwaitTimeoutSettingName) {
+		timeout, err := handler.ParseAwaitTimeout(sqlparser.String(setExpr.Expr))
+		if err != nil {
+			return err
+		}
+		pbi.GetHandlerCtx().SetAwaitTimeout(timeout)
+		return nil
+	}
+	if strings.EqualFold(lhsRaw, handler.ExplainAnalyzeMutationsSettingName) {
+		isPermitted, err := handler.ParseExplainAnalyzeMutations(sqlparser.String(setExpr.Expr))
+		if err != nil {
+			return err
+		}
+		pbi.GetHandlerCtx().SetExplainAnalyzeMutations(isPermitted)
+		return nil
+	}
 	lhsTrimmed := strings.TrimPrefix(lhsRaw, "$.")
 	if lhsTrimmed == lhsRaw {
 		return nil
 	}
 	exprStr := strings.Trim(sqlparser.String(setExpr.Expr), "'")
+	// String literals are taken verbatim, since formatting escapes quotes.
+	if sqlVal, isSQLVal := setExpr.Expr.(*sqlparser.SQLVal); isSQLVal && sqlVal.Type == sqlparser.StrVal {
+		exprStr = string(sqlVal.Val)
+	}
 	exprObj := map[string]interface{}{}
 	deserErr := json.Unmarshal([]byte(exprStr), &exprObj)
 	if deserErr != nil {
@@ -183,6 +219,26 @@ func setLogic(pbi planbuilderinput.PlanBuilderInput, setExpr *sqlparser.SetExpr)
 	return rv
 }
 
+// handleSetTransaction sets the isolation level of the next transaction,
+// which determines the resource locks taken by its statements.
+// The level is applied upon BEGIN.
+func (pgb *standardPlanGraphBuilder) handleSetTransaction(pbi planbuilderinput.PlanBuilderInput) error {
+	node, ok := pbi.GetStatement().(*sqlparser.SetTransaction)
+	if !ok {
+		return fmt.Errorf("could not cast node of type '%T' to required SetTransaction", pbi.GetStatement())
+	}
+	for _, characteristic := range node.Characteristics {
+		isolationLevel, isIsolationLevel := characteristic.(*sqlparser.IsolationLevel)
+		if !isIsolationLevel {
+			continue
+		}
+		if err := pbi.GetHandlerCtx().SetNextTransactionIsolationLevel(isolationLevel.Level); err != nil {
+			return err
+		}
+	}
+	return pgb.nop(pbi)
+}
+
 func (pgb *standardPlanGraphBuilder) handleSet(pbi planbuilderinput.PlanBuilderInput) error {
 	primitiveGenerator := pgb.rootPrimitiveGenerator
 	err := primitiveGenerator.AnalyzeStatement(pbi)
@@ -613,7 +669,7 @@ func (pgb *standardPlanGraphBuilder) handleDelete(pbi planbuilderinput.PlanBuild
 				handlerCtx,
 				node,
 				tbl,
-				nil,
+				primitiveGenerator.GetPrimitiveComposer().GetCommentDirectives(),
 				primitiveGenerator.GetPrimitiveComposer().IsAwait(),
 			)
 		} else {
@@ -1025,6 +1081,7 @@ func (pgb *standardPlanGraphBuilder) handleExec(pbi planbuilderinput.PlanBuilder
 			handlerCtx,
 			node,
 			tbl,
+			primitiveGenerator.GetPrimitiveComposer().GetCommentDirectives(),
 			primitiveGenerator.GetPrimitiveComposer().IsAwait(),
 			primitiveGenerator.IsShowResults(),
 		)
@@ -0,0 +1,135 @@
+package preparedstatement
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type CommandType int
+
+const (
+	PrepareCommand CommandType = iota
+	ExecuteCommand
+	DeallocateCommand
+)
+
+const (
+	deallocateAll string = "all"
+)
+
+var (
+	//nolint:gochecknoglobals // acceptable
+	prepareRegex = regexp.MustCompile(`(?is)^\s*prepare\s+(\w+)\s*(?:\([^)]*\))?\s+as\s+(.+?)[\s;]*$`)
+	//nolint:gochecknoglobals // acceptable
+	executeRegex = regexp.MustCompile(`(?is)^\s*execute\s+(\w+)\s*(?:\((.*)\))?[\s;]*$`)
+	//nolint:gochecknoglobals // acceptable
+	deallocateRegex = regexp.MustCompile(`(?is)^\s*deallocate\s+(?:prepare\s+)?(\w+)[\s;]*$`)
+)
+
+var (
+	_ Command = &standardCommand{}
+)
+
+// Command is a SQL level prepared statement command,
+// which is handled per session rather than planned.
+type Command interface {
+	GetArgs() []string
+	GetName() string
+	GetQuery() string
+	GetType() CommandType
+	IsDeallocateAll() bool
+}
+
+type standardCommand struct {
+	commandType CommandType
+	name        string
+	query       string
+	args        []string
+}
+
+func (cmd *standardCommand) GetType() CommandType {
+	return cmd.commandType
+}
+
+func (cmd *standardCommand) GetName() string {
+	return cmd.name
+}
+
+func (cmd *standardCommand) GetQuery() string {
+	return cmd.query
+}
+
+func (cmd *standardCommand) GetArgs() []string {
+	return cmd.args
+}
+
+func (cmd *standardCommand) IsDeallocateAll() bool {
+	return cmd.commandType == DeallocateCommand && strings.EqualFold(cmd.name, deallocateAll)
+}
+
+// ParseCommand recognises PREPARE, EXECUTE and DEALLOCATE.
+// The boolean return signals whether the query is such a command.
+func ParseCommand(query string) (Command, bool, error) {
+	if m := prepareRegex.FindStringSubmatch(query); m != nil {
+		return &standardCommand{
+			commandType: PrepareCommand,
+			name:        m[1],
+			query:       m[2],
+		}, true, nil
+	}
+	if m := executeRegex.FindStringSubmatch(query); m != nil {
+		args, err := splitArgs(m[2])
+		if err != nil {
+			return nil, true, err
+		}
+		return &standardCommand{
+			commandType: ExecuteCommand,
+			name:        m[1],
+			args:        args,
+		}, true, nil
+	}
+	if m := deallocateRegex.FindStringSubmatch(query); m != nil {
+		return &standardCommand{
+			commandType: DeallocateCommand,
+			name:        m[1],
+		}, true, nil
+	}
+	return nil, false, nil
+}
+
+// splitArgs splits EXECUTE arguments on commas
+// that are outside of quotes and parentheses.
+func splitArgs(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var rv []string
+	depth := 0
+	begin := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'', '"':
+			i = scanQuoted(s, i, c) - 1
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				rv = append(rv, strings.TrimSpace(s[begin:i]))
+				begin = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses in EXECUTE arguments")
+	}
+	rv = append(rv, strings.TrimSpace(s[begin:]))
+	for _, arg := range rv {
+		if arg == "" {
+			return nil, fmt.Errorf("empty argument in EXECUTE arguments")
+		}
+	}
+	return rv, nil
+}
@@ -0,0 +1,232 @@
+package preparedstatement
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	lrucache "github.com/stackql/stackql-parser/go/cache"
+)
+
+const (
+	cacheKeyPrefix string = "prepared statement\x00"
+	// Capacity of the plan cache of each statement,
+	// in bindings.
+	planCacheCapacity int64 = 16
+)
+
+var (
+	_ PreparedStatement = &standardPreparedStatement{}
+)
+
+// PreparedStatement is a named, parameterised query,
+// as established by PREPARE or by a Parse message
+// of the postgres extended query protocol.
+// Parameters are postgres style positional placeholders: $1, $2, ...
+type PreparedStatement interface {
+	// Bind substitutes SQL literals for placeholders,
+	// yielding a query ready for planning.
+	Bind(args []string) (string, error)
+	GetName() string
+	GetParameterCount() int
+	// GetPlanCache holds the plans of bound queries of this statement,
+	// so that, where plan caching is enabled, a binding described and
+	// then executed, or else executed repeatedly, is planned once only.
+	GetPlanCache() *lrucache.LRUCache
+	GetQuery() string
+}
+
+type standardPreparedStatement struct {
+	name           string
+	query          string
+	fragments      []string
+	parameterIdxs  []int
+	parameterCount int
+	planCache      *lrucache.LRUCache
+}
+
+// NewPreparedStatement tokenizes the query once, so that
+// subsequent binds are cheap. Placeholders inside string literals,
+// quoted identifiers and comments are not parameters.
+func NewPreparedStatement(name string, query string) (PreparedStatement, error) {
+	return newStandardPreparedStatement(name, query)
+}
+
+func newStandardPreparedStatement(name string, query string) (*standardPreparedStatement, error) {
+	rv := &standardPreparedStatement{
+		name:      name,
+		query:     query,
+		planCache: lrucache.NewLRUCache(planCacheCapacity),
+	}
+	var sb strings.Builder
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := scanQuoted(query, i, c)
+			sb.WriteString(query[i:end])
+			i = end - 1
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			sb.WriteString(query[i : i+end])
+			i += end - 1
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query)
+			} else {
+				end += i + 4
+			}
+			sb.WriteString(query[i:end])
+			i = end - 1
+		case c == '$' && i+1 < len(query) && isDigit(query[i+1]):
+			j := i + 1
+			for j < len(query) && isDigit(query[j]) {
+				j++
+			}
+			idx, err := strconv.Atoi(query[i+1 : j])
+			if err != nil || idx < 1 {
+				return nil, fmt.Errorf("invalid parameter placeholder '%s'", query[i:j])
+			}
+			rv.fragments = append(rv.fragments, sb.String())
+			sb.Reset()
+			rv.parameterIdxs = append(rv.parameterIdxs, idx)
+			if idx > rv.parameterCount {
+				rv.parameterCount = idx
+			}
+			i = j - 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	rv.fragments = append(rv.fragments, sb.String())
+	return rv, nil
+}
+
+// NewCachedPreparedStatement reuses the tokenization of the same
+// query, as prepared by any session, from the query cache.  Plans of
+// bound queries are cached per statement, and so per session.
+func NewCachedPreparedStatement(
+	cache *lrucache.LRUCache,
+	name string,
+	query string,
+) (PreparedStatement, error) {
+	if cache == nil {
+		return NewPreparedStatement(name, query)
+	}
+	key := cacheKeyPrefix + query
+	if v, ok := cache.Get(key); ok {
+		if template, isTemplate := v.(*standardPreparedStatement); isTemplate {
+			rv := *template
+			rv.name = name
+			rv.planCache = lrucache.NewLRUCache(planCacheCapacity)
+			return &rv, nil
+		}
+	}
+	ps, err := newStandardPreparedStatement(name, query)
+	if err != nil {
+		return nil, err
+	}
+	template := *ps
+	cache.Set(key, &template)
+	return ps, nil
+}
+
+// Size is defined so that statements can be given to a cache.LRUCache.
+func (ps *standardPreparedStatement) Size() int {
+	return 1
+}
+
+func (ps *standardPreparedStatement) GetName() string {
+	return ps.name
+}
+
+func (ps *standardPreparedStatement) GetQuery() string {
+	return ps.query
+}
+
+func (ps *standardPreparedStatement) GetParameterCount() int {
+	return ps.parameterCount
+}
+
+func (ps *standardPreparedStatement) GetPlanCache() *lrucache.LRUCache {
+	return ps.planCache
+}
+
+func (ps *standardPreparedStatement) Bind(args []string) (string, error) {
+	if len(args) != ps.parameterCount {
+		return "", fmt.Errorf(
+			"wrong number of parameters for prepared statement \"%s\": expected %d, got %d",
+			ps.name, ps.parameterCount, len(args))
+	}
+	var sb strings.Builder
+	for i, fragment := range ps.fragments {
+		sb.WriteString(fragment)
+		if i < len(ps.parameterIdxs) {
+			sb.WriteString(args[ps.parameterIdxs[i]-1])
+		}
+	}
+	return sb.String(), nil
+}
+
+// QuoteLiteral renders a bound parameter value, for instance
+// a text format value from a Bind message, as a SQL literal.
+func QuoteLiteral(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		rv := fmt.Sprintf("%v", v)
+		// NOTE: parenthesized, lest a preceding minus
+		// sign render a negative number a comment.
+		if strings.HasPrefix(rv, "-") {
+			return "(" + rv + ")"
+		}
+		return rv
+	case []byte:
+		return quoteString(string(v))
+	case string:
+		return quoteString(v)
+	default:
+		return quoteString(fmt.Sprintf("%v", v))
+	}
+}
+
+// quoteString escapes backslashes as well as quotes,
+// since the stackql lexer honours backslash escapes.
+func quoteString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", "''").Replace(s) + "'"
+}
+
+// scanQuoted returns the index one past the closing quote,
+// honouring doubled quotes and, in string
+// literals, backslash escapes, as does the stackql lexer.
+func scanQuoted(s string, start int, quote byte) int {
+	for i := start + 1; i < len(s); i++ {
+		if s[i] == '\\' && quote == '\'' {
+			i++
+			continue
+		}
+		if s[i] != quote {
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(s)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,126 @@
+package preparedstatement_test
+
+import (
+	"strings"
+	"testing"
+
+	lrucache "github.com/stackql/stackql-parser/go/cache"
+	"github.com/stackql/stackql-parser/go/vt/sqlparser"
+
+	"github.com/stackql/stackql/internal/stackql/plan"
+
+	. "github.com/stackql/stackql/internal/stackql/preparedstatement"
+)
+
+func TestBindSkipsQuotedPlaceholders(t *testing.T) {
+	ps, err := NewPreparedStatement(
+		"p",
+		`SELECT name, '$1' as lit, "$2" /* $3 */ FROM google.compute.instances WHERE project = $1 AND zone = $2 -- $3`,
+	)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if ps.GetParameterCount() != 2 {
+		t.Fatalf("test failed: expected 2 parameters, got %d", ps.GetParameterCount())
+	}
+	bound, err := ps.Bind([]string{QuoteLiteral("it's"), QuoteLiteral(42)})
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	expected := `SELECT name, '$1' as lit, "$2" /* $3 */ FROM google.compute.instances WHERE project = 'it''s' AND zone = 42 -- $3`
+	if bound != expected {
+		t.Fatalf("test failed: expected '%s', got '%s'", expected, bound)
+	}
+	if _, err = ps.Bind([]string{"1"}); err == nil {
+		t.Fatal("test failed: expected error for wrong parameter count")
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	cmd, isCmd, err := ParseCommand("PREPARE p (text, int) AS SELECT $1, $2;")
+	if err != nil || !isCmd || cmd.GetType() != PrepareCommand {
+		t.Fatalf("test failed: PREPARE not recognised: %v", err)
+	}
+	if cmd.GetName() != "p" || cmd.GetQuery() != "SELECT $1, $2" {
+		t.Fatalf("test failed: unexpected PREPARE parse: '%s', '%s'", cmd.GetName(), cmd.GetQuery())
+	}
+	cmd, isCmd, err = ParseCommand("execute p('a, b', coalesce(1, 2))")
+	if err != nil || !isCmd || cmd.GetType() != ExecuteCommand {
+		t.Fatalf("test failed: EXECUTE not recognised: %v", err)
+	}
+	if args := cmd.GetArgs(); len(args) != 2 || args[0] != "'a, b'" || args[1] != "coalesce(1, 2)" {
+		t.Fatalf("test failed: unexpected EXECUTE args: %v", cmd.GetArgs())
+	}
+	cmd, isCmd, err = ParseCommand("DEALLOCATE ALL")
+	if err != nil || !isCmd || !cmd.IsDeallocateAll() {
+		t.Fatalf("test failed: DEALLOCATE ALL not recognised: %v", err)
+	}
+	_, isCmd, _ = ParseCommand("EXEC google.compute.instances.start @project = 'p'")
+	if isCmd {
+		t.Fatal("test failed: EXEC misrecognised as prepared statement command")
+	}
+}
+
+func TestQuoteLiteralRoundTrips(t *testing.T) {
+	ps, err := NewPreparedStatement("p", `SELECT $1 FROM google.compute.instances WHERE zone = 'a\'$2' AND id = 1 -$3`)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if ps.GetParameterCount() != 3 {
+		t.Fatalf("test failed: expected 3 parameters, got %d", ps.GetParameterCount())
+	}
+	for _, s := range []string{`a\`, `x\'; drop table t; --`, `it's`} {
+		bound, err := ps.Bind([]string{QuoteLiteral(s), QuoteLiteral(""), QuoteLiteral(-1)})
+		if err != nil {
+			t.Fatalf("test failed: %v", err)
+		}
+		stmt, err := sqlparser.Parse(bound)
+		if err != nil {
+			t.Fatalf("test failed: bound query '%s' unparseable: %v", bound, err)
+		}
+		sel, ok := stmt.(*sqlparser.Select)
+		if !ok {
+			t.Fatalf("test failed: bound query '%s' parsed as %T", bound, stmt)
+		}
+		val, ok := sel.SelectExprs[0].(*sqlparser.AliasedExpr).Expr.(*sqlparser.SQLVal)
+		if !ok || string(val.Val) != s {
+			t.Fatalf("test failed: bound query '%s' does not round trip '%s'", bound, s)
+		}
+		if !strings.HasSuffix(bound, "1 -(-1)") {
+			t.Fatalf("test failed: negative number mis-bound in '%s'", bound)
+		}
+	}
+}
+
+func TestNewCachedPreparedStatementSharesTokenization(t *testing.T) {
+	cache := lrucache.NewLRUCache(10)
+	query := "SELECT $1"
+	if _, err := NewCachedPreparedStatement(cache, "a", query); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if cache.Length() != 1 {
+		t.Fatalf("test failed: statement not cached")
+	}
+	ps, err := NewCachedPreparedStatement(cache, "b", query)
+	if err != nil || ps.GetName() != "b" || ps.GetParameterCount() != 1 {
+		t.Fatalf("test failed: unexpected cached statement, error = %v", err)
+	}
+	if bound, _ := ps.Bind([]string{"1"}); bound != "SELECT 1" {
+		t.Fatalf("test failed: cached statement bound as '%s'", bound)
+	}
+	first, _ := NewCachedPreparedStatement(cache, "c", query)
+	first.GetPlanCache().Set("SELECT 1", plan.NewPlan("SELECT 1"))
+	if second, _ := NewCachedPreparedStatement(cache, "d", query); second.GetPlanCache().Length() != 0 {
+		t.Fatalf("test failed: plan cache shared between statements")
+	}
+}
+
+func TestBindSkipsUnterminatedBlockComment(t *testing.T) {
+	ps, err := NewPreparedStatement("p", "SELECT $1 /* $2")
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if ps.GetParameterCount() != 1 {
+		t.Fatalf("test failed: expected 1 parameter, got %d", ps.GetParameterCount())
+	}
+}
@@ -0,0 +1,68 @@
+package preparedstatement
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	_ Registry = &standardRegistry{}
+)
+
+// Registry holds the prepared statements of a single session.
+type Registry interface {
+	Clear()
+	Delete(name string) error
+	Get(name string) (PreparedStatement, error)
+	Put(PreparedStatement) error
+}
+
+type standardRegistry struct {
+	mutex      sync.Mutex
+	statements map[string]PreparedStatement
+}
+
+func NewRegistry() Registry {
+	return &standardRegistry{
+		statements: make(map[string]PreparedStatement),
+	}
+}
+
+// Put follows postgres in that named statements may not be
+// silently redefined, whereas the unnamed statement may.
+func (r *standardRegistry) Put(ps PreparedStatement) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	name := ps.GetName()
+	if _, exists := r.statements[name]; exists && name != "" {
+		return fmt.Errorf("prepared statement \"%s\" already exists", name)
+	}
+	r.statements[name] = ps
+	return nil
+}
+
+func (r *standardRegistry) Get(name string) (PreparedStatement, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	ps, ok := r.statements[name]
+	if !ok {
+		return nil, fmt.Errorf("prepared statement \"%s\" does not exist", name)
+	}
+	return ps, nil
+}
+
+func (r *standardRegistry) Delete(name string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.statements[name]; !ok {
+		return fmt.Errorf("prepared statement \"%s\" does not exist", name)
+	}
+	delete(r.statements, name)
+	return nil
+}
+
+func (r *standardRegistry) Clear() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.statements = make(map[string]PreparedStatement)
+}
@@ -0,0 +1,39 @@
+package primitive
+
+import (
+	"context"
+)
+
+var (
+	_ IPrimitiveCtx = &contextualPrimitiveCtx{}
+)
+
+type contextualPrimitiveCtx struct {
+	IPrimitiveCtx
+	ctx context.Context
+}
+
+// NewContextualPrimitiveCtx overlays a bounding
+// context upon an existing primitive context.
+func NewContextualPrimitiveCtx(pc IPrimitiveCtx, ctx context.Context) IPrimitiveCtx {
+	return &contextualPrimitiveCtx{
+		IPrimitiveCtx: pc,
+		ctx:           ctx,
+	}
+}
+
+func (pc *contextualPrimitiveCtx) GetContext() context.Context {
+	return pc.ctx
+}
+
+// ContextOf tolerates absent primitive contexts,
+// defaulting to an unbounded context.
+func ContextOf(pc IPrimitiveCtx) context.Context {
+	if pc == nil {
+		return context.Background()
+	}
+	if ctx := pc.GetContext(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
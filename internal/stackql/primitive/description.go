@@ -0,0 +1,85 @@
+package primitive
+
+var (
+	_ Description = &standardDescription{}
+)
+
+// Description is the plan time summary
+// of a primitive, as surfaced by EXPLAIN.
+type Description interface {
+	GetBuilderName() string
+	GetMethod() string
+	GetProvider() string
+	GetQuery() string
+	GetResource() string
+	GetService() string
+	WithMethod(string) Description
+	WithProvider(string) Description
+	WithQuery(string) Description
+	WithResource(string) Description
+	WithService(string) Description
+}
+
+type standardDescription struct {
+	builderName string
+	provider    string
+	service     string
+	resource    string
+	method      string
+	query       string
+}
+
+func NewDescription(builderName string) Description {
+	return &standardDescription{
+		builderName: builderName,
+	}
+}
+
+func (d *standardDescription) GetBuilderName() string {
+	return d.builderName
+}
+
+func (d *standardDescription) GetProvider() string {
+	return d.provider
+}
+
+func (d *standardDescription) GetService() string {
+	return d.service
+}
+
+func (d *standardDescription) GetResource() string {
+	return d.resource
+}
+
+func (d *standardDescription) GetMethod() string {
+	return d.method
+}
+
+func (d *standardDescription) GetQuery() string {
+	return d.query
+}
+
+func (d *standardDescription) WithProvider(provider string) Description {
+	d.provider = provider
+	return d
+}
+
+func (d *standardDescription) WithService(service string) Description {
+	d.service = service
+	return d
+}
+
+func (d *standardDescription) WithResource(resource string) Description {
+	d.resource = resource
+	return d
+}
+
+func (d *standardDescription) WithMethod(method string) Description {
+	d.method = method
+	return d
+}
+
+func (d *standardDescription) WithQuery(query string) Description {
+	d.query = query
+	return d
+}
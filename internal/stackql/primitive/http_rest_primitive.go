@@ -21,6 +21,7 @@ type HTTPRestPrimitive struct {
 	undoLog       binlog.LogEntry
 	redoLog       binlog.LogEntry
 	debugName     string
+	description   Description
 }
 
 func NewHTTPRestPrimitive(
@@ -116,3 +117,15 @@ func (pr *HTTPRestPrimitive) SetExecutor(ex func(pc IPrimitiveCtx) internaldto.E
 	pr.Executor = ex
 	return nil
 }
+
+func (pr *HTTPRestPrimitive) WithDescription(description Description) IPrimitive {
+	pr.description = description
+	return pr
+}
+
+func (pr *HTTPRestPrimitive) GetDescription() Description {
+	if pr.description != nil {
+		return pr.description
+	}
+	return NewDescription("HTTPRestPrimitive")
+}
@@ -0,0 +1,27 @@
+package primitive
+
+import (
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+)
+
+var (
+	_ IPrimitiveCtx = &instrumentedPrimitiveCtx{}
+)
+
+type instrumentedPrimitiveCtx struct {
+	IPrimitiveCtx
+	stats internaldto.ExecutionStats
+}
+
+// NewInstrumentedPrimitiveCtx overlays execution
+// statistics upon an existing primitive context.
+func NewInstrumentedPrimitiveCtx(pc IPrimitiveCtx, stats internaldto.ExecutionStats) IPrimitiveCtx {
+	return &instrumentedPrimitiveCtx{
+		IPrimitiveCtx: pc,
+		stats:         stats,
+	}
+}
+
+func (pc *instrumentedPrimitiveCtx) GetExecutionStats() (internaldto.ExecutionStats, bool) {
+	return pc.stats, true
+}
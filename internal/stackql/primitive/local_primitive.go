@@ -8,13 +8,14 @@ import (
 )
 
 type LocalPrimitive struct {
-	Executor   func(pc IPrimitiveCtx) internaldto.ExecutorOutput
-	Preparator func() *drm.PreparedStatementCtx
-	Inputs     map[int64]internaldto.ExecutorOutput
-	id         int64
-	undoLog    binlog.LogEntry
-	redoLog    binlog.LogEntry
-	debugName  string
+	Executor    func(pc IPrimitiveCtx) internaldto.ExecutorOutput
+	Preparator  func() *drm.PreparedStatementCtx
+	Inputs      map[int64]internaldto.ExecutorOutput
+	id          int64
+	undoLog     binlog.LogEntry
+	redoLog     binlog.LogEntry
+	debugName   string
+	description Description
 }
 
 func NewLocalPrimitive(executor func(pc IPrimitiveCtx) internaldto.ExecutorOutput) IPrimitive {
@@ -86,3 +87,15 @@ func (pr *LocalPrimitive) Execute(pc IPrimitiveCtx) internaldto.ExecutorOutput {
 	}
 	return internaldto.NewExecutorOutput(nil, nil, nil, nil, nil)
 }
+
+func (pr *LocalPrimitive) WithDescription(description Description) IPrimitive {
+	pr.description = description
+	return pr
+}
+
+func (pr *LocalPrimitive) GetDescription() Description {
+	if pr.description != nil {
+		return pr.description
+	}
+	return NewDescription("LocalPrimitive")
+}
@@ -10,13 +10,14 @@ import (
 )
 
 type MetaDataPrimitive struct {
-	Provider   provider.IProvider
-	Executor   func(pc IPrimitiveCtx) internaldto.ExecutorOutput
-	Preparator func() *drm.PreparedStatementCtx
-	id         int64
-	undoLog    binlog.LogEntry
-	redoLog    binlog.LogEntry
-	debugName  string
+	Provider    provider.IProvider
+	Executor    func(pc IPrimitiveCtx) internaldto.ExecutorOutput
+	Preparator  func() *drm.PreparedStatementCtx
+	id          int64
+	undoLog     binlog.LogEntry
+	redoLog     binlog.LogEntry
+	debugName   string
+	description Description
 }
 
 func (pr *MetaDataPrimitive) SetTxnID(_ int) {
@@ -89,3 +90,15 @@ func NewMetaDataPrimitive(
 		Executor: executor,
 	}
 }
+
+func (pr *MetaDataPrimitive) WithDescription(description Description) IPrimitive {
+	pr.description = description
+	return pr
+}
+
+func (pr *MetaDataPrimitive) GetDescription() Description {
+	if pr.description != nil {
+		return pr.description
+	}
+	return NewDescription("MetaDataPrimitive")
+}
@@ -16,6 +16,7 @@ type PassThroughPrimitive struct {
 	undoLog                binlog.LogEntry
 	redoLog                binlog.LogEntry
 	debugName              string
+	description            Description
 }
 
 func NewPassThroughPrimitive(
@@ -91,3 +92,15 @@ func (pr *PassThroughPrimitive) Execute(_ IPrimitiveCtx) internaldto.ExecutorOut
 	}
 	return internaldto.NewEmptyExecutorOutput()
 }
+
+func (pr *PassThroughPrimitive) WithDescription(description Description) IPrimitive {
+	pr.description = description
+	return pr
+}
+
+func (pr *PassThroughPrimitive) GetDescription() Description {
+	if pr.description != nil {
+		return pr.description
+	}
+	return NewDescription("PassThroughPrimitive")
+}
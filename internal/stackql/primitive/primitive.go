@@ -1,6 +1,7 @@
 package primitive
 
 import (
+	"context"
 	"io"
 
 	"github.com/stackql/any-sdk/pkg/dto"
@@ -10,8 +11,14 @@ import (
 
 type IPrimitiveCtx interface {
 	GetAuthContext(string) (*dto.AuthCtx, error)
+	// Get the context bounding execution;
+	// cancelled upon timeout or client interruption.
+	GetContext() context.Context
 	GetWriter() io.Writer
 	GetErrWriter() io.Writer
+	// Get the statistics sink for the executing primitive;
+	// only present under EXPLAIN ANALYZE.
+	GetExecutionStats() (internaldto.ExecutionStats, bool)
 }
 
 type IPrimitive interface {
@@ -42,4 +49,9 @@ type IPrimitive interface {
 	GetInputFromAlias(string) (internaldto.ExecutorOutput, bool)
 
 	WithDebugName(string) IPrimitive
+
+	// Get the plan time description, as surfaced by EXPLAIN.
+	GetDescription() Description
+
+	WithDescription(Description) IPrimitive
 }
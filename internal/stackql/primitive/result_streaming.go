@@ -0,0 +1,20 @@
+package primitive
+
+import (
+	"context"
+)
+
+type resultStreamingContextKey struct{}
+
+// NewResultStreamingContext marks whether a primitive may return its
+// result streamed lazily from the backend, rather than materialised.
+// Only those results certain to be read through should be streamed,
+// lest backend rows be held open.
+func NewResultStreamingContext(ctx context.Context, isStreaming bool) context.Context {
+	return context.WithValue(ctx, resultStreamingContextKey{}, isStreaming)
+}
+
+func ResultStreamingFromContext(ctx context.Context) bool {
+	isStreaming, _ := ctx.Value(resultStreamingContextKey{}).(bool)
+	return isStreaming
+}
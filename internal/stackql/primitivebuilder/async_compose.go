@@ -36,5 +36,5 @@ func composeAsyncMonitor(
 	if err != nil {
 		return nil, err
 	}
-	return primitive, err
+	return primitive.WithDescription(precursor.GetDescription()), err
 }
@@ -49,7 +49,7 @@ func (nb *DataflowGraphBuilder) Build() error {
 			)
 		},
 	)
-	nb.root = nb.graph.CreatePrimitiveNode(pr)
+	nb.root = nb.graph.CreatePrimitiveNode(pr.WithDescription(primitive.NewDescription("DataflowGraph")))
 	return nil
 }
 
@@ -139,7 +139,9 @@ func (ddo *ddl) Build() error {
 		)
 	}
 	graph := ddo.graph
-	ddlGraphNode := graph.CreatePrimitiveNode(primitive.NewLocalPrimitive(ddlEx))
+	ddlGraphNode := graph.CreatePrimitiveNode(
+		primitive.NewLocalPrimitive(ddlEx).WithDescription(primitive.NewDescription("DDL")),
+	)
 
 	ddo.root = ddlGraphNode
 	ddo.tail = ddlGraphNode
@@ -2,6 +2,7 @@ package primitivebuilder
 
 import (
 	"fmt"
+	"net/http"
 	"strconv"
 
 	"github.com/stackql/any-sdk/pkg/logging"
@@ -71,6 +72,7 @@ func (ss *Delete) Build() error {
 	tableName, _ := tbl.GetTableName()
 	ex := func(pc primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
 		var target map[string]interface{}
+		var lastResponse *http.Response
 		keys := make(map[string]map[string]interface{})
 		httpArmoury, httpErr := tbl.GetHTTPArmoury()
 		if httpErr != nil {
@@ -79,12 +81,19 @@ func (ss *Delete) Build() error {
 			))
 		}
 		for _, req := range httpArmoury.GetRequestParams() {
-			response, apiErr := httpmiddleware.HTTPApiCallFromRequest(handlerCtx.Clone(), prov, m, req.GetRequest())
+			response, apiErr := httpmiddleware.HTTPApiCallFromRequest(
+				primitive.ContextOf(pc),
+				handlerCtx.Clone(),
+				prov,
+				m,
+				req.GetRequest(),
+			)
 			if apiErr != nil {
 				return util.PrepareResultSet(internaldto.NewPrepareResultSetDTO(nil, nil, nil, nil, apiErr, nil,
 					ss.handlerCtx.GetTypingConfig(),
 				))
 			}
+			lastResponse = response
 			target, err = m.DeprecatedProcessResponse(response)
 			if response.StatusCode < 300 && len(target) < 1 {
 				return util.PrepareResultSet(internaldto.NewPrepareResultSetDTO(
@@ -104,7 +113,7 @@ func (ss *Delete) Build() error {
 							"Undo the delete on " + tableName,
 						},
 					),
-				)
+				).WithHTTPResponse(response)
 			}
 			handlerCtx.LogHTTPResponseMap(target)
 
@@ -142,7 +151,7 @@ func (ss *Delete) Build() error {
 			err,
 			false,
 			ss.handlerCtx.GetTypingConfig(),
-		)
+		).WithHTTPResponse(lastResponse)
 	}
 	deletePrimitive := primitive.NewHTTPRestPrimitive(
 		prov,
@@ -150,9 +159,9 @@ func (ss *Delete) Build() error {
 		nil,
 		nil,
 		primitive_context.NewPrimitiveContext(),
-	)
+	).WithDescription(describeTableAccess("Delete", ss.tbl))
 	if ss.isAwait {
-		deletePrimitive, err = composeAsyncMonitor(handlerCtx, deletePrimitive, prov, m, nil)
+		deletePrimitive, err = composeAsyncMonitor(handlerCtx, deletePrimitive, prov, m, ss.commentDirectives)
 	}
 	if err != nil {
 		return err
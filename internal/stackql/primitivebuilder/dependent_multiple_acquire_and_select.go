@@ -48,7 +48,7 @@ func (ss *DependentMultipleAcquireAndSelect) Build() error {
 			ss.sqlSystem,
 			ss.graph.GetTxnControlCounterSlice(),
 			false,
-		),
+		).WithDescription(primitive.NewDescription("DependentMultipleAcquireAndSelect")),
 	)
 	err := ss.selectBuilder.Build()
 	if err != nil {
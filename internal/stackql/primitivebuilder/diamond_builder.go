@@ -43,7 +43,7 @@ func (db *DiamondBuilder) Build() error {
 			db.sqlSystem,
 			db.graphHolder.GetTxnControlCounterSlice(),
 			false,
-		),
+		).WithDescription(primitive.NewDescription("Diamond")),
 	)
 	if db.parentBuilder != nil {
 		err := db.parentBuilder.Build()
@@ -58,7 +58,7 @@ func (db *DiamondBuilder) Build() error {
 				db.sqlSystem,
 				db.graphHolder.GetTxnControlCounterSlice(),
 				db.shouldCollectGarbage,
-			),
+			).WithDescription(primitive.NewDescription("Diamond")),
 		)
 		db.tailTail = db.tailRoot
 	}
@@ -2,6 +2,7 @@ package primitivebuilder
 
 import (
 	"fmt"
+	"net/http"
 	"strconv"
 
 	"github.com/stackql/any-sdk/pkg/logging"
@@ -18,13 +19,14 @@ import (
 )
 
 type Exec struct {
-	graph         primitivegraph.PrimitiveGraphHolder
-	handlerCtx    handler.HandlerContext
-	drmCfg        drm.Config
-	root          primitivegraph.PrimitiveNode
-	tbl           tablemetadata.ExtendedTableMetadata
-	isAwait       bool
-	isShowResults bool
+	graph             primitivegraph.PrimitiveGraphHolder
+	handlerCtx        handler.HandlerContext
+	drmCfg            drm.Config
+	root              primitivegraph.PrimitiveNode
+	tbl               tablemetadata.ExtendedTableMetadata
+	commentDirectives sqlparser.CommentDirectives
+	isAwait           bool
+	isShowResults     bool
 }
 
 func NewExec(
@@ -32,16 +34,18 @@ func NewExec(
 	handlerCtx handler.HandlerContext,
 	node sqlparser.SQLNode, //nolint:revive // future proofing
 	tbl tablemetadata.ExtendedTableMetadata,
+	commentDirectives sqlparser.CommentDirectives,
 	isAwait bool,
 	isShowResults bool,
 ) Builder {
 	return &Exec{
-		graph:         graph,
-		handlerCtx:    handlerCtx,
-		drmCfg:        handlerCtx.GetDrmConfig(),
-		tbl:           tbl,
-		isAwait:       isAwait,
-		isShowResults: isShowResults,
+		graph:             graph,
+		handlerCtx:        handlerCtx,
+		drmCfg:            handlerCtx.GetDrmConfig(),
+		tbl:               tbl,
+		commentDirectives: commentDirectives,
+		isAwait:           isAwait,
+		isShowResults:     isShowResults,
 	}
 }
 
@@ -70,18 +74,28 @@ func (ss *Exec) Build() error {
 	//nolint:revive // no big deal
 	ex := func(pc primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
 		var columnOrder []string
+		// the async monitor reads status headers and
+		// resolves relative poll urls from the response
+		var lastResponse *http.Response
 		keys := make(map[string]map[string]interface{})
 		httpArmoury, httpArmouryErr := tbl.GetHTTPArmoury()
 		if httpArmouryErr != nil {
 			return internaldto.NewErroneousExecutorOutput(httpArmouryErr)
 		}
 		for i, req := range httpArmoury.GetRequestParams() {
-			response, apiErr := httpmiddleware.HTTPApiCallFromRequest(handlerCtx.Clone(), prov, m, req.GetRequest())
+			response, apiErr := httpmiddleware.HTTPApiCallFromRequest(
+				primitive.ContextOf(pc),
+				handlerCtx.Clone(),
+				prov,
+				m,
+				req.GetRequest(),
+			)
 			if apiErr != nil {
 				return util.PrepareResultSet(internaldto.NewPrepareResultSetDTO(nil, nil, nil, nil, apiErr, nil,
 					handlerCtx.GetTypingConfig(),
 				))
 			}
+			lastResponse = response
 			if isNullary {
 				//nolint:mnd // acceptable for now
 				if response.StatusCode <= 300 {
@@ -137,7 +151,7 @@ func (ss *Exec) Build() error {
 			),
 			err, ss.isShowResults,
 			ss.handlerCtx.GetTypingConfig(),
-		)
+		).WithHTTPResponse(lastResponse)
 	}
 	execPrimitive := primitive.NewHTTPRestPrimitive(
 		prov,
@@ -145,12 +159,12 @@ func (ss *Exec) Build() error {
 		nil,
 		nil,
 		primitive_context.NewPrimitiveContext(),
-	)
+	).WithDescription(describeTableAccess("Exec", ss.tbl))
 	if !ss.isAwait {
 		ss.graph.CreatePrimitiveNode(execPrimitive)
 		return nil
 	}
-	pr, err := composeAsyncMonitor(handlerCtx, execPrimitive, prov, m, nil)
+	pr, err := composeAsyncMonitor(handlerCtx, execPrimitive, prov, m, ss.commentDirectives)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,105 @@
+package primitivebuilder
+
+import (
+	"context"
+	"sync"
+
+	"github.com/stackql/any-sdk/anysdk"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"golang.org/x/sync/semaphore"
+)
+
+// paramAcquisition is the outcome of acquisition
+// for a single combination of request parameters.
+type paramAcquisition struct {
+	paramsUsed  map[string]interface{}
+	reqEncoding string
+	pages       [][]map[string]interface{}
+	// output, where present, terminates acquisition
+	// once any preceding pages are persisted.
+	output      internaldto.ExecutorOutput
+	isCancelled bool
+}
+
+// acquisitionFanOut publishes each outcome on its own channel
+// so that the caller may consume, and persist, in order.
+type acquisitionFanOut struct {
+	outcomes []chan paramAcquisition
+	// window bounds outcomes in flight, or awaiting
+	// consumption, lest workers run ahead of the caller.
+	window chan struct{}
+	done   chan struct{}
+}
+
+// receive blocks upon the i-th outcome, making way for a further
+// worker once it is consumed.  It returns false should the context
+// be done first, as the outcome's worker may then never start.
+func (f *acquisitionFanOut) receive(ctx context.Context, i int) (paramAcquisition, bool) {
+	select {
+	case rv := <-f.outcomes[i]:
+		<-f.window
+		return rv, true
+	case <-ctx.Done():
+		return paramAcquisition{}, false
+	}
+}
+
+// wait blocks until all workers have returned; the
+// context of the fan out must first be done, or else
+// all outcomes must be received.
+func (f *acquisitionFanOut) wait() {
+	<-f.done
+}
+
+// fanOutAcquisitions acquires request parameter combinations concurrently.
+// Outcomes unreceived by the caller, in flight or complete, number no more
+// than the limit, if positive.  Workers further hold one of the slots, if
+// any, for their duration.  Once the context is done, no further workers start.
+func fanOutAcquisitions(
+	ctx context.Context,
+	limit int,
+	slots *semaphore.Weighted,
+	reqParams []anysdk.HTTPArmouryParameters,
+	acquire func(context.Context, int, anysdk.HTTPArmouryParameters) paramAcquisition,
+) *acquisitionFanOut {
+	windowSize := limit
+	if windowSize <= 0 {
+		windowSize = len(reqParams)
+	}
+	rv := &acquisitionFanOut{
+		outcomes: make([]chan paramAcquisition, len(reqParams)),
+		window:   make(chan struct{}, windowSize),
+		done:     make(chan struct{}),
+	}
+	for i := range rv.outcomes {
+		rv.outcomes[i] = make(chan paramAcquisition, 1)
+	}
+	// Submission blocks upon the window and slots,
+	// and so is detached from consumption by the caller.
+	go func() {
+		var workers sync.WaitGroup
+		defer close(rv.done)
+		defer workers.Wait()
+		for i, rc := range reqParams {
+			select {
+			case rv.window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			if slots != nil {
+				if err := slots.Acquire(ctx, 1); err != nil {
+					return
+				}
+			}
+			workers.Add(1)
+			go func() {
+				defer workers.Done()
+				if slots != nil {
+					defer slots.Release(1)
+				}
+				rv.outcomes[i] <- acquire(ctx, i, rc)
+			}()
+		}
+	}()
+	return rv
+}
@@ -0,0 +1,223 @@
+package primitivebuilder //nolint:testpackage // fan out is unexported
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stackql/any-sdk/anysdk"
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/primitivegraph"
+	"golang.org/x/sync/semaphore"
+)
+
+// fanOutTestCounters tracks acquisitions in progress and those
+// published yet unreceived, and the peaks thereof.
+type fanOutTestCounters struct {
+	mutex          sync.Mutex
+	started        int
+	running        int
+	peakRunning    int
+	unreceived     int
+	peakUnreceived int
+}
+
+func (c *fanOutTestCounters) start() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.started++
+	c.running++
+	c.unreceived++
+	c.peakRunning = max(c.peakRunning, c.running)
+	c.peakUnreceived = max(c.peakUnreceived, c.unreceived)
+}
+
+func (c *fanOutTestCounters) finish() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.running--
+}
+
+func (c *fanOutTestCounters) receive() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.unreceived--
+}
+
+func newFanOutTestAcquire(
+	counters *fanOutTestCounters,
+) func(context.Context, int, anysdk.HTTPArmouryParameters) paramAcquisition {
+	return func(_ context.Context, i int, _ anysdk.HTTPArmouryParameters) paramAcquisition {
+		counters.start()
+		defer counters.finish()
+		time.Sleep(time.Duration(rand.Intn(3)) * time.Millisecond) //nolint:gosec // test
+		return paramAcquisition{reqEncoding: string(rune('a' + i))}
+	}
+}
+
+func TestFanOutOrderedAndBounded(t *testing.T) {
+	counters := &fanOutTestCounters{}
+	fanOut := fanOutAcquisitions(
+		context.Background(),
+		3,
+		semaphore.NewWeighted(3),
+		make([]anysdk.HTTPArmouryParameters, 20),
+		newFanOutTestAcquire(counters),
+	)
+	for i := 0; i < 20; i++ {
+		// A slow consumer, lest workers run ahead.
+		time.Sleep(time.Millisecond)
+		acquisition, isReceived := fanOut.receive(context.Background(), i)
+		if !isReceived || acquisition.reqEncoding != string(rune('a'+i)) {
+			t.Fatalf("test failed: expected acquisition %d in order, got '%s'", i, acquisition.reqEncoding)
+		}
+		counters.receive()
+	}
+	fanOut.wait()
+	if counters.peakUnreceived > 3 {
+		t.Fatalf("test failed: %d acquisitions unreceived at once, beyond the limit of 3", counters.peakUnreceived)
+	}
+}
+
+func TestFanOutSlotsCapConcurrencyAcrossFanOuts(t *testing.T) {
+	counters := &fanOutTestCounters{}
+	slots := semaphore.NewWeighted(2)
+	var consumers sync.WaitGroup
+	for j := 0; j < 3; j++ {
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			fanOut := fanOutAcquisitions(
+				context.Background(),
+				2,
+				slots,
+				make([]anysdk.HTTPArmouryParameters, 10),
+				newFanOutTestAcquire(counters),
+			)
+			for i := 0; i < 10; i++ {
+				fanOut.receive(context.Background(), i)
+				counters.receive()
+			}
+			fanOut.wait()
+		}()
+	}
+	consumers.Wait()
+	if counters.peakRunning > 2 {
+		t.Fatalf("test failed: %d acquisitions ran at once, beyond the cap of 2", counters.peakRunning)
+	}
+}
+
+func TestFanOutErrorShortCircuits(t *testing.T) {
+	counters := &fanOutTestCounters{}
+	ctx, cancel := context.WithCancel(context.Background())
+	fanOut := fanOutAcquisitions(
+		ctx,
+		2,
+		semaphore.NewWeighted(2),
+		make([]anysdk.HTTPArmouryParameters, 50),
+		func(ctx context.Context, i int, rc anysdk.HTTPArmouryParameters) paramAcquisition {
+			if i == 1 {
+				counters.start()
+				counters.finish()
+				return paramAcquisition{output: internaldto.NewErroneousExecutorOutput(errors.New("forbidden"))}
+			}
+			return newFanOutTestAcquire(counters)(ctx, i, rc)
+		},
+	)
+	// As per the caller, which returns upon the first error.
+	for i := 0; i < 50; i++ {
+		acquisition, _ := fanOut.receive(ctx, i)
+		counters.receive()
+		if acquisition.output != nil {
+			break
+		}
+	}
+	cancel()
+	fanOut.wait()
+	// Those received, and at most a window's worth beyond.
+	if counters.started > 4 {
+		t.Fatalf("test failed: %d acquisitions started despite error at the second", counters.started)
+	}
+}
+
+func TestFanOutCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var started atomic.Int32
+	fanOut := fanOutAcquisitions(
+		ctx,
+		2,
+		semaphore.NewWeighted(2),
+		make([]anysdk.HTTPArmouryParameters, 10),
+		func(ctx context.Context, _ int, _ anysdk.HTTPArmouryParameters) paramAcquisition {
+			started.Add(1)
+			<-ctx.Done()
+			return paramAcquisition{isCancelled: true}
+		},
+	)
+	time.AfterFunc(20*time.Millisecond, cancel)
+	acquisition, isReceived := fanOut.receive(ctx, 0)
+	if isReceived && !acquisition.isCancelled {
+		t.Fatal("test failed: expected cancelled acquisition")
+	}
+	// Acquisitions never begun are not awaited.
+	if _, isReceived = fanOut.receive(ctx, 5); isReceived {
+		t.Fatal("test failed: received acquisition which was never begun")
+	}
+	fanOut.wait()
+	if started.Load() > 2 {
+		t.Fatalf("test failed: %d acquisitions started beyond the limit of 2", started.Load())
+	}
+}
+
+func TestFanOutUnboundedLimit(t *testing.T) {
+	counters := &fanOutTestCounters{}
+	release := make(chan struct{})
+	fanOut := fanOutAcquisitions(
+		context.Background(),
+		-1,
+		primitivegraph.NewPrimitiveGraphHolder(-1).GetAcquisitionSlots(),
+		make([]anysdk.HTTPArmouryParameters, 5),
+		func(ctx context.Context, i int, rc anysdk.HTTPArmouryParameters) paramAcquisition {
+			counters.start()
+			<-release
+			counters.finish()
+			return paramAcquisition{reqEncoding: string(rune('a' + i))}
+		},
+	)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		counters.mutex.Lock()
+		running := counters.running
+		counters.mutex.Unlock()
+		if running == 5 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("test failed: %d acquisitions ran at once, expected all 5 when unbounded", running)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	for i := 0; i < 5; i++ {
+		if acquisition, isReceived := fanOut.receive(context.Background(), i); !isReceived ||
+			acquisition.reqEncoding != string(rune('a'+i)) {
+			t.Fatalf("test failed: expected acquisition %d in order", i)
+		}
+	}
+	fanOut.wait()
+}
+
+func TestAcquisitionSlotsPerGraph(t *testing.T) {
+	lhs := primitivegraph.NewPrimitiveGraphHolder(1).GetAcquisitionSlots()
+	rhs := primitivegraph.NewPrimitiveGraphHolder(1).GetAcquisitionSlots()
+	if !lhs.TryAcquire(1) || !rhs.TryAcquire(1) {
+		t.Fatal("test failed: graphs share acquisition slots")
+	}
+	if lhs.TryAcquire(1) {
+		t.Fatal("test failed: acquisition slots exceed the limit of 1")
+	}
+}
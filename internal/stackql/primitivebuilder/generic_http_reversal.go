@@ -37,6 +37,14 @@ type genericHTTPReversal struct {
 	prov              provider.IProvider
 }
 
+// NewGenericHTTPReversal builds an inverse primitive
+// which issues each reversal in the preparator stream.
+func NewGenericHTTPReversal(
+	builderInput builder_input.BuilderInput,
+) (Builder, error) {
+	return newGenericHTTPReversal(builderInput)
+}
+
 func newGenericHTTPReversal(
 	builderInput builder_input.BuilderInput,
 ) (Builder, error) {
@@ -110,7 +118,7 @@ func (gh *genericHTTPReversal) Build() error {
 		nil,
 		nil,
 		primitive_context.NewPrimitiveContext(),
-	)
+	).WithDescription(primitive.NewDescription("GenericHTTPReversal").WithMethod(m.GetName()))
 	tableName := m.GetName()
 	target := make(map[string]interface{})
 	ex := func(pc primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
@@ -130,7 +138,13 @@ func (gh *genericHTTPReversal) Build() error {
 			for _, r := range httpArmoury.GetRequestParams() {
 				req := r
 				nullaryEx := func() internaldto.ExecutorOutput {
-					response, apiErr := httpmiddleware.HTTPApiCallFromRequest(handlerCtx.Clone(), prov, m, req.GetRequest())
+					response, apiErr := httpmiddleware.HTTPApiCallFromRequest(
+						primitive.ContextOf(pc),
+						handlerCtx.Clone(),
+						prov,
+						m,
+						req.GetRequest(),
+					)
 					if apiErr != nil {
 						return internaldto.NewErroneousExecutorOutput(apiErr)
 					}
@@ -180,7 +194,7 @@ func (gh *genericHTTPReversal) Build() error {
 									nil,
 									msgs,
 									nil,
-								),
+								).WithHTTPResponse(response),
 								tableName,
 							)
 						}
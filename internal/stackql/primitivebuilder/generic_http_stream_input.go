@@ -39,6 +39,7 @@ type genericHTTPStreamInput struct {
 	reversalStream    http_preparator_stream.HttpPreparatorStream
 	reversalBuilder   Builder
 	rollbackType      constants.RollbackType
+	compensations     []binlog.Compensation
 }
 
 func newGenericHTTPStreamInput(
@@ -95,11 +96,56 @@ func (gh *genericHTTPStreamInput) appendReversalData(prep anysdk.HTTPPreparator)
 	return gh.reversalStream.Write(prep)
 }
 
+// appendCompensation retains the reversal in serialisable form,
+// so that it may be issued after a restart.
+func (gh *genericHTTPStreamInput) appendCompensation(
+	inverse anysdk.OperationInverse,
+	processed anysdk.ProcessedOperationResponse,
+) {
+	resp, respOk := processed.GetResponse()
+	inverseOpStore, inverseOpStoreExists := inverse.GetOperationStore()
+	if !respOk || !inverseOpStoreExists {
+		return
+	}
+	params, err := inverse.GetParamMap(resp)
+	if err != nil {
+		logging.GetLogger().Debugf("cannot serialise reversal: %v", err)
+		return
+	}
+	compensation := binlog.Compensation{
+		Method:     inverseOpStore.GetMethodKey(),
+		Parameters: params,
+	}
+	if prov := inverseOpStore.GetProvider(); prov != nil {
+		compensation.Provider = prov.GetName()
+	}
+	if svc := inverseOpStore.GetProviderService(); svc != nil {
+		compensation.Service = svc.GetName()
+	}
+	if rsc := inverseOpStore.GetResource(); rsc != nil {
+		compensation.Resource = rsc.GetName()
+	}
+	gh.compensations = append(gh.compensations, compensation)
+}
+
+func (gh *genericHTTPStreamInput) getCompensationsRaw() []byte {
+	var rv []byte
+	for _, c := range gh.compensations {
+		b, err := c.ToRaw()
+		if err != nil {
+			logging.GetLogger().Debugf("cannot serialise reversal: %v", err)
+			continue
+		}
+		rv = append(rv, b...)
+	}
+	return rv
+}
+
 func (gh *genericHTTPStreamInput) decorateOutput(
 	op internaldto.ExecutorOutput, tableName string) internaldto.ExecutorOutput {
 	op.SetUndoLog(
 		binlog.NewSimpleLogEntry(
-			nil,
+			gh.getCompensationsRaw(),
 			[]string{
 				fmt.Sprintf("Undo the %s on %s", gh.verb, tableName),
 			},
@@ -187,10 +233,11 @@ func (gh *genericHTTPStreamInput) Build() error {
 		nil,
 		nil,
 		primitive_context.NewPrimitiveContext(),
-	)
+	).WithDescription(describeTableAccess("GenericHTTPStreamInput", tbl))
 	// reversalStream := streaming.NewStandardMapStream()
 	target := make(map[string]interface{})
 	ex := func(pc primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
+		gh.compensations = nil
 		pr, prErr := prov.GetProvider()
 		if prErr != nil {
 			return internaldto.NewErroneousExecutorOutput(prErr)
@@ -224,7 +271,13 @@ func (gh *genericHTTPStreamInput) Build() error {
 		for _, r := range httpArmoury.GetRequestParams() {
 			req := r
 			nullaryEx := func() internaldto.ExecutorOutput {
-				response, apiErr := httpmiddleware.HTTPApiCallFromRequest(handlerCtx.Clone(), prov, m, req.GetRequest())
+				response, apiErr := httpmiddleware.HTTPApiCallFromRequest(
+					primitive.ContextOf(pc),
+					handlerCtx.Clone(),
+					prov,
+					m,
+					req.GetRequest(),
+				)
 				if apiErr != nil {
 					return internaldto.NewErroneousExecutorOutput(apiErr)
 				}
@@ -241,6 +294,7 @@ func (gh *genericHTTPStreamInput) Build() error {
 						if reversalAppendErr != nil {
 							return internaldto.NewErroneousExecutorOutput(reversalAppendErr)
 						}
+						gh.appendCompensation(inverse, processed)
 					}
 					if !reversalExists && gh.isReverseRequired() {
 						return internaldto.NewErroneousExecutorOutput(fmt.Errorf("reversal is required but not provided"))
@@ -284,7 +338,7 @@ func (gh *genericHTTPStreamInput) Build() error {
 								nil,
 								msgs,
 								nil,
-							),
+							).WithHTTPResponse(response),
 							tableName,
 						)
 					}
@@ -88,11 +88,15 @@ func (ss *GraphQLSingleSelectAcquire) Build() error {
 		return fmt.Errorf("could not build graphql exection for table")
 	}
 	ex := func(pc primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
+		ctx := primitive.ContextOf(pc)
 		currentTcc := ss.insertPreparedStatementCtx.GetGCCtrlCtrs().Clone()
 		ss.graph.AddTxnControlCounters(currentTcc)
 
-		for _, reqCtx := range httpArmoury.GetRequestParams() {
-			req := reqCtx.GetRequest()
+		for i, reqCtx := range httpArmoury.GetRequestParams() {
+			if ctx.Err() != nil {
+				return cancelledAcquisition(ctx, ss.handlerCtx, i > 0)
+			}
+			req := reqCtx.GetRequest().WithContext(ctx)
 			housekeepingDone := false
 			client, err := httpmiddleware.GetAuthenticatedClient(ss.handlerCtx.Clone(), prov)
 			if err != nil {
@@ -117,8 +121,12 @@ func (ss *GraphQLSingleSelectAcquire) Build() error {
 				return internaldto.NewErroneousExecutorOutput(err)
 			}
 			reqEncoding := reqCtx.Encode()
-			//nolint:lll // chained
-			olderTcc, isMatch := ss.handlerCtx.GetNamespaceCollection().GetAnalyticsCacheTableNamespaceConfigurator().Match(tableName, reqEncoding, ss.drmCfg.GetControlAttributes().GetControlLatestUpdateColumnName(), ss.drmCfg.GetControlAttributes().GetControlInsertEncodedIDColumnName())
+			var olderTcc internaldto.TxnControlCounters
+			isMatch := false
+			if isAnalyticsCacheable(ss.handlerCtx) {
+				//nolint:lll // chained
+				olderTcc, isMatch = ss.handlerCtx.GetNamespaceCollection().GetAnalyticsCacheTableNamespaceConfigurator().Match(tableName, reqEncoding, ss.drmCfg.GetControlAttributes().GetControlLatestUpdateColumnName(), ss.drmCfg.GetControlAttributes().GetControlInsertEncodedIDColumnName())
+			}
 			if isMatch {
 				nonControlColumns := ss.insertPreparedStatementCtx.GetNonControlColumns()
 				var nonControlColumnNames []string
@@ -152,6 +160,9 @@ func (ss *GraphQLSingleSelectAcquire) Build() error {
 				return internaldto.NewErroneousExecutorOutput(err)
 			}
 			for {
+				if ctx.Err() != nil {
+					return cancelledAcquisition(ctx, ss.handlerCtx, housekeepingDone)
+				}
 				response, err := graphQLReader.Read()
 				ss.handlerCtx.LogHTTPResponseMap(response)
 				if len(response) > 0 {
@@ -196,6 +207,8 @@ func (ss *GraphQLSingleSelectAcquire) Build() error {
 		prep,
 		ss.txnCtrlCtr,
 		primitive_context.NewPrimitiveContext(),
+	).WithDescription(
+		describeTableAccess("GraphQLSingleSelectAcquire", ss.tableMeta),
 	)
 	graph := ss.graph
 	insertNode := graph.CreatePrimitiveNode(insertPrim)
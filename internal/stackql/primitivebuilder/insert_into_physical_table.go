@@ -69,7 +69,9 @@ func (ddo *insertIntoPhysicalTable) Build() error {
 		)
 	}
 	graph := ddo.graph
-	ddlGraphNode := graph.CreatePrimitiveNode(primitive.NewLocalPrimitive(refreshEx))
+	ddlGraphNode := graph.CreatePrimitiveNode(
+		primitive.NewLocalPrimitive(refreshEx).WithDescription(primitive.NewDescription("InsertIntoPhysicalTable")),
+	)
 
 	ddo.root = ddlGraphNode
 	ddo.tail = ddlGraphNode
@@ -86,7 +86,9 @@ func (ss *NativeSelect) Build() error {
 		)
 	}
 	graph := ss.graph
-	selectNode := graph.CreatePrimitiveNode(primitive.NewLocalPrimitive(selectEx))
+	selectNode := graph.CreatePrimitiveNode(
+		primitive.NewLocalPrimitive(selectEx).WithDescription(primitive.NewDescription("NativeSelect")),
+	)
 	ss.root = selectNode
 
 	return nil
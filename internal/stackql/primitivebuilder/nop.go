@@ -55,7 +55,7 @@ func (nb *NopBuilder) Build() error {
 			)
 		},
 	)
-	nb.root = nb.graph.CreatePrimitiveNode(pr)
+	nb.root = nb.graph.CreatePrimitiveNode(pr.WithDescription(primitive.NewDescription("Nop")))
 	return nil
 }
 
@@ -80,7 +80,11 @@ func (ss *RawNativeExec) Build() error {
 	}
 
 	graph := ss.graph
-	selectNode := graph.CreatePrimitiveNode(primitive.NewLocalPrimitive(selectEx))
+	selectNode := graph.CreatePrimitiveNode(
+		primitive.NewLocalPrimitive(selectEx).WithDescription(
+			primitive.NewDescription("RawNativeExec").WithQuery(ss.nativeQuery),
+		),
+	)
 	if dependencyNodeExists {
 		graph.NewDependency(dependencyNode, selectNode, 1.0)
 	}
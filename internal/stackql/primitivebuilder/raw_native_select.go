@@ -65,7 +65,11 @@ func (ss *RawNativeSelect) Build() error {
 	}
 
 	graph := ss.graph
-	selectNode := graph.CreatePrimitiveNode(primitive.NewLocalPrimitive(selectEx))
+	selectNode := graph.CreatePrimitiveNode(
+		primitive.NewLocalPrimitive(selectEx).WithDescription(
+			primitive.NewDescription("RawNativeSelect").WithQuery(ss.nativeQuery),
+		),
+	)
 	ss.root = selectNode
 
 	return nil
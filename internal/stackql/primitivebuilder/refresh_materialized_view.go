@@ -67,7 +67,9 @@ func (ddo *refreshMaterializedView) Build() error {
 		)
 	}
 	graph := ddo.graph
-	ddlGraphNode := graph.CreatePrimitiveNode(primitive.NewLocalPrimitive(refreshEx))
+	ddlGraphNode := graph.CreatePrimitiveNode(
+		primitive.NewLocalPrimitive(refreshEx).WithDescription(primitive.NewDescription("RefreshMaterializedView")),
+	)
 
 	ddo.root = ddlGraphNode
 	ddo.tail = ddlGraphNode
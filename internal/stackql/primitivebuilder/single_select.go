@@ -12,6 +12,7 @@ import (
 	"github.com/stackql/stackql/internal/stackql/primitivegraph"
 	"github.com/stackql/stackql/internal/stackql/streaming"
 	"github.com/stackql/stackql/internal/stackql/tableinsertioncontainer"
+	"github.com/stackql/stackql/internal/stackql/typing"
 )
 
 type SingleSelect struct {
@@ -65,25 +66,54 @@ func (ss *SingleSelect) Build() error {
 			),
 		)
 
-		outputter := output_data_staging.NewNaiveOutputter(
-			output_data_staging.NewNaivePacketPreparator(
-				output_data_staging.NewNaiveSource(
-					ss.handlerCtx.GetSQLEngine(),
-					drm.NewPreparedStatementParameterized(ss.selectPreparedStatementCtx, nil, true),
-					ss.drmCfg,
-				),
-				ss.selectPreparedStatementCtx.GetNonControlColumns(),
-				ss.stream,
+		outputter := newSelectOutputter(
+			pc,
+			output_data_staging.NewNaiveSource(
+				ss.handlerCtx.GetSQLEngine(),
+				drm.NewPreparedStatementParameterized(ss.selectPreparedStatementCtx, nil, true),
 				ss.drmCfg,
 			),
 			ss.selectPreparedStatementCtx.GetNonControlColumns(),
+			ss.stream,
+			ss.drmCfg,
 			ss.handlerCtx.GetTypingConfig(),
 		)
 		return outputter.OutputExecutorResult()
 	}
 	graph := ss.graph
-	selectNode := graph.CreatePrimitiveNode(primitive.NewLocalPrimitive(selectEx))
+	selectNode := graph.CreatePrimitiveNode(
+		primitive.NewLocalPrimitive(selectEx).WithDescription(
+			primitive.NewDescription("SingleSelect").WithQuery(describeQuery(ss.selectPreparedStatementCtx)),
+		),
+	)
 	ss.root = selectNode
 
 	return nil
 }
+
+// newSelectOutputter streams the selected rows from the backend
+// wherever the output is certain to be read through, and otherwise
+// materialises them.
+func newSelectOutputter(
+	pc primitive.IPrimitiveCtx,
+	source output_data_staging.Source,
+	nonControlColumns []typing.ColumnMetadata,
+	stream streaming.MapStream,
+	drmCfg drm.Config,
+	typCfg typing.Config,
+) output_data_staging.Outputter {
+	ctx := primitive.ContextOf(pc)
+	if primitive.ResultStreamingFromContext(ctx) && len(nonControlColumns) > 0 {
+		return output_data_staging.NewStreamingOutputter(ctx, source, nonControlColumns, stream, drmCfg, typCfg)
+	}
+	return output_data_staging.NewNaiveOutputter(
+		output_data_staging.NewNaivePacketPreparator(
+			source,
+			nonControlColumns,
+			stream,
+			drmCfg,
+		),
+		nonControlColumns,
+		typCfg,
+	)
+}
@@ -1,19 +1,24 @@
 package primitivebuilder
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 
+	"github.com/stackql/any-sdk/anysdk"
 	"github.com/stackql/any-sdk/pkg/httpelement"
 	"github.com/stackql/any-sdk/pkg/logging"
 	"github.com/stackql/any-sdk/pkg/response"
 	"github.com/stackql/stackql/internal/stackql/drm"
 	"github.com/stackql/stackql/internal/stackql/handler"
+	"github.com/stackql/stackql/internal/stackql/httpaudit"
 	"github.com/stackql/stackql/internal/stackql/httpmiddleware"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/primitive_context"
+	"github.com/stackql/stackql/internal/stackql/metrics"
 	"github.com/stackql/stackql/internal/stackql/primitive"
 	"github.com/stackql/stackql/internal/stackql/primitivegraph"
+	"github.com/stackql/stackql/internal/stackql/provider"
 	"github.com/stackql/stackql/internal/stackql/streaming"
 	"github.com/stackql/stackql/internal/stackql/tableinsertioncontainer"
 	"github.com/stackql/stackql/internal/stackql/tablemetadata"
@@ -125,7 +130,9 @@ func (ss *SingleSelectAcquire) Build() error {
 	}
 	ex := func(pc primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
 		logging.GetLogger().Infof("SingleSelectAcquire.Execute() beginning execution for table %s", tableName)
+		stats := executionStatsFrom(pc)
 		currentTcc := ss.insertPreparedStatementCtx.GetGCCtrlCtrs().Clone()
+		ctx := httpaudit.NewTxnIDContext(primitive.ContextOf(pc), currentTcc.GetTxnID())
 		ss.graphHolder.AddTxnControlCounters(currentTcc)
 		mr := prov.InferMaxResultsElement(m)
 		// TODO: instrument for split source vertices !!!important!!!
@@ -160,211 +167,106 @@ func (ss *SingleSelectAcquire) Build() error {
 		}
 		reqParams := httpArmoury.GetRequestParams()
 		logging.GetLogger().Infof("SingleSelectAcquire.Execute() req param count = %d", len(reqParams))
+		// Analytics cache lookups are cheap, and so are made up front, in order.
+		// As per sequential acquisition, those combinations
+		// following a cache hit are not acquired.
+		cacheHitIdx := len(reqParams)
+		var olderTcc internaldto.TxnControlCounters
 		for i, rc := range reqParams {
-			var urlStringForLogging string
-			if rc.GetRequest() != nil && rc.GetRequest().URL != nil {
-				urlStringForLogging = rc.GetRequest().URL.String()
+			if !isAnalyticsCacheable(ss.handlerCtx) {
+				break
 			}
-			logging.GetLogger().Infof("SingleSelectAcquire.Execute() executing request %d: %s", i, urlStringForLogging)
-			reqCtx := rc
-			paramsUsed, paramErr := reqCtx.ToFlatMap()
-			if paramErr != nil {
-				return internaldto.NewErroneousExecutorOutput(paramErr)
-			}
-			reqEncoding := reqCtx.Encode()
+			var isMatch bool
 			//nolint:lll // chaining
-			olderTcc, isMatch := ss.handlerCtx.GetNamespaceCollection().GetAnalyticsCacheTableNamespaceConfigurator().Match(tableName, reqEncoding, ss.drmCfg.GetControlAttributes().GetControlLatestUpdateColumnName(), ss.drmCfg.GetControlAttributes().GetControlInsertEncodedIDColumnName())
+			olderTcc, isMatch = ss.handlerCtx.GetNamespaceCollection().GetAnalyticsCacheTableNamespaceConfigurator().Match(tableName, rc.Encode(), ss.drmCfg.GetControlAttributes().GetControlLatestUpdateColumnName(), ss.drmCfg.GetControlAttributes().GetControlInsertEncodedIDColumnName())
 			if isMatch {
-				nonControlColumns := ss.insertPreparedStatementCtx.GetNonControlColumns()
-				var nonControlColumnNames []string
-				for _, c := range nonControlColumns {
-					nonControlColumnNames = append(nonControlColumnNames, c.GetName())
-				}
-				//nolint:errcheck // TODO: fix
-				ss.handlerCtx.GetGarbageCollector().Update(
-					tableName,
-					olderTcc.Clone(),
-					currentTcc,
-				)
-				//nolint:errcheck // TODO: fix
-				ss.insertionContainer.SetTableTxnCounters(tableName, olderTcc)
-				ss.insertPreparedStatementCtx.SetGCCtrlCtrs(olderTcc)
-				r, sqlErr := ss.handlerCtx.GetNamespaceCollection().GetAnalyticsCacheTableNamespaceConfigurator().Read(
-					tableName, reqEncoding,
-					ss.drmCfg.GetControlAttributes().GetControlInsertEncodedIDColumnName(),
-					nonControlColumnNames)
-				if sqlErr != nil {
-					internaldto.NewErroneousExecutorOutput(sqlErr)
-				}
-				ss.drmCfg.ExtractObjectFromSQLRows(r, nonControlColumns, ss.stream)
-				return internaldto.NewEmptyExecutorOutput()
+				cacheHitIdx = i
+				break
 			}
-			// TODO: fix cloning ops
-			response, apiErr := httpmiddleware.HTTPApiCallFromRequest(
-				ss.handlerCtx.Clone(),
-				prov,
-				m,
-				reqCtx.GetRequest().Clone(
-					reqCtx.GetRequest().Context(),
-				),
-			)
-			// TODO: refactor into package !!TECH_DEBT!!
-			if response != nil && response.StatusCode >= 400 {
-				continue
+		}
+		fanOutCtx, cancelFanOut := context.WithCancel(ctx)
+		fanOut := fanOutAcquisitions(
+			fanOutCtx,
+			ss.handlerCtx.GetRuntimeContext().ExecutionConcurrencyLimit,
+			ss.graphHolder.GetAcquisitionSlots(),
+			reqParams[:cacheHitIdx],
+			func(fanOutCtx context.Context, i int, rc anysdk.HTTPArmouryParameters) paramAcquisition {
+				var urlStringForLogging string
+				if rc.GetRequest() != nil && rc.GetRequest().URL != nil {
+					urlStringForLogging = rc.GetRequest().URL.String()
+				}
+				logging.GetLogger().Infof("SingleSelectAcquire.Execute() executing request %d: %s", i, urlStringForLogging)
+				return ss.acquireParams(fanOutCtx, prov, m, rc, stats)
+			},
+		)
+		// Cancellation is required to release any workers
+		// in flight upon early return.
+		defer func() {
+			cancelFanOut()
+			fanOut.wait()
+		}()
+		for i := range reqParams[:cacheHitIdx] {
+			acquisition, isReceived := fanOut.receive(ctx, i)
+			if !isReceived {
+				return cancelledAcquisition(ctx, ss.handlerCtx, i > 0)
 			}
+			// Housekeeping is per parameter combination,
+			// as per sequential acquisition.
 			housekeepingDone := false
-			npt := prov.InferNextPageResponseElement(ss.tableMeta.GetHeirarchyObjects())
-			nptRequest := prov.InferNextPageRequestElement(ss.tableMeta.GetHeirarchyObjects())
-			pageCount := 1
-			for {
-				if apiErr != nil {
-					return util.PrepareResultSet(internaldto.NewPrepareResultSetDTO(nil, nil, nil, ss.rowSort, apiErr, nil,
-						ss.handlerCtx.GetTypingConfig(),
-					))
-				}
-				processed, resErr := m.ProcessResponse(response)
-				if resErr != nil {
-					//nolint:errcheck // TODO: fix
-					ss.handlerCtx.GetOutErrFile().Write(
-						[]byte(fmt.Sprintf("error processing response: %s\n", resErr.Error())),
-					)
-					if processed == nil {
-						return internaldto.NewErroneousExecutorOutput(resErr)
-					}
-				}
-				res, respOk := processed.GetResponse()
-				if !respOk {
-					return internaldto.NewErroneousExecutorOutput(fmt.Errorf("response is not a valid response"))
+			for _, iArr := range acquisition.pages {
+				err = ss.stream.Write(iArr)
+				if err != nil {
+					return internaldto.NewErroneousExecutorOutput(err)
 				}
-				if res.HasError() {
-					return internaldto.NewNopEmptyExecutorOutput([]string{res.Error()})
+				if len(iArr) == 0 {
+					continue
 				}
-				ss.handlerCtx.LogHTTPResponseMap(res.GetProcessedBody())
-				logging.GetLogger().Infoln(fmt.Sprintf("SingleSelectAcquire.Execute() response = %v", res))
-				var items interface{}
-				var ok bool
-				target := res.GetProcessedBody()
-				logging.GetLogger().Infoln(fmt.Sprintf("SingleSelectAcquire.Execute() target = %v", target))
-				switch pl := target.(type) {
-				// add case for xml object,
-				case map[string]interface{}:
-					if ss.tableMeta.GetSelectItemsKey() != "" && ss.tableMeta.GetSelectItemsKey() != "/*" {
-						items, ok = pl[ss.tableMeta.GetSelectItemsKey()]
-						if !ok {
-							if resErr != nil {
-								items = []interface{}{}
-								ok = true
-							} else {
-								items = []interface{}{
-									pl,
-								}
-								ok = true
-							}
-						}
-					} else {
-						items = []interface{}{
-							pl,
-						}
-						ok = true
-					}
-				case []interface{}:
-					items = pl
-					ok = true
-				case []map[string]interface{}:
-					items = pl
-					ok = true
-				case nil:
-					return internaldto.NewEmptyExecutorOutput()
-				}
-				keys := make(map[string]map[string]interface{})
-
-				//nolint:nestif // TODO: fix
-				if ok {
-					iArr, iErr := castItemsArray(items)
-					if iErr != nil {
-						return internaldto.NewErroneousExecutorOutput(iErr)
-					}
-					err = ss.stream.Write(iArr)
-					if err != nil {
-						return internaldto.NewErroneousExecutorOutput(err)
-					}
-					if ok && len(iArr) > 0 {
-						if !housekeepingDone && ss.insertPreparedStatementCtx != nil {
-							_, err = ss.handlerCtx.GetSQLEngine().Exec(ss.insertPreparedStatementCtx.GetGCHousekeepingQueries())
-							tcc := ss.insertPreparedStatementCtx.GetGCCtrlCtrs()
-							tcc.SetTableName(tableName)
-							//nolint:errcheck // TODO: fix
-							ss.insertionContainer.SetTableTxnCounters(tableName, tcc)
-							housekeepingDone = true
-						}
-						if err != nil {
-							return internaldto.NewErroneousExecutorOutput(err)
-						}
-
-						for i, item := range iArr {
-							if item != nil {
-								if err == nil {
-									for k, v := range paramsUsed {
-										if _, itemOk := item[k]; !itemOk {
-											item[k] = v
-										}
-									}
-								}
-
-								logging.GetLogger().Infoln(
-									fmt.Sprintf(
-										"running insert with query = '''%s''', control parameters: %v",
-										ss.insertPreparedStatementCtx.GetQuery(),
-										ss.insertPreparedStatementCtx.GetGCCtrlCtrs(),
-									),
-								)
-								r, rErr := ss.drmCfg.ExecuteInsertDML(
-									ss.handlerCtx.GetSQLEngine(),
-									ss.insertPreparedStatementCtx,
-									item,
-									reqEncoding,
-								)
-								logging.GetLogger().Infoln(
-									fmt.Sprintf(
-										"insert result = %v, error = %v",
-										r,
-										rErr,
-									),
-								)
-								if rErr != nil {
-									return internaldto.NewErroneousExecutorOutput(
-										fmt.Errorf(
-											"sql insert error: '%w' from query: %s",
-											rErr,
-											ss.insertPreparedStatementCtx.GetQuery(),
-										),
-									)
-								}
-								keys[strconv.Itoa(i)] = item
-							}
-						}
-					}
-				}
-				if npt == nil || nptRequest == nil {
-					break
+				if !housekeepingDone && ss.insertPreparedStatementCtx != nil {
+					_, err = ss.handlerCtx.GetSQLEngine().Exec(ss.insertPreparedStatementCtx.GetGCHousekeepingQueries())
+					tcc := ss.insertPreparedStatementCtx.GetGCCtrlCtrs()
+					tcc.SetTableName(tableName)
+					//nolint:errcheck // TODO: fix
+					ss.insertionContainer.SetTableTxnCounters(tableName, tcc)
+					housekeepingDone = true
 				}
-				tk := extractNextPageToken(res, npt)
-				//nolint:lll // long conditional
-				if tk == "" || tk == "<nil>" || tk == "[]" || (ss.handlerCtx.GetRuntimeContext().HTTPPageLimit > 0 && pageCount >= ss.handlerCtx.GetRuntimeContext().HTTPPageLimit) {
-					break
+				if err != nil {
+					return internaldto.NewErroneousExecutorOutput(err)
 				}
-				pageCount++
-				req, reqErr := reqCtx.SetNextPage(m, tk, nptRequest)
-				if reqErr != nil {
-					return internaldto.NewErroneousExecutorOutput(reqErr)
+				if insertOutput, isErroneous := ss.insertItems(iArr, acquisition, stats); isErroneous {
+					return insertOutput
 				}
-				response, apiErr = httpmiddleware.HTTPApiCallFromRequest(ss.handlerCtx.Clone(), prov, m, req)
 			}
-			if reqCtx.GetRequest() != nil {
-				q := reqCtx.GetRequest().URL.Query()
-				q.Del(nptRequest.GetName())
-				reqCtx.SetRawQuery(q.Encode())
+			if acquisition.isCancelled {
+				return cancelledAcquisition(ctx, ss.handlerCtx, i > 0 || housekeepingDone)
+			}
+			if acquisition.output != nil {
+				return acquisition.output
+			}
+		}
+		if cacheHitIdx < len(reqParams) {
+			nonControlColumns := ss.insertPreparedStatementCtx.GetNonControlColumns()
+			var nonControlColumnNames []string
+			for _, c := range nonControlColumns {
+				nonControlColumnNames = append(nonControlColumnNames, c.GetName())
+			}
+			//nolint:errcheck // TODO: fix
+			ss.handlerCtx.GetGarbageCollector().Update(
+				tableName,
+				olderTcc.Clone(),
+				currentTcc,
+			)
+			//nolint:errcheck // TODO: fix
+			ss.insertionContainer.SetTableTxnCounters(tableName, olderTcc)
+			ss.insertPreparedStatementCtx.SetGCCtrlCtrs(olderTcc)
+			r, sqlErr := ss.handlerCtx.GetNamespaceCollection().GetAnalyticsCacheTableNamespaceConfigurator().Read(
+				tableName, reqParams[cacheHitIdx].Encode(),
+				ss.drmCfg.GetControlAttributes().GetControlInsertEncodedIDColumnName(),
+				nonControlColumnNames)
+			if sqlErr != nil {
+				internaldto.NewErroneousExecutorOutput(sqlErr)
 			}
+			ss.drmCfg.ExtractObjectFromSQLRows(r, nonControlColumns, ss.stream)
+			return internaldto.NewEmptyExecutorOutput()
 		}
 		logging.GetLogger().Infof("SingleSelectAcquire.Execute() returning empty for table %s", tableName)
 		return internaldto.NewEmptyExecutorOutput()
@@ -381,7 +283,11 @@ func (ss *SingleSelectAcquire) Build() error {
 		prep,
 		ss.txnCtrlCtr,
 		primitiveCtx,
-	).WithDebugName(fmt.Sprintf("insert_%s_%s", tableName, ss.tableMeta.GetAlias()))
+	).WithDebugName(
+		fmt.Sprintf("insert_%s_%s", tableName, ss.tableMeta.GetAlias()),
+	).WithDescription(
+		describeTableAccess("SingleSelectAcquire", ss.tableMeta),
+	)
 	graphHolder := ss.graphHolder
 	insertNode := graphHolder.CreatePrimitiveNode(insertPrim)
 	ss.root = insertNode
@@ -389,6 +295,209 @@ func (ss *SingleSelectAcquire) Build() error {
 	return nil
 }
 
+// acquireParams follows the pagination chain for a single
+// combination of request parameters.  It is safe for concurrent
+// use, in that persistence is left to the caller.
+//
+//nolint:funlen,gocognit // TODO: investigate
+func (ss *SingleSelectAcquire) acquireParams(
+	ctx context.Context,
+	prov provider.IProvider,
+	m anysdk.OperationStore,
+	reqCtx anysdk.HTTPArmouryParameters,
+	stats internaldto.ExecutionStats,
+) paramAcquisition {
+	var rv paramAcquisition
+	if ctx.Err() != nil {
+		rv.isCancelled = true
+		return rv
+	}
+	paramsUsed, paramErr := reqCtx.ToFlatMap()
+	if paramErr != nil {
+		rv.output = internaldto.NewErroneousExecutorOutput(paramErr)
+		return rv
+	}
+	rv.paramsUsed = paramsUsed
+	if isAnalyticsCacheable(ss.handlerCtx) {
+		rv.reqEncoding = reqCtx.Encode()
+	}
+	// TODO: fix cloning ops
+	response, apiErr := httpmiddleware.HTTPApiCallFromRequest(
+		ctx,
+		ss.handlerCtx.Clone(),
+		prov,
+		m,
+		reqCtx.GetRequest().Clone(
+			reqCtx.GetRequest().Context(),
+		),
+	)
+	stats.AddHTTPRequests(1)
+	metrics.AddPaginationPage(prov.GetProviderString())
+	// TODO: refactor into package !!TECH_DEBT!!
+	if response != nil && response.StatusCode >= 400 {
+		return rv
+	}
+	npt := prov.InferNextPageResponseElement(ss.tableMeta.GetHeirarchyObjects())
+	nptRequest := prov.InferNextPageRequestElement(ss.tableMeta.GetHeirarchyObjects())
+	pageCount := 1
+	for {
+		if apiErr != nil && ctx.Err() != nil {
+			rv.isCancelled = true
+			return rv
+		}
+		if apiErr != nil {
+			rv.output = util.PrepareResultSet(internaldto.NewPrepareResultSetDTO(nil, nil, nil, ss.rowSort, apiErr, nil,
+				ss.handlerCtx.GetTypingConfig(),
+			))
+			return rv
+		}
+		processed, resErr := m.ProcessResponse(response)
+		if resErr != nil {
+			//nolint:errcheck // TODO: fix
+			ss.handlerCtx.GetOutErrFile().Write(
+				[]byte(fmt.Sprintf("error processing response: %s\n", resErr.Error())),
+			)
+			if processed == nil {
+				rv.output = internaldto.NewErroneousExecutorOutput(resErr)
+				return rv
+			}
+		}
+		res, respOk := processed.GetResponse()
+		if !respOk {
+			rv.output = internaldto.NewErroneousExecutorOutput(fmt.Errorf("response is not a valid response"))
+			return rv
+		}
+		if res.HasError() {
+			rv.output = internaldto.NewNopEmptyExecutorOutput([]string{res.Error()})
+			return rv
+		}
+		stats.AddPages(1)
+		ss.handlerCtx.LogHTTPResponseMap(res.GetProcessedBody())
+		logging.GetLogger().Infoln(fmt.Sprintf("SingleSelectAcquire.Execute() response = %v", res))
+		var items interface{}
+		var ok bool
+		target := res.GetProcessedBody()
+		logging.GetLogger().Infoln(fmt.Sprintf("SingleSelectAcquire.Execute() target = %v", target))
+		switch pl := target.(type) {
+		// add case for xml object,
+		case map[string]interface{}:
+			if ss.tableMeta.GetSelectItemsKey() != "" && ss.tableMeta.GetSelectItemsKey() != "/*" {
+				items, ok = pl[ss.tableMeta.GetSelectItemsKey()]
+				if !ok {
+					if resErr != nil {
+						items = []interface{}{}
+						ok = true
+					} else {
+						items = []interface{}{
+							pl,
+						}
+						ok = true
+					}
+				}
+			} else {
+				items = []interface{}{
+					pl,
+				}
+				ok = true
+			}
+		case []interface{}:
+			items = pl
+			ok = true
+		case []map[string]interface{}:
+			items = pl
+			ok = true
+		case nil:
+			rv.output = internaldto.NewEmptyExecutorOutput()
+			return rv
+		}
+		if ok {
+			iArr, iErr := castItemsArray(items)
+			if iErr != nil {
+				rv.output = internaldto.NewErroneousExecutorOutput(iErr)
+				return rv
+			}
+			rv.pages = append(rv.pages, iArr)
+		}
+		if npt == nil || nptRequest == nil {
+			break
+		}
+		tk := extractNextPageToken(res, npt)
+		//nolint:lll // long conditional
+		if tk == "" || tk == "<nil>" || tk == "[]" || (ss.handlerCtx.GetRuntimeContext().HTTPPageLimit > 0 && pageCount >= ss.handlerCtx.GetRuntimeContext().HTTPPageLimit) {
+			break
+		}
+		if ctx.Err() != nil {
+			rv.isCancelled = true
+			return rv
+		}
+		pageCount++
+		req, reqErr := reqCtx.SetNextPage(m, tk, nptRequest)
+		if reqErr != nil {
+			rv.output = internaldto.NewErroneousExecutorOutput(reqErr)
+			return rv
+		}
+		response, apiErr = httpmiddleware.HTTPApiCallFromRequest(ctx, ss.handlerCtx.Clone(), prov, m, req)
+		stats.AddHTTPRequests(1)
+		metrics.AddPaginationPage(prov.GetProviderString())
+	}
+	if reqCtx.GetRequest() != nil {
+		q := reqCtx.GetRequest().URL.Query()
+		q.Del(nptRequest.GetName())
+		reqCtx.SetRawQuery(q.Encode())
+	}
+	return rv
+}
+
+// insertItems persists a page of items, decorated with the request
+// parameters used.  Output is returned only upon error.
+func (ss *SingleSelectAcquire) insertItems(
+	iArr []map[string]interface{},
+	acquisition paramAcquisition,
+	stats internaldto.ExecutionStats,
+) (internaldto.ExecutorOutput, bool) {
+	for _, item := range iArr {
+		if item == nil {
+			continue
+		}
+		for k, v := range acquisition.paramsUsed {
+			if _, itemOk := item[k]; !itemOk {
+				item[k] = v
+			}
+		}
+		logging.GetLogger().Infoln(
+			fmt.Sprintf(
+				"running insert with query = '''%s''', control parameters: %v",
+				ss.insertPreparedStatementCtx.GetQuery(),
+				ss.insertPreparedStatementCtx.GetGCCtrlCtrs(),
+			),
+		)
+		r, rErr := ss.drmCfg.ExecuteInsertDML(
+			ss.handlerCtx.GetSQLEngine(),
+			ss.insertPreparedStatementCtx,
+			item,
+			acquisition.reqEncoding,
+		)
+		logging.GetLogger().Infoln(
+			fmt.Sprintf(
+				"insert result = %v, error = %v",
+				r,
+				rErr,
+			),
+		)
+		if rErr != nil {
+			return internaldto.NewErroneousExecutorOutput(
+				fmt.Errorf(
+					"sql insert error: '%w' from query: %s",
+					rErr,
+					ss.insertPreparedStatementCtx.GetQuery(),
+				),
+			), true
+		}
+		stats.AddRowsInserted(1)
+	}
+	return nil, false
+}
+
 func extractNextPageToken(res response.Response, tokenKey sdk_internal_dto.HTTPElement) string {
 	//nolint:exhaustive // TODO: review
 	switch tokenKey.GetType() {
@@ -142,6 +142,8 @@ func (ss *sqlDataSourceSingleSelectAcquire) Build() error {
 		prep,
 		ss.txnCtrlCtr,
 		primitiveCtx,
+	).WithDescription(
+		describeTableAccess("SQLDataSourceSingleSelectAcquire", ss.tableMeta).WithQuery(ss.query),
 	)
 	graph := ss.graph
 	insertNode := graph.CreatePrimitiveNode(insertPrim)
@@ -22,24 +22,26 @@ func (un *Union) Build() error {
 	//nolint:revive // acceptable for now
 	unionEx := func(pc primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
 		us := drm.NewPreparedStatementParameterized(un.unionCtx, nil, false)
-		outputter := output_data_staging.NewNaiveOutputter(
-			output_data_staging.NewNaivePacketPreparator(
-				output_data_staging.NewNaiveSource(
-					un.handlerCtx.GetSQLEngine(),
-					us,
-					un.drmCfg,
-				),
-				un.unionCtx.GetNonControlColumns(),
-				streaming.NewNopMapStream(),
+		outputter := newSelectOutputter(
+			pc,
+			output_data_staging.NewNaiveSource(
+				un.handlerCtx.GetSQLEngine(),
+				us,
 				un.drmCfg,
 			),
 			un.unionCtx.GetNonControlColumns(),
+			streaming.NewNopMapStream(),
+			un.drmCfg,
 			un.handlerCtx.GetTypingConfig(),
 		)
 		return outputter.OutputExecutorResult()
 	}
 	graph := un.graph
-	unionNode := graph.CreatePrimitiveNode(primitive.NewLocalPrimitive(unionEx))
+	unionNode := graph.CreatePrimitiveNode(
+		primitive.NewLocalPrimitive(unionEx).WithDescription(
+			primitive.NewDescription("Union").WithQuery(describeQuery(un.unionCtx)),
+		),
+	)
 	un.root = unionNode
 	un.tail = unionNode
 	return nil
@@ -1,14 +1,77 @@
 package primitivebuilder
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/stackql/any-sdk/pkg/logging"
+	"github.com/stackql/stackql/internal/stackql/drm"
+	"github.com/stackql/stackql/internal/stackql/handler"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/primitive"
 	"github.com/stackql/stackql/internal/stackql/tablemetadata"
 	"github.com/stackql/stackql/internal/stackql/typing"
 	"github.com/stackql/stackql/internal/stackql/util"
 )
 
+// describeTableAccess summarises, for EXPLAIN, the builder
+// and the resolved provider resource accessed by a primitive.
+// Unresolvable hierarchy components are left blank.
+func describeTableAccess(builderName string, meta tablemetadata.ExtendedTableMetadata) primitive.Description {
+	rv := primitive.NewDescription(builderName)
+	if meta == nil {
+		return rv
+	}
+	if prov, err := meta.GetProviderStr(); err == nil {
+		rv = rv.WithProvider(prov)
+	}
+	if svc, err := meta.GetServiceStr(); err == nil {
+		rv = rv.WithService(svc)
+	}
+	if rsc, err := meta.GetResourceStr(); err == nil {
+		rv = rv.WithResource(rsc)
+	}
+	if m, err := meta.GetMethodStr(); err == nil {
+		rv = rv.WithMethod(m)
+	}
+	return rv
+}
+
+// executionStatsFrom yields the instrumentation sink for a primitive,
+// or a discardable one where execution is not instrumented.
+func executionStatsFrom(pc primitive.IPrimitiveCtx) internaldto.ExecutionStats {
+	if pc != nil {
+		if stats, isInstrumented := pc.GetExecutionStats(); isInstrumented && stats != nil {
+			return stats
+		}
+	}
+	return internaldto.NewExecutionStats()
+}
+
+// cancelledAcquisition reports a cancelled acquisition and, where
+// rows were partially acquired, hands them to the garbage collector.
+func cancelledAcquisition(
+	ctx context.Context,
+	handlerCtx handler.HandlerContext,
+	isPartial bool,
+) internaldto.ExecutorOutput {
+	if isPartial {
+		if gcErr := handlerCtx.GetGarbageCollector().Collect(); gcErr != nil {
+			logging.GetLogger().Infof("garbage collection after cancellation failed: %s", gcErr.Error())
+		}
+	}
+	return internaldto.NewErroneousExecutorOutput(context.Cause(ctx))
+}
+
+// describeQuery tolerates absent prepared statements,
+// for instance where acquisition is not persisted.
+func describeQuery(ctx drm.PreparedStatementCtx) string {
+	if ctx == nil {
+		return ""
+	}
+	return ctx.GetQuery()
+}
+
 func generateSuccessMessagesFromHeirarchy(meta tablemetadata.ExtendedTableMetadata, isAwait bool) []string {
 	baseSuccessString := "The operation completed successfully"
 	if !isAwait {
@@ -45,3 +108,11 @@ func generateResultIfNeededfunc(
 	}
 	return internaldto.NewExecutorOutput(nil, body, nil, msg, err)
 }
+
+// isAnalyticsCacheable is false for sessions holding their own
+// credentials, as per cached plans.  Cached data is visible to
+// every session, so that such sessions neither read the cache,
+// nor key the rows they acquire such that others may match them.
+func isAnalyticsCacheable(handlerCtx handler.HandlerContext) bool {
+	return !handlerCtx.HasSessionAuthContexts()
+}
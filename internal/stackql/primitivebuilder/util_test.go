@@ -0,0 +1,23 @@
+package primitivebuilder //nolint:testpackage // isAnalyticsCacheable is unexported
+
+import (
+	"testing"
+
+	"github.com/stackql/stackql/internal/stackql/handler"
+)
+
+type sessionAuthHandlerContext struct {
+	handler.HandlerContext
+	hasSessionAuthContexts bool
+}
+
+func (hc *sessionAuthHandlerContext) HasSessionAuthContexts() bool { return hc.hasSessionAuthContexts }
+
+func TestAnalyticsCacheBypassedWithSessionCredentials(t *testing.T) {
+	if !isAnalyticsCacheable(&sessionAuthHandlerContext{}) {
+		t.Fatal("test failed: analytics cache bypassed with shared credentials")
+	}
+	if isAnalyticsCacheable(&sessionAuthHandlerContext{hasSessionAuthContexts: true}) {
+		t.Fatal("test failed: analytics cache used with session credentials")
+	}
+}
@@ -42,6 +42,7 @@ type PrimitiveComposer interface {
 	GetInsertPreparedStatementCtx() drm.PreparedStatementCtx
 	GetInsertValOnlyRows() map[int]map[int]interface{}
 	GetLikeAbleColumns() []string
+	GetNonControlColumns() []typing.ColumnMetadata
 	GetParent() PrimitiveComposer
 	GetProvider() provider.IProvider
 	GetRoot() primitivegraph.PrimitiveNode
@@ -15,6 +15,7 @@ import (
 	"github.com/stackql/stackql/internal/stackql/astformat"
 	"github.com/stackql/stackql/internal/stackql/astindirect"
 	"github.com/stackql/stackql/internal/stackql/astvisit"
+	"github.com/stackql/stackql/internal/stackql/asyncmonitor"
 	"github.com/stackql/stackql/internal/stackql/drm"
 	"github.com/stackql/stackql/internal/stackql/handler"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/builder_input"
@@ -520,7 +521,7 @@ func (pb *standardPrimitiveGenerator) parseComments(comments sqlparser.Comments)
 	if comments != nil {
 		pb.PrimitiveComposer.SetCommentDirectives(sqlparser.ExtractCommentDirectives(comments))
 		// This pattern supports preset.
-		if pb.PrimitiveComposer.GetCommentDirectives().IsSet("AWAIT") {
+		if asyncmonitor.IsAwaitDirective(pb.PrimitiveComposer.GetCommentDirectives()) {
 			pb.PrimitiveComposer.SetAwait(true)
 		}
 	}
@@ -558,10 +559,6 @@ func (pb *standardPrimitiveGenerator) AnalyzeUnaryExec(
 		return nil, err
 	}
 
-	if pb.PrimitiveComposer.IsAwait() && !method.IsAwaitable() {
-		return nil, fmt.Errorf("method %s is not awaitable", method.GetName())
-	}
-
 	requiredParams := method.GetRequiredParameters()
 
 	colz, err := parserutil.GetColumnUsageTypesForExec(node)
@@ -583,6 +580,11 @@ func (pb *standardPrimitiveGenerator) AnalyzeUnaryExec(
 	if err != nil {
 		return nil, err
 	}
+	if pb.PrimitiveComposer.IsAwait() {
+		if awaitErr := asyncmonitor.CheckAwaitable(prov, method); awaitErr != nil {
+			return nil, awaitErr
+		}
+	}
 	svcStr, err := meta.GetServiceStr()
 	if err != nil {
 		return nil, err
@@ -1062,8 +1064,10 @@ func (pb *standardPrimitiveGenerator) AnalyzeInsert(pbi planbuilderinput.PlanBui
 		return err
 	}
 
-	if pb.PrimitiveComposer.IsAwait() && !method.IsAwaitable() {
-		return fmt.Errorf("method %s is not awaitable", method.GetName())
+	if pb.PrimitiveComposer.IsAwait() {
+		if awaitErr := asyncmonitor.CheckAwaitable(prov, method); awaitErr != nil {
+			return awaitErr
+		}
 	}
 
 	_, err = checkResource(handlerCtx, prov, currentService, currentResource)
@@ -1120,8 +1124,10 @@ func (pb *standardPrimitiveGenerator) AnalyzeUpdate(pbi planbuilderinput.PlanBui
 		return err
 	}
 
-	if pb.PrimitiveComposer.IsAwait() && !method.IsAwaitable() {
-		return fmt.Errorf("method %s is not awaitable", method.GetName())
+	if pb.PrimitiveComposer.IsAwait() {
+		if awaitErr := asyncmonitor.CheckAwaitable(prov, method); awaitErr != nil {
+			return awaitErr
+		}
 	}
 
 	_, err = checkResource(handlerCtx, prov, currentService, currentResource)
@@ -1182,11 +1188,10 @@ func (pb *standardPrimitiveGenerator) analyzeDelete(
 		return err
 	}
 
-	if pb.PrimitiveComposer.IsAwait() && !method.IsAwaitable() {
-		return fmt.Errorf("method %s is not awaitable", method.GetName())
-	}
-	if pb.PrimitiveComposer.IsAwait() && !method.IsAwaitable() {
-		return fmt.Errorf("method %s is not awaitable", method.GetName())
+	if pb.PrimitiveComposer.IsAwait() {
+		if awaitErr := asyncmonitor.CheckAwaitable(prov, method); awaitErr != nil {
+			return awaitErr
+		}
 	}
 	currentService, err := tbl.GetServiceStr()
 	if err != nil {
@@ -3,10 +3,14 @@ package primitivegraph
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/stackql/stackql/internal/stackql/acid/binlog"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
 	"github.com/stackql/stackql/internal/stackql/primitive"
+	"github.com/stackql/stackql/internal/stackql/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"gonum.org/v1/gonum/graph"
 
@@ -24,8 +28,7 @@ type standardBasePrimitiveGraph struct {
 	g                      *simple.WeightedDirectedGraph
 	sorted                 []graph.Node
 	txnControlCounterSlice []internaldto.TxnControlCounters
-	errGroup               *errgroup.Group
-	errGroupCtx            context.Context
+	concurrencyLimit       int
 	containsView           bool
 	containsUserRelation   bool // for tables and materialized views
 }
@@ -96,7 +99,10 @@ func (pg *standardBasePrimitiveGraph) GetUndoLog() (binlog.LogEntry, bool) {
 			}
 		}
 	}
-	return nil, false
+	if rv.Size() == 0 && len(rv.GetHumanReadable()) == 0 {
+		return nil, false
+	}
+	return rv, true
 }
 
 func (pg *standardBasePrimitiveGraph) AddTxnControlCounters(t internaldto.TxnControlCounters) {
@@ -151,6 +157,8 @@ func (pg *standardBasePrimitiveGraph) reset() {
 // This particular implementation:
 //   - Uses the errgroup package to execute the graph in parallel.
 //   - Blocks on any node that has a dependency that has not been executed.
+//   - Ceases scheduling once the bounding context is cancelled,
+//     which in turn propagates to all running nodes.
 //
 //nolint:gocognit // inherent complexity
 func (pg *standardBasePrimitiveGraph) Execute(ctx primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
@@ -172,6 +180,14 @@ func (pg *standardBasePrimitiveGraph) Execute(ctx primitive.IPrimitiveCtx) inter
 		outChan[i] = make(chan internaldto.ExecutorOutput, 1)
 	}
 	outCache := make(map[int]internaldto.ExecutorOutput)
+	// A fresh group per execution, so that neither cancellation
+	// nor failure leaks into re-execution of cached plans.
+	errGroup, errGroupCtx := errgroup.WithContext(primitive.ContextOf(ctx))
+	// NOTE: a non positive limit is unbounded, whereas
+	// errgroup would admit no goroutine upon zero.
+	if pg.concurrencyLimit > 0 {
+		errGroup.SetLimit(pg.concurrencyLimit)
+	}
 	var currentNodeIdx int
 	idxMap := make(map[int64]int)
 	for _, node := range pg.sorted {
@@ -198,6 +214,7 @@ func (pg *standardBasePrimitiveGraph) Execute(ctx primitive.IPrimitiveCtx) inter
 					}
 					inputFromDependency, ok := outCache[incidentNodeIdx]
 					if !ok {
+						// dependencies always publish output, even when cancelled
 						inputFromDependency = <-outChan[incidentNodeIdx]
 						outCache[incidentNodeIdx] = inputFromDependency
 					}
@@ -212,11 +229,33 @@ func (pg *standardBasePrimitiveGraph) Execute(ctx primitive.IPrimitiveCtx) inter
 				}
 				hasNext = incidentNodes.Next()
 			}
+			if errGroupCtx.Err() != nil {
+				return pg.abandonExecution(errGroup, errGroupCtx)
+			}
 			nodeIdx := currentNodeIdx
 			idxMap[nodeID] = nodeIdx
-			pg.errGroup.Go(
+			nodeStats, isInstrumented := nodeExecutionStats(ctx, nodeID)
+			errGroup.Go(
 				func() error {
-					funOutput := node.GetOperation().Execute(ctx)
+					spanCtx, span := startNodeSpan(errGroupCtx, node)
+					if nodeIdx != primitiveNodeCount-1 {
+						// only the graph output is read through
+						spanCtx = primitive.NewResultStreamingContext(spanCtx, false)
+					}
+					var nodeCtx primitive.IPrimitiveCtx = primitive.NewContextualPrimitiveCtx(ctx, spanCtx)
+					if isInstrumented {
+						nodeCtx = primitive.NewInstrumentedPrimitiveCtx(nodeCtx, nodeStats)
+					}
+					start := time.Now()
+					funOutput := node.GetOperation().Execute(nodeCtx)
+					if isInstrumented {
+						nodeStats.SetElapsed(time.Since(start))
+					}
+					if funOutput != nil {
+						tracing.End(span, funOutput.GetError())
+					} else {
+						tracing.End(span, nil)
+					}
 					thisChan := outChan[nodeIdx]
 					thisChan <- funOutput
 					close(thisChan)
@@ -234,14 +273,72 @@ func (pg *standardBasePrimitiveGraph) Execute(ctx primitive.IPrimitiveCtx) inter
 			return internaldto.NewExecutorOutput(nil, nil, nil, nil, fmt.Errorf("unknown execution primitive type: '%T'", node))
 		}
 	}
-	if err := pg.errGroup.Wait(); err != nil {
+	if err := errGroup.Wait(); err != nil {
+		releaseGraphOutput(outChan)
 		undoLog, _ := output.GetUndoLog()
 		return internaldto.NewExecutorOutput(nil, nil, nil, nil, err).WithUndoLog(undoLog)
 	}
+	if err := context.Cause(primitive.ContextOf(ctx)); err != nil {
+		releaseGraphOutput(outChan)
+		return internaldto.NewExecutorOutput(nil, nil, nil, nil, err)
+	}
 	output = <-outChan[primitiveNodeCount-1]
 	return output
 }
 
+// releaseGraphOutput releases any streamed output that is to
+// be discarded; the final node may never have run.
+func releaseGraphOutput(outChan []chan internaldto.ExecutorOutput) {
+	if len(outChan) == 0 {
+		return
+	}
+	select {
+	case output := <-outChan[len(outChan)-1]:
+		internaldto.ReleaseSQLResult(output) //nolint:errcheck // output discarded
+	default:
+	}
+}
+
+// startNodeSpan is started once the node is
+// scheduled, so as to exclude time spent queued.
+func startNodeSpan(ctx context.Context, node PrimitiveNode) (context.Context, trace.Span) {
+	description := node.GetOperation().GetDescription()
+	attrs := []attribute.KeyValue{
+		tracing.AttrNodeID.Int64(node.ID()),
+		tracing.AttrPrimitive.String(description.GetBuilderName()),
+	}
+	if providerName := description.GetProvider(); providerName != "" {
+		attrs = append(attrs, tracing.AttrProvider.String(providerName))
+	}
+	return tracing.Start(ctx, "primitive", attrs...)
+}
+
+// abandonExecution awaits those nodes already running,
+// which are expected to observe cancellation promptly.
+func (pg *standardBasePrimitiveGraph) abandonExecution(
+	errGroup *errgroup.Group,
+	errGroupCtx context.Context,
+) internaldto.ExecutorOutput {
+	err := errGroup.Wait()
+	if err == nil {
+		err = context.Cause(errGroupCtx)
+	}
+	return internaldto.NewExecutorOutput(nil, nil, nil, nil, err)
+}
+
+// nodeExecutionStats returns the statistics sink for
+// a graph node, present only when execution is instrumented.
+func nodeExecutionStats(ctx primitive.IPrimitiveCtx, nodeID int64) (internaldto.ExecutionStats, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	stats, isInstrumented := ctx.GetExecutionStats()
+	if !isInstrumented || stats == nil {
+		return nil, false
+	}
+	return stats.Child(nodeID), true
+}
+
 func (pg *standardBasePrimitiveGraph) SetTxnID(id int) {
 	nodes := pg.g.Nodes()
 	for {
@@ -272,7 +369,13 @@ func (pg *standardBasePrimitiveGraph) SetInputAlias(alias string, id int64) erro
 	return nil
 }
 
+// Sort returns the nodes in topological order; once optimised,
+// this is the execution order, wherein the final primitive
+// node supplies the graph output.
 func (pg *standardBasePrimitiveGraph) Sort() ([]graph.Node, error) {
+	if pg.sorted != nil && len(pg.sorted) == pg.g.Nodes().Len() {
+		return append([]graph.Node(nil), pg.sorted...), nil
+	}
 	return topo.Sort(pg.g)
 }
 
@@ -280,12 +383,22 @@ func (pg *standardBasePrimitiveGraph) WithDebugName(_ string) primitive.IPrimiti
 	return pg
 }
 
+func (pg *standardBasePrimitiveGraph) WithDescription(_ primitive.Description) primitive.IPrimitive {
+	return pg
+}
+
+func (pg *standardBasePrimitiveGraph) GetDescription() primitive.Description {
+	return primitive.NewDescription("PrimitiveGraph")
+}
+
+// To returns the nodes upon which the node with supplied ID depends.
+func (pg *standardBasePrimitiveGraph) To(id int64) graph.Nodes {
+	return pg.g.To(id)
+}
+
 func newBasePrimitiveGraph(concurrencyLimit int) BasePrimitiveGraph {
-	eg, egCtx := errgroup.WithContext(context.Background())
-	eg.SetLimit(concurrencyLimit)
 	return &standardBasePrimitiveGraph{
-		g:           simple.NewWeightedDirectedGraph(0.0, 0.0),
-		errGroup:    eg,
-		errGroupCtx: egCtx,
+		g:                simple.NewWeightedDirectedGraph(0.0, 0.0),
+		concurrencyLimit: concurrencyLimit,
 	}
 }
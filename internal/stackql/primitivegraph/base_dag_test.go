@@ -0,0 +1,60 @@
+package primitivegraph_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/primitive"
+
+	. "github.com/stackql/stackql/internal/stackql/primitivegraph"
+)
+
+func TestExecuteCancelledMidFlight(t *testing.T) {
+	holder := NewPrimitiveGraphHolder(4)
+	pg := holder.GetPrimitiveGraph()
+	started := make(chan struct{})
+	var downstreamExecuted bool
+	upstream := pg.CreatePrimitiveNode(
+		primitive.NewLocalPrimitive(func(pc primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
+			close(started)
+			<-pc.GetContext().Done()
+			return internaldto.NewErroneousExecutorOutput(context.Cause(pc.GetContext()))
+		}),
+	)
+	downstream := pg.CreatePrimitiveNode(
+		primitive.NewLocalPrimitive(func(pc primitive.IPrimitiveCtx) internaldto.ExecutorOutput {
+			downstreamExecuted = true
+			return internaldto.NewEmptyExecutorOutput()
+		}),
+	)
+	pg.NewDependency(upstream, downstream, 1.0)
+	if err := pg.Optimise(); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	cause := errors.New("statement timeout")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	go func() {
+		<-started
+		cancel(cause)
+	}()
+	done := make(chan internaldto.ExecutorOutput, 1)
+	go func() {
+		done <- pg.Execute(
+			internaldto.NewBasicPrimitiveContext(nil, nil, nil).WithContext(ctx),
+		)
+	}()
+	select {
+	case output := <-done:
+		if !errors.Is(output.GetError(), cause) {
+			t.Fatalf("test failed: expected cancellation cause, got: %v", output.GetError())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("test failed: execution did not observe cancellation")
+	}
+	if downstreamExecuted {
+		t.Fatal("test failed: dependent primitive executed after cancellation")
+	}
+}
@@ -3,6 +3,7 @@ package primitivegraph
 import (
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
 	"github.com/stackql/stackql/internal/stackql/primitive"
+	"golang.org/x/sync/semaphore"
 )
 
 var (
@@ -31,11 +32,21 @@ type PrimitiveGraphHolder interface {
 	SetContainsUserManagedRelation(containsUserRelation bool)
 	InverseContainsUserManagedRelation() bool
 	ContainsUserManagedRelation() bool
+	// GetAcquisitionSlots caps concurrent acquisitions across all
+	// primitives of the graph, such that concurrently executing
+	// primitives do not multiply the concurrency limit.
+	// It is nil where the concurrency limit is unbounded.
+	GetAcquisitionSlots() *semaphore.Weighted
 }
 
 type standardPrimitiveGraphHolder struct {
-	pg  PrimitiveGraph
-	ipg PrimitiveGraph
+	pg               PrimitiveGraph
+	ipg              PrimitiveGraph
+	acquisitionSlots *semaphore.Weighted
+}
+
+func (pgh *standardPrimitiveGraphHolder) GetAcquisitionSlots() *semaphore.Weighted {
+	return pgh.acquisitionSlots
 }
 
 func (pgh *standardPrimitiveGraphHolder) GetPrimitiveGraph() PrimitiveGraph {
@@ -121,8 +132,13 @@ func (pgh *standardPrimitiveGraphHolder) SetInverseContainsIndirect(containsView
 func NewPrimitiveGraphHolder(concurrencyLimit int) PrimitiveGraphHolder {
 	pg := newPrimitiveGraph(concurrencyLimit)
 	ipg := newSequentialPrimitiveGraph(concurrencyLimit)
-	return &standardPrimitiveGraphHolder{
+	rv := &standardPrimitiveGraphHolder{
 		pg:  pg,
 		ipg: ipg,
 	}
+	// NOTE: a non positive limit is unbounded, as for graph execution.
+	if concurrencyLimit > 0 {
+		rv.acquisitionSlots = semaphore.NewWeighted(int64(concurrencyLimit))
+	}
+	return rv
 }
@@ -1,9 +1,35 @@
 package psqlwire_test
 
 import (
+	"fmt"
 	"testing"
+
+	"github.com/stackql/any-sdk/pkg/dto"
+	"github.com/stackql/stackql/internal/stackql/srvauth"
+
+	. "github.com/stackql/stackql/internal/stackql/psqlwire"
 )
 
 func TestMockedStream(t *testing.T) {
 	//
 }
+
+func TestPasswordAuthRequiresTLS(t *testing.T) {
+	verifier, err := srvauth.NewSCRAMSHA256Verifier("s3cret")
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	users, err := srvauth.NewUserRegistry([]byte(fmt.Sprintf("users:\n  - username: reader\n    password: %s\n", verifier)))
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	if _, err = MakeWireServer(nil, dto.RuntimeCtx{}, users, false); err == nil {
+		t.Fatal("test failed: expected password authentication without TLS to be refused")
+	}
+	if _, err = MakeWireServer(nil, dto.RuntimeCtx{}, users, true); err != nil {
+		t.Fatalf("test failed: expected explicit opt in to be honoured: %v", err)
+	}
+	if _, err = MakeWireServer(nil, dto.RuntimeCtx{}, nil, false); err != nil {
+		t.Fatalf("test failed: expected open server without TLS: %v", err)
+	}
+}
@@ -6,16 +6,25 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stackql/any-sdk/pkg/dto"
 	"github.com/stackql/any-sdk/pkg/logging"
+	"github.com/stackql/stackql/internal/stackql/metrics"
+	"github.com/stackql/stackql/internal/stackql/srvauth"
 
 	"github.com/stackql/psql-wire/pkg/sqlbackend"
 
 	wire "github.com/stackql/psql-wire"
 )
 
+var (
+	//nolint:gochecknoglobals // acceptable
+	errInsecureAuth = fmt.Errorf(
+		"password authentication requires TLS; supply --pgsrv.tls, or else --pgsrv.allow-insecure-auth")
+)
+
 type IWireServer interface {
 	Serve() error
 }
@@ -27,10 +36,24 @@ type SimpleWireServer struct {
 	tlsCfg dto.PgTLSCfg
 }
 
+// MakeWireServer optionally requires password authentication
+// of users; nil users implies the server is open.
+// Password authentication mandates TLS, unless
+// insecure auth is explicitly allowed.
+//
 //nolint:gocognit,nestif,nolintlint
-func MakeWireServer(sbe sqlbackend.SQLBackendFactory, cfg dto.RuntimeCtx) (IWireServer, error) {
+func MakeWireServer(
+	sbe sqlbackend.SQLBackendFactory,
+	cfg dto.RuntimeCtx,
+	users srvauth.UserRegistry,
+	allowInsecureAuth bool,
+) (IWireServer, error) {
 	logger := logging.GetLogger()
 
+	if users != nil && cfg.PGSrvRawTLSCfg == "" && !allowInsecureAuth {
+		return nil, errInsecureAuth
+	}
+
 	var tlsCfg dto.PgTLSCfg
 	var server *wire.Server
 
@@ -78,6 +101,14 @@ func MakeWireServer(sbe sqlbackend.SQLBackendFactory, cfg dto.RuntimeCtx) (IWire
 			return nil, err
 		}
 	}
+	if users != nil {
+		server.Auth = wire.SCRAMSHA256(scramCredentialsLookup(users))
+		if cfg.PGSrvRawTLSCfg == "" {
+			logger.Warn("server password authentication is configured without TLS; queries and results will be sent in the clear")
+		} else {
+			server.RequireTLS = true
+		}
+	}
 	return &SimpleWireServer{
 		logger: logger,
 		rtCtx:  cfg,
@@ -92,5 +123,24 @@ func (sws *SimpleWireServer) Serve() error {
 			sws.rtCtx.PGSrvAddress,
 			sws.rtCtx.PGSrvPort),
 	)
-	return sws.server.ListenAndServe(fmt.Sprintf("%s:%d", sws.rtCtx.PGSrvAddress, sws.rtCtx.PGSrvPort))
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", sws.rtCtx.PGSrvAddress, sws.rtCtx.PGSrvPort))
+	if err != nil {
+		return err
+	}
+	return sws.server.Serve(metrics.NewSessionCountingListener(listener))
+}
+
+func scramCredentialsLookup(users srvauth.UserRegistry) func(string) (wire.SCRAMCredentials, bool, error) {
+	return func(username string) (wire.SCRAMCredentials, bool, error) {
+		verifier, ok := users.GetSCRAMVerifier(username)
+		if !ok {
+			return wire.SCRAMCredentials{}, false, nil
+		}
+		return wire.SCRAMCredentials{
+			Iterations: verifier.GetIterations(),
+			Salt:       verifier.GetSalt(),
+			StoredKey:  verifier.GetStoredKey(),
+			ServerKey:  verifier.GetServerKey(),
+		}, true, nil
+	}
 }
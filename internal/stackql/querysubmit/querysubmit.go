@@ -1,6 +1,9 @@
 package querysubmit
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/stackql/any-sdk/pkg/logging"
 	"github.com/stackql/stackql-parser/go/vt/sqlparser"
 	"github.com/stackql/stackql/internal/stackql/acid/binlog"
@@ -9,6 +12,7 @@ import (
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
 	"github.com/stackql/stackql/internal/stackql/plan"
 	"github.com/stackql/stackql/internal/stackql/planbuilder"
+	"github.com/stackql/stackql/internal/stackql/primitive"
 	"github.com/stackql/stackql/internal/stackql/primitivegraph"
 )
 
@@ -91,12 +95,20 @@ func (qs *basicQuerySubmitter) PrepareQuery(handlerCtx handler.HandlerContext) e
 
 func (qs *basicQuerySubmitter) SubmitQuery() internaldto.ExecutorOutput {
 	logging.GetLogger().Debugln("SubmitQuery() invoked...")
+	ctx, cancel := newStatementContext(qs.handlerCtx)
+	// The result of a read only statement is returned forthwith
+	// to the client, and so may be streamed from the backend.
+	ctx = primitive.NewResultStreamingContext(ctx, qs.IsReadOnly())
 	pl := internaldto.NewBasicPrimitiveContext(
 		nil,
 		qs.handlerCtx.GetOutfile(),
 		qs.handlerCtx.GetOutErrFile(),
-	)
-	return qs.queryPlan.GetInstructions().GetPrimitiveGraph().Execute(pl)
+	).WithContext(ctx)
+	output := qs.queryPlan.GetInstructions().GetPrimitiveGraph().Execute(pl)
+	// Streamed rows are read after submission returns, and so
+	// the statement context is held until they are released.
+	internaldto.OnSQLResultReleased(output, func(error) { cancel() })
+	return output
 }
 
 func (qs *basicQuerySubmitter) PrepareUndoQuery(handlerCtx handler.HandlerContext) error {
@@ -117,3 +129,18 @@ func (qs *basicQuerySubmitter) UndoQuery() internaldto.ExecutorOutput {
 	)
 	return qs.queryPlan.GetInstructions().GetInversePrimitiveGraph().Execute(pl)
 }
+
+// newStatementContext bounds execution of a single statement
+// by the query context and any session statement timeout.
+func newStatementContext(handlerCtx handler.HandlerContext) (context.Context, context.CancelFunc) {
+	ctx := handlerCtx.GetContext()
+	timeout := handlerCtx.GetStatementTimeout()
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(
+		ctx,
+		timeout,
+		fmt.Errorf("canceling statement due to statement timeout of %s", timeout),
+	)
+}
@@ -40,6 +40,7 @@ func HandleResponse(handlerCtx handler.HandlerContext, response internaldto.Exec
 			},
 		)
 		if outputWriter == nil || err != nil {
+			internaldto.ReleaseSQLResult(response) //nolint:errcheck // output discarded
 			handleEmptyWriter(outputWriter, err)
 			return err
 		}
@@ -18,6 +18,7 @@ import (
 	"github.com/stackql/stackql/internal/stackql/astfuncrewrite"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/relationaldto"
+	"github.com/stackql/stackql/internal/stackql/metrics"
 	"github.com/stackql/stackql/internal/stackql/sqlcontrol"
 	"github.com/stackql/stackql/internal/stackql/sqlengine"
 	"github.com/stackql/stackql/internal/stackql/typing"
@@ -1329,7 +1330,12 @@ func (eng *postgresSystem) gCCollectObsoleted(minTransactionID int) error {
 	if err != nil {
 		return err
 	}
-	return eng.readExecGeneratedQueries(deleteQueryResultSet)
+	rowCount, err := eng.readExecGeneratedQueriesCount(deleteQueryResultSet)
+	if err != nil {
+		return err
+	}
+	metrics.AddGCRowsCollected(rowCount)
+	return nil
 }
 
 func (eng *postgresSystem) GCCollectAll() error {
@@ -1365,7 +1371,12 @@ func (eng *postgresSystem) gCCollectAll() error {
 	if err != nil {
 		return err
 	}
-	return eng.readExecGeneratedQueries(deleteQueryResultSet)
+	rowCount, err := eng.readExecGeneratedQueriesCount(deleteQueryResultSet)
+	if err != nil {
+		return err
+	}
+	metrics.AddGCRowsCollected(rowCount)
+	return nil
 }
 
 func (eng *postgresSystem) GCControlTablesPurge() error {
@@ -1559,22 +1570,21 @@ func (eng *postgresSystem) purgeAll() error {
 }
 
 func (eng *postgresSystem) readExecGeneratedQueries(queryResultSet *sql.Rows) error {
-	defer queryResultSet.Close()
-	var queries []string
-	for {
-		hasNext := queryResultSet.Next()
-		if !hasNext {
-			break
-		}
-		var s string
-		err := queryResultSet.Scan(&s)
-		if err != nil {
-			return err
-		}
-		queries = append(queries, s)
+	queries, err := readGeneratedQueries(queryResultSet)
+	if err != nil {
+		return err
 	}
-	err := eng.sqlEngine.ExecInTxn(queries)
-	return err
+	return eng.sqlEngine.ExecInTxn(queries)
+}
+
+// readExecGeneratedQueriesCount returns the total rows
+// affected, which is zero upon any failure.
+func (eng *postgresSystem) readExecGeneratedQueriesCount(queryResultSet *sql.Rows) (int64, error) {
+	queries, err := readGeneratedQueries(queryResultSet)
+	if err != nil {
+		return 0, err
+	}
+	return execInTxnCountingRows(eng.sqlEngine, queries)
 }
 
 func (eng *postgresSystem) GetRelationalType(discoType string) string {
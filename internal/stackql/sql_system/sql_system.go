@@ -134,6 +134,51 @@ func getNodeFormatter(name string) sqlparser.NodeFormatter {
 	return astformat.DefaultSelectExprsFormatter
 }
 
+// readGeneratedQueries reads queries generated by the backend.
+func readGeneratedQueries(queryResultSet *sql.Rows) ([]string, error) {
+	defer queryResultSet.Close()
+	var queries []string
+	for queryResultSet.Next() {
+		var s string
+		if err := queryResultSet.Scan(&s); err != nil {
+			return nil, err
+		}
+		queries = append(queries, s)
+	}
+	return queries, queryResultSet.Err()
+}
+
+// execInTxnCountingRows executes the queries in a single transaction,
+// as per ExecInTxn, and returns the total rows affected, which is zero
+// unless the transaction is committed.
+func execInTxnCountingRows(sqlEngine sqlengine.SQLEngine, queries []string) (int64, error) {
+	db, err := sqlEngine.GetDB()
+	if err != nil {
+		return 0, err
+	}
+	txn, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	var rowsAffected int64
+	for _, query := range queries {
+		res, execErr := txn.Exec(query)
+		if execErr != nil {
+			//nolint:errcheck // intentionally ignoring error TODO: publish variadic error(s)
+			txn.Rollback()
+			return 0, execErr
+		}
+		// Not all drivers and statements report rows affected.
+		if n, rowsErr := res.RowsAffected(); rowsErr == nil {
+			rowsAffected += n
+		}
+	}
+	if err = txn.Commit(); err != nil {
+		return 0, err
+	}
+	return rowsAffected, nil
+}
+
 func NewSQLSystem(
 	sqlEngine sqlengine.SQLEngine,
 	analyticsNamespaceLikeString string,
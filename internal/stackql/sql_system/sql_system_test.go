@@ -0,0 +1,55 @@
+package sql_system //nolint:revive,stylecheck,testpackage // the transaction helper is unexported
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stackql/stackql/internal/stackql/sqlengine"
+)
+
+// txnTestSQLEngine supplies only the database.
+type txnTestSQLEngine struct {
+	sqlengine.SQLEngine
+	db *sql.DB
+}
+
+func (se *txnTestSQLEngine) GetDB() (*sql.DB, error) {
+	return se.db, nil
+}
+
+func newTxnTestSQLEngine(t *testing.T) (*txnTestSQLEngine, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &txnTestSQLEngine{db: db}, mock
+}
+
+func TestExecInTxnCountingRows(t *testing.T) {
+	se, mock := newTxnTestSQLEngine(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM a").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("DELETE FROM b").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	rowCount, err := execInTxnCountingRows(se, []string{"DELETE FROM a", "DELETE FROM b"})
+	if err != nil || rowCount != 5 {
+		t.Fatalf("test failed: expected 5 rows, got %d and error %v", rowCount, err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+}
+
+func TestExecInTxnCountingRowsUponFailedCommit(t *testing.T) {
+	se, mock := newTxnTestSQLEngine(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM a").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit().WillReturnError(errors.New("database is locked"))
+	rowCount, err := execInTxnCountingRows(se, []string{"DELETE FROM a"})
+	if err == nil || rowCount != 0 {
+		t.Fatalf("test failed: expected no rows upon failed commit, got %d and error %v", rowCount, err)
+	}
+}
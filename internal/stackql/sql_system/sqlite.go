@@ -18,6 +18,7 @@ import (
 	"github.com/stackql/stackql/internal/stackql/astfuncrewrite"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/relationaldto"
+	"github.com/stackql/stackql/internal/stackql/metrics"
 	"github.com/stackql/stackql/internal/stackql/sqlcontrol"
 	"github.com/stackql/stackql/internal/stackql/sqlengine"
 	"github.com/stackql/stackql/internal/stackql/typing"
@@ -432,7 +433,12 @@ func (eng *sqLiteSystem) gCCollectObsoleted(minTransactionID int) error {
 	if err != nil {
 		return err
 	}
-	return eng.readExecGeneratedQueries(deleteQueryResultSet)
+	rowCount, err := eng.readExecGeneratedQueriesCount(deleteQueryResultSet)
+	if err != nil {
+		return err
+	}
+	metrics.AddGCRowsCollected(rowCount)
+	return nil
 }
 
 func (eng *sqLiteSystem) GCCollectAll() error {
@@ -460,7 +466,12 @@ func (eng *sqLiteSystem) gCCollectAll() error {
 	if err != nil {
 		return err
 	}
-	return eng.readExecGeneratedQueries(deleteQueryResultSet)
+	rowCount, err := eng.readExecGeneratedQueriesCount(deleteQueryResultSet)
+	if err != nil {
+		return err
+	}
+	metrics.AddGCRowsCollected(rowCount)
+	return nil
 }
 
 func (eng *sqLiteSystem) generateDropTableStatement(relationalTable relationaldto.RelationalTable) (string, error) {
@@ -1496,22 +1507,21 @@ func (eng *sqLiteSystem) DelimitOrderByColumn(term string) string {
 }
 
 func (eng *sqLiteSystem) readExecGeneratedQueries(queryResultSet *sql.Rows) error {
-	defer queryResultSet.Close()
-	var queries []string
-	for {
-		hasNext := queryResultSet.Next()
-		if !hasNext {
-			break
-		}
-		var s string
-		err := queryResultSet.Scan(&s)
-		if err != nil {
-			return err
-		}
-		queries = append(queries, s)
+	queries, err := readGeneratedQueries(queryResultSet)
+	if err != nil {
+		return err
 	}
-	err := eng.sqlEngine.ExecInTxn(queries)
-	return err
+	return eng.sqlEngine.ExecInTxn(queries)
+}
+
+// readExecGeneratedQueriesCount returns the total rows
+// affected, which is zero upon any failure.
+func (eng *sqLiteSystem) readExecGeneratedQueriesCount(queryResultSet *sql.Rows) (int64, error) {
+	queries, err := readGeneratedQueries(queryResultSet)
+	if err != nil {
+		return 0, err
+	}
+	return execInTxnCountingRows(eng.sqlEngine, queries)
 }
 
 func (eng *sqLiteSystem) GetRelationalType(discoType string) string {
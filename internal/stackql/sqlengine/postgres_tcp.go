@@ -2,6 +2,7 @@
 package sqlengine
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -290,15 +291,23 @@ func (se postgresTCPEngine) bodgePrepStmt(s string) (string, bool) {
 }
 
 func (se postgresTCPEngine) Query(query string, varArgs ...interface{}) (*sql.Rows, error) {
-	return se.query(query, varArgs...)
+	return se.query(context.Background(), query, varArgs...)
 }
 
-func (se postgresTCPEngine) query(query string, varArgs ...interface{}) (*sql.Rows, error) {
+func (se postgresTCPEngine) QueryContext(
+	ctx context.Context,
+	query string,
+	varArgs ...interface{},
+) (*sql.Rows, error) {
+	return se.query(ctx, query, varArgs...)
+}
+
+func (se postgresTCPEngine) query(ctx context.Context, query string, varArgs ...interface{}) (*sql.Rows, error) {
 	bodgedQuery, containsParameters := se.bodgePrepStmt(query)
 	if !containsParameters {
-		res, err := se.db.Query(bodgedQuery, []interface{}{}...)
+		res, err := se.db.QueryContext(ctx, bodgedQuery, []interface{}{}...)
 		return res, err
 	}
-	res, err := se.db.Query(bodgedQuery, varArgs...)
+	res, err := se.db.QueryContext(ctx, bodgedQuery, varArgs...)
 	return res, err
 }
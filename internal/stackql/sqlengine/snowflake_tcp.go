@@ -2,6 +2,7 @@
 package sqlengine
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -272,10 +273,18 @@ func (se snowflakeTCPEngine) CacheStorePut(key string, val []byte, tablespace st
 }
 
 func (se snowflakeTCPEngine) Query(query string, varArgs ...interface{}) (*sql.Rows, error) {
-	return se.query(query, varArgs...)
+	return se.query(context.Background(), query, varArgs...)
 }
 
-func (se snowflakeTCPEngine) query(query string, varArgs ...interface{}) (*sql.Rows, error) {
-	res, err := se.db.Query(query, varArgs...)
+func (se snowflakeTCPEngine) QueryContext(
+	ctx context.Context,
+	query string,
+	varArgs ...interface{},
+) (*sql.Rows, error) {
+	return se.query(ctx, query, varArgs...)
+}
+
+func (se snowflakeTCPEngine) query(ctx context.Context, query string, varArgs ...interface{}) (*sql.Rows, error) {
+	res, err := se.db.QueryContext(ctx, query, varArgs...)
 	return res, err
 }
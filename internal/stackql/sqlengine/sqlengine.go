@@ -1,6 +1,7 @@
 package sqlengine
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -15,6 +16,7 @@ type SQLEngine interface {
 	GetTx() (*sql.Tx, error)
 	Exec(string, ...interface{}) (sql.Result, error)
 	Query(string, ...interface{}) (*sql.Rows, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
 	QueryRow(query string, args ...any) *sql.Row
 	ExecFileLocal(string) error
 	ExecFile(string) error
@@ -1,6 +1,7 @@
 package sqlengine
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -272,12 +273,20 @@ func (se sqLiteEmbeddedEngine) CacheStorePut(key string, val []byte, tablespace
 }
 
 func (se sqLiteEmbeddedEngine) Query(query string, varArgs ...interface{}) (*sql.Rows, error) {
-	return se.query(query, varArgs...)
+	return se.query(context.Background(), query, varArgs...)
 }
 
-func (se sqLiteEmbeddedEngine) query(query string, varArgs ...interface{}) (*sql.Rows, error) {
+func (se sqLiteEmbeddedEngine) QueryContext(
+	ctx context.Context,
+	query string,
+	varArgs ...interface{},
+) (*sql.Rows, error) {
+	return se.query(ctx, query, varArgs...)
+}
+
+func (se sqLiteEmbeddedEngine) query(ctx context.Context, query string, varArgs ...interface{}) (*sql.Rows, error) {
 	logging.GetLogger().Debugln(fmt.Sprintf("sqlite embedded raw query = %s, varArgs = %v", query, varArgs))
-	res, err := se.db.Query(query, varArgs...)
+	res, err := se.db.QueryContext(ctx, query, varArgs...)
 	// logging.GetLogger().Infoln(fmt.Sprintf("res= %v, err = %v", res, err))
 	return res, err
 }
@@ -1,11 +1,21 @@
 package sqlmachinery
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+)
 
 type Querier interface {
 	Query(string, ...interface{}) (*sql.Rows, error)
 }
 
+// ContextQuerier queries subject to a context,
+// the cancellation of which closes the rows.
+type ContextQuerier interface {
+	Querier
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}
+
 type ExecQuerier interface {
 	Exec(string, ...interface{}) (sql.Result, error)
 }
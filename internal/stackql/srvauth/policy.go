@@ -0,0 +1,134 @@
+package srvauth
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+var (
+	_ Policy = &standardPolicy{}
+)
+
+// Policy constrains what an authenticated server user may do.
+// A nil Policy, as for exec and shell sessions, is unrestricted.
+type Policy interface {
+	GetUsername() string
+	// AuthoriseResource errors unless the resource lies within
+	// the select scope of the policy.
+	AuthoriseResource(provider, service, resource string) error
+	// AuthoriseTable errors unless the physical table, materialized
+	// view or native DBMS table lies within the tables scope of the
+	// policy, since such tables hold data obtained by any user.
+	AuthoriseTable(name string) error
+	// AuthoriseMutation errors unless the policy permits
+	// INSERT, UPDATE, DELETE and EXEC.
+	AuthoriseMutation(statementType string) error
+	// AuthoriseAdministration errors unless the policy permits
+	// statements acting upon the server as a whole, outside of
+	// the scope of resources and tables: NATIVEQUERY, DDL,
+	// REGISTRY PULL and PURGE.
+	AuthoriseAdministration(statementType string) error
+}
+
+// PolicyCfg is the policy section of a users file entry.
+// Select patterns are of the form provider[.service[.resource]],
+// each segment being a glob, so that "google" and "google.*.*"
+// are equivalent.  Mutations are permitted only upon
+// resources within the select scope.  Tables patterns are globs
+// upon table names, such as "information_schema.*"; no table is
+// accessible unless matched.  Admin permits statements which
+// bypass both scopes, such as NATIVEQUERY.
+type PolicyCfg struct {
+	Select []string `json:"select" yaml:"select"`
+	Tables []string `json:"tables" yaml:"tables"`
+	Mutate bool     `json:"mutate" yaml:"mutate"`
+	Admin  bool     `json:"admin" yaml:"admin"`
+}
+
+type standardPolicy struct {
+	username      string
+	patterns      [][]string
+	tablePatterns []string
+	mutate        bool
+	admin         bool
+}
+
+func newPolicy(username string, cfg PolicyCfg) (Policy, error) {
+	rv := &standardPolicy{
+		username: username,
+		mutate:   cfg.Mutate,
+		admin:    cfg.Admin,
+	}
+	for _, p := range cfg.Select {
+		segments := strings.Split(strings.TrimSpace(p), ".")
+		if len(segments) > 3 { //nolint:mnd // provider.service.resource
+			return nil, fmt.Errorf("invalid select pattern '%s' for user '%s'", p, username)
+		}
+		for _, segment := range segments {
+			if _, err := path.Match(segment, ""); err != nil || segment == "" {
+				return nil, fmt.Errorf("invalid select pattern '%s' for user '%s'", p, username)
+			}
+		}
+		rv.patterns = append(rv.patterns, segments)
+	}
+	for _, p := range cfg.Tables {
+		p = strings.TrimSpace(p)
+		if _, err := path.Match(p, ""); err != nil || p == "" {
+			return nil, fmt.Errorf("invalid tables pattern '%s' for user '%s'", p, username)
+		}
+		rv.tablePatterns = append(rv.tablePatterns, p)
+	}
+	return rv, nil
+}
+
+func (p *standardPolicy) GetUsername() string {
+	return p.username
+}
+
+func (p *standardPolicy) AuthoriseResource(provider, service, resource string) error {
+	fqn := []string{provider, service, resource}
+	for _, pattern := range p.patterns {
+		if matchSegments(pattern, fqn) {
+			return nil
+		}
+	}
+	return fmt.Errorf(
+		"permission denied for user '%s' on resource '%s'",
+		p.username,
+		strings.Join(fqn, "."),
+	)
+}
+
+func (p *standardPolicy) AuthoriseTable(name string) error {
+	for _, pattern := range p.tablePatterns {
+		if matched, err := path.Match(pattern, name); err == nil && matched {
+			return nil
+		}
+	}
+	return fmt.Errorf("permission denied for user '%s' on table '%s'", p.username, name)
+}
+
+func (p *standardPolicy) AuthoriseMutation(statementType string) error {
+	if p.mutate {
+		return nil
+	}
+	return fmt.Errorf("permission denied for user '%s' to %s", p.username, statementType)
+}
+
+func (p *standardPolicy) AuthoriseAdministration(statementType string) error {
+	if p.admin {
+		return nil
+	}
+	return fmt.Errorf("permission denied for user '%s' to %s", p.username, statementType)
+}
+
+func matchSegments(pattern []string, fqn []string) bool {
+	for i, segment := range pattern {
+		matched, err := path.Match(segment, fqn[i])
+		if err != nil || !matched {
+			return false
+		}
+	}
+	return true
+}
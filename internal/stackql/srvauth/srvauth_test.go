@@ -0,0 +1,114 @@
+package srvauth_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"golang.org/x/crypto/pbkdf2"
+
+	. "github.com/stackql/stackql/internal/stackql/srvauth"
+)
+
+// Verifier for password "pencil" with the salt
+// and iteration count of the RFC 7677 example.
+const rfc7677Verifier = "SCRAM-SHA-256$4096:W22ZaJ0SNY7soEsUEjb6gQ==$" +
+	"WG5d8oPm3OtcPnkdi4Uo7BkeZkBFzpcXkuLmtbsT4qY=:wfPLwcE6nTWhTAmQ7tl2KeoiWGPlZqQxSrmfPwDl2dU="
+
+// matchesPassword recomputes StoredKey from a cleartext
+// password, as the client side of a SCRAM exchange would.
+func matchesPassword(verifier SCRAMVerifier, password string) bool {
+	saltedPassword := pbkdf2.Key([]byte(password), verifier.GetSalt(), verifier.GetIterations(), sha256.Size, sha256.New)
+	mac := hmac.New(sha256.New, saltedPassword)
+	mac.Write([]byte("Client Key"))
+	storedKey := sha256.Sum256(mac.Sum(nil))
+	return bytes.Equal(storedKey[:], verifier.GetStoredKey())
+}
+
+func TestVerifiersAndPolicy(t *testing.T) {
+	generated, err := NewSCRAMSHA256Verifier("s3cret")
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	reg, err := NewUserRegistry([]byte(fmt.Sprintf(`
+users:
+  - username: reader
+    password: %s
+    policy:
+      select: [ "google.compute", "okta.*.apps" ]
+      tables: [ "information_schema.*" ]
+  - username: rfc
+    password: %s
+    policy:
+      select: [ "*" ]
+      mutate: true
+      admin: true
+`, generated, rfc7677Verifier)))
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	for _, tc := range []struct {
+		username string
+		password string
+		expected bool
+	}{
+		{"reader", "s3cret", true},
+		{"reader", "wrong", false},
+		{"rfc", "pencil", true},
+		{"nobody", "s3cret", false},
+	} {
+		verifier, ok := reg.GetSCRAMVerifier(tc.username)
+		if ok = ok && matchesPassword(verifier, tc.password); ok != tc.expected {
+			t.Fatalf("test failed: verifier for '%s' expected %v, got %v", tc.username, tc.expected, ok)
+		}
+	}
+	verifier, ok := reg.GetSCRAMVerifier("rfc")
+	if !ok || verifier.GetIterations() != 4096 || len(verifier.GetStoredKey()) != sha256.Size {
+		t.Fatal("test failed: unexpected SCRAM verifier for 'rfc'")
+	}
+	if _, ok = reg.GetSCRAMVerifier("nobody"); ok {
+		t.Fatal("test failed: SCRAM verifier found for unknown user")
+	}
+	policy, ok := reg.GetPolicy("reader")
+	if !ok {
+		t.Fatal("test failed: policy not found")
+	}
+	if policy.AuthoriseResource("google", "compute", "instances") != nil {
+		t.Fatal("test failed: expected google.compute.instances to be permitted")
+	}
+	if policy.AuthoriseResource("okta", "application", "apps") != nil {
+		t.Fatal("test failed: expected okta.application.apps to be permitted")
+	}
+	if policy.AuthoriseResource("google", "storage", "buckets") == nil {
+		t.Fatal("test failed: expected google.storage.buckets to be denied")
+	}
+	if policy.AuthoriseTable("information_schema.tables") != nil {
+		t.Fatal("test failed: expected information_schema.tables to be permitted")
+	}
+	if policy.AuthoriseTable("stackql_history.queries") == nil {
+		t.Fatal("test failed: expected stackql_history.queries to be denied")
+	}
+	if policy.AuthoriseMutation("INSERT") == nil {
+		t.Fatal("test failed: expected mutation to be denied")
+	}
+	if policy.AuthoriseAdministration("NATIVEQUERY") == nil {
+		t.Fatal("test failed: expected administration to be denied")
+	}
+	policy, ok = reg.GetPolicy("rfc")
+	if !ok || policy.AuthoriseAdministration("NATIVEQUERY") != nil {
+		t.Fatal("test failed: expected administration to be permitted for 'rfc'")
+	}
+}
+
+func TestMalformedVerifierRejected(t *testing.T) {
+	_, err := NewUserRegistry([]byte(`
+users:
+  - username: plain
+    password: hunter2
+`))
+	if err == nil {
+		t.Fatal("test failed: expected cleartext password to be rejected")
+	}
+}
@@ -0,0 +1,104 @@
+package srvauth
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	_ UserRegistry = &standardUserRegistry{}
+)
+
+// UserRegistry holds the users permitted to connect
+// to the wire server, along with their policies.
+type UserRegistry interface {
+	// GetSCRAMVerifier backs a SCRAM-SHA-256 exchange,
+	// in which the password is never sent.
+	GetSCRAMVerifier(username string) (SCRAMVerifier, bool)
+	GetPolicy(username string) (Policy, bool)
+}
+
+// usersCfg is the users file, in yaml or json form, eg:
+//
+//	users:
+//	  - username: analyst
+//	    password: SCRAM-SHA-256$4096:<salt>$<StoredKey>:<ServerKey>
+//	    policy:
+//	      select: [ "google.compute", "okta.application.apps" ]
+//	      tables: [ "information_schema.*" ]
+//	      mutate: false
+type usersCfg struct {
+	Users []userCfg `json:"users" yaml:"users"`
+}
+
+type userCfg struct {
+	Username string    `json:"username" yaml:"username"`
+	Password string    `json:"password" yaml:"password"`
+	Policy   PolicyCfg `json:"policy" yaml:"policy"`
+}
+
+type userEntry struct {
+	verifier *scramVerifier
+	policy   Policy
+}
+
+type standardUserRegistry struct {
+	users map[string]userEntry
+}
+
+func NewUserRegistryFromFile(filePath string) (UserRegistry, error) {
+	b, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read users file: %w", err)
+	}
+	return NewUserRegistry(b)
+}
+
+func NewUserRegistry(raw []byte) (UserRegistry, error) {
+	var cfg usersCfg
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("cannot parse users file: %w", err)
+	}
+	rv := &standardUserRegistry{
+		users: make(map[string]userEntry, len(cfg.Users)),
+	}
+	for _, u := range cfg.Users {
+		if u.Username == "" {
+			return nil, fmt.Errorf("users file entry missing username")
+		}
+		if _, exists := rv.users[u.Username]; exists {
+			return nil, fmt.Errorf("duplicate user '%s' in users file", u.Username)
+		}
+		verifier, err := parseSCRAMVerifier(u.Password)
+		if err != nil {
+			return nil, fmt.Errorf("user '%s': %w", u.Username, err)
+		}
+		policy, err := newPolicy(u.Username, u.Policy)
+		if err != nil {
+			return nil, err
+		}
+		rv.users[u.Username] = userEntry{
+			verifier: verifier,
+			policy:   policy,
+		}
+	}
+	return rv, nil
+}
+
+func (r *standardUserRegistry) GetSCRAMVerifier(username string) (SCRAMVerifier, bool) {
+	entry, ok := r.users[username]
+	if !ok {
+		return nil, false
+	}
+	return entry.verifier, true
+}
+
+func (r *standardUserRegistry) GetPolicy(username string) (Policy, bool) {
+	entry, ok := r.users[username]
+	if !ok {
+		return nil, false
+	}
+	return entry.policy, true
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
+	"github.com/stackql/stackql/internal/stackql/metrics"
 	"github.com/stackql/stackql/internal/stackql/sql_system"
 	"github.com/stackql/stackql/internal/stackql/sqlengine"
 	"github.com/stackql/stackql/internal/stackql/templatenamespace"
@@ -93,6 +94,7 @@ func (stc *regexTableNamespaceConfigurator) Match(
 	}
 	isPresent := stc.sqlSystem.IsTablePresent(actualTableName, requestEncoding, requestEncodingColName)
 	if !isPresent {
+		metrics.ObserveAnalyticsCacheLookup(false)
 		return nil, false
 	}
 	oldestUpdate, tcc := stc.sqlSystem.TableOldestUpdateUTC(
@@ -102,8 +104,10 @@ func (stc *regexTableNamespaceConfigurator) Match(
 	diff := time.Since(oldestUpdate)
 	ds := diff.Seconds()
 	if stc.ttl > -1 && int(ds) > stc.ttl {
+		metrics.ObserveAnalyticsCacheLookup(false)
 		return nil, false
 	}
+	metrics.ObserveAnalyticsCacheLookup(true)
 	return tcc, true
 }
 
@@ -9,6 +9,7 @@ import (
 	"github.com/stackql/stackql/internal/stackql/handler"
 	"github.com/stackql/stackql/internal/stackql/internal_data_transfer/internaldto"
 	"github.com/stackql/stackql/internal/stackql/parserutil"
+	"github.com/stackql/stackql/internal/stackql/srvauth"
 	"github.com/stackql/stackql/internal/stackql/tablemetadata"
 	"github.com/stackql/stackql/pkg/name_mangle"
 
@@ -116,6 +117,85 @@ func GetHIDs(
 	return hIDs, nil
 }
 
+const (
+	// maxAuthorisedViewDepth bounds the resolution of views upon views.
+	maxAuthorisedViewDepth int = 16
+)
+
+// authoriseHeirarchy applies any user policy to provider resources
+// and tables.  Views are authorised by the relations of their bodies,
+// in turn.  Postgres catalog objects are exempt, since clients
+// query them for metadata.
+func authoriseHeirarchy(handlerCtx handler.HandlerContext, hIDs internaldto.HeirarchyIdentifiers) error {
+	policy := handlerCtx.GetAuthorisationPolicy()
+	if policy == nil {
+		return nil
+	}
+	return authoriseHeirarchyWithPolicy(handlerCtx, policy, hIDs, 0)
+}
+
+func authoriseHeirarchyWithPolicy(
+	handlerCtx handler.HandlerContext,
+	policy srvauth.Policy,
+	hIDs internaldto.HeirarchyIdentifiers,
+	depth int,
+) error {
+	if hIDs.IsPhysicalTable() || hIDs.IsMaterializedView() {
+		return policy.AuthoriseTable(hIDs.GetTableName())
+	}
+	if viewDTO, isView := hIDs.GetView(); isView {
+		return authoriseView(handlerCtx, policy, hIDs, viewDTO, depth)
+	}
+	if hIDs.IsPgInternalObject() {
+		return nil
+	}
+	if hIDs.ContainsNativeDBMSTable() {
+		return policy.AuthoriseTable(hIDs.GetTableName())
+	}
+	return policy.AuthoriseResource(hIDs.GetProviderStr(), hIDs.GetServiceStr(), hIDs.GetResourceStr())
+}
+
+// authoriseView authorises each table of the view body.  Views
+// defined by providers are named for, and select from, their resource.
+func authoriseView(
+	handlerCtx handler.HandlerContext,
+	policy srvauth.Policy,
+	hIDs internaldto.HeirarchyIdentifiers,
+	viewDTO internaldto.RelationDTO,
+	depth int,
+) error {
+	if hIDs.GetProviderStr() != "" {
+		if err := policy.AuthoriseResource(hIDs.GetProviderStr(), hIDs.GetServiceStr(), hIDs.GetResourceStr()); err != nil {
+			return err
+		}
+	}
+	if depth >= maxAuthorisedViewDepth {
+		return fmt.Errorf("cannot authorise view '%s': views nested too deeply", hIDs.GetTableName())
+	}
+	stmt, err := sqlparser.Parse(viewDTO.GetRawQuery())
+	if err != nil {
+		return fmt.Errorf("cannot authorise view '%s': %w", hIDs.GetTableName(), err)
+	}
+	return sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		tableExpr, isTableExpr := node.(*sqlparser.AliasedTableExpr)
+		if !isTableExpr {
+			return true, nil
+		}
+		tableName, isTableName := tableExpr.Expr.(sqlparser.TableName)
+		if !isTableName {
+			return true, nil
+		}
+		tableHIDs, hidsErr := GetHIDs(handlerCtx, tableName, parserutil.NewParameterMap(), true)
+		if hidsErr != nil {
+			return false, fmt.Errorf("cannot authorise view '%s': %w", hIDs.GetTableName(), hidsErr)
+		}
+		if tableHIDs.GetTableName() == hIDs.GetTableName() {
+			return false, nil
+		}
+		return false, authoriseHeirarchyWithPolicy(handlerCtx, policy, tableHIDs, depth+1)
+	}, stmt)
+}
+
 func GetAliasFromStatement(node sqlparser.SQLNode) string {
 	switch n := node.(type) {
 	case *sqlparser.AliasedTableExpr:
@@ -197,6 +277,9 @@ func GetHeirarchyFromStatement(
 	default:
 		return nil, fmt.Errorf("cannot resolve taxonomy")
 	}
+	if err = authoriseHeirarchy(handlerCtx, hIDs); err != nil {
+		return nil, err
+	}
 	retVal := tablemetadata.NewHeirarchyObjects(hIDs)
 	sqlDataSource, isSQLDataSource := handlerCtx.GetSQLDataSource(hIDs.GetProviderStr())
 	if isSQLDataSource {
@@ -0,0 +1,136 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName  string = "github.com/stackql/stackql"
+	serviceName string = "stackql"
+
+	AttrNodeID        attribute.Key = "stackql.primitive.node_id"
+	AttrPrimitive     attribute.Key = "stackql.primitive.builder"
+	AttrProvider      attribute.Key = "stackql.provider"
+	AttrQuery         attribute.Key = "db.statement"
+	AttrHTTPMethod    attribute.Key = "http.request.method"
+	AttrHTTPURL       attribute.Key = "url.full"
+	AttrHTTPStatus    attribute.Key = "http.response.status_code"
+	AttrStatementType attribute.Key = "stackql.statement_type"
+)
+
+// Until Init is called, the global tracer provider is a
+// no-op, so that spans cost next to nothing and
+// no trace context is propagated.
+//
+//nolint:gochecknoglobals // process wide, per flags
+var shutdown = func(context.Context) error { return nil }
+
+// Init exports spans in batches over OTLP / HTTP to the collector
+// at the supplied base endpoint URL, eg: 'http://localhost:4318'.
+func Init(endpointURL string) error {
+	exporter, err := otlptracehttp.New(
+		context.Background(),
+		otlptracehttp.WithEndpointURL(fmt.Sprintf("%s/v1/traces", strings.TrimSuffix(endpointURL, "/"))),
+	)
+	if err != nil {
+		return err
+	}
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewSchemaless(attribute.String("service.name", serviceName)),
+	)
+	if err != nil {
+		return err
+	}
+	InitWithProvider(sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	))
+	return nil
+}
+
+// InitWithProvider is exposed for testing.
+func InitWithProvider(tp *sdktrace.TracerProvider) {
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	shutdown = tp.Shutdown
+}
+
+// Shutdown flushes any buffered spans.
+func Shutdown(ctx context.Context) error {
+	return shutdown(ctx)
+}
+
+func Start(ctx context.Context, spanName string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return otel.Tracer(tracerName).Start(ctx, spanName, trace.WithAttributes(attrs...))
+}
+
+// End records any error against the span.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// GetTracedHTTPClient spans each round trip and propagates
+// the trace context as 'traceparent' on the outbound request.
+func GetTracedHTTPClient(client *http.Client) *http.Client {
+	if client == nil {
+		return nil
+	}
+	inner := client.Transport
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+	rv := *client
+	rv.Transport = &tracedRoundTripper{
+		inner: inner,
+	}
+	return &rv
+}
+
+type tracedRoundTripper struct {
+	inner http.RoundTripper
+}
+
+func (rt *tracedRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	ctx, span := otel.Tracer(tracerName).Start(
+		request.Context(),
+		fmt.Sprintf("HTTP %s", request.Method),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			AttrHTTPMethod.String(request.Method),
+			// The query string may carry credentials.
+			AttrHTTPURL.String(fmt.Sprintf("%s://%s%s", request.URL.Scheme, request.URL.Host, request.URL.Path)),
+		),
+	)
+	// Per the RoundTripper contract, the request is cloned prior to mutation.
+	request = request.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(request.Header))
+	response, err := rt.inner.RoundTrip(request)
+	if response != nil {
+		span.SetAttributes(AttrHTTPStatus.Int(response.StatusCode))
+		if response.StatusCode >= http.StatusBadRequest {
+			span.SetStatus(codes.Error, response.Status)
+		}
+	}
+	End(span, err)
+	return response, err
+}
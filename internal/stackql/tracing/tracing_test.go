@@ -0,0 +1,64 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	. "github.com/stackql/stackql/internal/stackql/tracing"
+)
+
+func TestTracedHTTPClientPropagatesTraceparent(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	InitWithProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	defer Shutdown(context.Background()) //nolint:errcheck // test
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	ctx, rootSpan := Start(context.Background(), "statement")
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/items?key=secret", nil)
+	response, err := GetTracedHTTPClient(&http.Client{}).Do(request)
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+	response.Body.Close()
+	if request.Header.Get("traceparent") != "" {
+		t.Fatalf("test failed: caller's request mutated")
+	}
+	End(rootSpan, errors.New("statement failed"))
+
+	traceID := rootSpan.SpanContext().TraceID().String()
+	if !strings.Contains(traceparent, traceID) {
+		t.Fatalf("test failed: expected traceparent bearing trace '%s', got '%s'", traceID, traceparent)
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("test failed: expected 2 spans, got %d", len(spans))
+	}
+	httpSpan, stmtSpan := spans[0], spans[1]
+	if httpSpan.Parent.SpanID() != stmtSpan.SpanContext.SpanID() {
+		t.Fatalf("test failed: http span not child of statement span")
+	}
+	if !strings.Contains(traceparent, httpSpan.SpanContext.SpanID().String()) {
+		t.Fatalf("test failed: traceparent does not reference http span")
+	}
+	for _, attr := range httpSpan.Attributes {
+		if attr.Key == AttrHTTPURL && strings.Contains(attr.Value.AsString(), "secret") {
+			t.Fatalf("test failed: query string leaked into span")
+		}
+	}
+	if httpSpan.Status.Code.String() != "Error" || stmtSpan.Status.Description != "statement failed" {
+		t.Fatalf("test failed: unexpected span statuses %v, %v", httpSpan.Status, stmtSpan.Status)
+	}
+}
@@ -229,6 +229,15 @@ func extractRaw(raw interface{}, ignoreTuples bool) (interface{}, error) {
 	}
 }
 
+func ArrangeOrderedColumnRow(
+	row map[string]interface{},
+	columns []sqldata.ISQLColumn,
+	columnOrder []string,
+	colNumber int,
+) []interface{} {
+	return arrangeOrderedColumnRow(row, columns, columnOrder, colNumber)
+}
+
 func arrangeOrderedColumnRow(
 	row map[string]interface{},
 	columns []sqldata.ISQLColumn, //nolint:unparam,revive // TODO: review
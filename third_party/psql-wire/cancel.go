@@ -0,0 +1,154 @@
+package wire
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"sync"
+
+	"github.com/stackql/psql-wire/internal/buffer"
+	"github.com/stackql/psql-wire/internal/types"
+	"go.uber.org/zap"
+)
+
+// BackendKey identifies a connection to a subsequent CancelRequest,
+// as announced to the client through BackendKeyData.
+type BackendKey struct {
+	ProcessID int32
+	SecretKey int32
+}
+
+// backendCanceller holds the cancel func of the command
+// in flight upon a connection, if any.
+type backendCanceller struct {
+	mutex  sync.Mutex
+	key    BackendKey
+	cancel context.CancelFunc
+}
+
+func (bc *backendCanceller) set(cancel context.CancelFunc) {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	bc.cancel = cancel
+}
+
+func (bc *backendCanceller) clear() {
+	bc.set(nil)
+}
+
+// cancelCommand cancels the command in flight;
+// an idle connection is unaffected, as per postgres.
+func (bc *backendCanceller) cancelCommand() {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	if bc.cancel != nil {
+		bc.cancel()
+	}
+}
+
+// backendCancellers is the registry of live connections, keyed on process ID.
+type backendCancellers struct {
+	mutex      sync.Mutex
+	cancellers map[int32]*backendCanceller
+}
+
+func (bcs *backendCancellers) register() (*backendCanceller, error) {
+	bcs.mutex.Lock()
+	defer bcs.mutex.Unlock()
+	if bcs.cancellers == nil {
+		bcs.cancellers = make(map[int32]*backendCanceller)
+	}
+	for {
+		key, err := newBackendKey()
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := bcs.cancellers[key.ProcessID]; exists || key.ProcessID == 0 {
+			continue
+		}
+		rv := &backendCanceller{key: key}
+		bcs.cancellers[key.ProcessID] = rv
+		return rv, nil
+	}
+}
+
+func (bcs *backendCancellers) deregister(bc *backendCanceller) {
+	bcs.mutex.Lock()
+	defer bcs.mutex.Unlock()
+	delete(bcs.cancellers, bc.key.ProcessID)
+}
+
+// cancel returns false where the key matches no live connection.
+// The secret is compared in constant time, since it is all that
+// prevents one client from cancelling another's commands.
+func (bcs *backendCancellers) cancel(key BackendKey) bool {
+	bcs.mutex.Lock()
+	bc, exists := bcs.cancellers[key.ProcessID]
+	bcs.mutex.Unlock()
+	if !exists {
+		return false
+	}
+	if subtle.ConstantTimeEq(bc.key.SecretKey, key.SecretKey) != 1 {
+		return false
+	}
+	bc.cancelCommand()
+	return true
+}
+
+func newBackendKey() (BackendKey, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return BackendKey{}, err
+	}
+	return BackendKey{
+		ProcessID: int32(binary.BigEndian.Uint32(b[:4]) &^ (1 << 31)),
+		SecretKey: int32(binary.BigEndian.Uint32(b[4:])),
+	}, nil
+}
+
+func setBackendCanceller(ctx context.Context, bc *backendCanceller) context.Context {
+	return context.WithValue(ctx, ctxBackendCanceller, bc)
+}
+
+func backendCancellerFrom(ctx context.Context) (*backendCanceller, bool) {
+	bc, ok := ctx.Value(ctxBackendCanceller).(*backendCanceller)
+	return bc, ok
+}
+
+// ConnectionBackendKey returns the key announced to the
+// client of the given connection context, if any.
+func ConnectionBackendKey(ctx context.Context) (BackendKey, bool) {
+	bc, ok := backendCancellerFrom(ctx)
+	if !ok {
+		return BackendKey{}, false
+	}
+	return bc.key, true
+}
+
+// writeBackendKeyData announces the key with which
+// the client may cancel commands upon this connection.
+func writeBackendKeyData(writer buffer.Writer, key BackendKey) error {
+	writer.Start(types.ServerBackendKeyData)
+	writer.AddInt32(key.ProcessID)
+	writer.AddInt32(key.SecretKey)
+	return writer.End()
+}
+
+// handleCancelRequest reads the key of a CancelRequest, whose
+// connection carries no other messages and receives no response.
+func (srv *Server) handleCancelRequest(reader buffer.Reader) error {
+	processID, err := reader.GetUint32()
+	if err != nil {
+		return err
+	}
+	secretKey, err := reader.GetUint32()
+	if err != nil {
+		return err
+	}
+	key := BackendKey{ProcessID: int32(processID), SecretKey: int32(secretKey)}
+	if !srv.cancellers.cancel(key) {
+		srv.logger.Debug("ignoring cancel request for unknown backend", zap.Int32("pid", key.ProcessID))
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package wire
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+func TestCancelRequest(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	handler := func(ctx context.Context, query string, writer DataWriter) error {
+		close(started)
+		select {
+		case <-ctx.Done():
+			close(cancelled)
+			return errors.New("canceling statement due to user request")
+		case <-time.After(10 * time.Second):
+			return writer.Complete("OK")
+		}
+	}
+
+	server, err := NewServer(SimpleQuery(handler))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address := TListenAndServe(t, server)
+
+	ctx := context.Background()
+	connstr := fmt.Sprintf("postgres://%s:%d?sslmode=disable", address.IP, address.Port)
+	conn, err := pgx.Connect(ctx, connstr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(ctx)
+
+	if conn.PgConn().PID() == 0 {
+		t.Fatal("no backend key data received")
+	}
+
+	queryErr := make(chan error, 1)
+	go func() {
+		_, err := conn.PgConn().Exec(ctx, "SELECT pg_sleep(10)").ReadAll()
+		queryErr <- err
+	}()
+
+	<-started
+	err = conn.PgConn().CancelRequest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("command was not cancelled")
+	}
+
+	if err := <-queryErr; err == nil {
+		t.Fatal("expected an error from the cancelled command")
+	}
+}
+
+func TestCancelRequestSecretMismatch(t *testing.T) {
+	t.Parallel()
+
+	var cancellers backendCancellers
+	canceller, err := cancellers.register()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var isCancelled bool
+	canceller.set(func() { isCancelled = true })
+
+	key := canceller.key
+	key.SecretKey++
+	if cancellers.cancel(key) || isCancelled {
+		t.Fatal("command cancelled despite secret mismatch")
+	}
+
+	if !cancellers.cancel(canceller.key) || !isCancelled {
+		t.Fatal("command not cancelled")
+	}
+
+	cancellers.deregister(canceller)
+	if cancellers.cancel(canceller.key) {
+		t.Fatal("deregistered connection cancelled")
+	}
+}
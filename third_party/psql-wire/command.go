@@ -0,0 +1,292 @@
+package wire
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lib/pq/oid"
+	"github.com/stackql/psql-wire/codes"
+	psqlerr "github.com/stackql/psql-wire/errors"
+	"github.com/stackql/psql-wire/internal/buffer"
+	"github.com/stackql/psql-wire/internal/types"
+	"github.com/stackql/psql-wire/pkg/sqldata"
+	"go.uber.org/zap"
+)
+
+// NewErrUnimplementedMessageType is called whenever a unimplemented message
+// type is send. This error indicates to the client that the send message cannot
+// be processed at this moment in time.
+func NewErrUnimplementedMessageType(t types.ClientMessage) error {
+	err := fmt.Errorf("unimplemented client message type: %d", t)
+	return psqlerr.WithSeverity(psqlerr.WithCode(err, codes.ConnectionDoesNotExist), psqlerr.LevelFatal)
+}
+
+type SimpleQueryFn func(ctx context.Context, query string, writer DataWriter) error
+
+type CloseFn func(ctx context.Context) error
+
+// consumeCommands consumes incoming commands send over the Postgres wire connection.
+// Commands consumed from the connection are returned through a go channel.
+// Responses for the given message type are written back to the client.
+// This method keeps consuming messages until the client issues a close message
+// or the connection is terminated.
+func (srv *Server) consumeCommands(ctx context.Context, conn SQLConnection) (err error) {
+	srv.logger.Debug("ready for query... starting to consume commands")
+
+	// TODO(Jeroen): include a indentification value inside the context that
+	// could be used to identify connections at a later stage.
+
+	eq := newExtendedQuery()
+	defer eq.close()
+
+	isReady := true
+	for {
+		// NOTE: messages of the extended query protocol
+		// are answered by ReadyForQuery only upon Sync.
+		if isReady {
+			err = readyForQuery(conn, types.ServerIdle)
+			if err != nil {
+				return err
+			}
+		}
+
+		t, length, err := conn.ReadTypedMsg()
+		if err == io.EOF {
+			return srv.handleConnClose(ctx)
+		}
+
+		// NOTE(Jeroen): we could recover from this scenario
+		if errors.Is(err, buffer.ErrMessageSizeExceeded) {
+			err = srv.handleMessageSizeExceeded(conn, conn, err)
+			if err != nil {
+				return err
+			}
+
+			isReady = true
+			continue
+		}
+
+		srv.logger.Debug("incoming command", zap.Int("length", length), zap.String("type", string(t)))
+
+		if err != nil {
+			return err
+		}
+
+		isReady = !isExtendedQueryMessage(t)
+		if eq.isFailed && isExtendedQueryMessage(t) {
+			continue
+		}
+
+		err = srv.handleCommand(ctx, conn, eq, t)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// handleMessageSizeExceeded attempts to unwrap the given error message as
+// message size exceeded. The expected message size will be consumed and
+// discarded from the given reader. An error message is written to the client
+// once the expected message size is read.
+//
+// The given error is returned if it does not contain an message size exceeded
+// type. A fatal error is returned when an unexpected error is returned while
+// consuming the expected message size or when attempting to write the error
+// message back to the client.
+func (srv *Server) handleMessageSizeExceeded(reader buffer.Reader, writer buffer.Writer, exceeded error) (err error) {
+	unwrapped, has := buffer.UnwrapMessageSizeExceeded(exceeded)
+	if !has {
+		return exceeded
+	}
+
+	err = reader.Slurp(unwrapped.Size)
+	if err != nil {
+		return err
+	}
+
+	return ErrorCode(writer, exceeded)
+}
+
+// handleCommand handles the given client message. A client message includes a
+// message type and reader buffer containing the actual message. The type
+// indecates a action executed by the client.
+func (srv *Server) handleCommand(connCtx context.Context, conn SQLConnection, eq *extendedQuery, t types.ClientMessage) (err error) {
+	ctx, cancel := context.WithCancel(connCtx)
+	defer cancel()
+
+	// NOTE: the command may be cancelled by a CancelRequest
+	// upon another connection, keyed on BackendKeyData.
+	if canceller, ok := backendCancellerFrom(ctx); ok {
+		canceller.set(cancel)
+		defer canceller.clear()
+	}
+
+	switch t {
+	case types.ClientSync:
+		eq.sync()
+	case types.ClientSimpleQuery:
+		// TODO: make this a function of connection
+		return srv.handleSimpleQuery(ctx, conn)
+	case types.ClientParse, types.ClientBind, types.ClientDescribe, types.ClientExecute, types.ClientClose:
+		return srv.handleExtendedQuery(connCtx, ctx, conn, eq, t)
+	case types.ClientFlush:
+		// NOTE: responses are written unbuffered, and so are already flushed.
+	case types.ClientCopyData, types.ClientCopyDone, types.ClientCopyFail:
+		// We're supposed to ignore these messages, per the protocol spec. This
+		// state will happen when an error occurs on the server-side during a copy
+		// operation: the server will send an error and a ready message back to
+		// the client, and must then ignore further copy messages. See:
+		// https://github.com/postgres/postgres/blob/6e1dd2773eb60a6ab87b27b8d9391b756e904ac3/src/backend/tcop/postgres.c#L4295
+		break
+	case types.ClientTerminate:
+		err = srv.handleConnTerminate(ctx)
+		if err != nil {
+			return err
+		}
+
+		return conn.Close()
+	default:
+		return ErrorCode(conn, NewErrUnimplementedMessageType(t))
+	}
+
+	return nil
+}
+
+func (srv *Server) handleSimpleQuery(ctx context.Context, cn SQLConnection) error {
+	if srv.SimpleQuery == nil && srv.SQLBackendFactory == nil {
+		return ErrorCode(cn, NewErrUnimplementedMessageType(types.ClientSimpleQuery))
+	}
+
+	query, err := cn.GetString()
+	if err != nil {
+		return err
+	}
+
+	srv.logger.Debug("incoming query", zap.String("query", query))
+
+	if cn.HasSQLBackend() {
+		qArr, err := cn.SplitCompoundQuery(query)
+		if err != nil {
+			return err
+		}
+		var isAnswered bool
+		for _, q := range qArr {
+			// NOTE: empty statements, such as that following
+			// a final semicolon, yield no result.
+			if strings.TrimSpace(q) == "" {
+				continue
+			}
+			isAnswered = true
+			rdr, err := cn.HandleSimpleQuery(ctx, q)
+			if err != nil {
+				return ErrorCode(cn, err)
+			}
+			// NOTE: per the protocol, an error
+			// abandons any remaining queries.
+			if err = srv.streamSQLResult(ctx, cn, rdr); err != nil {
+				return ErrorCode(cn, err)
+			}
+		}
+		if !isAnswered {
+			return emptyQuery(cn)
+		}
+		return nil
+	}
+
+	err = srv.SimpleQuery(ctx, query, &dataWriter{
+		ctx:    ctx,
+		client: cn,
+	})
+
+	if err != nil {
+		return ErrorCode(cn, err)
+	}
+
+	return nil
+}
+
+// streamSQLResult writes each chunk of the result as it is read from the
+// backend, the columns being defined upon the first.  Upon error, the
+// stream is closed, so that the backend may release any unread rows.
+func (srv *Server) streamSQLResult(ctx context.Context, cn SQLConnection, rdr sqldata.ISQLResultStream) (err error) {
+	dw := &dataWriter{
+		ctx:    ctx,
+		client: cn,
+	}
+	if rdr == nil {
+		return dw.Complete("OK")
+	}
+	defer func() {
+		if err != nil {
+			rdr.Close()
+		}
+	}()
+	var headersWritten bool
+	for {
+		res, readErr := rdr.Read()
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return readErr
+		}
+		if res != nil {
+			if !headersWritten {
+				headersWritten = true
+				if err = srv.writeSQLResultHeader(ctx, res, dw); err != nil {
+					return err
+				}
+			}
+			if err = srv.writeSQLResultRows(ctx, res, dw); err != nil {
+				return err
+			}
+		}
+		if readErr != nil {
+			return dw.Complete("OK")
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+func (srv *Server) writeSQLResultRows(ctx context.Context, res sqldata.ISQLResult, writer DataWriter) error {
+	for _, r := range res.GetRows() {
+		if err := writer.Row(r.GetRowDataForPgWire()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (srv *Server) writeSQLResultHeader(ctx context.Context, res sqldata.ISQLResult, writer DataWriter) error {
+	var colz Columns
+	for _, c := range res.GetColumns() {
+		colz = append(colz,
+			Column{
+				Table:  c.GetTableId(),
+				Name:   c.GetName(),
+				Oid:    oid.Oid(c.GetObjectID()),
+				Width:  c.GetWidth(),
+				Format: TextFormat,
+			},
+		)
+	}
+	return writer.Define(colz)
+}
+
+func (srv *Server) handleConnClose(ctx context.Context) error {
+	if srv.CloseConn == nil {
+		return nil
+	}
+
+	return srv.CloseConn(ctx)
+}
+
+func (srv *Server) handleConnTerminate(ctx context.Context) error {
+	if srv.TerminateConn == nil {
+		return nil
+	}
+
+	return srv.TerminateConn(ctx)
+}
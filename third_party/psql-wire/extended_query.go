@@ -0,0 +1,662 @@
+package wire
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq/oid"
+	"github.com/stackql/psql-wire/codes"
+	psqlerr "github.com/stackql/psql-wire/errors"
+	"github.com/stackql/psql-wire/internal/buffer"
+	"github.com/stackql/psql-wire/internal/types"
+	"github.com/stackql/psql-wire/pkg/sqlbackend"
+	"github.com/stackql/psql-wire/pkg/sqldata"
+)
+
+// statement records the parameter types declared by a Parse message;
+// unspecified types are zero.
+type statement struct {
+	parameterOIDs []oid.Oid
+}
+
+// portal is a statement bound by a Bind message.  The statement is
+// described from its plan and executed only upon Execute, save
+// that a read only statement whose result is known only upon
+// execution is executed upon Describe.  The result is held until
+// read through, so that Execute may resume a portal suspended
+// upon reaching its row limit.
+type portal struct {
+	statementName string
+	args          []interface{}
+	resultFormats []FormatCode
+	// isDescribed signals that the columns are those of the
+	// description, and so rows of a portal described by
+	// NoData are discarded.
+	isDescribed bool
+	isStarted   bool
+	cancel      context.CancelFunc
+	stream      sqldata.ISQLResultStream
+	columns     Columns
+	pending     []sqldata.ISQLRow
+	isEOF       bool
+}
+
+// extendedQuery is the extended query protocol state of a connection.
+type extendedQuery struct {
+	statements map[string]*statement
+	portals    map[string]*portal
+	// isFailed signals that messages are discarded
+	// until the next Sync, as per the protocol.
+	isFailed bool
+}
+
+func newExtendedQuery() *extendedQuery {
+	return &extendedQuery{
+		statements: make(map[string]*statement),
+		portals:    make(map[string]*portal),
+	}
+}
+
+// isExtendedQueryMessage is true of those messages
+// which are not followed by ReadyForQuery.
+func isExtendedQueryMessage(t types.ClientMessage) bool {
+	switch t {
+	case types.ClientParse, types.ClientBind, types.ClientDescribe,
+		types.ClientExecute, types.ClientClose, types.ClientFlush:
+		return true
+	default:
+		return false
+	}
+}
+
+// sync concludes the implicit transaction, which
+// destroys the unnamed portal, and ends any failure.
+func (eq *extendedQuery) sync() {
+	eq.closePortal("")
+	eq.isFailed = false
+}
+
+func (eq *extendedQuery) closePortal(name string) {
+	if p, ok := eq.portals[name]; ok {
+		p.close()
+		delete(eq.portals, name)
+	}
+}
+
+func (eq *extendedQuery) close() {
+	for name := range eq.portals {
+		eq.closePortal(name)
+	}
+}
+
+func (p *portal) close() {
+	if p.stream != nil && !p.isEOF {
+		p.stream.Close()
+	}
+	if p.cancel != nil {
+		p.cancel()
+	}
+}
+
+// handleExtendedQuery dispatches a message of the extended query protocol.
+// Upon error, the error is written and subsequent messages are
+// discarded until Sync.  The portal context derives from the connection
+// context, whereas the message context is that cancelled by CancelRequest.
+func (srv *Server) handleExtendedQuery(
+	connCtx context.Context,
+	msgCtx context.Context,
+	conn SQLConnection,
+	eq *extendedQuery,
+	t types.ClientMessage,
+) error {
+	eqb, ok := conn.ExtendedQueryBackend()
+	if !ok {
+		return ErrorCode(conn, NewErrUnimplementedMessageType(t))
+	}
+	var err error
+	switch t {
+	case types.ClientParse:
+		err = srv.handleParse(msgCtx, conn, eqb, eq)
+	case types.ClientBind:
+		err = srv.handleBind(conn, eq)
+	case types.ClientDescribe:
+		err = srv.handleDescribe(connCtx, msgCtx, conn, eqb, eq)
+	case types.ClientExecute:
+		err = srv.handleExecute(connCtx, msgCtx, conn, eqb, eq)
+	case types.ClientClose:
+		err = srv.handleClose(msgCtx, conn, eqb, eq)
+	}
+	if err == nil {
+		return nil
+	}
+	eq.isFailed = true
+	var connErr *connectionError
+	if errors.As(err, &connErr) {
+		return connErr.err
+	}
+	return ErrorCode(conn, err)
+}
+
+// connectionError is a failure to write to the client,
+// upon which the connection is abandoned.
+type connectionError struct {
+	err error
+}
+
+func (e *connectionError) Error() string { return e.err.Error() }
+
+func (e *connectionError) Unwrap() error { return e.err }
+
+func writeErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &connectionError{err: err}
+}
+
+func (srv *Server) handleParse(
+	ctx context.Context,
+	conn SQLConnection,
+	eqb sqlbackend.IExtendedQueryBackend,
+	eq *extendedQuery,
+) error {
+	name, err := conn.GetString()
+	if err != nil {
+		return err
+	}
+	name = cloneString(name)
+	query, err := conn.GetString()
+	if err != nil {
+		return err
+	}
+	query = cloneString(query)
+	declaredCount, err := conn.GetUint16()
+	if err != nil {
+		return err
+	}
+	declaredOIDs := make([]oid.Oid, declaredCount)
+	for i := range declaredOIDs {
+		v, err := conn.GetUint32()
+		if err != nil {
+			return err
+		}
+		declaredOIDs[i] = oid.Oid(v)
+	}
+	parameterCount, err := eqb.HandleParse(ctx, name, query)
+	if err != nil {
+		return err
+	}
+	if parameterCount < len(declaredOIDs) {
+		parameterCount = len(declaredOIDs)
+	}
+	stmt := &statement{parameterOIDs: make([]oid.Oid, parameterCount)}
+	copy(stmt.parameterOIDs, declaredOIDs)
+	eq.statements[name] = stmt
+	conn.Start(types.ServerParseComplete)
+	return writeErr(conn.End())
+}
+
+func (srv *Server) handleBind(conn SQLConnection, eq *extendedQuery) error {
+	portalName, err := conn.GetString()
+	if err != nil {
+		return err
+	}
+	portalName = cloneString(portalName)
+	statementName, err := conn.GetString()
+	if err != nil {
+		return err
+	}
+	statementName = cloneString(statementName)
+	parameterFormats, err := readFormatCodes(conn)
+	if err != nil {
+		return err
+	}
+	parameterCount, err := conn.GetUint16()
+	if err != nil {
+		return err
+	}
+	var parameterOIDs []oid.Oid
+	// NOTE: statements prepared by PREPARE are unknown to the
+	// wire server, and so their parameters are of unspecified type.
+	if stmt, ok := eq.statements[statementName]; ok {
+		parameterOIDs = stmt.parameterOIDs
+	}
+	args := make([]interface{}, parameterCount)
+	for i := range args {
+		format, err := formatCodeAt(parameterFormats, i, int(parameterCount))
+		if err != nil {
+			return err
+		}
+		var paramOID oid.Oid
+		if i < len(parameterOIDs) {
+			paramOID = parameterOIDs[i]
+		}
+		size, err := conn.GetUint32()
+		if err != nil {
+			return err
+		}
+		if int32(size) < 0 {
+			continue
+		}
+		raw, err := conn.GetBytes(int(size))
+		if err != nil {
+			return err
+		}
+		if args[i], err = decodeParameter(paramOID, format, raw); err != nil {
+			return err
+		}
+	}
+	resultFormats, err := readFormatCodes(conn)
+	if err != nil {
+		return err
+	}
+	if _, exists := eq.portals[portalName]; exists && portalName != "" {
+		return psqlerr.WithCode(fmt.Errorf("portal \"%s\" already exists", portalName), codes.DuplicateCursor)
+	}
+	eq.closePortal(portalName)
+	eq.portals[portalName] = &portal{
+		statementName: statementName,
+		args:          args,
+		resultFormats: resultFormats,
+	}
+	conn.Start(types.ServerBindComplete)
+	return writeErr(conn.End())
+}
+
+// handleDescribe describes a statement or portal from its plan, such
+// that the RowDescription precedes Execute, as clients expect.  A
+// statement whose result is unknown before it is bound is described
+// by NoData.
+func (srv *Server) handleDescribe(
+	connCtx context.Context,
+	msgCtx context.Context,
+	conn SQLConnection,
+	eqb sqlbackend.IExtendedQueryBackend,
+	eq *extendedQuery,
+) error {
+	describeType, err := conn.GetPrepareType()
+	if err != nil {
+		return err
+	}
+	name, err := conn.GetString()
+	if err != nil {
+		return err
+	}
+	switch describeType {
+	case buffer.PrepareStatement:
+		stmt, ok := eq.statements[name]
+		if !ok {
+			return psqlerr.WithCode(
+				fmt.Errorf("prepared statement \"%s\" does not exist", name), codes.InvalidSQLStatementName)
+		}
+		columns, err := eqb.HandleDescribeStatement(msgCtx, name)
+		if err != nil {
+			return err
+		}
+		conn.Start(types.ServerParameterDescription)
+		conn.AddInt16(int16(len(stmt.parameterOIDs)))
+		for _, paramOID := range stmt.parameterOIDs {
+			if paramOID == 0 {
+				paramOID = oid.T_text
+			}
+			conn.AddInt32(int32(paramOID))
+		}
+		if err = conn.End(); err != nil {
+			return writeErr(err)
+		}
+		// NOTE: result formats are unknown until bound, and so are text.
+		described, err := describedColumns(columns, nil)
+		if err != nil {
+			return err
+		}
+		if described == nil {
+			return writeErr(noData(conn))
+		}
+		return writeErr(described.Define(msgCtx, conn))
+	case buffer.PreparePortal:
+		p, err := getPortal(eq, name)
+		if err != nil {
+			return err
+		}
+		if err = srv.describePortal(connCtx, msgCtx, eqb, p); err != nil {
+			return err
+		}
+		if p.columns == nil {
+			return writeErr(noData(conn))
+		}
+		return writeErr(p.columns.Define(msgCtx, conn))
+	default:
+		return psqlerr.WithCode(
+			fmt.Errorf("invalid describe message subtype %d", describeType), codes.ProtocolViolation)
+	}
+}
+
+// describePortal describes the portal, once only, from the plan
+// of its bound statement, or else by executing its read only statement.
+func (srv *Server) describePortal(
+	connCtx context.Context,
+	msgCtx context.Context,
+	eqb sqlbackend.IExtendedQueryBackend,
+	p *portal,
+) error {
+	if p.isDescribed || p.isStarted {
+		return nil
+	}
+	columns, isKnown, err := eqb.HandleDescribePortal(msgCtx, p.statementName, p.args)
+	if err != nil {
+		return err
+	}
+	if !isKnown {
+		return srv.startPortal(connCtx, msgCtx, eqb, p)
+	}
+	p.isDescribed = true
+	p.columns, err = describedColumns(columns, p.resultFormats)
+	return err
+}
+
+// handleExecute writes the rows of the portal, up to the row limit
+// if non zero, whereupon the portal is suspended.
+func (srv *Server) handleExecute(
+	connCtx context.Context,
+	msgCtx context.Context,
+	conn SQLConnection,
+	eqb sqlbackend.IExtendedQueryBackend,
+	eq *extendedQuery,
+) error {
+	name, err := conn.GetString()
+	if err != nil {
+		return err
+	}
+	maxRows, err := conn.GetUint32()
+	if err != nil {
+		return err
+	}
+	p, err := getPortal(eq, name)
+	if err != nil {
+		return err
+	}
+	if err = srv.startPortal(connCtx, msgCtx, eqb, p); err != nil {
+		return err
+	}
+	stop := cancelPortalUpon(msgCtx, p)
+	defer stop()
+	var rowCount uint32
+	for {
+		for len(p.pending) > 0 {
+			if maxRows > 0 && rowCount == maxRows {
+				conn.Start(types.ServerPortalSuspended)
+				return writeErr(conn.End())
+			}
+			row := p.pending[0]
+			p.pending = p.pending[1:]
+			if err = p.columns.Write(msgCtx, conn, row.GetRowDataForPgWire()); err != nil {
+				return err
+			}
+			rowCount++
+		}
+		if p.isEOF {
+			break
+		}
+		if err = msgCtx.Err(); err != nil {
+			return err
+		}
+		if err = p.read(); err != nil {
+			return err
+		}
+	}
+	p.close()
+	return writeErr(commandComplete(conn, "OK"))
+}
+
+func (srv *Server) handleClose(
+	ctx context.Context,
+	conn SQLConnection,
+	eqb sqlbackend.IExtendedQueryBackend,
+	eq *extendedQuery,
+) error {
+	closeType, err := conn.GetPrepareType()
+	if err != nil {
+		return err
+	}
+	name, err := conn.GetString()
+	if err != nil {
+		return err
+	}
+	switch closeType {
+	case buffer.PrepareStatement:
+		if err = eqb.HandleCloseStatement(ctx, name); err != nil {
+			return err
+		}
+		delete(eq.statements, name)
+	case buffer.PreparePortal:
+		eq.closePortal(name)
+	default:
+		return psqlerr.WithCode(
+			fmt.Errorf("invalid close message subtype %d", closeType), codes.ProtocolViolation)
+	}
+	conn.Start(types.ServerCloseComplete)
+	return writeErr(conn.End())
+}
+
+func getPortal(eq *extendedQuery, name string) (*portal, error) {
+	p, ok := eq.portals[name]
+	if !ok {
+		return nil, psqlerr.WithCode(fmt.Errorf("portal \"%s\" does not exist", name), codes.InvalidCursorName)
+	}
+	return p, nil
+}
+
+// startPortal executes the portal's statement, once only, and reads
+// the result up to its columns.  The result outlives the message, and
+// so is read under a context derived from that of the connection.
+func (srv *Server) startPortal(
+	connCtx context.Context,
+	msgCtx context.Context,
+	eqb sqlbackend.IExtendedQueryBackend,
+	p *portal,
+) error {
+	if p.isStarted {
+		return nil
+	}
+	p.isStarted = true
+	var portalCtx context.Context
+	portalCtx, p.cancel = context.WithCancel(connCtx)
+	stop := cancelPortalUpon(msgCtx, p)
+	defer stop()
+	stream, err := eqb.HandleExecutePrepared(portalCtx, p.statementName, p.args)
+	if err != nil {
+		p.isEOF = true
+		return err
+	}
+	p.stream = stream
+	if stream == nil {
+		p.isEOF = true
+		return nil
+	}
+	for p.columns == nil && !p.isDescribed && !p.isEOF {
+		if err = p.read(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cancelPortalUpon relays the cancellation of the message, by
+// CancelRequest, to the portal, until the returned func is called.
+func cancelPortalUpon(msgCtx context.Context, p *portal) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-msgCtx.Done():
+			select {
+			case <-done:
+			default:
+				p.cancel()
+			}
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+// read reads the next chunk of the result; rows without columns,
+// as of a statement without result set or one described by
+// NoData, are discarded.
+func (p *portal) read() error {
+	res, err := p.stream.Read()
+	if err != nil && !errors.Is(err, io.EOF) {
+		p.isEOF = true
+		p.stream.Close()
+		return err
+	}
+	p.isEOF = err != nil
+	if res == nil {
+		return nil
+	}
+	if p.columns == nil && !p.isDescribed && len(res.GetColumns()) > 0 {
+		columns, err := describedColumns(res.GetColumns(), p.resultFormats)
+		if err != nil {
+			return err
+		}
+		p.columns = columns
+	}
+	if p.columns != nil {
+		p.pending = append(p.pending, res.GetRows()...)
+	}
+	return nil
+}
+
+// describedColumns yields nil for a statement without result set.
+func describedColumns(columns []sqldata.ISQLColumn, resultFormats []FormatCode) (Columns, error) {
+	var rv Columns
+	for i, c := range columns {
+		format, err := formatCodeAt(resultFormats, i, len(columns))
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, Column{
+			Table:  c.GetTableId(),
+			Name:   c.GetName(),
+			Oid:    oid.Oid(c.GetObjectID()),
+			Width:  c.GetWidth(),
+			Format: format,
+		})
+	}
+	return rv, nil
+}
+
+func noData(writer buffer.Writer) error {
+	writer.Start(types.ServerNoData)
+	return writer.End()
+}
+
+func readFormatCodes(reader buffer.Reader) ([]FormatCode, error) {
+	count, err := reader.GetUint16()
+	if err != nil {
+		return nil, err
+	}
+	rv := make([]FormatCode, count)
+	for i := range rv {
+		v, err := reader.GetUint16()
+		if err != nil {
+			return nil, err
+		}
+		rv[i] = FormatCode(v)
+	}
+	return rv, nil
+}
+
+// formatCodeAt applies the protocol's convention that no codes
+// signify text throughout, and a single code applies throughout.
+func formatCodeAt(formats []FormatCode, i int, count int) (FormatCode, error) {
+	var rv FormatCode
+	switch len(formats) {
+	case 0:
+		rv = TextFormat
+	case 1:
+		rv = formats[0]
+	case count:
+		rv = formats[i]
+	default:
+		return 0, psqlerr.WithCode(
+			fmt.Errorf("%d format codes given for %d values", len(formats), count), codes.ProtocolViolation)
+	}
+	if rv != TextFormat && rv != BinaryFormat {
+		return 0, psqlerr.WithCode(fmt.Errorf("invalid format code %d", rv), codes.ProtocolViolation)
+	}
+	return rv, nil
+}
+
+// decodeParameter decodes a bound parameter value per its type, as
+// a string, int64, float64 or bool; unspecified types are text.
+func decodeParameter(paramOID oid.Oid, format FormatCode, raw []byte) (interface{}, error) {
+	if format == BinaryFormat {
+		return decodeBinaryParameter(paramOID, raw)
+	}
+	s := string(raw)
+	switch paramOID {
+	case oid.T_int2, oid.T_int4, oid.T_int8, oid.T_oid:
+		i, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			return nil, invalidParameter("integer", s)
+		}
+		return i, nil
+	case oid.T_float4, oid.T_float8, oid.T_numeric:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, invalidParameter("numeric", s)
+		}
+		return f, nil
+	case oid.T_bool:
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "t", "true", "y", "yes", "on", "1":
+			return true, nil
+		case "f", "false", "n", "no", "off", "0":
+			return false, nil
+		default:
+			return nil, invalidParameter("boolean", s)
+		}
+	default:
+		return s, nil
+	}
+}
+
+func decodeBinaryParameter(paramOID oid.Oid, raw []byte) (interface{}, error) {
+	switch {
+	case paramOID == oid.T_int2 && len(raw) == 2:
+		return int64(int16(binary.BigEndian.Uint16(raw))), nil
+	case paramOID == oid.T_int4 && len(raw) == 4:
+		return int64(int32(binary.BigEndian.Uint32(raw))), nil
+	case paramOID == oid.T_int8 && len(raw) == 8:
+		return int64(binary.BigEndian.Uint64(raw)), nil
+	case paramOID == oid.T_float4 && len(raw) == 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(raw))), nil
+	case paramOID == oid.T_float8 && len(raw) == 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(raw)), nil
+	case paramOID == oid.T_bool && len(raw) == 1:
+		return raw[0] != 0, nil
+	case paramOID == 0 || paramOID == oid.T_text || paramOID == oid.T_varchar ||
+		paramOID == oid.T_bpchar || paramOID == oid.T_name || paramOID == oid.T_unknown:
+		return string(raw), nil
+	default:
+		return nil, psqlerr.WithCode(
+			fmt.Errorf("unsupported binary format for parameter of type %d", paramOID), codes.InvalidBinaryRepresentation)
+	}
+}
+
+func invalidParameter(typeName string, s string) error {
+	return psqlerr.WithCode(
+		fmt.Errorf("invalid input syntax for type %s: \"%s\"", typeName, s), codes.InvalidTextRepresentation)
+}
+
+// cloneString copies a string read from the message, whose
+// buffer is reused by subsequent messages.
+func cloneString(s string) string {
+	return string(append([]byte(nil), s...))
+}
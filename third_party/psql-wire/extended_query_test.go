@@ -0,0 +1,328 @@
+package wire
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/lib/pq/oid"
+	"github.com/stackql/psql-wire/pkg/sqlbackend"
+	"github.com/stackql/psql-wire/pkg/sqldata"
+)
+
+// extendedTestBackend prepares statements with a parameter per
+// placeholder, and yields a row per bound argument upon execution.
+// Statements are described, by their leading keyword, as yielding
+// a value column, no result or a result unknown until executed.
+type extendedTestBackend struct {
+	mu         sync.Mutex
+	statements map[string]string
+	args       [][]interface{}
+}
+
+func (b *extendedTestBackend) NewSQLBackend() (sqlbackend.ISQLBackend, error) {
+	return b, nil
+}
+
+func (b *extendedTestBackend) CloneSQLBackend() sqlbackend.ISQLBackend {
+	return b
+}
+
+func (b *extendedTestBackend) HandleSimpleQuery(context.Context, string) (sqldata.ISQLResultStream, error) {
+	return nil, nil
+}
+
+func (b *extendedTestBackend) SplitCompoundQuery(s string) ([]string, error) {
+	return []string{s}, nil
+}
+
+func (b *extendedTestBackend) HandleParse(_ context.Context, name string, query string) (int, error) {
+	if strings.HasPrefix(query, "invalid") {
+		return 0, errors.New("syntax error")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.statements[name] = query
+	return strings.Count(query, "$"), nil
+}
+
+func (b *extendedTestBackend) HandleExecutePrepared(
+	_ context.Context,
+	name string,
+	args []interface{},
+) (sqldata.ISQLResultStream, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.statements[name]; !ok {
+		return nil, fmt.Errorf("prepared statement \"%s\" does not exist", name)
+	}
+	b.args = append(b.args, args)
+	var rows []sqldata.ISQLRow
+	for _, arg := range args {
+		rows = append(rows, sqldata.NewSQLRow([]interface{}{fmt.Sprintf("%v", arg)}))
+	}
+	return sqldata.NewSimpleSQLResultStream(sqldata.NewSQLResult(newStreamTestColumns("value"), 0, 0, rows)), nil
+}
+
+func (b *extendedTestBackend) HandleDescribeStatement(
+	_ context.Context,
+	name string,
+) ([]sqldata.ISQLColumn, error) {
+	columns, _, err := b.describe(name)
+	return columns, err
+}
+
+func (b *extendedTestBackend) HandleDescribePortal(
+	_ context.Context,
+	name string,
+	_ []interface{},
+) ([]sqldata.ISQLColumn, bool, error) {
+	return b.describe(name)
+}
+
+func (b *extendedTestBackend) describe(name string) ([]sqldata.ISQLColumn, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	query, ok := b.statements[name]
+	if !ok {
+		return nil, false, fmt.Errorf("prepared statement \"%s\" does not exist", name)
+	}
+	switch {
+	case strings.HasPrefix(query, "SELECT"):
+		return newStreamTestColumns("value"), true, nil
+	case strings.HasPrefix(query, "INSERT"):
+		return nil, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+func (b *extendedTestBackend) HandleCloseStatement(_ context.Context, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.statements, name)
+	return nil
+}
+
+func (b *extendedTestBackend) executionCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.args)
+}
+
+func (b *extendedTestBackend) lastArgs() []interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.args[len(b.args)-1]
+}
+
+func newExtendedTestConn(t *testing.T) (*pgconn.PgConn, *extendedTestBackend) {
+	backend := &extendedTestBackend{statements: make(map[string]string)}
+	server, err := NewServer(SQLBackendFactory(backend))
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := TListenAndServe(t, server)
+	conn, err := pgconn.Connect(context.Background(), fmt.Sprintf("postgres://%s:%d", address.IP, address.Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close(context.Background()) }) //nolint:errcheck
+	return conn, backend
+}
+
+func resultValues(t *testing.T, result *pgconn.Result) []string {
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	if len(result.FieldDescriptions) != 1 || string(result.FieldDescriptions[0].Name) != "value" {
+		t.Fatalf("unexpected row description %v", result.FieldDescriptions)
+	}
+	var rv []string
+	for _, row := range result.Rows {
+		rv = append(rv, string(row[0]))
+	}
+	return rv
+}
+
+func TestExtendedQueryBindsParameters(t *testing.T) {
+	t.Parallel()
+
+	conn, backend := newExtendedTestConn(t)
+	ctx := context.Background()
+
+	result := conn.ExecParams(ctx, "SELECT $1, $2, $3", [][]byte{[]byte("a"), []byte("42"), nil}, []uint32{0, uint32(oid.T_int4)}, nil, nil).Read()
+	if values := resultValues(t, result); !reflect.DeepEqual(values, []string{"a", "42", "<nil>"}) {
+		t.Fatalf("unexpected rows %v", values)
+	}
+	if args := backend.lastArgs(); !reflect.DeepEqual(args, []interface{}{"a", int64(42), nil}) {
+		t.Fatalf("unexpected arguments %#v", args)
+	}
+
+	result = conn.ExecParams(ctx, "SELECT $1", [][]byte{[]byte("x")}, []uint32{uint32(oid.T_int4)}, nil, nil).Read()
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "invalid input syntax") {
+		t.Fatalf("expected invalid integer error, got %v", result.Err)
+	}
+}
+
+func TestExtendedQueryPreparedStatement(t *testing.T) {
+	t.Parallel()
+
+	conn, backend := newExtendedTestConn(t)
+	ctx := context.Background()
+
+	sd, err := conn.Prepare(ctx, "s1", "SELECT $1, $2", []uint32{uint32(oid.T_int8)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sd.ParamOIDs, []uint32{uint32(oid.T_int8), uint32(oid.T_text)}) {
+		t.Fatalf("unexpected parameter description %v", sd.ParamOIDs)
+	}
+	if len(sd.Fields) != 1 || string(sd.Fields[0].Name) != "value" {
+		t.Fatalf("unexpected row description %v", sd.Fields)
+	}
+	if backend.executionCount() != 0 {
+		t.Fatalf("statement executed upon describe")
+	}
+	arg := make([]byte, 8)
+	binary.BigEndian.PutUint64(arg, 7)
+	for i := 0; i < 2; i++ {
+		result := conn.ExecPrepared(ctx, "s1", [][]byte{arg, []byte("b")}, []int16{1, 0}, nil).Read()
+		if values := resultValues(t, result); !reflect.DeepEqual(values, []string{"7", "b"}) {
+			t.Fatalf("unexpected rows %v", values)
+		}
+	}
+	if args := backend.lastArgs(); !reflect.DeepEqual(args, []interface{}{int64(7), "b"}) {
+		t.Fatalf("unexpected arguments %#v", args)
+	}
+}
+
+func TestExtendedQueryRecoversUponSync(t *testing.T) {
+	t.Parallel()
+
+	conn, _ := newExtendedTestConn(t)
+	ctx := context.Background()
+
+	result := conn.ExecParams(ctx, "invalid $1", [][]byte{[]byte("a")}, nil, nil, nil).Read()
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "syntax error") {
+		t.Fatalf("expected syntax error, got %v", result.Err)
+	}
+	result = conn.ExecParams(ctx, "SELECT $1", [][]byte{[]byte("a")}, nil, nil, nil).Read()
+	if values := resultValues(t, result); !reflect.DeepEqual(values, []string{"a"}) {
+		t.Fatalf("unexpected rows %v after failure", values)
+	}
+}
+
+// exchangeMessages sends the messages and
+// names those received up to ReadyForQuery.
+func exchangeMessages(t *testing.T, conn *pgconn.PgConn, messages ...pgproto3.FrontendMessage) []string {
+	hijacked, err := conn.Hijack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { hijacked.Conn.Close() })
+	frontend := pgproto3.NewFrontend(pgproto3.NewChunkReader(hijacked.Conn), hijacked.Conn)
+	for _, msg := range messages {
+		if err = frontend.Send(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var received []string
+	for {
+		msg, err := frontend.Receive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		received = append(received, reflect.TypeOf(msg).Elem().Name())
+		if _, ok := msg.(*pgproto3.ReadyForQuery); ok {
+			return received
+		}
+	}
+}
+
+func TestExtendedQuerySuspendsPortal(t *testing.T) {
+	t.Parallel()
+
+	conn, _ := newExtendedTestConn(t)
+	received := exchangeMessages(t, conn,
+		&pgproto3.Parse{Query: "SELECT $1, $2, $3"},
+		&pgproto3.Bind{Parameters: [][]byte{[]byte("a"), []byte("b"), []byte("c")}},
+		&pgproto3.Execute{MaxRows: 2},
+		&pgproto3.Execute{},
+		&pgproto3.Sync{},
+	)
+	expected := []string{
+		"ParseComplete", "BindComplete", "DataRow", "DataRow",
+		"PortalSuspended", "DataRow", "CommandComplete", "ReadyForQuery",
+	}
+	if !reflect.DeepEqual(received, expected) {
+		t.Fatalf("received %v, expected %v", received, expected)
+	}
+}
+
+func TestExtendedQueryDescribesPortalWithoutExecution(t *testing.T) {
+	t.Parallel()
+
+	conn, backend := newExtendedTestConn(t)
+	received := exchangeMessages(t, conn,
+		&pgproto3.Parse{Query: "INSERT $1"},
+		&pgproto3.Bind{Parameters: [][]byte{[]byte("a")}},
+		&pgproto3.Describe{ObjectType: 'P'},
+		&pgproto3.Sync{},
+	)
+	if expected := []string{"ParseComplete", "BindComplete", "NoData", "ReadyForQuery"}; !reflect.DeepEqual(received, expected) {
+		t.Fatalf("received %v, expected %v", received, expected)
+	}
+	if backend.executionCount() != 0 {
+		t.Fatalf("statement executed upon describe")
+	}
+}
+
+func TestExtendedQueryDiscardsRowsOfPortalDescribedByNoData(t *testing.T) {
+	t.Parallel()
+
+	conn, backend := newExtendedTestConn(t)
+	received := exchangeMessages(t, conn,
+		&pgproto3.Parse{Query: "INSERT $1"},
+		&pgproto3.Bind{Parameters: [][]byte{[]byte("a")}},
+		&pgproto3.Describe{ObjectType: 'P'},
+		&pgproto3.Execute{},
+		&pgproto3.Sync{},
+	)
+	expected := []string{"ParseComplete", "BindComplete", "NoData", "CommandComplete", "ReadyForQuery"}
+	if !reflect.DeepEqual(received, expected) {
+		t.Fatalf("received %v, expected %v", received, expected)
+	}
+	if backend.executionCount() != 1 {
+		t.Fatalf("statement executed %d times, expected once", backend.executionCount())
+	}
+}
+
+func TestExtendedQueryDescribesUnknownPortalByExecution(t *testing.T) {
+	t.Parallel()
+
+	conn, backend := newExtendedTestConn(t)
+	received := exchangeMessages(t, conn,
+		&pgproto3.Parse{Query: "SHOW $1"},
+		&pgproto3.Bind{Parameters: [][]byte{[]byte("a")}},
+		&pgproto3.Describe{ObjectType: 'P'},
+		&pgproto3.Execute{},
+		&pgproto3.Sync{},
+	)
+	expected := []string{
+		"ParseComplete", "BindComplete", "RowDescription", "DataRow", "CommandComplete", "ReadyForQuery",
+	}
+	if !reflect.DeepEqual(received, expected) {
+		t.Fatalf("received %v, expected %v", received, expected)
+	}
+	if backend.executionCount() != 1 {
+		t.Fatalf("statement executed %d times, expected once", backend.executionCount())
+	}
+}
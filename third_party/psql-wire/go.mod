@@ -0,0 +1,14 @@
+module github.com/stackql/psql-wire
+
+go 1.16
+
+require (
+	github.com/jackc/pgconn v1.9.1-0.20210724152538-d89c8390a530
+	github.com/jackc/pgproto3/v2 v2.1.1
+	github.com/jackc/pgtype v1.8.1
+	github.com/jackc/pgx/v4 v4.12.1-0.20210724153913-640aa07df17c
+	github.com/lib/pq v1.10.4
+	github.com/sirupsen/logrus v1.4.2
+	go.uber.org/zap v1.19.1
+	golang.org/x/tools v0.1.5
+)
@@ -0,0 +1,95 @@
+package sqlbackend
+
+import (
+	"context"
+
+	"github.com/stackql/psql-wire/pkg/sqldata"
+)
+
+type QueryCallback func(context.Context, string) (sqldata.ISQLResultStream, error)
+
+type SQLBackendFactory interface {
+	NewSQLBackend() (ISQLBackend, error)
+}
+
+type ISQLBackend interface {
+	HandleSimpleQuery(context.Context, string) (sqldata.ISQLResultStream, error)
+	SplitCompoundQuery(string) ([]string, error)
+}
+
+// IExtendedQueryBackend is implemented by backends supporting the
+// extended query protocol.  Statements are prepared by the backend,
+// whereas portals are held by the wire server.
+type IExtendedQueryBackend interface {
+	// HandleParse prepares the named statement, replacing any
+	// unnamed statement, and returns its count of parameters.
+	HandleParse(ctx context.Context, name string, query string) (int, error)
+	// HandleExecutePrepared executes the named statement bound to args,
+	// each of which is nil, for NULL, or else a string, int64, float64 or bool.
+	HandleExecutePrepared(ctx context.Context, name string, args []interface{}) (sqldata.ISQLResultStream, error)
+	// HandleDescribeStatement describes the result of the named
+	// statement, as yet unbound, without executing it; nil columns
+	// describe a statement without result set, or else one whose
+	// result is unknown until bound.
+	HandleDescribeStatement(ctx context.Context, name string) ([]sqldata.ISQLColumn, error)
+	// HandleDescribePortal describes the result of the named statement
+	// bound to args, without executing it.  The description is
+	// unknown only of read only statements, which are then
+	// described by their execution.
+	HandleDescribePortal(ctx context.Context, name string, args []interface{}) ([]sqldata.ISQLColumn, bool, error)
+	HandleCloseStatement(ctx context.Context, name string) error
+}
+
+type SimpleSQLBackend struct {
+	simpleCallback QueryCallback
+}
+
+func (sb *SimpleSQLBackend) CloneSQLBackend() ISQLBackend {
+	return &SimpleSQLBackend{
+		simpleCallback: sb.simpleCallback,
+	}
+}
+
+func (sb *SimpleSQLBackend) HandleSimpleQuery(ctx context.Context, query string) (sqldata.ISQLResultStream, error) {
+	return sb.simpleCallback(ctx, query)
+}
+
+func (sb *SimpleSQLBackend) SplitCompoundQuery(s string) ([]string, error) {
+	res := []string{}
+	var beg int
+	var inDoubleQuotes bool
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == ';' && !inDoubleQuotes {
+			res = append(res, s[beg:i])
+			beg = i + 1
+		} else if s[i] == '"' {
+			if !inDoubleQuotes {
+				inDoubleQuotes = true
+			} else if i > 0 && s[i-1] != '\\' {
+				inDoubleQuotes = false
+			}
+		}
+	}
+	return append(res, s[beg:]), nil
+}
+
+func NewSimpleSQLBackend(simpleCallback QueryCallback) ISQLBackend {
+	return &SimpleSQLBackend{
+		simpleCallback: simpleCallback,
+	}
+}
+
+type simpleSQLBackendFactory struct {
+	sqlBackend ISQLBackend
+}
+
+func (sbf *simpleSQLBackendFactory) NewSQLBackend() (ISQLBackend, error) {
+	return sbf.sqlBackend, nil
+}
+
+func NewSimpleSQLBackendFactory(simpleCallback QueryCallback) SQLBackendFactory {
+	return &simpleSQLBackendFactory{
+		sqlBackend: NewSimpleSQLBackend(simpleCallback),
+	}
+}
@@ -0,0 +1,277 @@
+package wire
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/stackql/psql-wire/codes"
+	pgerror "github.com/stackql/psql-wire/errors"
+	"github.com/stackql/psql-wire/internal/buffer"
+	"github.com/stackql/psql-wire/internal/types"
+)
+
+const (
+	// authSASL announces the SASL mechanisms supported by the server.
+	authSASL authType = 10
+	// authSASLContinue carries a SASL challenge.
+	authSASLContinue authType = 11
+	// authSASLFinal carries the SASL outcome, prior to authOK.
+	authSASLFinal authType = 12
+)
+
+const (
+	scramSHA256Mechanism = "SCRAM-SHA-256"
+	scramNonceLength     = 18
+)
+
+// SCRAMCredentials are the stored secrets of a user, as per pg_authid.rolpassword
+// in postgres. The cleartext password is neither required nor revealed.
+type SCRAMCredentials struct {
+	Iterations int
+	Salt       []byte
+	StoredKey  []byte
+	ServerKey  []byte
+}
+
+// SCRAMSHA256 authenticates the client via a SCRAM-SHA-256 exchange (RFC 5802, RFC 7677),
+// as per postgres. Channel binding is not supported. The lookup func returns false
+// for unknown users, which are then put through a mock exchange, so that failure
+// does not disclose whether the user exists.
+func SCRAMSHA256(lookup func(username string) (SCRAMCredentials, bool, error)) AuthStrategy {
+	return func(ctx context.Context, writer buffer.Writer, reader buffer.Reader) error {
+		creds, exists, err := lookup(ClientParameters(ctx)[ParamUsername])
+		if err != nil {
+			return err
+		}
+		if !exists {
+			creds, err = newMockSCRAMCredentials()
+			if err != nil {
+				return err
+			}
+		}
+
+		writer.Start(types.ServerAuth)
+		writer.AddInt32(int32(authSASL))
+		writer.AddString(scramSHA256Mechanism)
+		writer.AddNullTerminate()
+		writer.AddNullTerminate()
+		err = writer.End()
+		if err != nil {
+			return err
+		}
+
+		clientFirst, err := readSASLInitialResponse(reader)
+		if err != nil {
+			return err
+		}
+
+		gs2Header, clientFirstBare, clientNonce, err := parseSCRAMClientFirst(clientFirst)
+		if err != nil {
+			return err
+		}
+
+		serverNonce := make([]byte, scramNonceLength)
+		_, err = rand.Read(serverNonce)
+		if err != nil {
+			return err
+		}
+
+		nonce := clientNonce + base64.StdEncoding.EncodeToString(serverNonce)
+		serverFirst := fmt.Sprintf("r=%s,s=%s,i=%d", nonce, base64.StdEncoding.EncodeToString(creds.Salt), creds.Iterations)
+		err = writeSASLMessage(writer, authSASLContinue, serverFirst)
+		if err != nil {
+			return err
+		}
+
+		clientFinal, err := readSASLResponse(reader)
+		if err != nil {
+			return err
+		}
+
+		clientFinalWithoutProof, proof, err := parseSCRAMClientFinal(clientFinal, gs2Header, nonce)
+		if err != nil {
+			return err
+		}
+
+		authMessage := clientFirstBare + "," + serverFirst + "," + clientFinalWithoutProof
+		if !verifySCRAMClientProof(creds, authMessage, proof) || !exists {
+			return ErrorCode(writer, pgerror.WithCode(errors.New("invalid username/password"), codes.InvalidPassword))
+		}
+
+		serverSignature := scramHMAC(creds.ServerKey, authMessage)
+		err = writeSASLMessage(writer, authSASLFinal, "v="+base64.StdEncoding.EncodeToString(serverSignature))
+		if err != nil {
+			return err
+		}
+
+		return writeAuthType(writer, authOK)
+	}
+}
+
+// readSASLInitialResponse reads the selected mechanism and the client-first-message.
+func readSASLInitialResponse(reader buffer.Reader) (string, error) {
+	t, _, err := reader.ReadTypedMsg()
+	if err != nil {
+		return "", err
+	}
+
+	if t != types.ClientPassword {
+		return "", errors.New("unexpected SASL initial response message")
+	}
+
+	mechanism, err := reader.GetString()
+	if err != nil {
+		return "", err
+	}
+
+	if mechanism != scramSHA256Mechanism {
+		return "", fmt.Errorf("unsupported SASL mechanism: %s", mechanism)
+	}
+
+	size, err := reader.GetUint32()
+	if err != nil {
+		return "", err
+	}
+
+	if int32(size) < 0 {
+		return "", errors.New("missing SASL initial response")
+	}
+
+	bb, err := reader.GetBytes(int(size))
+	if err != nil {
+		return "", err
+	}
+
+	return string(bb), nil
+}
+
+// readSASLResponse reads the client-final-message, which fills the remainder of the message.
+func readSASLResponse(reader buffer.Reader) (string, error) {
+	t, _, err := reader.ReadTypedMsg()
+	if err != nil {
+		return "", err
+	}
+
+	if t != types.ClientPassword {
+		return "", errors.New("unexpected SASL response message")
+	}
+
+	return string(reader.PeekMsg()), nil
+}
+
+func writeSASLMessage(writer buffer.Writer, status authType, data string) error {
+	writer.Start(types.ServerAuth)
+	writer.AddInt32(int32(status))
+	writer.AddBytes([]byte(data))
+	return writer.End()
+}
+
+// parseSCRAMClientFirst splits the client-first-message. The username attribute is ignored,
+// as per postgres, in favour of the startup parameter.
+func parseSCRAMClientFirst(msg string) (gs2Header string, clientFirstBare string, nonce string, err error) {
+	parts := strings.SplitN(msg, ",", 3)
+	if len(parts) != 3 {
+		return "", "", "", errors.New("malformed SCRAM client-first-message")
+	}
+
+	switch {
+	case parts[0] == "n", parts[0] == "y":
+	case strings.HasPrefix(parts[0], "p="):
+		return "", "", "", errors.New("SCRAM channel binding is not supported")
+	default:
+		return "", "", "", errors.New("malformed SCRAM gs2 header")
+	}
+
+	if parts[1] != "" {
+		return "", "", "", errors.New("SCRAM authorization identity is not supported")
+	}
+
+	gs2Header = parts[0] + "," + parts[1] + ","
+	clientFirstBare = parts[2]
+	for _, attr := range strings.Split(clientFirstBare, ",") {
+		if strings.HasPrefix(attr, "r=") {
+			nonce = strings.TrimPrefix(attr, "r=")
+		}
+	}
+
+	if nonce == "" {
+		return "", "", "", errors.New("missing SCRAM client nonce")
+	}
+
+	return gs2Header, clientFirstBare, nonce, nil
+}
+
+// parseSCRAMClientFinal checks the channel binding and nonce attributes
+// and returns the message without proof, along with the proof.
+func parseSCRAMClientFinal(msg string, gs2Header string, nonce string) (string, []byte, error) {
+	pos := strings.LastIndex(msg, ",p=")
+	if pos == -1 {
+		return "", nil, errors.New("missing SCRAM client proof")
+	}
+
+	withoutProof := msg[:pos]
+	proof, err := base64.StdEncoding.DecodeString(msg[pos+len(",p="):])
+	if err != nil {
+		return "", nil, errors.New("malformed SCRAM client proof")
+	}
+
+	attrs := strings.Split(withoutProof, ",")
+	if len(attrs) < 2 {
+		return "", nil, errors.New("malformed SCRAM client-final-message")
+	}
+
+	if attrs[0] != "c="+base64.StdEncoding.EncodeToString([]byte(gs2Header)) {
+		return "", nil, errors.New("SCRAM channel binding mismatch")
+	}
+
+	if attrs[1] != "r="+nonce {
+		return "", nil, errors.New("SCRAM nonce mismatch")
+	}
+
+	return withoutProof, proof, nil
+}
+
+// verifySCRAMClientProof recovers ClientKey from the proof
+// and checks that it hashes to StoredKey.
+func verifySCRAMClientProof(creds SCRAMCredentials, authMessage string, proof []byte) bool {
+	clientSignature := scramHMAC(creds.StoredKey, authMessage)
+	if len(proof) != len(clientSignature) {
+		return false
+	}
+
+	clientKey := make([]byte, len(proof))
+	for i := range proof {
+		clientKey[i] = proof[i] ^ clientSignature[i]
+	}
+
+	storedKey := sha256.Sum256(clientKey)
+	return subtle.ConstantTimeCompare(storedKey[:], creds.StoredKey) == 1
+}
+
+func scramHMAC(key []byte, msg string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(msg))
+	return mac.Sum(nil)
+}
+
+func newMockSCRAMCredentials() (SCRAMCredentials, error) {
+	bb := make([]byte, 16+2*sha256.Size)
+	_, err := rand.Read(bb)
+	if err != nil {
+		return SCRAMCredentials{}, err
+	}
+
+	return SCRAMCredentials{
+		Iterations: 4096,
+		Salt:       bb[:16],
+		StoredKey:  bb[16 : 16+sha256.Size],
+		ServerKey:  bb[16+sha256.Size:],
+	}, nil
+}
@@ -0,0 +1,118 @@
+package wire
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// newTestSCRAMCredentials derives credentials as per postgres; a single
+// PBKDF2 block suffices, since the key length is that of the digest.
+func newTestSCRAMCredentials(password string, salt []byte, iterations int) SCRAMCredentials {
+	block := make([]byte, 4)
+	binary.BigEndian.PutUint32(block, 1)
+	u := hmacBytes([]byte(password), append(append([]byte{}, salt...), block...))
+	saltedPassword := append([]byte{}, u...)
+	for i := 1; i < iterations; i++ {
+		u = hmacBytes([]byte(password), u)
+		for j := range saltedPassword {
+			saltedPassword[j] ^= u[j]
+		}
+	}
+
+	clientKey := scramHMAC(saltedPassword, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	return SCRAMCredentials{
+		Iterations: iterations,
+		Salt:       salt,
+		StoredKey:  storedKey[:],
+		ServerKey:  scramHMAC(saltedPassword, "Server Key"),
+	}
+}
+
+func hmacBytes(key []byte, msg []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(msg)
+	return mac.Sum(nil)
+}
+
+func TestSCRAMSHA256(t *testing.T) {
+	t.Parallel()
+
+	creds := newTestSCRAMCredentials("pencil", []byte("0123456789abcdef"), 4096)
+	lookup := func(username string) (SCRAMCredentials, bool, error) {
+		if username != "user" {
+			return SCRAMCredentials{}, false, nil
+		}
+
+		return creds, true, nil
+	}
+
+	server, err := NewServer(SimpleQuery(func(ctx context.Context, query string, writer DataWriter) error {
+		return writer.Complete("OK")
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.Auth = SCRAMSHA256(lookup)
+	address := TListenAndServe(t, server)
+
+	tests := []struct {
+		username string
+		password string
+		ok       bool
+	}{
+		{"user", "pencil", true},
+		{"user", "pen", false},
+		{"nobody", "pencil", false},
+	}
+
+	for _, test := range tests {
+		ctx := context.Background()
+		connstr := fmt.Sprintf("postgres://%s:%s@%s:%d?sslmode=disable", test.username, test.password, address.IP, address.Port)
+		conn, err := pgx.Connect(ctx, connstr)
+		if !test.ok {
+			if err == nil {
+				conn.Close(ctx) //nolint:errcheck
+				t.Fatalf("unexpected success authenticating %s with password %s", test.username, test.password)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("authenticating %s: %s", test.username, err)
+		}
+
+		_, err = conn.Exec(ctx, "SELECT 1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		conn.Close(ctx) //nolint:errcheck
+	}
+}
+
+func TestSCRAMChannelBindingRejected(t *testing.T) {
+	t.Parallel()
+
+	_, _, _, err := parseSCRAMClientFirst("p=tls-server-end-point,,n=,r=abc")
+	if err == nil {
+		t.Fatal("expected channel binding to be rejected")
+	}
+
+	gs2Header, bare, nonce, err := parseSCRAMClientFirst("n,,n=,r=abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gs2Header != "n,," || bare != "n=,r=abc" || nonce != "abc" {
+		t.Fatalf("unexpected client-first-message parse: %q %q %q", gs2Header, bare, nonce)
+	}
+}
@@ -0,0 +1,140 @@
+package wire
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq/oid"
+	"github.com/stackql/psql-wire/pkg/sqlbackend"
+	"github.com/stackql/psql-wire/pkg/sqldata"
+)
+
+// openRowsStream yields its first chunk at once, and its final chunk
+// only once released, as per backend rows still open upon the first.
+type openRowsStream struct {
+	cols    []sqldata.ISQLColumn
+	reads   int
+	release chan struct{}
+}
+
+func (s *openRowsStream) Read() (sqldata.ISQLResult, error) {
+	s.reads++
+	if s.reads == 1 {
+		return sqldata.NewSQLResult(s.cols, 0, 0, []sqldata.ISQLRow{sqldata.NewSQLRow([]interface{}{"first"})}), nil
+	}
+	select {
+	case <-s.release:
+	case <-time.After(5 * time.Second):
+		return nil, errors.New("first row not delivered whilst backend rows open")
+	}
+	return sqldata.NewSQLResult(s.cols, 0, 0, []sqldata.ISQLRow{sqldata.NewSQLRow([]interface{}{"second"})}), io.EOF
+}
+
+func (s *openRowsStream) Write(sqldata.ISQLResult) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (s *openRowsStream) Close() error {
+	return nil
+}
+
+func newStreamTestColumns(name string) []sqldata.ISQLColumn {
+	return []sqldata.ISQLColumn{
+		sqldata.NewSQLColumn(sqldata.NewSQLTable(0, ""), name, 0, uint32(oid.T_text), 256, 0, "TextFormat"),
+	}
+}
+
+func TestSQLBackendStreamsRowsWhileBackendRowsOpen(t *testing.T) {
+	t.Parallel()
+
+	stream := &openRowsStream{cols: newStreamTestColumns("name"), release: make(chan struct{})}
+	qcb := func(context.Context, string) (sqldata.ISQLResultStream, error) {
+		return stream, nil
+	}
+	server, err := NewServer(SQLBackendFactory(sqlbackend.NewSimpleSQLBackendFactory(qcb)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := TListenAndServe(t, server)
+
+	conn, err := sql.Open("postgres", fmt.Sprintf("host=%s port=%d sslmode=disable", address.IP, address.Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	rows, err := conn.Query("SELECT name FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+		if name == "first" {
+			close(stream.release)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "first,second" {
+		t.Fatalf("unexpected rows %v", names)
+	}
+}
+
+func TestSQLBackendCompoundQuery(t *testing.T) {
+	t.Parallel()
+
+	qcb := func(_ context.Context, query string) (sqldata.ISQLResultStream, error) {
+		query = strings.TrimSpace(query)
+		return sqldata.NewSimpleSQLResultStream(sqldata.NewSQLResult(
+			newStreamTestColumns(query),
+			0,
+			0,
+			[]sqldata.ISQLRow{sqldata.NewSQLRow([]interface{}{query})},
+		)), nil
+	}
+	server, err := NewServer(SQLBackendFactory(sqlbackend.NewSimpleSQLBackendFactory(qcb)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := TListenAndServe(t, server)
+
+	conn, err := sql.Open("postgres", fmt.Sprintf("host=%s port=%d sslmode=disable", address.IP, address.Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	rows, err := conn.Query("SELECT a; SELECT b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []string
+	for {
+		for rows.Next() {
+			var val string
+			if err = rows.Scan(&val); err != nil {
+				t.Fatal(err)
+			}
+			results = append(results, val)
+		}
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(results, ",") != "SELECT a,SELECT b" {
+		t.Fatalf("expected a result per query, got %v", results)
+	}
+}
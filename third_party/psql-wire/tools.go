@@ -0,0 +1,8 @@
+// +build tools
+
+package tools
+
+import (
+	_ "golang.org/x/tools/cmd/goimports"
+	_ "golang.org/x/tools/cmd/stringer"
+)